@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"time"
+)
+
+// Analytics events are small records of the milestones of a game, e.g. the
+// first merge or surviving a coming up event. They make funnel metrics (how
+// many games get to the first merge, how many players quit after their first
+// game) possible without downloading and replaying every playthrough. They
+// are collected in batches and posted to log-events-clone1.php. Like the
+// other uploads, they are best effort: a batch that can't be sent is dropped.
+// Every event belongs to a session, from the start of the program until it
+// is closed. The session_start and session_end events say how long the
+// session was, how many games it had and on which device, so retention and
+// session lengths don't have to be pieced together from the playthroughs.
+
+type AnalyticsEventName string
+
+const (
+	AnalyticsGameStarted      AnalyticsEventName = "game_started"
+	AnalyticsFirstMerge       AnalyticsEventName = "first_merge"
+	AnalyticsComingUpSurvived AnalyticsEventName = "coming_up_survived"
+	AnalyticsGameOver         AnalyticsEventName = "game_over"
+	AnalyticsSessionStart     AnalyticsEventName = "session_start"
+	AnalyticsSessionEnd       AnalyticsEventName = "session_end"
+)
+
+type AnalyticsEvent struct {
+	Name AnalyticsEventName `json:"name"`
+	// Unix milliseconds.
+	Moment        int64  `json:"moment"`
+	PlaythroughId string `json:"playthrough_id"`
+	// How many frames of the game were played when the event happened.
+	FrameIdx int64 `json:"frame_idx"`
+	Score    int64 `json:"score"`
+	// Only for AnalyticsGameOver: Won or Lost.
+	Outcome   string `json:"outcome,omitempty"`
+	SessionId string `json:"session_id"`
+	// Only for AnalyticsSessionEnd: how long the session was and how many
+	// games were started in it.
+	DurationMs int64 `json:"duration_ms,omitempty"`
+	Games      int64 `json:"games,omitempty"`
+	// Only for AnalyticsSessionStart and AnalyticsSessionEnd, see Device.
+	Device string `json:"device,omitempty"`
+}
+
+// analyticsBatch is what is posted to the server, as JSON.
+type analyticsBatch struct {
+	User           string           `json:"user"`
+	ReleaseVersion int64            `json:"release_version"`
+	Events         []AnalyticsEvent `json:"events"`
+}
+
+// A batch is sent once it has analyticsBatchSize events, or every
+// analyticsBatchInterval if it has any.
+const analyticsBatchSize = 20
+const analyticsBatchInterval = 30 * time.Second
+
+// BatchAnalytics collects the events from ch and calls send with them once
+// there are analyticsBatchSize of them, when tick fires and when ch is closed.
+// It returns after ch is closed and the last events are sent.
+func BatchAnalytics(ch <-chan AnalyticsEvent, tick <-chan time.Time,
+	send func(events []AnalyticsEvent)) {
+	var batch []AnalyticsEvent
+	flush := func() {
+		if len(batch) > 0 {
+			send(batch)
+			batch = nil
+		}
+	}
+	for {
+		select {
+		case e, ok := <-ch:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, e)
+			if len(batch) >= analyticsBatchSize {
+				flush()
+			}
+		case <-tick:
+			flush()
+		}
+	}
+}
+
+// UploadAnalytics sends the events it receives on ch to the server, in
+// batches. It returns after ch is closed and the last batch was sent.
+func (g *Gui) UploadAnalytics(user string, ch <-chan AnalyticsEvent) {
+	ticker := time.NewTicker(analyticsBatchInterval)
+	defer ticker.Stop()
+	BatchAnalytics(ch, ticker.C, func(events []AnalyticsEvent) {
+		data, err := json.Marshal(analyticsBatch{user, ReleaseVersion, events})
+		Check(err)
+		// This might fail, but we really do not care that much. The game
+		// should not be interrupted by this function failing. If it does
+		// fail, just try a couple more times, then give up.
+		_ = g.uploadMetrics.Do(analyticsQueue, backgroundRetryPolicy,
+			func() error {
+				return g.telemetry.LogEvents(string(data))
+			})
+	})
+}
+
+// RecordAnalytics fills in the details of the current game and sends e to
+// UploadAnalytics, unless that risks blocking.
+func (g *Gui) RecordAnalytics(e AnalyticsEvent) {
+	if g.analyticsChannel == nil || !g.UploadsAllowed() {
+		return
+	}
+	e.Moment = g.clock.Now().UnixMilli()
+	e.SessionId = g.sessionId.String()
+	e.PlaythroughId = g.playthrough.Id.String()
+	e.FrameIdx = int64(len(g.playthrough.History))
+	e.Score = g.world.Score
+	if len(g.analyticsChannel) < cap(g.analyticsChannel) {
+		g.analyticsChannel <- e
+	}
+}
+
+// ListenForAnalytics records the analytics events of the game that just
+// started in g.world.
+func (g *Gui) ListenForAnalytics() {
+	g.sessionGames++
+	g.RecordAnalytics(AnalyticsEvent{Name: AnalyticsGameStarted})
+	merged := false
+	g.world.OnMerge(func(e WorldEvent) {
+		if !merged {
+			merged = true
+			g.RecordAnalytics(AnalyticsEvent{Name: AnalyticsFirstMerge})
+		}
+	})
+	g.world.OnStateChange(func(from WorldState, to WorldState) {
+		if from == ComingUp && to == Regular {
+			g.RecordAnalytics(AnalyticsEvent{Name: AnalyticsComingUpSurvived})
+		}
+	})
+}
+
+// Device describes what the game runs on, for the session events: the OS, the
+// architecture, the size of the window and, in the browser, the user agent.
+func (g *Gui) Device() string {
+	m := NewMetadata(SystemClock{}, g.outsideWidth, g.outsideHeight)
+	device := fmt.Sprintf("%s/%s %dx%d", m.OS, m.Arch, m.ScreenWidth,
+		m.ScreenHeight)
+	if agent := userAgent(); agent != "" {
+		device += " " + agent
+	}
+	return device
+}
+
+// StartSession records the start of the session.
+func (g *Gui) StartSession() {
+	g.sessionId = uuid.New()
+	g.sessionStart = g.clock.Now()
+	g.RecordAnalytics(AnalyticsEvent{
+		Name:   AnalyticsSessionStart,
+		Device: g.Device()})
+}
+
+// EndAnalytics records the end of the session and stops UploadAnalytics,
+// once it sent the last batch. Shutdown waits for that.
+func (g *Gui) EndAnalytics() {
+	if g.analyticsChannel == nil {
+		return
+	}
+	g.RecordAnalytics(AnalyticsEvent{
+		Name:       AnalyticsSessionEnd,
+		DurationMs: g.clock.Now().Sub(g.sessionStart).Milliseconds(),
+		Games:      g.sessionGames,
+		Device:     g.Device()})
+	close(g.analyticsChannel)
+	g.analyticsChannel = nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestBatchAnalytics(t *testing.T) {
+	// Unbuffered, so that the events and ticks arrive in the order they are
+	// sent.
+	ch := make(chan AnalyticsEvent)
+	tick := make(chan time.Time)
+	var batches [][]AnalyticsEvent
+	done := make(chan struct{})
+	go func() {
+		BatchAnalytics(ch, tick, func(events []AnalyticsEvent) {
+			batches = append(batches, events)
+		})
+		close(done)
+	}()
+
+	// A full batch is sent right away.
+	for range analyticsBatchSize + 2 {
+		ch <- AnalyticsEvent{Name: AnalyticsFirstMerge}
+	}
+	// The rest waits for the tick, which sends whatever there is.
+	tick <- time.Time{}
+	tick <- time.Time{}
+	// Closing sends the last events.
+	ch <- AnalyticsEvent{Name: AnalyticsSessionEnd}
+	close(ch)
+	<-done
+
+	assert.Len(t, batches, 3)
+	assert.Len(t, batches[0], analyticsBatchSize)
+	assert.Len(t, batches[1], 2)
+	assert.Equal(t, []AnalyticsEvent{{Name: AnalyticsSessionEnd}}, batches[2])
+}
+
+func TestGui_ListenForAnalytics(t *testing.T) {
+	var g Gui
+	g.clock = FixedClock{time.UnixMilli(1700000000123)}
+	g.analyticsChannel = make(chan AnalyticsEvent, 1000)
+	p := DeserializePlaythrough(ReadFile("data/demo.clone1"))
+	g.playthrough = *p.Clone()
+	g.world = NewWorldFromPlaythrough(g.playthrough)
+	g.ListenForAnalytics()
+	nSurvived := 0
+	g.world.OnStateChange(func(from WorldState, to WorldState) {
+		if from == ComingUp && to == Regular {
+			nSurvived++
+		}
+	})
+	for _, input := range p.History {
+		g.world.Step(input)
+	}
+	close(g.analyticsChannel)
+
+	var names []AnalyticsEventName
+	for e := range g.analyticsChannel {
+		names = append(names, e.Name)
+		assert.Equal(t, int64(1700000000123), e.Moment)
+		assert.Equal(t, p.Id.String(), e.PlaythroughId)
+	}
+	assert.Equal(t, AnalyticsGameStarted, names[0])
+	count := map[AnalyticsEventName]int{}
+	for _, name := range names {
+		count[name]++
+	}
+	assert.Equal(t, 1, count[AnalyticsFirstMerge])
+	assert.Positive(t, nSurvived)
+	assert.Equal(t, nSurvived, count[AnalyticsComingUpSurvived])
+}
+
+func TestGui_SessionEvents(t *testing.T) {
+	var g Gui
+	clock := &FixedClock{time.UnixMilli(1700000000000)}
+	g.clock = clock
+	g.analyticsChannel = make(chan AnalyticsEvent, 1000)
+	ch := g.analyticsChannel
+
+	g.StartSession()
+	for range 3 {
+		g.world = NewWorld(0, Level{})
+		g.ListenForAnalytics()
+	}
+	clock.Moment = clock.Moment.Add(90 * time.Second)
+	g.EndAnalytics()
+
+	var events []AnalyticsEvent
+	for e := range ch {
+		events = append(events, e)
+		assert.Equal(t, g.sessionId.String(), e.SessionId)
+	}
+	start, end := events[0], events[len(events)-1]
+	assert.Equal(t, AnalyticsSessionStart, start.Name)
+	assert.Equal(t, g.Device(), start.Device)
+	assert.Equal(t, AnalyticsSessionEnd, end.Name)
+	assert.Equal(t, int64(90000), end.DurationMs)
+	assert.Equal(t, int64(3), end.Games)
+	assert.Equal(t, g.Device(), end.Device)
+}
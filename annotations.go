@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"slices"
+)
+
+// Annotation is a note attached to a frame of a playthrough during playback,
+// e.g. "brick overlaps here", for whoever looks at the playthrough next.
+type Annotation struct {
+	FrameIdx int64  `yaml:"FrameIdx"`
+	Text     string `yaml:"Text"`
+}
+
+// Annotations are the notes of every playthrough, by Playthrough Id, so that
+// they follow a playthrough wherever its file is copied or renamed. The notes
+// of a playthrough are sorted by frame.
+type Annotations map[string][]Annotation
+
+const annotationsFile = "annotations.yaml"
+
+// An annotation is shown for this many frames, starting with its own frame,
+// so that it can be read while the playback is running.
+const annotationDisplayFrames = 120
+
+// LoadAnnotations returns the saved annotations, or no annotations if none
+// were saved yet.
+func LoadAnnotations() (a Annotations) {
+	fsys := os.DirFS(".").(FS)
+	if FileExists(fsys, annotationsFile) {
+		LoadYAML(fsys, annotationsFile, &a)
+	}
+	if a == nil {
+		a = Annotations{}
+	}
+	return
+}
+
+func (a Annotations) Save() {
+	SaveYAML(annotationsFile, a)
+}
+
+// Add attaches text to frameIdx of playthrough id.
+func (a Annotations) Add(id string, frameIdx int64, text string) {
+	notes := a[id]
+	i, _ := slices.BinarySearchFunc(notes, frameIdx,
+		func(n Annotation, frameIdx int64) int {
+			return int(n.FrameIdx - frameIdx)
+		})
+	a[id] = slices.Insert(notes, i, Annotation{frameIdx, text})
+}
+
+// Remove deletes the notes attached to frameIdx of playthrough id.
+func (a Annotations) Remove(id string, frameIdx int64) {
+	a[id] = slices.DeleteFunc(a[id], func(n Annotation) bool {
+		return n.FrameIdx == frameIdx
+	})
+	if len(a[id]) == 0 {
+		delete(a, id)
+	}
+}
+
+// Shown returns the notes of playthrough id that are shown at frameIdx.
+func (a Annotations) Shown(id string, frameIdx int64) (shown []Annotation) {
+	for _, n := range a[id] {
+		if n.FrameIdx <= frameIdx &&
+			frameIdx < n.FrameIdx+annotationDisplayFrames {
+			shown = append(shown, n)
+		}
+	}
+	return
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"github.com/goccy/go-yaml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestAnnotations(t *testing.T) {
+	a := Annotations{}
+	a.Add("id1", 500, "second")
+	a.Add("id1", 100, "first")
+	a.Add("id2", 100, "other playthrough")
+	assert.Equal(t, []Annotation{{100, "first"}, {500, "second"}}, a["id1"])
+
+	assert.Equal(t, []Annotation{{100, "first"}}, a.Shown("id1", 100))
+	assert.Equal(t, []Annotation{{100, "first"}},
+		a.Shown("id1", 100+annotationDisplayFrames-1))
+	assert.Empty(t, a.Shown("id1", 100+annotationDisplayFrames))
+	assert.Empty(t, a.Shown("id1", 99))
+
+	data, err := yaml.Marshal(a)
+	require.NoError(t, err)
+	var loaded Annotations
+	require.NoError(t, yaml.Unmarshal(data, &loaded))
+	assert.Equal(t, a, loaded)
+
+	a.Remove("id1", 100)
+	assert.Equal(t, []Annotation{{500, "second"}}, a["id1"])
+	a.Remove("id2", 100)
+	assert.NotContains(t, a, "id2")
+}
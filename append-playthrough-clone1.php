@@ -0,0 +1,64 @@
+<?php
+require_once "./auth-clone1.php";
+$servername = "172.232.206.74";
+$username = "playfulp_temp";
+$password = "comeonthough";
+$dbname = "playfulp_clone1";
+
+// Appends the inputs recorded since the last upload to a playthrough that is
+// uploaded as a recording stream. The delta is only appended if the stored
+// playthrough is exactly offset bytes long, so a delta that was already
+// appended, or one that comes after a delta that was lost, is ignored.
+// Either way, the response is the length of the stored playthrough, which is
+// where the next delta has to start.
+
+function LogInfo($message) {
+    // 	file_put_contents("./append-playthrough-clone1.log", "INFO: " . $message . "\n", FILE_APPEND);
+}
+
+function LogError($message) {
+	file_put_contents("./append-playthrough-clone1.log", "ERROR: " . $message . "\n", FILE_APPEND);
+    http_response_code(513);
+	die();
+}
+
+LogInfo("Start.");
+if ($_SERVER['REQUEST_METHOD'] == 'POST') {
+    VerifyRequest();
+    $table = Table("playthroughs");
+    LogInfo("Attempt to connect to database.");
+    $conn = new mysqli($servername, $username, $password, $dbname);
+    if ($conn->connect_error) {
+        LogError("Connection failed: " . $conn->connect_error);
+    }
+    LogInfo("Connection succeeded!");
+
+    $user = $conn->real_escape_string($_POST['user']);
+    $id = $conn->real_escape_string($_POST['id']);
+    $offset = intval($_POST['offset']);
+    LogInfo("We got user: " . $user . ", id: " . $id . ", offset: " . $offset);
+    if (!isset($_FILES['delta'])) {
+        LogError("No delta.");
+    }
+    $delta = $conn->real_escape_string(file_get_contents($_FILES['delta']['tmp_name']));
+
+    $sql = "UPDATE $table SET end_moment=now(), playthrough = CONCAT(COALESCE(playthrough, ''), '$delta') " .
+        "WHERE user = '$user' AND id = '$id' AND LENGTH(COALESCE(playthrough, '')) = $offset";
+    try {
+        $conn->query($sql);
+        $result = $conn->query("SELECT LENGTH(COALESCE(playthrough, '')) AS length FROM $table " .
+            "WHERE user = '$user' AND id = '$id'");
+    } catch(Exception $e) {
+        LogError("Error appending data: " . $e->getMessage());
+    }
+
+    if ($result->num_rows > 0) {
+        $row = $result->fetch_assoc();
+        echo $row["length"];
+    } else {
+        LogError("Unknown playthrough.");
+    }
+    $conn->close();
+}
+LogInfo("End.");
+?>
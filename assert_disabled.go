@@ -2,5 +2,7 @@
 
 package main
 
+const assertEnabled = false
+
 func Assert(condition bool) {
 }
@@ -2,6 +2,8 @@
 
 package main
 
+const assertEnabled = true
+
 func Assert(condition bool) {
 	if !condition {
 		panic("assert failed")
@@ -0,0 +1,74 @@
+<?php
+// Checks the signature that the game adds to every request, see SignRequest
+// in auth.go. The secret of each release is in release-secrets-clone1.php,
+// which defines $release_secrets as an array from the release version to the
+// secret. That file is only on the server, never in the repo. Without it,
+// e.g. on a test server, requests aren't checked. With it, only requests
+// signed by one of the releases in it are accepted.
+
+// How far the time of a request may be from the time of the server, in
+// seconds.
+$auth_max_skew = 600;
+
+// Requests of development builds say that they are for staging, see
+// Endpoints in endpoints.go, and their data goes to the staging tables
+// instead of the tables of the players. A staging table is named like the
+// table it stands for, with _staging at the end, e.g.
+// CREATE TABLE playthroughs_staging LIKE playthroughs.
+function Table($name) {
+    if (isset($_POST['environment']) && $_POST['environment'] == "staging") {
+        return $name . "_staging";
+    }
+    return $name;
+}
+
+function RejectRequest($message) {
+    file_put_contents("./auth-clone1.log", "REJECTED: " . $message . "\n", FILE_APPEND);
+    http_response_code(401);
+    die();
+}
+
+function VerifyRequest() {
+    global $auth_max_skew;
+    $release_secrets = array();
+    if (file_exists("./release-secrets-clone1.php")) {
+        include "./release-secrets-clone1.php";
+    }
+
+    if (count($release_secrets) == 0) {
+        return;
+    }
+    if (!isset($_POST['auth_release'])) {
+        RejectRequest("unsigned request");
+    }
+    $release = intval($_POST['auth_release']);
+    if (!isset($release_secrets[$release])) {
+        RejectRequest("unknown release " . $release);
+    }
+    if (!isset($_POST['auth_moment']) || !isset($_POST['auth_signature'])) {
+        RejectRequest("incomplete signature, release " . $release);
+    }
+    if (abs(time() - intval($_POST['auth_moment'])) > $auth_max_skew) {
+        RejectRequest("expired request, release " . $release);
+    }
+
+    // Every field except the signature, in the order of their names, then
+    // every file, as the sha256 of its contents.
+    $fields = $_POST;
+    unset($fields['auth_signature']);
+    ksort($fields, SORT_STRING);
+    $message = "";
+    foreach ($fields as $name => $value) {
+        $message .= $name . "=" . $value . "\n";
+    }
+    $files = $_FILES;
+    ksort($files, SORT_STRING);
+    foreach ($files as $name => $file) {
+        $message .= $name . "=" . hash_file('sha256', $file['tmp_name']) . "\n";
+    }
+    $expected = hash_hmac('sha256', $message, $release_secrets[$release]);
+    if (!hash_equals($expected, $_POST['auth_signature'])) {
+        RejectRequest("wrong signature, release " . $release);
+    }
+}
+?>
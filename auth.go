@@ -0,0 +1,69 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"maps"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// Requests to the server are signed with a secret of the release, so that the
+// server can tell them apart from requests made by hand, e.g. someone posting
+// a score for another user. The signature is an HMAC of every field and file
+// of the request, along with the ReleaseVersion and the time of the request,
+// which the server checks against the secret it has for that release (see
+// auth-clone1.php). A request can't be changed or replayed much later without
+// the server noticing.
+// The secret is set when the release is built:
+// go build -ldflags "-X main.releaseSecret=..."
+// See cmd/release, which takes it from the CLONE1_RELEASE_SECRET environment
+// variable. Like the credentials of the database, it is never written in a
+// file of the repo. It is in the executable, so it only keeps out people who
+// don't take it apart, but a secret that leaks only matters for one release.
+// Builds without a secret, e.g. during development, don't sign their
+// requests and only work with a server that doesn't check them.
+var releaseSecret = ""
+
+// The fields that SignRequest adds to a request.
+const (
+	authReleaseField   = "auth_release"
+	authMomentField    = "auth_moment"
+	authSignatureField = "auth_signature"
+)
+
+// SignRequest returns the fields that authenticate a request with fields and
+// files, made at moment by releaseVersion. It returns no fields if secret is
+// empty.
+func SignRequest(secret string, releaseVersion int64, moment time.Time,
+	fields map[string]string, files map[string][]byte) map[string]string {
+	if secret == "" {
+		return nil
+	}
+	auth := map[string]string{
+		authReleaseField: strconv.FormatInt(releaseVersion, 10),
+		authMomentField:  strconv.FormatInt(moment.Unix(), 10),
+	}
+
+	// The message is every field, in the order of their names, one per line
+	// as name=value, then every file as name=sha256 of its contents, in hex.
+	all := map[string]string{}
+	for k, v := range fields {
+		all[k] = v
+	}
+	for k, v := range auth {
+		all[k] = v
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	for _, k := range slices.Sorted(maps.Keys(all)) {
+		mac.Write([]byte(k + "=" + all[k] + "\n"))
+	}
+	for _, k := range slices.Sorted(maps.Keys(files)) {
+		sum := sha256.Sum256(files[k])
+		mac.Write([]byte(k + "=" + hex.EncodeToString(sum[:]) + "\n"))
+	}
+	auth[authSignatureField] = hex.EncodeToString(mac.Sum(nil))
+	return auth
+}
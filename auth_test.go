@@ -0,0 +1,40 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestSignRequest(t *testing.T) {
+	moment := time.Unix(1700000000, 0)
+	fields := map[string]string{"user": "ana", "id": "1"}
+	files := map[string][]byte{"playthrough": {1, 2, 3}}
+	auth := SignRequest("secret", 99, moment, fields, files)
+	assert.Equal(t, "99", auth[authReleaseField])
+	assert.Equal(t, "1700000000", auth[authMomentField])
+	assert.Len(t, auth[authSignatureField], 64)
+
+	// The signature doesn't depend on the order of the fields.
+	same := map[string]string{"id": "1", "user": "ana"}
+	assert.Equal(t, auth, SignRequest("secret", 99, moment, same, files))
+
+	// Changing anything changes the signature.
+	signature := func(secret string, release int64, moment time.Time,
+		fields map[string]string, files map[string][]byte) string {
+		return SignRequest(secret, release, moment, fields,
+			files)[authSignatureField]
+	}
+	s := auth[authSignatureField]
+	assert.NotEqual(t, s, signature("other", 99, moment, fields, files))
+	assert.NotEqual(t, s, signature("secret", 98, moment, fields, files))
+	assert.NotEqual(t, s, signature("secret", 99, moment.Add(time.Second),
+		fields, files))
+	assert.NotEqual(t, s, signature("secret", 99, moment,
+		map[string]string{"user": "bob", "id": "1"}, files))
+	assert.NotEqual(t, s, signature("secret", 99, moment, fields,
+		map[string][]byte{"playthrough": {1, 2, 4}}))
+
+	// Builds without a secret don't sign.
+	assert.Empty(t, SignRequest("", 99, moment, fields, files))
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"github.com/goccy/go-yaml"
+	"io/fs"
+	"os"
+	"slices"
+)
+
+// Bookmarks are frames of a playthrough, marked during playback, so that
+// interesting moments are easy to come back to later. They are saved in a
+// file next to the playthrough.
+type Bookmarks struct {
+	// Sorted, with no duplicates.
+	Frames []int64 `yaml:"Frames"`
+}
+
+func BookmarksFile(playthroughFile string) string {
+	return playthroughFile + "-bookmarks"
+}
+
+// LoadBookmarks returns the bookmarks saved for playthroughFile, or no
+// bookmarks if none were saved yet. playthroughFile is a path on disk, like
+// the one Save writes to, absolute or relative to the working directory.
+func LoadBookmarks(playthroughFile string) (b Bookmarks) {
+	data, err := os.ReadFile(BookmarksFile(playthroughFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return
+	}
+	Check(err)
+	Check(yaml.Unmarshal(data, &b))
+	return
+}
+
+func (b *Bookmarks) Save(playthroughFile string) {
+	SaveYAML(BookmarksFile(playthroughFile), b)
+}
+
+// Toggle adds a bookmark at frameIdx, or removes it if it is already there.
+func (b *Bookmarks) Toggle(frameIdx int64) {
+	i, found := slices.BinarySearch(b.Frames, frameIdx)
+	if found {
+		b.Frames = slices.Delete(b.Frames, i, i+1)
+	} else {
+		b.Frames = slices.Insert(b.Frames, i, frameIdx)
+	}
+}
+
+// Next returns the first bookmark after frameIdx. If there is none, it returns
+// frameIdx.
+func (b *Bookmarks) Next(frameIdx int64) int64 {
+	i, found := slices.BinarySearch(b.Frames, frameIdx)
+	if found {
+		i++
+	}
+	if i < len(b.Frames) {
+		return b.Frames[i]
+	}
+	return frameIdx
+}
+
+// Previous returns the last bookmark before frameIdx. If there is none, it
+// returns frameIdx.
+func (b *Bookmarks) Previous(frameIdx int64) int64 {
+	i, _ := slices.BinarySearch(b.Frames, frameIdx)
+	if i > 0 {
+		return b.Frames[i-1]
+	}
+	return frameIdx
+}
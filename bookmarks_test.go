@@ -0,0 +1,37 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"testing"
+)
+
+func TestBookmarks(t *testing.T) {
+	var b Bookmarks
+	b.Toggle(50)
+	b.Toggle(10)
+	b.Toggle(30)
+	assert.Equal(t, []int64{10, 30, 50}, b.Frames)
+
+	assert.Equal(t, int64(10), b.Next(0))
+	assert.Equal(t, int64(50), b.Next(30))
+	assert.Equal(t, int64(50), b.Next(40))
+	assert.Equal(t, int64(60), b.Next(60))
+	assert.Equal(t, int64(30), b.Previous(50))
+	assert.Equal(t, int64(30), b.Previous(40))
+	assert.Equal(t, int64(5), b.Previous(5))
+
+	// Toggling an existing bookmark removes it.
+	b.Toggle(30)
+	assert.Equal(t, []int64{10, 50}, b.Frames)
+}
+
+func TestLoadBookmarks(t *testing.T) {
+	// Playthroughs are often opened with an absolute path.
+	file := filepath.Join(t.TempDir(), "game.clone1")
+	assert.Empty(t, LoadBookmarks(file).Frames)
+
+	b := Bookmarks{Frames: []int64{10, 50}}
+	b.Save(file)
+	assert.Equal(t, b, LoadBookmarks(file))
+}
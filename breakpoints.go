@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Breakpoint is a condition on the World that pauses playback at the first
+// frame in which it becomes true. This saves a lot of scrubbing back and forth
+// when looking for the moment an edge case happens.
+type Breakpoint struct {
+	Name      string
+	Condition func(w *World) bool
+	// The condition was true in the previous frame. Playback only pauses when
+	// the condition goes from false to true, otherwise it would stay paused
+	// for as long as the condition holds.
+	WasTrue bool
+}
+
+// ParseBreakpoint creates a Breakpoint from its description in the config.
+// The possible descriptions are:
+// - "Val==N": a brick with value N exists
+// - "State==S": the World is in state S (Regular, ComingUp, Lost or Won)
+// - "Intersect": two bricks intersect
+func ParseBreakpoint(s string) (b Breakpoint, err error) {
+	b.Name = s
+	if val, ok := strings.CutPrefix(s, "Val=="); ok {
+		v, err := strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			return b, fmt.Errorf("invalid breakpoint %q: %w", s, err)
+		}
+		b.Condition = func(w *World) bool {
+			for i := range w.Bricks {
+				if w.Bricks[i].Val == v && !w.Bricks[i].Stone {
+					return true
+				}
+			}
+			return false
+		}
+		return b, nil
+	}
+
+	if name, ok := strings.CutPrefix(s, "State=="); ok {
+		state, found := worldStateNames[name]
+		if !found {
+			return b, fmt.Errorf("invalid breakpoint %q: unknown state %q",
+				s, name)
+		}
+		b.Condition = func(w *World) bool { return w.State == state }
+		return b, nil
+	}
+
+	if s == "Intersect" {
+		b.Condition = func(w *World) bool {
+			for i := range w.Bricks {
+				for j := i + 1; j < len(w.Bricks); j++ {
+					if w.Bricks[i].Bounds.Intersects(w.Bricks[j].Bounds) {
+						return true
+					}
+				}
+			}
+			return false
+		}
+		return b, nil
+	}
+
+	return b, fmt.Errorf("invalid breakpoint %q", s)
+}
+
+// ResetBreakpoints evaluates the breakpoints for w without triggering any of
+// them. It is meant for when playback jumps to a different frame, which
+// shouldn't count as the conditions becoming true.
+func ResetBreakpoints(breakpoints []Breakpoint, w *World) {
+	for i := range breakpoints {
+		breakpoints[i].WasTrue = breakpoints[i].Condition(w)
+	}
+}
+
+// CheckBreakpoints evaluates the breakpoints for w and returns the first one
+// whose condition just became true, or nil if there is none.
+func CheckBreakpoints(breakpoints []Breakpoint, w *World) (hit *Breakpoint) {
+	for i := range breakpoints {
+		b := &breakpoints[i]
+		isTrue := b.Condition(w)
+		if isTrue && !b.WasTrue && hit == nil {
+			hit = b
+		}
+		b.WasTrue = isTrue
+	}
+	return
+}
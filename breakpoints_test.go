@@ -0,0 +1,41 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestParseBreakpoint(t *testing.T) {
+	var l Level
+	l.TimerDisabled = true
+	l.BricksParams = []BrickParams{
+		{Pos: CanonicalPosToPixelPos(Pt{0, 0}), Val: 3},
+		{Pos: CanonicalPosToPixelPos(Pt{0, 3}), Val: 3},
+	}
+	w := NewWorld(0, l)
+
+	val4, err := ParseBreakpoint("Val==4")
+	require.NoError(t, err)
+	regular, err := ParseBreakpoint("State==Regular")
+	require.NoError(t, err)
+	breakpoints := []Breakpoint{val4, regular}
+	ResetBreakpoints(breakpoints, &w)
+
+	// The breakpoint triggers once, in the frame in which the bricks merge.
+	var hits []string
+	for range 100 {
+		w.Step(PlayerInput{})
+		if hit := CheckBreakpoints(breakpoints, &w); hit != nil {
+			hits = append(hits, hit.Name)
+		}
+	}
+	assert.Equal(t, []string{"Val==4"}, hits)
+
+	_, err = ParseBreakpoint("Val==abc")
+	assert.Error(t, err)
+	_, err = ParseBreakpoint("State==Sleeping")
+	assert.Error(t, err)
+	_, err = ParseBreakpoint("Whatever")
+	assert.Error(t, err)
+}
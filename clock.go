@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// Clock is the source of wall-clock time for everything that ends up in
+// recorded data: the seed of a new playthrough, the timestamps in the names of
+// error recordings etc.
+// The Gui never calls time.Now() directly for these things. This way a test
+// can give the Gui a FixedClock and know exactly which seed and which file
+// names will come out of a startup flow.
+// Things that are purely about measuring the real passage of time (e.g. how
+// long a frame took to draw) don't go through the Clock, they are not recorded
+// and it makes no sense to fake them.
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is the Clock used by the actual game. It just asks the OS.
+type SystemClock struct{}
+
+func (c SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// FixedClock always returns the same moment. It is meant for tests.
+type FixedClock struct {
+	Moment time.Time
+}
+
+func (c FixedClock) Now() time.Time {
+	return c.Moment
+}
+
+// SeedPolicy decides where the seed of a new playthrough comes from.
+// The policy is chosen via Config.SeedPolicy:
+// - "Time" (or empty): the seed is the current time of the Clock, in
+// nanoseconds. This is what regular play uses.
+// - "Curated": the seed is picked from Config.CuratedSeeds. This is useful if
+// I want players to only ever see starting configurations that I checked by
+// hand. The Clock is still used to decide which seed in the list is picked.
+// - "Fixed": the seed is always Config.Seed. This is useful for comparing
+// several runs on exactly the same level, for example a seed the user typed.
+type SeedPolicy string
+
+const (
+	SeedFromTime    SeedPolicy = "Time"
+	SeedFromCurated SeedPolicy = "Curated"
+	SeedFixed       SeedPolicy = "Fixed"
+)
+
+// ChooseSeed returns the seed for a new playthrough, according to policy.
+func ChooseSeed(policy SeedPolicy, clock Clock, curated []int64,
+	fixed int64) int64 {
+	switch policy {
+	case SeedFromTime, "":
+		return clock.Now().UnixNano()
+	case SeedFromCurated:
+		if len(curated) == 0 {
+			Check(fmt.Errorf("seed policy is %s but no curated seeds are "+
+				"configured", policy))
+			return clock.Now().UnixNano()
+		}
+		idx := clock.Now().UnixNano() % int64(len(curated))
+		if idx < 0 {
+			idx += int64(len(curated))
+		}
+		return curated[idx]
+	case SeedFixed:
+		return fixed
+	default:
+		Check(fmt.Errorf("invalid seed policy: %s", policy))
+		return clock.Now().UnixNano()
+	}
+}
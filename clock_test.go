@@ -0,0 +1,60 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestGui_InitializeWorldToNewGameSeedFromTime(t *testing.T) {
+	var g Gui
+	g.FSys = &embeddedFiles
+	g.playthrough.SimulationVersion = SimulationVersion
+	g.clock = FixedClock{time.Unix(0, 123456789)}
+	g.InitializeWorldToNewGame()
+	assert.Equal(t, int64(123456789), g.playthrough.Seed)
+	assert.Equal(t, int64(123456789), g.world.Seed)
+}
+
+func TestGui_InitializeWorldToNewGameSeedCurated(t *testing.T) {
+	var g Gui
+	g.FSys = &embeddedFiles
+	g.playthrough.SimulationVersion = SimulationVersion
+	g.SeedPolicy = SeedFromCurated
+	g.CuratedSeeds = []int64{10, 20, 30}
+	g.clock = FixedClock{time.Unix(0, 4)}
+	g.InitializeWorldToNewGame()
+	assert.Equal(t, int64(20), g.playthrough.Seed)
+}
+
+func TestGui_InitializeWorldToNewGameSeedFixed(t *testing.T) {
+	var g Gui
+	g.FSys = &embeddedFiles
+	g.playthrough.SimulationVersion = SimulationVersion
+	g.SeedPolicy = SeedFixed
+	g.Seed = 77
+	g.clock = FixedClock{time.Unix(0, 4)}
+	g.InitializeWorldToNewGame()
+	assert.Equal(t, int64(77), g.playthrough.Seed)
+
+	// The same seed always gives the same world.
+	w1 := g.world
+	g.InitializeWorldToNewGame()
+	assert.Equal(t, w1.StateBytes(), g.world.StateBytes())
+}
+
+func TestGui_RecordInputMoment(t *testing.T) {
+	var g Gui
+	g.clock = FixedClock{time.UnixMilli(1700000000123)}
+	g.RecordInput(PlayerInput{JustPressed: true})
+	assert.Equal(t, int64(1700000000123), g.playthrough.History[0].Moment)
+
+	// The Moment survives serialization and plays no part in the simulation.
+	p := g.playthrough
+	p.InputVersion = InputVersion
+	p.SimulationVersion = SimulationVersion
+	assert.Equal(t, p.History, DeserializePlaythrough(p.Serialize()).History)
+	expected := RegressionId(p)
+	p.History[0].Moment = 0
+	assert.Equal(t, expected, RegressionId(p))
+}
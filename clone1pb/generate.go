@@ -0,0 +1,5 @@
+// Package clone1pb holds the protobuf messages that a Playthrough is saved
+// as, generated from playthrough.proto.
+package clone1pb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative playthrough.proto
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"archive/zip"
+	"flag"
+	"fmt"
+	"github.com/goccy/go-yaml"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The release tool builds the executables that are given to players and
+// packages each of them together with the data folder and a manifest.
+//
+// The philosophy of this project is to release a different executable for
+// each variation (see ReleaseVersion in the game's main.go). This tool is what
+// makes that philosophy cheap: one command per variation, and the manifest
+// inside the package records exactly what the variation is.
+//
+// It must be run from the root of the repository:
+// go run ./cmd/release -tags assert_disabled,http_enabled
+
+type Manifest struct {
+	ReleaseVersion    int64    `yaml:"ReleaseVersion"`
+	SimulationVersion int64    `yaml:"SimulationVersion"`
+	InputVersion      int64    `yaml:"InputVersion"`
+	Commit            string   `yaml:"Commit"`
+	CommitDirty       bool     `yaml:"CommitDirty"`
+	BuildTags         []string `yaml:"BuildTags"`
+	BuildMoment       string   `yaml:"BuildMoment"`
+	GoVersion         string   `yaml:"GoVersion"`
+	Artifacts         []string `yaml:"Artifacts"`
+	SelfTestPassed    bool     `yaml:"SelfTestPassed"`
+	// The executable the self-test ran: one of the Artifacts, or an
+	// executable built from the same code for the platform of the release
+	// tool, if none of the Artifacts could run there.
+	SelfTestedWith string `yaml:"SelfTestedWith"`
+	// The executables sign their requests to the server with the secret in
+	// releaseSecretEnvVar. The secret itself is not in the manifest.
+	SignsRequests bool `yaml:"SignsRequests"`
+	// The pins of the server's keys that the executables built with
+	// pinning_enabled accept, from pinnedKeysEnvVar. Unlike the secret, they
+	// are public.
+	PinnedKeys []string `yaml:"PinnedKeys"`
+}
+
+// The environment variable with the secret that the released executables sign
+// their requests with, see releaseSecret in the game's auth.go. The server
+// must have the same secret for this ReleaseVersion.
+const releaseSecretEnvVar = "CLONE1_RELEASE_SECRET"
+
+// The environment variable with the pins of the server's keys, separated by
+// commas, see pinnedKeys in the game's pinning_enabled.go. Only used with
+// pinning_enabled.
+const pinnedKeysEnvVar = "CLONE1_PINNED_KEYS"
+
+func main() {
+	tags := flag.String("tags", "assert_disabled,http_enabled",
+		"build tags for the released executables")
+	outDir := flag.String("out", "release", "folder for the release packages")
+	windows := flag.Bool("windows", true, "build the Windows executable")
+	wasm := flag.Bool("wasm", true, "build the WASM bundle")
+	selfTest := flag.Bool("self-test", true,
+		"replay the regression tests with the released code")
+	flag.Parse()
+
+	var m Manifest
+	m.ReleaseVersion = ReadIntConst("main.go", "ReleaseVersion")
+	m.SimulationVersion = ReadIntConst("world.go", "SimulationVersion")
+	m.InputVersion = ReadIntConst("playthrough.go", "InputVersion")
+	m.Commit = strings.TrimSpace(Run(nil, "git", "rev-parse", "HEAD"))
+	m.CommitDirty = strings.TrimSpace(Run(nil, "git", "status",
+		"--porcelain")) != ""
+	m.BuildTags = strings.Split(*tags, ",")
+	m.BuildMoment = time.Now().Format("2006-01-02 15:04:05")
+	m.GoVersion = runtime.Version()
+	secret := os.Getenv(releaseSecretEnvVar)
+	m.SignsRequests = secret != ""
+	if !m.SignsRequests {
+		fmt.Printf("WARNING: %s is not set, the server will reject the "+
+			"requests of this release if it checks signatures\n",
+			releaseSecretEnvVar)
+	}
+	pins := ""
+	if slices.Contains(m.BuildTags, "pinning_enabled") {
+		pins = os.Getenv(pinnedKeysEnvVar)
+		if pins == "" {
+			Check(fmt.Errorf("%s is not set, the executables built with "+
+				"pinning_enabled would not accept any server",
+				pinnedKeysEnvVar))
+		}
+		m.PinnedKeys = strings.Split(pins, ",")
+	}
+	ldflags := LinkerFlags(secret, pins)
+	if m.CommitDirty {
+		fmt.Println("WARNING: the working tree has uncommitted changes, the " +
+			"commit in the manifest does not describe the release exactly")
+	}
+
+	name := fmt.Sprintf("clone1-%02d-%02d-%02d", m.ReleaseVersion,
+		m.SimulationVersion, m.InputVersion)
+	dir := filepath.Join(*outDir, name)
+	Check(os.RemoveAll(dir))
+	Check(os.MkdirAll(dir, 0755))
+
+	if *windows {
+		exe := name + ".exe"
+		Build("windows", "amd64", *tags, ldflags, filepath.Join(dir, exe))
+		m.Artifacts = append(m.Artifacts, exe)
+	}
+	if *wasm {
+		wasmFile := name + ".wasm"
+		Build("js", "wasm", *tags, ldflags, filepath.Join(dir, wasmFile))
+		goRoot := strings.TrimSpace(Run(nil, "go", "env", "GOROOT"))
+		CopyFile(filepath.Join(goRoot, "lib", "wasm", "wasm_exec.js"),
+			filepath.Join(dir, "wasm_exec.js"))
+		m.Artifacts = append(m.Artifacts, wasmFile, "wasm_exec.js")
+	}
+
+	if *selfTest {
+		m.SelfTestPassed, m.SelfTestedWith = SelfTest(*tags, ldflags, dir)
+	}
+
+	data, err := yaml.Marshal(m)
+	Check(err)
+	WriteFile(filepath.Join(dir, "manifest.yaml"), data)
+
+	zipFile := filepath.Join(*outDir, name+".zip")
+	ZipRelease(zipFile, dir, "data")
+	fmt.Printf("release package: %s\n", zipFile)
+	if *selfTest && !m.SelfTestPassed {
+		fmt.Println("WARNING: the self-test failed, see the output above")
+		os.Exit(1)
+	}
+}
+
+// ReadIntConst returns the value of an integer constant declared at the top
+// level of a Go file in the game's package. The game is a main package so it
+// can't be imported, and the versions are only ever declared as literals, so
+// parsing the source is enough.
+func ReadIntConst(filename string, constName string) int64 {
+	f, err := parser.ParseFile(token.NewFileSet(), filename, nil, 0)
+	Check(err)
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			v := spec.(*ast.ValueSpec)
+			for i, n := range v.Names {
+				if n.Name != constName {
+					continue
+				}
+				lit, ok := v.Values[i].(*ast.BasicLit)
+				if !ok {
+					Check(fmt.Errorf("%s in %s is not a literal", constName,
+						filename))
+				}
+				val, err := strconv.ParseInt(lit.Value, 10, 64)
+				Check(err)
+				return val
+			}
+		}
+	}
+	Check(fmt.Errorf("%s not found in %s", constName, filename))
+	return 0
+}
+
+// LinkerFlags sets the variables of the game that aren't in the repo. If
+// secret is not empty, the game signs its requests with it. If pins is not
+// empty, builds with pinning_enabled only accept those keys of the server.
+func LinkerFlags(secret string, pins string) string {
+	var flags []string
+	if secret != "" {
+		flags = append(flags, "-X main.releaseSecret="+secret)
+	}
+	if pins != "" {
+		flags = append(flags, "-X main.pinnedKeys="+pins)
+	}
+	return strings.Join(flags, " ")
+}
+
+// Build compiles the game for a target platform, with the ldflags of
+// LinkerFlags.
+func Build(goos string, goarch string, tags string, ldflags string,
+	output string) {
+	fmt.Printf("building %s\n", output)
+	env := append(os.Environ(), "GOOS="+goos, "GOARCH="+goarch)
+	args := []string{"build", "-tags", tags, "-o", output}
+	if ldflags != "" {
+		args = append(args, "-ldflags", ldflags)
+	}
+	Run(env, "go", append(args, ".")...)
+}
+
+// SelfTest runs the game's self-test with the code of the release and
+// returns whether it passed and which executable it ran.
+// The Windows executable is run directly if we are on Windows, or with wine
+// if it is installed. Otherwise (and for the WASM bundle, which needs a
+// browser) the same code is built for the current platform, with the same
+// tags, and that executable is run instead. That checks the code of the
+// release but not the shipped executable, which a different platform or
+// compiler backend may run differently, so the manifest says which one ran.
+func SelfTest(tags string, ldflags string, dir string) (passed bool,
+	testedWith string) {
+	var exe string
+	var runner []string
+	matches, err := filepath.Glob(filepath.Join(dir, "*.exe"))
+	Check(err)
+	if len(matches) > 0 {
+		if runtime.GOOS == "windows" {
+			exe = matches[0]
+		} else if wine, err := exec.LookPath("wine"); err == nil {
+			exe = matches[0]
+			runner = []string{wine}
+		}
+	}
+	if exe != "" {
+		testedWith = filepath.Base(exe)
+	} else {
+		tmpDir, err := os.MkdirTemp("", "clone1-release")
+		Check(err)
+		defer func() { Check(os.RemoveAll(tmpDir)) }()
+		exe = filepath.Join(tmpDir, "clone1-self-test")
+		if runtime.GOOS == "windows" {
+			exe += ".exe"
+		}
+		Build(runtime.GOOS, runtime.GOARCH, tags, ldflags, exe)
+		testedWith = fmt.Sprintf("rebuilt for %s/%s", runtime.GOOS,
+			runtime.GOARCH)
+		fmt.Println("WARNING: none of the artifacts can run here, the " +
+			"self-test runs the same code built for this platform instead")
+	}
+
+	fmt.Printf("running self-test with %s\n", exe)
+	absExe, err := filepath.Abs(exe)
+	Check(err)
+	args := append(runner, absExe, "self-test")
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run() == nil, testedWith
+}
+
+// ZipRelease creates a zip archive containing everything in dir (at the root
+// of the archive) and the data folder (as a data folder in the archive).
+func ZipRelease(zipFile string, dir string, dataDir string) {
+	out, err := os.Create(zipFile)
+	Check(err)
+	defer func(file *os.File) { Check(file.Close()) }(out)
+	w := zip.NewWriter(out)
+	AddToZip(w, dir, "")
+	AddToZip(w, dataDir, "data")
+	Check(w.Close())
+}
+
+func AddToZip(w *zip.Writer, root string, prefix string) {
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry,
+		err error) error {
+		Check(err)
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		Check(err)
+		name := filepath.ToSlash(filepath.Join(prefix, rel))
+		f, err := w.Create(name)
+		Check(err)
+		src, err := os.Open(path)
+		Check(err)
+		defer func(file *os.File) { Check(file.Close()) }(src)
+		_, err = io.Copy(f, src)
+		Check(err)
+		return nil
+	})
+	Check(err)
+}
+
+// Run executes a command and returns its stdout. Any failure is fatal for the
+// release.
+func Run(env []string, name string, args ...string) string {
+	cmd := exec.Command(name, args...)
+	cmd.Env = env
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		Check(fmt.Errorf("%s %s failed: %w", name, strings.Join(args, " "),
+			err))
+	}
+	return string(out)
+}
+
+func CopyFile(source, dest string) {
+	data, err := os.ReadFile(source)
+	Check(err)
+	WriteFile(dest, data)
+}
+
+func Check(e error) {
+	if e != nil {
+		panic(e)
+	}
+}
+
+func WriteFile(name string, data []byte) {
+	err := os.WriteFile(name, data, 0644)
+	Check(err)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"fmt"
+	"github.com/klauspost/compress/zstd"
+	"io"
+)
+
+// Compression decides how a Playthrough is compressed when it is serialized.
+// The compression is chosen via Config.Compression:
+// - "Zip" (or empty): a zip archive with a single file in it. This is what
+// all the playthroughs recorded so far use.
+// - "Zstd": zstd, which is faster and gives smaller files. This is meant for
+// uploads from the browser, over slow connections.
+// Playthroughs are decompressed the same way no matter the Compression, see
+// Decompress.
+type Compression string
+
+const (
+	ZipCompression  Compression = "Zip"
+	ZstdCompression Compression = "Zstd"
+)
+
+// CompressionParams groups everything that decides how data is compressed.
+// They are part of the Config, so that each build can make its own trade-off
+// between CPU and size, e.g. WASM builds spend as little CPU as possible.
+type CompressionParams struct {
+	Compression Compression `yaml:"Compression"`
+	// The level of the compression: 1 (fastest) to 9 (smallest) for zip, 1
+	// to 22 for zstd, like the zstd command line tool. 0 means the default
+	// level.
+	CompressionLevel int64 `yaml:"CompressionLevel"`
+	// Data shorter than this many bytes is stored raw. Compressing a short
+	// playthrough saves next to nothing and it still costs CPU. 0 means
+	// everything is compressed.
+	RawBelowSize int64 `yaml:"RawBelowSize"`
+}
+
+// A zip archive starts with the bytes "PK". Data compressed in other ways
+// starts with a format byte, which says how the rest is compressed. The format
+// bytes must never be 'P', so that old playthroughs can still be read. See
+// recordingStreamFormat and encryptedFormat as well.
+const (
+	zstdFormat byte = 1
+	rawFormat  byte = 5
+)
+
+func Compress(data []byte, c CompressionParams) []byte {
+	if int64(len(data)) < c.RawBelowSize {
+		return append([]byte{rawFormat}, data...)
+	}
+	switch c.Compression {
+	case ZipCompression, "":
+		return zipWithLevel(data, c.CompressionLevel)
+	case ZstdCompression:
+		level := zstd.SpeedDefault
+		if c.CompressionLevel != 0 {
+			level = zstd.EncoderLevelFromZstd(int(c.CompressionLevel))
+		}
+		encoder, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(level))
+		Check(err)
+		defer func() { Check(encoder.Close()) }()
+		return encoder.EncodeAll(data, []byte{zstdFormat})
+	default:
+		Check(fmt.Errorf("unknown compression: %s", c.Compression))
+		return nil
+	}
+}
+
+// zipWithLevel is Zip, with the given level of deflate compression.
+func zipWithLevel(data []byte, level int64) []byte {
+	if level == 0 {
+		return Zip(data)
+	}
+	if level < flate.BestSpeed || level > flate.BestCompression {
+		Check(fmt.Errorf("invalid zip compression level: %d", level))
+	}
+	buf := new(bytes.Buffer)
+	w := zip.NewWriter(buf)
+	w.RegisterCompressor(zip.Deflate, func(out io.Writer) (io.WriteCloser,
+		error) {
+		return flate.NewWriter(out, int(level))
+	})
+	f, err := w.Create("recorded-inputs")
+	Check(err)
+	_, err = f.Write(data)
+	Check(err)
+	Check(w.Close())
+	return buf.Bytes()
+}
+
+// Decompress reverses Compress, for any CompressionParams.
+func Decompress(data []byte) []byte {
+	if len(data) >= 2 && data[0] == 'P' && data[1] == 'K' {
+		return Unzip(data)
+	}
+	if len(data) == 0 {
+		Check(fmt.Errorf("can't decompress empty data"))
+	}
+	switch data[0] {
+	case encryptedFormat:
+		Check(fmt.Errorf("the data is encrypted, it must be decrypted " +
+			"first"))
+		return nil
+	case rawFormat:
+		return data[1:]
+	case zstdFormat:
+		decoder, err := zstd.NewReader(nil)
+		Check(err)
+		defer decoder.Close()
+		decompressed, err := decoder.DecodeAll(data[1:], nil)
+		Check(err)
+		return decompressed
+	default:
+		Check(fmt.Errorf("unknown compression format: %d", data[0]))
+		return nil
+	}
+}
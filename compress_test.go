@@ -0,0 +1,64 @@
+package main
+
+import (
+	"github.com/goccy/go-yaml"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCompress(t *testing.T) {
+	data := []byte("some data, some data, some data")
+	for _, c := range []Compression{"", ZipCompression, ZstdCompression} {
+		assert.Equal(t, data,
+			Decompress(Compress(data, CompressionParams{Compression: c})), c)
+		for _, level := range []int64{1, 9} {
+			params := CompressionParams{Compression: c, CompressionLevel: level}
+			assert.Equal(t, data, Decompress(Compress(data, params)), c)
+		}
+	}
+	assert.Panics(t, func() {
+		Compress(data, CompressionParams{Compression: "Rar"})
+	})
+	assert.Panics(t, func() {
+		Compress(data, CompressionParams{CompressionLevel: 10})
+	})
+	assert.Panics(t, func() { Decompress([]byte{'R', 'a', 'r'}) })
+	assert.Panics(t, func() { Decompress(nil) })
+}
+
+func TestCompress_RawBelowSize(t *testing.T) {
+	data := []byte("some data, some data, some data")
+	c := CompressionParams{Compression: ZstdCompression,
+		RawBelowSize: int64(len(data)) + 1}
+	raw := Compress(data, c)
+	assert.Equal(t, append([]byte{rawFormat}, data...), raw)
+	assert.Equal(t, data, Decompress(raw))
+
+	// At the threshold, data is compressed.
+	c.RawBelowSize = int64(len(data))
+	assert.NotEqual(t, rawFormat, Compress(data, c)[0])
+}
+
+func TestConfig_CompressionParams(t *testing.T) {
+	var c Config
+	assert.NoError(t, yaml.Unmarshal([]byte("Compression: Zstd\n"+
+		"CompressionLevel: 3\nRawBelowSize: 100\n"), &c))
+	assert.Equal(t, CompressionParams{ZstdCompression, 3, 100},
+		c.CompressionParams)
+}
+
+func TestPlaythrough_SerializeWith(t *testing.T) {
+	// The demo is compressed with zip, the default.
+	data := ReadFile("data/demo.clone1")
+	p := DeserializePlaythrough(data)
+	zstd := p.SerializeWith(CompressionParams{Compression: ZstdCompression})
+	assert.Less(t, len(zstd), len(data))
+	assert.Equal(t, p, DeserializePlaythrough(zstd))
+
+	// A short playthrough is stored raw.
+	short := *p.Clone()
+	short.History = short.History[:10]
+	raw := short.SerializeWith(CompressionParams{RawBelowSize: 1000})
+	assert.Equal(t, rawFormat, stripFileHeader(raw)[0])
+	assert.Equal(t, short, DeserializePlaythrough(raw))
+}
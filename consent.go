@@ -0,0 +1,78 @@
+package main
+
+import (
+	"github.com/goccy/go-yaml"
+)
+
+// The first time the game runs, before anything is uploaded, the player is
+// asked if the game may send their games, scores and crash reports to the
+// server, see the consent screen. The answer is stored like the Identity and
+// can be changed from the home screen. Opting out stops the uploads at once,
+// in the same build: the playthroughs, the UserData, the analytics events and
+// the logs and crash reports stay on the device. Reading from the server,
+// e.g. the leaderboard, goes on either way.
+
+const consentStorageKey = "consent.yaml"
+
+type Consent struct {
+	// False until the player answers the consent screen.
+	Answered bool `yaml:"Answered"`
+	OptedOut bool `yaml:"OptedOut"`
+}
+
+// LoadConsent returns the stored Consent. Until the player answers, they are
+// opted out, so nothing is uploaded without their consent.
+func LoadConsent() Consent {
+	s, found := LoadStoredString(consentStorageKey)
+	return ResolveConsent(s, found)
+}
+
+// ResolveConsent reads a stored Consent. One that can't be read, e.g.
+// because the file was edited by hand, is as good as none: the player is
+// asked again.
+func ResolveConsent(stored string, found bool) (c Consent) {
+	if found {
+		_ = yaml.Unmarshal([]byte(stored), &c)
+	}
+	if !c.Answered {
+		c = Consent{OptedOut: true}
+	}
+	return
+}
+
+// StoreConsent remembers the answer of the player for the next runs.
+func StoreConsent(c Consent) {
+	data, err := yaml.Marshal(c)
+	Check(err)
+	StoreString(consentStorageKey, string(data))
+}
+
+// UploadsAllowed is true unless the player opted out of uploads, or didn't
+// answer yet.
+func (g *Gui) UploadsAllowed() bool {
+	return !g.consent.OptedOut
+}
+
+// OpenConsent shows the consent screen, which goes to next once the player
+// answers.
+func (g *Gui) OpenConsent(next GameState) {
+	g.consentNext = next
+}
+
+func (g *Gui) UpdateConsentScreen() {
+	optedOut := false
+	if g.JustPressed(consentScreenNoButton) {
+		optedOut = true
+	} else if !g.JustPressed(consentScreenYesButton) {
+		return
+	}
+	g.consent = Consent{Answered: true, OptedOut: optedOut}
+	StoreConsent(g.consent)
+	g.AddBreadcrumb("game", "uploads opted out: %v", optedOut)
+	if g.consentNext == PlayScreen {
+		// The game that waited for the answer starts over, so that it is
+		// uploaded from the start, or not at all.
+		g.InitializeWorldToNewGame()
+	}
+	g.ChangeState(g.consentNext, ConsentButtonPressed)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestResolveConsent(t *testing.T) {
+	// Nothing is uploaded until the player answers.
+	assert.Equal(t, Consent{OptedOut: true}, ResolveConsent("", false))
+	assert.Equal(t, Consent{OptedOut: true}, ResolveConsent("{{{", true))
+
+	assert.Equal(t, Consent{Answered: true},
+		ResolveConsent("Answered: true\nOptedOut: false\n", true))
+	assert.Equal(t, Consent{Answered: true, OptedOut: true},
+		ResolveConsent("Answered: true\nOptedOut: true\n", true))
+}
+
+func TestLoadConsent(t *testing.T) {
+	t.Chdir(t.TempDir())
+	assert.False(t, LoadConsent().Answered)
+	StoreConsent(Consent{Answered: true})
+	assert.Equal(t, Consent{Answered: true}, LoadConsent())
+}
+
+func TestGui_OptedOut(t *testing.T) {
+	var g Gui
+	g.FSys = &embeddedFiles
+	g.clock = FixedClock{time.UnixMilli(1700000000000)}
+	g.playthrough = DeserializePlaythrough(ReadFile("data/demo.clone1"))
+	g.telemetry = NopSink{}
+	g.UploadPlaybackToHttp = true
+	g.LogNonErrors = true
+	g.uploadDataChannel = make(chan uploadData, 10)
+	g.uploadUserDataChannel = make(chan UserData, 10)
+	g.uploadLogChannel = make(chan logData, 10)
+	g.analyticsChannel = make(chan AnalyticsEvent, 10)
+	g.consent = Consent{Answered: true, OptedOut: true}
+
+	// Nothing goes to the uploads, but the game is still recorded.
+	g.InitializeWorldToNewGame()
+	assert.True(t, g.playthrough.Metadata.OptedOut)
+	g.uploadWorld(true)
+	g.uploadUserData()
+	g.Log("info", "message")
+	g.RecordAnalytics(AnalyticsEvent{Name: AnalyticsGameOver})
+	assert.Empty(t, g.uploadDataChannel)
+	assert.Empty(t, g.uploadUserDataChannel)
+	assert.Empty(t, g.uploadLogChannel)
+	assert.Empty(t, g.analyticsChannel)
+
+	// Opting in takes effect at once.
+	g.consent.OptedOut = false
+	g.InitializeWorldToNewGame()
+	assert.False(t, g.playthrough.Metadata.OptedOut)
+	g.uploadWorld(true)
+	assert.Len(t, g.uploadDataChannel, 1)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// SimulationConstants are the numbers that define the simulation of this
+// executable. Tools that analyze recorded playthroughs need some of them (e.g.
+// to normalize by the timer cooldown). They get them from the executable that
+// recorded the playthroughs, with the export-constants command, instead of
+// keeping copies that drift out of date.
+type SimulationConstants struct {
+	ReleaseVersion           int64
+	SimulationVersion        int64
+	InputVersion             int64
+	FramesPerSecond          int64
+	NCols                    int64
+	NRows                    int64
+	BrickPixelSize           int64
+	BrickMarginPixelSize     int64
+	PlayAreaWidth            int64
+	PlayAreaHeight           int64
+	MaxPointers              int64
+	MaxBrickValue            int64
+	MaxInitialBrickValue     int64
+	DragSpeed                int64
+	CanonicalAdjustmentSpeed int64
+	BrickFallAcceleration    int64
+	DefaultDifficultyParams  DifficultyParams
+}
+
+func GetSimulationConstants() (c SimulationConstants) {
+	c.ReleaseVersion = ReleaseVersion
+	c.SimulationVersion = SimulationVersion
+	c.InputVersion = InputVersion
+	c.FramesPerSecond = ebiten.DefaultTPS
+	c.NCols = NCols
+	c.NRows = NRows
+	c.BrickPixelSize = BrickPixelSize
+	c.BrickMarginPixelSize = BrickMarginPixelSize
+	c.PlayAreaWidth = PlayAreaWidth
+	c.PlayAreaHeight = PlayAreaHeight
+	c.MaxPointers = MaxPointers
+
+	// Some constants are only set by NewWorld. Take them from an actual World
+	// so that they can't be different from what the simulation uses.
+	w := NewWorld(0, Level{})
+	c.MaxBrickValue = w.MaxBrickValue
+	c.MaxInitialBrickValue = w.MaxInitialBrickValue
+	c.DragSpeed = w.DragSpeed
+	c.CanonicalAdjustmentSpeed = w.CanonicalAdjustmentSpeed
+	c.BrickFallAcceleration = w.BrickFallAcceleration
+	c.DefaultDifficultyParams = DefaultDifficultyParams()
+	return
+}
+
+// ExportSimulationConstants returns the SimulationConstants as indented JSON.
+func ExportSimulationConstants() []byte {
+	data, err := json.MarshalIndent(GetSimulationConstants(), "", "  ")
+	Check(err)
+	return data
+}
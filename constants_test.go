@@ -0,0 +1,19 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestExportSimulationConstants(t *testing.T) {
+	var c SimulationConstants
+	err := json.Unmarshal(ExportSimulationConstants(), &c)
+	require.NoError(t, err)
+	assert.Equal(t, GetSimulationConstants(), c)
+	assert.Equal(t, int64(SimulationVersion), c.SimulationVersion)
+	assert.Equal(t, int64(60), c.FramesPerSecond)
+	assert.Equal(t, int64(678),
+		c.DefaultDifficultyParams.TimerCooldownBase)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// The names that recordings had over time:
+// - name.clone1: the current one, the versions are only inside the file.
+// - name.clone1-016: the ReleaseVersion, from before there were separate
+// simulation and input versions.
+// - name.clone1-19-12: the SimulationVersion and InputVersion.
+// The last two are used by the download tool.
+var recordingName = regexp.MustCompile(`^(.*)\.clone1(-\d{3}|-\d+-\d+)?$`)
+
+// ConvertTree goes through all the recordings in the directory tree under
+// root, whatever their name, and rewrites the ones that are not yet in the
+// current format and with the current name (name.clone1). Recordings that
+// can't be read, e.g. because there is no migration for their InputVersion,
+// and recordings whose new name is taken, are reported and left as they are.
+// It returns the number of recordings that were converted and the number that
+// failed.
+func ConvertTree(root string) (nConverted int, nFailed int) {
+	nFiles := 0
+	err := fs.WalkDir(os.DirFS(root), ".",
+		func(path string, d fs.DirEntry, err error) error {
+			Check(err)
+			if d.IsDir() || !recordingName.MatchString(d.Name()) {
+				return nil
+			}
+			nFiles++
+			file := filepath.Join(root, path)
+			converted, err := convertFile(file)
+			if err != nil {
+				fmt.Printf("FAIL %s: %v\n", file, err)
+				nFailed++
+			} else if converted != "" {
+				fmt.Printf("converted %s to %s\n", file, converted)
+				nConverted++
+			}
+			return nil
+		})
+	Check(err)
+	fmt.Printf("%d files, %d converted, %d failed\n", nFiles, nConverted,
+		nFailed)
+	return
+}
+
+// convertFile returns the name of the converted recording, or "" if it was
+// already fine.
+func convertFile(file string) (converted string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	data := ReadFile(file)
+	target := recordingName.ReplaceAllString(file, "$1.clone1")
+	if target == file && !NeedsMigration(data) {
+		return "", nil
+	}
+	p := DeserializePlaythrough(data)
+	if target != file {
+		if _, err := os.Stat(target); err == nil {
+			Check(fmt.Errorf("%s already exists", target))
+		}
+	}
+	WriteFile(target, p.Serialize())
+	if target != file {
+		DeleteFile(file)
+	}
+	return target, nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConvertTree(t *testing.T) {
+	root := t.TempDir()
+	demo := ReadFile("data/demo.clone1")
+	old := ReadFile("regression-tests/average-playthrough.clone1")
+	Check(os.MkdirAll(filepath.Join(root, "user"), 0755))
+	write := func(name string, data []byte) {
+		WriteFile(filepath.Join(root, name), data)
+	}
+	write("current.clone1", demo)
+	write("user/20240101-120000.clone1-99-99", demo)
+	write("user/20230101-120000.clone1-016", old)
+	write("user/broken.clone1-01-01", []byte("not a recording"))
+	write("user/taken.clone1", demo)
+	write("user/taken.clone1-99-99", demo)
+	write("user/20240101-120000.clone1-hash", []byte("hash"))
+
+	nConverted, nFailed := ConvertTree(root)
+	assert.Equal(t, 2, nConverted)
+	assert.Equal(t, 2, nFailed)
+
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(root, name))
+		return err == nil
+	}
+	assert.True(t, exists("user/20240101-120000.clone1"))
+	assert.False(t, exists("user/20240101-120000.clone1-99-99"))
+	assert.True(t, exists("user/20230101-120000.clone1"))
+	assert.False(t, exists("user/20230101-120000.clone1-016"))
+	assert.True(t, exists("user/broken.clone1-01-01"))
+	assert.True(t, exists("user/taken.clone1-99-99"))
+	assert.True(t, exists("user/20240101-120000.clone1-hash"))
+
+	migrated := ReadFile(filepath.Join(root, "user/20230101-120000.clone1"))
+	assert.False(t, NeedsMigration(migrated))
+
+	// Everything that could be converted was, the rest still fails.
+	nConverted, nFailed = ConvertTree(root)
+	assert.Equal(t, 0, nConverted)
+	assert.Equal(t, 2, nFailed)
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A crash report is what HandlePanic sends and logs: the error and its stack,
+// along with what is needed to understand a crash that only happens on
+// someone else's device, e.g. in a browser, without reproducing it. It has
+// the platform, the memory stats, the breadcrumbs (the last things that
+// happened in the Gui) and the last inputs of the playthrough.
+
+// How many crash reports a session sends to the server at most, see
+// ErrorReportLimiter.
+const maxErrorReports = 10
+
+// How many breadcrumbs are kept, older ones are dropped.
+const maxBreadcrumbs = 50
+
+// How many of the last inputs of the playthrough are in a crash report. The
+// whole playthrough is uploaded along with the report, these are only there
+// to read the report without replaying it.
+const crashReportInputs = 10
+
+type Breadcrumb struct {
+	Moment  time.Time
+	Kind    string
+	Message string
+}
+
+// Breadcrumbs keeps the last maxBreadcrumbs breadcrumbs. The Gui adds them,
+// HandlePanic reads them, maybe on another goroutine.
+type Breadcrumbs struct {
+	mutex   sync.Mutex
+	entries []Breadcrumb
+	// Where the next breadcrumb goes, once entries is full.
+	next int
+}
+
+func (b *Breadcrumbs) Add(moment time.Time, kind string, message string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	crumb := Breadcrumb{moment, kind, message}
+	if len(b.entries) < maxBreadcrumbs {
+		b.entries = append(b.entries, crumb)
+		return
+	}
+	b.entries[b.next] = crumb
+	b.next = (b.next + 1) % maxBreadcrumbs
+}
+
+// List returns the breadcrumbs, oldest first.
+func (b *Breadcrumbs) List() []Breadcrumb {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	list := make([]Breadcrumb, 0, len(b.entries))
+	list = append(list, b.entries[b.next:]...)
+	return append(list, b.entries[:b.next]...)
+}
+
+// AddBreadcrumb adds a breadcrumb at the current time.
+func (g *Gui) AddBreadcrumb(kind string, format string, a ...any) {
+	if g.clock == nil {
+		return
+	}
+	g.breadcrumbs.Add(g.clock.Now(), kind, fmt.Sprintf(format, a...))
+}
+
+// ErrorReportLimiter decides which errors are sent to the server, so that a
+// crash loop, e.g. a failing assert in Draw, doesn't send a report every
+// frame. An error is sent the 1st, 2nd, 4th, 8th etc. time it happens, with
+// how many times it happened, and a session sends at most maxErrorReports
+// reports. Errors are the same if they have the same ErrorSignature.
+type ErrorReportLimiter struct {
+	mutex    sync.Mutex
+	counts   map[string]int64
+	nErrors  int64
+	nReports int64
+}
+
+// ErrorCount is how often errors happened in the session, for the report.
+type ErrorCount struct {
+	// How many times this error happened, including this time.
+	Same int64
+	// How many errors of any kind happened, including this one.
+	All int64
+	// How many errors weren't sent to the server, not counting this one.
+	NotSent int64
+}
+
+// Allow counts an error with the stack trace errorMsg and returns whether to
+// send it to the server.
+func (l *ErrorReportLimiter) Allow(errorMsg string) (send bool,
+	count ErrorCount) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.counts == nil {
+		l.counts = map[string]int64{}
+	}
+	signature := ErrorSignature(errorMsg)
+	l.counts[signature]++
+	l.nErrors++
+	same := l.counts[signature]
+	// Powers of 2.
+	send = same&(same-1) == 0 && l.nReports < maxErrorReports
+	count = ErrorCount{same, l.nErrors, l.nErrors - 1 - l.nReports}
+	if send {
+		l.nReports++
+	}
+	return
+}
+
+var goroutineHeader = regexp.MustCompile(`(?m)^goroutine \d+ `)
+var stackArgs = regexp.MustCompile(`(?m)\([^()]*\)$`)
+var stackOffset = regexp.MustCompile(`(?m) \+0x[0-9a-f]+$`)
+
+// ErrorSignature is the part of errorMsg, see StackTrace, that is the same
+// every time the same error happens: the stack without the id of the
+// goroutine, the values of the arguments and the offsets in the code.
+func ErrorSignature(errorMsg string) string {
+	s := goroutineHeader.ReplaceAllString(errorMsg, "goroutine ")
+	s = stackArgs.ReplaceAllString(s, "()")
+	return stackOffset.ReplaceAllString(s, "")
+}
+
+// CrashReport returns the report for the error errorMsg, see StackTrace.
+func (g *Gui) CrashReport(errorMsg string) string {
+	var s strings.Builder
+	s.WriteString(errorMsg)
+
+	s.WriteString("\n\n--- platform ---\n")
+	m := NewMetadata(SystemClock{}, g.outsideWidth, g.outsideHeight)
+	fmt.Fprintf(&s, "os: %s, arch: %s, wasm: %v, go: %s\n", m.OS, m.Arch,
+		m.Wasm, runtime.Version())
+	fmt.Fprintf(&s, "window: %dx%d, build tags: %s\n", m.ScreenWidth,
+		m.ScreenHeight, strings.Join(m.BuildTags, ","))
+	fmt.Fprintf(&s, "release: %d, simulation: %d, input: %d\n",
+		g.playthrough.ReleaseVersion, g.playthrough.SimulationVersion,
+		g.playthrough.InputVersion)
+	if agent := userAgent(); agent != "" {
+		fmt.Fprintf(&s, "user agent: %s\n", agent)
+	}
+
+	s.WriteString("\n--- memory ---\n")
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	fmt.Fprintf(&s, "heap: %d KB in use, %d KB from the OS, %d GCs, "+
+		"%d goroutines\n", mem.HeapAlloc/1024, mem.Sys/1024, mem.NumGC,
+		runtime.NumGoroutine())
+
+	s.WriteString("\n--- breadcrumbs ---\n")
+	for _, b := range g.breadcrumbs.List() {
+		fmt.Fprintf(&s, "%s %s: %s\n", b.Moment.Format("15:04:05.000"),
+			b.Kind, b.Message)
+	}
+
+	s.WriteString("\n--- last inputs ---\n")
+	history := g.playthrough.History
+	first := max(0, len(history)-crashReportInputs)
+	for i := first; i < len(history); i++ {
+		fmt.Fprintf(&s, "%d: %+v\n", i, history[i])
+	}
+	return s.String()
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBreadcrumbs(t *testing.T) {
+	var b Breadcrumbs
+	assert.Empty(t, b.List())
+
+	start := time.Unix(1700000000, 0)
+	for i := range maxBreadcrumbs + 3 {
+		b.Add(start.Add(time.Duration(i)*time.Second), "test", "")
+	}
+
+	// Only the last ones are kept, oldest first.
+	list := b.List()
+	assert.Len(t, list, maxBreadcrumbs)
+	assert.Equal(t, start.Add(3*time.Second), list[0].Moment)
+	assert.Equal(t, start.Add((maxBreadcrumbs+2)*time.Second),
+		list[len(list)-1].Moment)
+}
+
+func TestGui_CrashReport(t *testing.T) {
+	var g Gui
+	g.clock = FixedClock{time.Unix(1700000000, 0)}
+	g.ChangeState(PlayScreen, PlayButtonPressed)
+	for i := range crashReportInputs + 5 {
+		g.RecordInput(PlayerInput{Pos: Pt{int64(i), 0}})
+	}
+
+	report := g.CrashReport("boom")
+	assert.True(t, strings.HasPrefix(report, "boom"))
+	assert.Contains(t, report, "HomeScreen -> PlayScreen (play button)")
+	assert.Contains(t, report, "heap: ")
+	assert.Contains(t, report, "build tags: assert_enabled")
+
+	// Only the last inputs.
+	assert.Contains(t, report, "\n14: ")
+	assert.NotContains(t, report, "\n4: ")
+	assert.Contains(t, report, "\n5: ")
+}
+
+func TestErrorReportLimiter(t *testing.T) {
+	var l ErrorReportLimiter
+	var sent []ErrorCount
+	for range 20 {
+		if send, count := l.Allow("index out of range"); send {
+			sent = append(sent, count)
+		}
+	}
+	// The same error is sent less and less often, but always with its count.
+	assert.Equal(t, []ErrorCount{{1, 1, 0}, {2, 2, 0}, {4, 4, 1}, {8, 8, 4},
+		{16, 16, 11}}, sent)
+
+	// Other errors are counted separately, up to the limit of the session.
+	nSent := int64(len(sent))
+	for i := range 100 {
+		if send, _ := l.Allow(fmt.Sprintf("error %d", i)); send {
+			nSent++
+		}
+	}
+	assert.Equal(t, int64(maxErrorReports), nSent)
+}
+
+// panicIn returns the stack trace of a panic in a function that gets arg, on
+// a new goroutine.
+func panicIn(arg int) (errorMsg string) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer func() { errorMsg = StackTrace(recover()) }()
+		failWith(&arg)
+	}()
+	<-done
+	return
+}
+
+//go:noinline
+func failWith(arg *int) {
+	panic("failed")
+}
+
+func TestErrorSignature(t *testing.T) {
+	a, b := panicIn(1), panicIn(2)
+	// The goroutines and the arguments differ, the error is the same.
+	assert.NotEqual(t, a, b)
+	assert.Equal(t, ErrorSignature(a), ErrorSignature(b))
+	assert.NotEqual(t, ErrorSignature(a),
+		ErrorSignature(StackTrace("other")))
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/nacl/box"
+	"os"
+)
+
+// Uploaded playthroughs hold usernames and everything the player did, so they
+// can be encrypted before they leave the game. They are sealed with a NaCl
+// anonymous box for the public key in Config.UploadPublicKey, which is
+// embedded in the executable along with the rest of the config. Only the
+// holder of the private key can open them, the server just stores them.
+// An encrypted playthrough starts with encryptedFormat, see Compress for the
+// other format bytes, then the sealed box of what SerializeWith returned.
+const encryptedFormat byte = 4
+
+// The environment variable that holds the private key, for the tools that
+// decrypt downloaded playthroughs. Like the credentials of the database, it
+// is never written in a file of the repo.
+const privateKeyEnvVar = "CLONE1_UPLOAD_PRIVATE_KEY"
+
+type EncryptionKey = [32]byte
+
+// GenerateKeys returns a new pair of keys, as base64 strings, to be used as
+// the UploadPublicKey and the private key.
+func GenerateKeys() (publicKey string, privateKey string) {
+	pub, priv, err := box.GenerateKey(rand.Reader)
+	Check(err)
+	return base64.StdEncoding.EncodeToString(pub[:]),
+		base64.StdEncoding.EncodeToString(priv[:])
+}
+
+// ParseKey reads a key written by GenerateKeys.
+func ParseKey(s string) (key *EncryptionKey) {
+	data, err := base64.StdEncoding.DecodeString(s)
+	Check(err)
+	key = new(EncryptionKey)
+	if len(data) != len(key) {
+		Check(fmt.Errorf("invalid key, it has %d bytes instead of %d",
+			len(data), len(key)))
+	}
+	copy(key[:], data)
+	return
+}
+
+func IsEncrypted(data []byte) bool {
+	return len(data) > 0 && data[0] == encryptedFormat
+}
+
+// Encrypt seals data so that only the holder of the private key of publicKey
+// can open it.
+func Encrypt(data []byte, publicKey *EncryptionKey) []byte {
+	sealed, err := box.SealAnonymous([]byte{encryptedFormat}, data,
+		publicKey, rand.Reader)
+	Check(err)
+	return sealed
+}
+
+// Decrypt reverses Encrypt.
+func Decrypt(data []byte, privateKey *EncryptionKey) []byte {
+	if !IsEncrypted(data) {
+		Check(fmt.Errorf("the data is not encrypted"))
+	}
+	publicKey := new(EncryptionKey)
+	curve25519.ScalarBaseMult(publicKey, privateKey)
+	opened, ok := box.OpenAnonymous(nil, data[1:], publicKey, privateKey)
+	if !ok {
+		Check(fmt.Errorf("can't decrypt the data, it was encrypted for " +
+			"another key or it is corrupted"))
+	}
+	return opened
+}
+
+// PrivateKeyFromEnv returns the private key in privateKeyEnvVar.
+func PrivateKeyFromEnv() *EncryptionKey {
+	s := os.Getenv(privateKeyEnvVar)
+	if s == "" {
+		Check(fmt.Errorf("the private key must be set in %s",
+			privateKeyEnvVar))
+	}
+	return ParseKey(s)
+}
+
+// DecryptDir decrypts, in place, all the files in dir which match pattern
+// and are encrypted. Files that can't be decrypted are reported and left as
+// they are. It returns the number of files that were decrypted and the number
+// that failed.
+func DecryptDir(fsys FS, dir string, pattern string,
+	privateKey *EncryptionKey) (nDecrypted int, nFailed int) {
+	files := GetFiles(fsys, dir, pattern)
+	for _, file := range files {
+		decrypted, err := decryptFile(file, privateKey)
+		if err != nil {
+			fmt.Printf("FAIL %s: %v\n", file, err)
+			nFailed++
+		} else if decrypted {
+			fmt.Printf("decrypted %s\n", file)
+			nDecrypted++
+		}
+	}
+	fmt.Printf("%d files, %d decrypted, %d failed\n", len(files), nDecrypted,
+		nFailed)
+	return
+}
+
+func decryptFile(file string, privateKey *EncryptionKey) (decrypted bool,
+	err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	data := ReadFile(file)
+	if !IsEncrypted(data) {
+		return false, nil
+	}
+	WriteFile(file, Decrypt(data, privateKey))
+	return true, nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+)
+
+func TestEncrypt(t *testing.T) {
+	p := DeserializePlaythrough(ReadFile("data/demo.clone1"))
+	publicKey, privateKey := GenerateKeys()
+	var g Gui
+	g.UploadPublicKey = publicKey
+	data := g.SerializeForUpload(&p)
+	assert.True(t, IsEncrypted(data))
+	assert.Panics(t, func() { DeserializePlaythrough(data) })
+	assert.Equal(t, p,
+		DeserializePlaythrough(Decrypt(data, ParseKey(privateKey))))
+
+	// Only the right key opens it.
+	_, otherKey := GenerateKeys()
+	assert.Panics(t, func() { Decrypt(data, ParseKey(otherKey)) })
+
+	// Without a key, uploads are not encrypted.
+	g.UploadPublicKey = ""
+	assert.False(t, IsEncrypted(g.SerializeForUpload(&p)))
+}
+
+func TestDecryptDir(t *testing.T) {
+	p := DeserializePlaythrough(ReadFile("data/demo.clone1"))
+	publicKey, privateKey := GenerateKeys()
+	t.Chdir(t.TempDir())
+	Check(os.Mkdir("downloads", 0755))
+	WriteFile("downloads/a.clone1", Encrypt(p.Serialize(),
+		ParseKey(publicKey)))
+	WriteFile("downloads/b.clone1-19-12", p.Serialize())
+	WriteFile("downloads/c.clone1", []byte{encryptedFormat, 1, 2, 3})
+
+	nDecrypted, nFailed := DecryptDir(os.DirFS(".").(FS), "downloads",
+		"*.clone1*", ParseKey(privateKey))
+	assert.Equal(t, 1, nDecrypted)
+	assert.Equal(t, 1, nFailed)
+	assert.Equal(t, p, DeserializePlaythrough(ReadFile("downloads/a.clone1")))
+}
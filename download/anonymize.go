@@ -0,0 +1,44 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+)
+
+// Anonymizer replaces the user names of the downloaded playthroughs with
+// hashes, in the names of the folders, the index, the mirror and the
+// reports, so that the downloads can be shared with collaborators without
+// exposing who the testers are. The playthroughs themselves don't have the
+// user names.
+// The hashes are keyed with a secret salt, otherwise anyone with a list of
+// user names could hash them and find them in the downloads. The salt must
+// stay the same from one run to the next, so that a user keeps their hash.
+// A nil Anonymizer leaves the user names as they are.
+type Anonymizer struct {
+	Salt []byte
+}
+
+// NewAnonymizer takes the salt from CLONE1_ANONYMIZATION_SALT, like the
+// credentials of the database, so it stays out of the shared downloads and
+// out of the shell history.
+func NewAnonymizer() *Anonymizer {
+	salt := os.Getenv("CLONE1_ANONYMIZATION_SALT")
+	if salt == "" {
+		Check(errors.New("-anonymize needs CLONE1_ANONYMIZATION_SALT"))
+	}
+	return &Anonymizer{Salt: []byte(salt)}
+}
+
+// User returns the name that user gets in the downloads.
+func (a *Anonymizer) User(user string) string {
+	if a == nil {
+		return user
+	}
+	mac := hmac.New(sha256.New, a.Salt)
+	mac.Write([]byte(user))
+	// 128 bits are more than enough to tell the users apart.
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
@@ -1,67 +1,658 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
 	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"github.com/go-sql-driver/mysql"
 	"github.com/google/uuid"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
+// Where DownloadRecordings remembers how far it got: the last time a
+// playthrough that it downloaded was written to. The next run only fetches
+// the playthroughs that started or changed since, instead of the whole
+// table. Delete the file to download everything again.
+const highWaterMarkFile = "high-water-mark.txt"
+
+// How the dates of -since and -until are written.
+const dateLayout = "2006-01-02"
+
+// How many characters wide the progress bar is.
+const progressBarWidth = 40
+
+// The index of every playthrough downloaded so far, see Index, in the format
+// chosen with -index.
+const (
+	csvIndexFile   = "index.csv"
+	jsonlIndexFile = "index.jsonl"
+)
+
+// Filters choose which playthroughs to download, e.g. all the playthroughs of
+// one user with SimulationVersion 19 from last week. The zero value chooses
+// all of them.
+type Filters struct {
+	User string
+	// The versions, from Min to Max included, see ParseRange.
+	Release    Range
+	Simulation Range
+	Input      Range
+	// The playthroughs that started on Since or later and before Until.
+	// Zero values mean no limit.
+	Since time.Time
+	Until time.Time
+}
+
+type Range struct {
+	Min int64
+	Max int64
+}
+
+// ParseRange reads a version, e.g. "19", or a range of versions, e.g.
+// "17-19". An empty string is every version.
+func ParseRange(s string) (r Range, err error) {
+	if s == "" {
+		return
+	}
+	minStr, maxStr, isRange := strings.Cut(s, "-")
+	r.Min, err = strconv.ParseInt(minStr, 10, 64)
+	if err != nil {
+		return Range{}, fmt.Errorf("invalid version range: %q", s)
+	}
+	r.Max = r.Min
+	if isRange {
+		r.Max, err = strconv.ParseInt(maxStr, 10, 64)
+		if err != nil || r.Max < r.Min {
+			return Range{}, fmt.Errorf("invalid version range: %q", s)
+		}
+	}
+	return
+}
+
+// parseDate reads a date of -since or -until. An empty string is no limit.
+func parseDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(dateLayout, s)
+}
+
+// Empty is true if f chooses every playthrough.
+func (f Filters) Empty() bool {
+	return f == Filters{}
+}
+
+// Where returns the WHERE clause of f and its arguments. The versions that
+// are NULL in the table, from before the split into release, simulation and
+// input versions, never match a range of them.
+func (f Filters) Where() (where string, args []any) {
+	var conditions []string
+	if f.User != "" {
+		conditions = append(conditions, "user = ?")
+		args = append(args, f.User)
+	}
+	for _, c := range []struct {
+		column string
+		r      Range
+	}{
+		{"release_version", f.Release},
+		{"simulation_version", f.Simulation},
+		{"input_version", f.Input},
+	} {
+		if c.r == (Range{}) {
+			continue
+		}
+		conditions = append(conditions, c.column+" BETWEEN ? AND ?")
+		args = append(args, c.r.Min, c.r.Max)
+	}
+	if !f.Since.IsZero() {
+		conditions = append(conditions, "start_moment >= ?")
+		args = append(args, f.Since)
+	}
+	if !f.Until.IsZero() {
+		conditions = append(conditions, "start_moment < ?")
+		args = append(args, f.Until)
+	}
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+	return
+}
+
 func main() {
-	DownloadRecordings()
+	var f Filters
+	var err error
+	flag.StringVar(&f.User, "user", "", "only the playthroughs of this user")
+	release := flag.String("release", "",
+		"only these release versions, e.g. 19 or 17-19")
+	simulation := flag.String("simulation", "",
+		"only these simulation versions, e.g. 19 or 17-19")
+	input := flag.String("input", "",
+		"only these input versions, e.g. 12 or 10-12")
+	since := flag.String("since", "",
+		"only the playthroughs that started on this day or later, "+
+			"e.g. 2024-05-01")
+	until := flag.String("until", "",
+		"only the playthroughs that started before this day, e.g. 2024-05-08")
+	workers := flag.Int("workers", 8, "how many files to write at once")
+	index := flag.String("index", "csv",
+		"the format of the index of the playthroughs: csv, jsonl or none")
+	mirrorFile := flag.String("mirror", "",
+		"also add the playthroughs to this SQLite database, e.g. clone1.db")
+	gamePath := flag.String("game", "",
+		"the game executable that replays the playthroughs added to the "+
+			"mirror, to find their frames and final scores, or validates them")
+	validate := flag.Bool("validate", false,
+		"replay the playthroughs of the SimulationVersion of -game and "+
+			"report the ones that crash, diverge or can't be read")
+	anonymize := flag.Bool("anonymize", false,
+		"replace the user names with hashes salted with "+
+			"CLONE1_ANONYMIZATION_SALT")
+	flag.Parse()
+	if *workers < 1 {
+		Check(fmt.Errorf("invalid number of workers: %d", *workers))
+	}
+	if *index != "csv" && *index != "jsonl" && *index != "none" {
+		Check(fmt.Errorf("invalid index format: %q", *index))
+	}
+	if *gamePath != "" && *mirrorFile == "" && !*validate {
+		Check(errors.New("-game is only used with -mirror or -validate"))
+	}
+	if *validate && *gamePath == "" {
+		Check(errors.New("-validate needs -game"))
+	}
+	f.Release, err = ParseRange(*release)
+	Check(err)
+	f.Simulation, err = ParseRange(*simulation)
+	Check(err)
+	f.Input, err = ParseRange(*input)
+	Check(err)
+	f.Since, err = parseDate(*since)
+	Check(err)
+	f.Until, err = parseDate(*until)
+	Check(err)
+	var mirror *Mirror
+	if *mirrorFile != "" {
+		mirror = OpenMirror(*mirrorFile)
+	}
+	var game *Game
+	if *gamePath != "" {
+		// The mirror wants the frames of every playthrough, validation only
+		// makes sense for the ones the game can reproduce.
+		game = NewGame(*gamePath, mirror != nil)
+	}
+	var anonymizer *Anonymizer
+	if *anonymize {
+		anonymizer = NewAnonymizer()
+	}
+	entries, replays := DownloadRecordings(f, *workers, mirror, game,
+		anonymizer)
+	if mirror != nil {
+		mirror.Close()
+	}
+	if *validate {
+		Validate(entries, replays, game.SimulationVersion)
+	}
+	switch *index {
+	case "csv":
+		StoreCsvIndex(csvIndexFile, MergeIndex(LoadCsvIndex(csvIndexFile),
+			entries))
+	case "jsonl":
+		StoreJsonlIndex(jsonlIndexFile,
+			MergeIndex(LoadJsonlIndex(jsonlIndexFile), entries))
+	}
 }
 
-func DownloadRecordings() {
+// DownloadRecordings downloads the playthroughs chosen by f. Without
+// filters, it only downloads the playthroughs that changed since its last
+// run, see highWaterMarkFile. With filters, it downloads all the
+// playthroughs they choose and leaves the mark alone, because the ones they
+// leave out still have to be downloaded.
+// The rows are listed without their BLOBs and handed to nWorkers goroutines
+// that fetch and write them, so only a few BLOBs are in memory at once and
+// the files start appearing right away.
+// Each file is complete before it gets its name and is then recorded in the
+// manifest, see manifestFile. The playthroughs that are already in it are
+// not fetched again, so a run that died half-way can simply be started
+// again.
+// If game is not nil, the playthroughs it Replays are replayed once written.
+// If mirror is not nil, the rows are added to it too, with the metrics of
+// their replays.
+// The user names are replaced as soon as they are read, by anonymizer, see
+// Anonymizer. The -user filter still takes the real name.
+// It returns an entry of the index for each playthrough it wrote and, in the
+// same order, its replay, or nil if it wasn't replayed.
+func DownloadRecordings(f Filters, nWorkers int, mirror *Mirror,
+	game *Game, anonymizer *Anonymizer) (entries []IndexEntry,
+	replays []*ReplayMetrics) {
+	where, args := f.Where()
+	var mark time.Time
+	if f.Empty() {
+		mark = LoadHighWaterMark()
+		// A playthrough changes until its game is over, so what counts is
+		// when it was last written to. The rows of the second of the mark
+		// are fetched again, in case more were written in that second after
+		// the last run; they are simply overwritten.
+		where = "WHERE COALESCE(end_moment, start_moment) >= ?"
+		args = []any{mark}
+	}
+	// Rows without a playthrough are games whose upload didn't start yet.
+	// They are downloaded once they have one, since that changes them.
+	if where == "" {
+		where = "WHERE LENGTH(playthrough) > 0"
+	} else {
+		where += " AND LENGTH(playthrough) > 0"
+	}
 	db := ConnectToDbSql()
-	rows, err := db.Query("SELECT " +
-		"start_moment, " +
-		"COALESCE(end_moment, start_moment), " +
-		"user, " +
-		"release_version, " +
-		"COALESCE(simulation_version, -1), " +
-		"COALESCE(input_version, -1), " +
-		"id, " +
-		"playthrough " +
-		"FROM playthroughs")
+
+	// Count the playthroughs first, only for the progress bar.
+	var total int
+	err := db.QueryRow("SELECT COUNT(DISTINCT id) FROM playthroughs "+where,
+		args...).Scan(&total)
+	Check(err)
+
+	rows, err := db.Query("SELECT "+
+		"start_moment, "+
+		"COALESCE(end_moment, start_moment), "+
+		"user, "+
+		"release_version, "+
+		"COALESCE(simulation_version, -1), "+
+		"COALESCE(input_version, -1), "+
+		"id, "+
+		"LENGTH(playthrough) "+
+		"FROM playthroughs "+
+		where+" "+
+		// The rows of the same playthrough come together, longest first,
+		// see below.
+		"ORDER BY id, LENGTH(playthrough) DESC", args...)
 	Check(err)
 	defer func(rows *sql.Rows) { Check(rows.Close()) }(rows)
 
-	dbRows := []dbRow{}
+	manifest := OpenManifest(manifestFile)
+	defer manifest.Close()
+
+	// Small buffers, so that scanning stays just ahead of writing.
+	toWrite := make(chan dbRow, nWorkers)
+	written := make(chan dbRow, nWorkers)
+	var workers sync.WaitGroup
+	for range nWorkers {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for row := range toWrite {
+				if row.downloaded {
+					// The mirror wants the data, which is in the file.
+					if mirror != nil {
+						row.data = ReadFile(Filename(row))
+					}
+				} else {
+					row.data = FetchPlaythrough(db, row.id)
+					row.size = len(row.data)
+					_ = os.Mkdir(row.user, os.ModeDir)
+					WriteFile(Filename(row), row.data)
+					manifest.Record(row)
+				}
+				if game != nil && game.Replays(row) {
+					r := game.ReplayFile(Filename(row))
+					row.replay = &r
+				}
+				if mirror == nil {
+					// The data is not needed anymore, let it be collected.
+					row.data = nil
+				}
+				written <- row
+			}
+		}()
+	}
+
+	// Report the progress and add up the summaries as the files get written.
+	summaries := map[string]*UserSummary{}
+	reported := make(chan struct{})
+	go func() {
+		defer close(reported)
+		progress := Progress{Total: total}
+		progress.Print()
+		for row := range written {
+			s := summaries[row.user]
+			if s == nil {
+				s = &UserSummary{User: row.user}
+				summaries[row.user] = s
+			}
+			s.Add(row)
+			entries = append(entries, NewIndexEntry(row))
+			replays = append(replays, row.replay)
+			if mirror != nil {
+				mirror.Add(row)
+			}
+			progress.Done++
+			progress.Print()
+		}
+		fmt.Println()
+	}()
+
+	newMark := mark
+	nDuplicates := 0
+	nDownloaded := 0
+	var lastId uuid.UUID
 	for rows.Next() {
 		row := dbRow{}
 		err = rows.Scan(&row.startMoment, &row.endMoment, &row.user,
 			&row.releaseVersion, &row.simulationVersion, &row.inputVersion,
-			&row.id, &row.data)
+			&row.id, &row.size)
 		Check(err)
-		dbRows = append(dbRows, row)
-	}
-
-	for i := range dbRows {
-		dir := dbRows[i].user
-		_ = os.Mkdir(dir, os.ModeDir)
-		m := dbRows[i].startMoment
-		var filename string
-		if dbRows[i].simulationVersion == -1 || dbRows[i].inputVersion == -1 {
-			// -1 values mean the fields were NULL (check the SQL query above).
-			// If the simulation or input version is NULL it means we are
-			// dealing with a playthrough recorded before splitting the version
-			// into release, simulation and input versions.
-			// Use the old extension system (e.g. .clone1016).
-			filename = fmt.Sprintf("%s/%d%02d%02d-%02d%02d%02d.clone1-%03d",
-				dir, m.Year(), m.Month(), m.Day(), m.Hour(), m.Minute(),
-				m.Second(), dbRows[i].releaseVersion)
-		} else {
-			// Use the extension system that includes both simulation and
-			// input versions: .clone1-019-012
-			filename = fmt.Sprintf(
-				"%s/%d%02d%02d-%02d%02d%02d.clone1-%02d-%02d", dir, m.Year(),
-				m.Month(), m.Day(), m.Hour(), m.Minute(), m.Second(),
-				dbRows[i].simulationVersion, dbRows[i].inputVersion)
+		row.user = anonymizer.User(row.user)
+		if row.endMoment.After(newMark) {
+			newMark = row.endMoment
+		}
+		// A playthrough can have more than one row, e.g. when the request
+		// that creates its row was retried. Uploads only make a playthrough
+		// longer, so the longest row has the longest History and the others
+		// are shorter versions of it.
+		if row.id == lastId {
+			nDuplicates++
+			continue
+		}
+		lastId = row.id
+		// A playthrough that is already downloaded still goes through the
+		// workers, for the index, the mirror and the replays, which may not
+		// have been saved if the last run died. Its file may be gone, e.g.
+		// if the last run used other user names, see Anonymizer.
+		if manifest.Has(row) && FileExists(Filename(row)) {
+			row.downloaded = true
+			nDownloaded++
+		}
+		toWrite <- row
+	}
+	Check(rows.Err())
+	close(toWrite)
+	workers.Wait()
+	close(written)
+	<-reported
+
+	count := 0
+	for _, s := range SortedSummaries(summaries) {
+		fmt.Println(s)
+		count += s.Playthroughs
+	}
+	if nDuplicates > 0 {
+		fmt.Printf("skipped %d shorter copies of playthroughs\n",
+			nDuplicates)
+	}
+	if nDownloaded > 0 {
+		fmt.Printf("%d playthroughs were already downloaded\n", nDownloaded)
+	}
+	if !f.Empty() {
+		fmt.Printf("downloaded %d playthroughs\n", count)
+		return
+	}
+	StoreHighWaterMark(newMark)
+	fmt.Printf("downloaded %d playthroughs changed since %s\n", count,
+		mark.Format(time.RFC3339))
+	return
+}
+
+// Filename returns where the playthrough of row is written: in the folder of
+// its user, named after its Id, with an extension that tells which versions
+// can play it back. A playthrough that is downloaded again, e.g. because it
+// got longer, overwrites its file.
+func Filename(row dbRow) string {
+	if row.simulationVersion == -1 || row.inputVersion == -1 {
+		// -1 values mean the fields were NULL (check the SQL query in
+		// DownloadRecordings).
+		// If the simulation or input version is NULL it means we are
+		// dealing with a playthrough recorded before splitting the version
+		// into release, simulation and input versions.
+		// Use the old extension system (e.g. .clone1016).
+		return fmt.Sprintf("%s/%s.clone1-%03d", row.user, row.id,
+			row.releaseVersion)
+	}
+	// Use the extension system that includes both simulation and
+	// input versions: .clone1-019-012
+	return fmt.Sprintf("%s/%s.clone1-%02d-%02d", row.user, row.id,
+		row.simulationVersion, row.inputVersion)
+}
+
+// Progress is a progress bar, redrawn in place on a single line.
+type Progress struct {
+	Done  int
+	Total int
+}
+
+func (p Progress) String() string {
+	filled := progressBarWidth
+	if p.Total > 0 {
+		filled = min(p.Done*progressBarWidth/p.Total, progressBarWidth)
+	}
+	return fmt.Sprintf("[%s%s] %d/%d", strings.Repeat("=", filled),
+		strings.Repeat(" ", progressBarWidth-filled), p.Done, p.Total)
+}
+
+func (p Progress) Print() {
+	fmt.Printf("\r%s", p)
+}
+
+// UserSummary is what was downloaded for one user.
+type UserSummary struct {
+	User         string
+	Playthroughs int
+	Bytes        int
+	First        time.Time
+	Last         time.Time
+}
+
+func (s *UserSummary) Add(row dbRow) {
+	if s.Playthroughs == 0 || row.startMoment.Before(s.First) {
+		s.First = row.startMoment
+	}
+	if s.Playthroughs == 0 || row.startMoment.After(s.Last) {
+		s.Last = row.startMoment
+	}
+	s.Playthroughs++
+	s.Bytes += row.size
+}
+
+func (s *UserSummary) String() string {
+	return fmt.Sprintf("%s: %d playthroughs, %d bytes, from %s to %s",
+		s.User, s.Playthroughs, s.Bytes, s.First.Format(time.RFC3339),
+		s.Last.Format(time.RFC3339))
+}
+
+// SortedSummaries returns the summaries ordered by user, so that the report
+// is the same from one run to the next.
+func SortedSummaries(summaries map[string]*UserSummary) []*UserSummary {
+	sorted := make([]*UserSummary, 0, len(summaries))
+	for _, s := range summaries {
+		sorted = append(sorted, s)
+	}
+	slices.SortFunc(sorted, func(a, b *UserSummary) int {
+		return strings.Compare(a.User, b.User)
+	})
+	return sorted
+}
+
+// IndexEntry describes one downloaded playthrough, so that analysis
+// notebooks can choose the recordings they need without parsing all of them.
+// The versions are -1 when they are NULL in the table, see Filename.
+type IndexEntry struct {
+	Id                string    `json:"id"`
+	User              string    `json:"user"`
+	ReleaseVersion    int64     `json:"release_version"`
+	SimulationVersion int64     `json:"simulation_version"`
+	InputVersion      int64     `json:"input_version"`
+	StartMoment       time.Time `json:"start_moment"`
+	EndMoment         time.Time `json:"end_moment"`
+	DurationSeconds   float64   `json:"duration_seconds"`
+	Path              string    `json:"path"`
+	Size              int       `json:"size"`
+}
+
+// The header of index.csv, in the order of the fields of IndexEntry.
+var indexCsvHeader = []string{"id", "user", "release_version",
+	"simulation_version", "input_version", "start_moment", "end_moment",
+	"duration_seconds", "path", "size"}
+
+func NewIndexEntry(row dbRow) IndexEntry {
+	return IndexEntry{
+		Id:                row.id.String(),
+		User:              row.user,
+		ReleaseVersion:    row.releaseVersion,
+		SimulationVersion: row.simulationVersion,
+		InputVersion:      row.inputVersion,
+		StartMoment:       row.startMoment,
+		EndMoment:         row.endMoment,
+		DurationSeconds:   row.endMoment.Sub(row.startMoment).Seconds(),
+		Path:              Filename(row),
+		Size:              row.size,
+	}
+}
+
+// MergeIndex adds the entries of a run to the index of the previous runs. A
+// playthrough downloaded again, because it changed, replaces its old entry.
+// The result is ordered by start moment, then by id.
+func MergeIndex(index []IndexEntry, entries []IndexEntry) []IndexEntry {
+	byId := map[string]IndexEntry{}
+	for _, e := range index {
+		byId[e.Id] = e
+	}
+	for _, e := range entries {
+		byId[e.Id] = e
+	}
+	merged := make([]IndexEntry, 0, len(byId))
+	for _, e := range byId {
+		merged = append(merged, e)
+	}
+	slices.SortFunc(merged, func(a, b IndexEntry) int {
+		if c := a.StartMoment.Compare(b.StartMoment); c != 0 {
+			return c
 		}
-		WriteFile(filename, dbRows[i].data)
+		return strings.Compare(a.Id, b.Id)
+	})
+	return merged
+}
+
+// LoadCsvIndex reads the index written by StoreCsvIndex, or returns an empty
+// one if there is no such file yet.
+func LoadCsvIndex(name string) (index []IndexEntry) {
+	data, err := os.ReadFile(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	Check(err)
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	Check(err)
+	if len(records) == 0 || !slices.Equal(records[0], indexCsvHeader) {
+		Check(fmt.Errorf("unexpected header in %s", name))
 	}
+	for _, r := range records[1:] {
+		var e IndexEntry
+		e.Id = r[0]
+		e.User = r[1]
+		e.ReleaseVersion, err = strconv.ParseInt(r[2], 10, 64)
+		Check(err)
+		e.SimulationVersion, err = strconv.ParseInt(r[3], 10, 64)
+		Check(err)
+		e.InputVersion, err = strconv.ParseInt(r[4], 10, 64)
+		Check(err)
+		e.StartMoment, err = time.Parse(time.RFC3339, r[5])
+		Check(err)
+		e.EndMoment, err = time.Parse(time.RFC3339, r[6])
+		Check(err)
+		e.DurationSeconds, err = strconv.ParseFloat(r[7], 64)
+		Check(err)
+		e.Path = r[8]
+		e.Size, err = strconv.Atoi(r[9])
+		Check(err)
+		index = append(index, e)
+	}
+	return
+}
+
+func StoreCsvIndex(name string, index []IndexEntry) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	Check(w.Write(indexCsvHeader))
+	for _, e := range index {
+		Check(w.Write([]string{
+			e.Id,
+			e.User,
+			strconv.FormatInt(e.ReleaseVersion, 10),
+			strconv.FormatInt(e.SimulationVersion, 10),
+			strconv.FormatInt(e.InputVersion, 10),
+			e.StartMoment.Format(time.RFC3339),
+			e.EndMoment.Format(time.RFC3339),
+			strconv.FormatFloat(e.DurationSeconds, 'f', -1, 64),
+			e.Path,
+			strconv.Itoa(e.Size),
+		}))
+	}
+	w.Flush()
+	Check(w.Error())
+	WriteFile(name, buf.Bytes())
+}
+
+// LoadJsonlIndex reads the index written by StoreJsonlIndex, or returns an
+// empty one if there is no such file yet.
+func LoadJsonlIndex(name string) (index []IndexEntry) {
+	data, err := os.ReadFile(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	Check(err)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		var e IndexEntry
+		Check(json.Unmarshal(scanner.Bytes(), &e))
+		index = append(index, e)
+	}
+	Check(scanner.Err())
+	return
+}
+
+// StoreJsonlIndex writes the index with one JSON object per line.
+func StoreJsonlIndex(name string, index []IndexEntry) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, e := range index {
+		Check(encoder.Encode(e))
+	}
+	WriteFile(name, buf.Bytes())
+}
+
+// FetchPlaythrough returns the data of the playthrough with the given id,
+// from its longest row.
+func FetchPlaythrough(db *sql.DB, id uuid.UUID) (data []byte) {
+	err := db.QueryRow("SELECT playthrough FROM playthroughs WHERE id = ? "+
+		"ORDER BY LENGTH(playthrough) DESC LIMIT 1", id).Scan(&data)
+	Check(err)
+	return
+}
+
+// LoadHighWaterMark returns the mark stored by the last run, see
+// highWaterMarkFile, or a time before every playthrough if there was no run
+// yet.
+func LoadHighWaterMark() (mark time.Time) {
+	data, err := os.ReadFile(highWaterMarkFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return time.Unix(0, 0).UTC()
+	}
+	Check(err)
+	mark, err = time.Parse(time.RFC3339, strings.TrimSpace(string(data)))
+	Check(err)
+	return
+}
+
+func StoreHighWaterMark(mark time.Time) {
+	WriteFile(highWaterMarkFile, []byte(mark.Format(time.RFC3339)+"\n"))
 }
 
 func ConnectToDbSql() *sql.DB {
@@ -97,9 +688,33 @@ type dbRow struct {
 	inputVersion      int64
 	id                uuid.UUID
 	data              []byte
+	size              int
+	replay            *ReplayMetrics
+	// The file of the playthrough is complete, see Manifest.
+	downloaded bool
 }
 
+// WriteFile writes data to a temporary file, syncs it to disk and only then
+// renames it, so that name is never left with part of data, e.g. when the
+// disk is full.
 func WriteFile(name string, data []byte) {
-	err := os.WriteFile(name, data, 0644)
+	tmp := name + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	Check(err)
+	_, err = f.Write(data)
+	Check(err)
+	Check(f.Sync())
+	Check(f.Close())
+	Check(os.Rename(tmp, name))
+}
+
+func FileExists(name string) bool {
+	_, err := os.Stat(name)
+	return err == nil
+}
+
+func ReadFile(name string) []byte {
+	data, err := os.ReadFile(name)
 	Check(err)
+	return data
 }
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Where DownloadRecordings records each playthrough as soon as its file is
+// complete. The high-water mark only moves at the end of a run, so a run that
+// dies half-way (network blip, full disk) fetches the same rows again next
+// time, and the manifest lets it skip the ones it already has.
+const manifestFile = "manifest.txt"
+
+// Manifest is the set of playthroughs whose files are complete, with the
+// version of each that was downloaded. A line is appended for each, so the
+// last line of an Id is the one that counts.
+type Manifest struct {
+	mutex sync.Mutex
+	file  *os.File
+	done  map[uuid.UUID]manifestEntry
+}
+
+type manifestEntry struct {
+	endMoment time.Time
+	size      int
+}
+
+// OpenManifest reads the manifest in file name, if there is one, and opens it
+// for recording more playthroughs.
+func OpenManifest(name string) (m *Manifest) {
+	m = &Manifest{done: map[uuid.UUID]manifestEntry{}}
+	data, err := os.ReadFile(name)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		Check(err)
+	}
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		// The last line may be cut short if a run died while writing it,
+		// that playthrough is simply downloaded again.
+		if len(fields) != 3 {
+			continue
+		}
+		id, err := uuid.Parse(fields[0])
+		if err != nil {
+			continue
+		}
+		var e manifestEntry
+		e.endMoment, err = time.Parse(time.RFC3339, fields[1])
+		if err != nil {
+			continue
+		}
+		e.size, err = strconv.Atoi(fields[2])
+		if err != nil {
+			continue
+		}
+		m.done[id] = e
+	}
+	Check(scanner.Err())
+	m.file, err = os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_APPEND,
+		0644)
+	Check(err)
+	// Don't glue the next line to a line that was cut short.
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		_, err = m.file.WriteString("\n")
+		Check(err)
+	}
+	return
+}
+
+// Has is true if the version of the playthrough in row was downloaded.
+func (m *Manifest) Has(row dbRow) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	e, ok := m.done[row.id]
+	return ok && e.endMoment.Equal(row.endMoment) && e.size == row.size
+}
+
+// Record adds the playthrough in row, whose file is complete, to the
+// manifest. The manifest is synced to disk right away, since it is only
+// useful if it survives a crash.
+func (m *Manifest) Record(row dbRow) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	e := manifestEntry{endMoment: row.endMoment, size: row.size}
+	_, err := fmt.Fprintf(m.file, "%s %s %d\n", row.id,
+		e.endMoment.UTC().Format(time.RFC3339), e.size)
+	Check(err)
+	Check(m.file.Sync())
+	m.done[row.id] = e
+}
+
+func (m *Manifest) Close() {
+	Check(m.file.Close())
+}
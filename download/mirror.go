@@ -0,0 +1,106 @@
+package main
+
+import (
+	"database/sql"
+	_ "github.com/mattn/go-sqlite3"
+	"time"
+)
+
+// Mirror is a local SQLite database with a copy of the rows of the
+// playthroughs table that were downloaded, and metrics derived from them, so
+// that they can be queried offline as often as needed without going to the
+// MySQL server.
+// All the rows of a run are added in one transaction, as they are
+// downloaded, so the BLOBs don't pile up in memory.
+type Mirror struct {
+	db     *sql.DB
+	tx     *sql.Tx
+	insert *sql.Stmt
+}
+
+// The table of the mirror. It has the columns of the MySQL table, with NULL
+// versions where the MySQL table has them, plus:
+// - frames: the number of frames in the playthrough, found by a headless
+// replay, or NULL if there was no replay.
+// - final_score: the score at the end of the playthrough, found by a headless
+// replay, or NULL if there was no replay or it didn't reproduce the
+// playthrough, see ReplayMetrics.
+// - replay_crashed: 1 if the replay crashed, NULL if there was no replay.
+const mirrorSchema = `CREATE TABLE IF NOT EXISTS playthroughs (
+	id TEXT PRIMARY KEY,
+	user TEXT NOT NULL,
+	release_version INTEGER NOT NULL,
+	simulation_version INTEGER,
+	input_version INTEGER,
+	start_moment TEXT NOT NULL,
+	end_moment TEXT NOT NULL,
+	playthrough BLOB NOT NULL,
+	frames INTEGER,
+	final_score INTEGER,
+	replay_crashed INTEGER
+);
+CREATE INDEX IF NOT EXISTS playthroughs_user ON playthroughs (user);
+CREATE INDEX IF NOT EXISTS playthroughs_start_moment
+	ON playthroughs (start_moment);
+`
+
+// OpenMirror starts adding rows to the database in file name, which is
+// created if it doesn't exist yet.
+func OpenMirror(name string) *Mirror {
+	m := &Mirror{}
+	var err error
+	m.db, err = sql.Open("sqlite3", name)
+	Check(err)
+	_, err = m.db.Exec(mirrorSchema)
+	Check(err)
+	m.tx, err = m.db.Begin()
+	Check(err)
+	m.insert, err = m.tx.Prepare("INSERT OR REPLACE INTO playthroughs " +
+		"VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+	Check(err)
+	return m
+}
+
+// Add adds row to the mirror, or replaces it if it was mirrored before.
+func (m *Mirror) Add(row dbRow) {
+	r := row.replay
+	_, err := m.insert.Exec(row.id.String(), row.user, row.releaseVersion,
+		sqlVersion(row.simulationVersion), sqlVersion(row.inputVersion),
+		row.startMoment.UTC().Format(time.RFC3339),
+		row.endMoment.UTC().Format(time.RFC3339),
+		row.data, r.sqlFrames(), r.sqlFinalScore(), r.sqlCrashed())
+	Check(err)
+}
+
+// Close commits the rows added so far and closes the database.
+func (m *Mirror) Close() {
+	Check(m.insert.Close())
+	Check(m.tx.Commit())
+	Check(m.db.Close())
+}
+
+// sqlVersion turns the -1 of a NULL version back into NULL, see Filename.
+func sqlVersion(v int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: v, Valid: v != -1}
+}
+
+func (r *ReplayMetrics) sqlFrames() sql.NullInt64 {
+	if r == nil || !r.Replayed {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: r.Frames, Valid: true}
+}
+
+func (r *ReplayMetrics) sqlFinalScore() sql.NullInt64 {
+	if r == nil || !r.Replayed || r.Crashed || r.Diverged {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: r.FinalScore, Valid: true}
+}
+
+func (r *ReplayMetrics) sqlCrashed() sql.NullBool {
+	if r == nil || !r.Replayed {
+		return sql.NullBool{}
+	}
+	return sql.NullBool{Bool: r.Crashed, Valid: true}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+)
+
+// Game is the game executable that replays the downloaded playthroughs. The
+// World can't be imported here, so the playthroughs are replayed by the game
+// itself, with its replay command.
+type Game struct {
+	Path string
+	// The SimulationVersion of the executable. Only the playthroughs recorded
+	// with it replay the same way they were played.
+	SimulationVersion int64
+	// Whether to replay every playthrough or only the ones of
+	// SimulationVersion, see Replays.
+	ReplayAll bool
+}
+
+// NewGame asks the executable in path for its SimulationVersion, with its
+// export-constants command.
+func NewGame(path string, replayAll bool) (g *Game) {
+	out, err := exec.Command(path, "export-constants").Output()
+	Check(err)
+	var constants struct{ SimulationVersion int64 }
+	Check(json.Unmarshal(out, &constants))
+	return &Game{Path: path, SimulationVersion: constants.SimulationVersion,
+		ReplayAll: replayAll}
+}
+
+// Replays is true if the playthrough of row should be replayed.
+func (g *Game) Replays(row dbRow) bool {
+	return g.ReplayAll || row.simulationVersion == g.SimulationVersion
+}
+
+// ReplayMetrics is what a headless replay of a downloaded playthrough found
+// out.
+type ReplayMetrics struct {
+	// Whether there was a replay whose output could be read. The game can't
+	// read the playthrough at all if it is corrupted or was recorded with a
+	// newer version, in which case Error says why.
+	Replayed   bool
+	Error      string
+	Frames     int64
+	FinalScore int64
+	// The frame in which the World crashed and why, if Crashed.
+	Crashed    bool
+	CrashFrame int64
+	CrashMsg   string
+	// The playthrough has a FinalHash and the World of the replay ended with
+	// a different one, typically because the game has a different
+	// SimulationVersion than the one the playthrough was recorded with. The
+	// FinalScore of the replay is not the one of the playthrough then.
+	Diverged bool
+}
+
+// ReplayFile replays the playthrough in file with the game and reads its
+// report, see ReplayReport in the game.
+func (g *Game) ReplayFile(file string) (r ReplayMetrics) {
+	// The game exits with an error if the replay crashed or diverged, or if
+	// it couldn't read the playthrough, but its report is still there.
+	out, err := exec.Command(g.Path, "replay", file, "-json").Output()
+	var report struct {
+		NFrames          int64
+		FinalScore       int64
+		CrashFrameIdx    int64
+		CrashMsg         string
+		FinalHashDiffers bool
+		Error            string
+	}
+	if jsonErr := json.Unmarshal(out, &report); jsonErr != nil {
+		r.Error = "no report from the game: " + jsonErr.Error()
+		if err != nil {
+			r.Error = "no report from the game: " + err.Error()
+		}
+		return
+	}
+	if report.Error != "" {
+		r.Error = report.Error
+		return
+	}
+	r.Replayed = true
+	r.Frames = report.NFrames
+	r.FinalScore = report.FinalScore
+	r.Crashed = report.CrashFrameIdx >= 0
+	if r.Crashed {
+		r.CrashFrame = report.CrashFrameIdx
+		r.CrashMsg = report.CrashMsg
+	}
+	r.Diverged = report.FinalHashDiffers
+	return
+}
+
+// Problem describes what is wrong with the playthrough according to its
+// replay, or is empty if nothing is.
+func (r ReplayMetrics) Problem() string {
+	switch {
+	case !r.Replayed:
+		return "can't be read: " + r.Error
+	case r.Crashed:
+		return fmt.Sprintf("crashed at frame %d: %s", r.CrashFrame, r.CrashMsg)
+	case r.Diverged:
+		return "diverges from its final hash"
+	}
+	return ""
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Where Validate writes which of the downloaded playthroughs are broken.
+const validationReportFile = "validation.txt"
+
+// Validate reports the downloaded playthroughs of simulationVersion whose
+// replays crashed, diverged from their final hashes or couldn't even be read,
+// so that corrupt rows are found right after they are downloaded instead of
+// when someone needs them. The other playthroughs can't be replayed the way
+// they were played by this game, so they are skipped.
+// The entries and replays are the ones returned by DownloadRecordings.
+func Validate(entries []IndexEntry, replays []*ReplayMetrics,
+	simulationVersion int64) {
+	report, nValidated, nFlagged := ValidationReport(entries, replays,
+		simulationVersion)
+	WriteFile(validationReportFile, []byte(report))
+	fmt.Printf("validated %d playthroughs of SimulationVersion %d, %d "+
+		"broken, see %s\n", nValidated, simulationVersion, nFlagged,
+		validationReportFile)
+}
+
+// ValidationReport returns a line for each broken playthrough, see Validate,
+// with the path of its file and what is wrong with it.
+func ValidationReport(entries []IndexEntry, replays []*ReplayMetrics,
+	simulationVersion int64) (report string, nValidated int, nFlagged int) {
+	var sb strings.Builder
+	for i := range entries {
+		if entries[i].SimulationVersion != simulationVersion ||
+			replays[i] == nil {
+			continue
+		}
+		nValidated++
+		if problem := replays[i].Problem(); problem != "" {
+			nFlagged++
+			sb.WriteString(fmt.Sprintf("%s: %s\n", entries[i].Path, problem))
+		}
+	}
+	return sb.String(), nValidated, nFlagged
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/text"
 	"image/color"
+	"strings"
 	"time"
 )
 
@@ -33,14 +34,27 @@ func (g *Gui) Draw(screen *ebiten.Image) {
 		A: 255,
 	})
 
+	// The recorded positions of the virtual pointer can be far apart,
+	// especially if the playthrough was recorded with a SlowdownFactor, so the
+	// pointer is drawn moving smoothly between them while the playback runs.
+	// This is only for drawing, the World still gets the recorded positions.
+	if g.state == Playback && !g.playbackPaused {
+		g.drawnPointerPos = EaseTowards(g.drawnPointerPos,
+			g.virtualPointerPos, time.Since(g.lastFrameTime))
+	} else {
+		g.drawnPointerPos = g.virtualPointerPos
+	}
+
 	// Draw the game area.
 	gameScreen := SubImage(screen, g.gameArea)
 
 	switch g.state {
 	case HomeScreen:
 		g.DrawHomeScreen(gameScreen)
+		g.DrawHealthIcon(gameScreen)
 	case PlayScreen:
 		g.DrawPlayScreen(gameScreen)
+		g.DrawHealthIcon(gameScreen)
 	case PausedScreen:
 		g.DrawPlayScreen(gameScreen)
 		g.DrawPausedScreen(gameScreen)
@@ -50,10 +64,38 @@ func (g *Gui) Draw(screen *ebiten.Image) {
 	case GameWonScreen:
 		g.DrawPlayScreen(gameScreen)
 		g.DrawGameWonScreen(gameScreen)
+	case LeaderboardScreen:
+		g.DrawLeaderboardScreen(gameScreen)
+	case RecordingsScreen:
+		g.DrawRecordingsScreen(gameScreen)
+	case ConsentScreen:
+		g.DrawConsentScreen(gameScreen)
 	case Playback:
 		g.DrawPlayScreen(gameScreen)
+		if g.gifExport != nil {
+			g.DrawText(gameScreen, fmt.Sprintf("exporting GIF: %d%%",
+				g.gifExport.Progress()), true, false, color.NRGBA{
+				R: 255,
+				G: 0,
+				B: 0,
+				A: 255,
+			})
+		}
+		if g.beforeRecording != nil {
+			g.DrawTextButton(gameScreen, playbackBackButton, "back")
+		}
+		if g.compareMode {
+			g.DrawCompareWorld(SubImage(screen, g.compareGameArea))
+		}
 	case DebugCrash:
-		g.DrawPlayScreen(gameScreen)
+		if g.crashPhase != AllPhases {
+			// Show the World in the middle of the frame.
+			g.world, g.crashPhaseWorld = g.crashPhaseWorld, g.world
+			g.DrawPlayScreen(gameScreen)
+			g.world, g.crashPhaseWorld = g.crashPhaseWorld, g.world
+		} else {
+			g.DrawPlayScreen(gameScreen)
+		}
 	default:
 		panic("unhandled default case")
 	}
@@ -61,6 +103,8 @@ func (g *Gui) Draw(screen *ebiten.Image) {
 	// Draw debug controls.
 	if g.enableDebugAreas {
 		g.DrawDebugControlsHorizontal(SubImage(screen, g.horizontalDebugArea))
+	}
+	if g.ShowingUploadMetrics() {
 		g.DrawDebugControlsVertical(SubImage(screen, g.verticalDebugArea))
 	}
 
@@ -75,13 +119,188 @@ func (g *Gui) Draw(screen *ebiten.Image) {
 
 func (g *Gui) DrawHomeScreen(screen *ebiten.Image) {
 	DrawSpriteStretched(screen, g.imgHomeScreen)
+
+	if g.attractMode {
+		// Draw the demo faded over the menu, so that the menu is still
+		// recognizable and it's clear that this isn't a real game.
+		if g.imgAttract == nil {
+			g.imgAttract = ebiten.NewImage(int(GameWidth), int(GameHeight))
+		}
+		g.imgAttract.Clear()
+		g.DrawPlayScreen(g.imgAttract)
+		op := &ebiten.DrawImageOptions{}
+		op.ColorScale.ScaleAlpha(0.4)
+		op.GeoM.Translate(float64(screen.Bounds().Min.X),
+			float64(screen.Bounds().Min.Y))
+		screen.DrawImage(g.imgAttract, op)
+	}
+
+	if g.ShowingMotd() {
+		g.DrawMotd(screen)
+	}
+	if !g.attractMode {
+		g.DrawTextButton(screen, homeScreenLeaderboardButton, "leaderboard")
+		g.DrawTextButton(screen, homeScreenRecordingsButton, "recordings")
+		label := "uploads: on"
+		if !g.UploadsAllowed() {
+			label = "uploads: off"
+		}
+		g.DrawTextButton(screen, homeScreenConsentButton, label)
+	}
+}
+
+// DrawHealthIcon draws a dot that shows the ShownHealth of the server: green
+// when online, orange when degraded, red when offline and gray until the
+// first ping comes back.
+func (g *Gui) DrawHealthIcon(screen *ebiten.Image) {
+	var c color.NRGBA
+	switch g.ShownHealth() {
+	case HealthOnline:
+		c = color.NRGBA{R: 60, G: 200, B: 80, A: 255}
+	case HealthDegraded:
+		c = color.NRGBA{R: 240, G: 150, B: 40, A: 255}
+	case HealthOffline:
+		c = color.NRGBA{R: 220, G: 50, B: 50, A: 255}
+	default:
+		c = color.NRGBA{R: 150, G: 150, B: 150, A: 255}
+	}
+	FillCircle(screen, healthIconArea, c)
+}
+
+// DrawTextButton draws a button that has no sprite, as a box with a label.
+func (g *Gui) DrawTextButton(screen *ebiten.Image, area Rectangle,
+	label string) {
+	button := SubImage(screen, area)
+	button.Fill(color.NRGBA{R: 20, G: 60, B: 90, A: 220})
+	g.DrawText(button, label, true, true,
+		color.NRGBA{R: 255, G: 255, B: 255, A: 255})
+}
+
+// DrawLeaderboardScreen draws the leaderboard over the home screen, with the
+// player's line highlighted.
+func (g *Gui) DrawLeaderboardScreen(screen *ebiten.Image) {
+	DrawSpriteStretched(screen, g.imgHomeScreen)
+	card := SubImage(screen, leaderboardScreenCard)
+	card.Fill(color.NRGBA{R: 20, G: 60, B: 90, A: 220})
+
+	lines := []string{"loading..."}
+	highlighted := -1
+	if g.leaderboard != nil {
+		lines, highlighted = g.leaderboard.Lines(g.username, g.BestScore)
+	}
+	lines = append([]string{"best scores", ""}, lines...)
+	if highlighted >= 0 {
+		highlighted += 2
+	}
+
+	white := color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+	area := leaderboardScreenCard
+	area.Max.Y = area.Min.Y + leaderboardScreenLineHeight
+	for i, line := range lines {
+		if area.Max.Y > leaderboardScreenCard.Max.Y {
+			break
+		}
+		if i == highlighted {
+			SubImage(screen, area).Fill(
+				color.NRGBA{R: 240, G: 150, B: 40, A: 255})
+		}
+		g.DrawText(SubImage(screen, area), line, true, true, white)
+		area.Min.Y += leaderboardScreenLineHeight
+		area.Max.Y += leaderboardScreenLineHeight
+	}
+
+	g.DrawTextButton(screen, leaderboardScreenBackButton, "back")
+}
+
+// DrawRecordingsScreen draws the player's recordings over the home screen,
+// one per line, see recordingsScreenLine.
+func (g *Gui) DrawRecordingsScreen(screen *ebiten.Image) {
+	DrawSpriteStretched(screen, g.imgHomeScreen)
+	card := SubImage(screen, recordingsScreenCard)
+	card.Fill(color.NRGBA{R: 20, G: 60, B: 90, A: 220})
+
+	white := color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+	gray := color.NRGBA{R: 150, G: 150, B: 150, A: 255}
+	title := SubImage(screen, recordingsScreenLine(-2))
+	g.DrawText(title, "my recordings", true, true, white)
+	lines := []string{"loading..."}
+	if g.recordings != nil {
+		lines = g.recordings.Lines()
+	}
+	for i, line := range lines {
+		area := recordingsScreenLine(i)
+		if area.Max.Y > recordingsScreenCard.Max.Y {
+			break
+		}
+		c := white
+		if g.recordings != nil && i < len(g.recordings.Entries) &&
+			!g.recordings.Entries[i].Playable() {
+			c = gray
+		}
+		g.DrawText(SubImage(screen, area), line, true, true, c)
+	}
+
+	g.DrawTextButton(screen, recordingsScreenBackButton, "back")
+}
+
+// DrawConsentScreen asks the player if the game may upload their data, see
+// Consent.
+func (g *Gui) DrawConsentScreen(screen *ebiten.Image) {
+	DrawSpriteStretched(screen, g.imgHomeScreen)
+	card := SubImage(screen, consentScreenCard)
+	card.Fill(color.NRGBA{R: 20, G: 60, B: 90, A: 220})
+
+	lines := []string{
+		"may the game send your games,",
+		"scores and crash reports",
+		"to its server?",
+		"",
+		"they help make the game better",
+		"and keep your progress",
+		"on your other devices.",
+		"",
+		"you can change this later",
+		"on the home screen.",
+	}
+	white := color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+	area := consentScreenCard
+	area.Max.Y = area.Min.Y + consentScreenLineHeight
+	for _, line := range lines {
+		g.DrawText(SubImage(screen, area), line, true, true, white)
+		area.Min.Y += consentScreenLineHeight
+		area.Max.Y += consentScreenLineHeight
+	}
+
+	g.DrawTextButton(screen, consentScreenYesButton, "yes")
+	g.DrawTextButton(screen, consentScreenNoButton, "no")
+}
+
+// DrawMotd draws the message of the day on a card, one line of the message
+// per line of text. Tapping the card closes it.
+func (g *Gui) DrawMotd(screen *ebiten.Image) {
+	card := SubImage(screen, homeScreenMotdCard)
+	card.Fill(color.NRGBA{R: 20, G: 60, B: 90, A: 220})
+
+	white := color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+	lines := strings.Split(strings.TrimSpace(g.motd.Message), "\n")
+	lines = append(lines, "", "(tap to close)")
+	area := homeScreenMotdCard
+	area.Max.Y = area.Min.Y + homeScreenMotdLineHeight
+	for _, line := range lines {
+		if area.Max.Y > homeScreenMotdCard.Max.Y {
+			break
+		}
+		g.DrawText(SubImage(screen, area), line, true, true, white)
+		area.Min.Y += homeScreenMotdLineHeight
+		area.Max.Y += homeScreenMotdLineHeight
+	}
 }
 
 func (g *Gui) DrawPlayScreen(screen *ebiten.Image) {
 	DrawSpriteStretched(screen, g.imgScreenPlay)
 
-	g.DrawScore(screen, g.BestScore, 444)
-	g.DrawScore(screen, g.world.Score, 886)
+	g.DrawScore(screen, *g.BestScoreForMode(g.playthrough.Mode), 444, 56)
+	g.DrawScore(screen, g.world.Score, 886, 56)
 
 	// Draw time left in orange.
 	timeLeftWidth := playScreenTimerArea.Width() *
@@ -98,6 +317,13 @@ func (g *Gui) DrawPlayScreen(screen *ebiten.Image) {
 	// Draw timer-only sprite with a transparent area, over the time left to
 	// round off the edges.
 	DrawSpriteStretched(screen, g.imgTimer)
+	if g.playthrough.PushNowEnabled {
+		DrawSprite(screen, g.imgPushNow,
+			float64(playScreenPushNowButton.Min.X),
+			float64(playScreenPushNowButton.Min.Y),
+			float64(playScreenPushNowButton.Width()),
+			float64(playScreenPushNowButton.Height()))
+	}
 
 	// Draw world.
 	worldScreen := SubImage(screen, playScreenWorldArea)
@@ -137,6 +363,10 @@ func (g *Gui) DrawPlayScreen(screen *ebiten.Image) {
 			float64(SplashAnimationSize))
 	}
 
+	if g.showBrickInternals {
+		g.DrawBrickInternals(worldScreen)
+	}
+
 	// Draw debugging info.
 	for _, pt := range g.world.DebugPts {
 		DrawPixel(screen, pt, color.NRGBA{
@@ -147,6 +377,16 @@ func (g *Gui) DrawPlayScreen(screen *ebiten.Image) {
 		})
 	}
 
+	if g.state == PlayScreen && g.slowMotion > 1 {
+		g.DrawText(screen, fmt.Sprintf("slow motion: 1/%d", g.slowMotion),
+			false, false, color.NRGBA{
+				R: 255,
+				G: 0,
+				B: 0,
+				A: 255,
+			})
+	}
+
 	if g.DisplayFPS {
 		g.DrawText(screen, fmt.Sprintf("ActualTPS: %f", ebiten.ActualTPS()), false,
 			false,
@@ -168,15 +408,140 @@ func (g *Gui) DrawPlayScreen(screen *ebiten.Image) {
 			})
 	}
 
+	if g.DisplayFPS || g.enableDebugAreas {
+		if lines := g.WorkerFailureLines(); len(lines) > 0 {
+			g.DrawText(screen, strings.Join(lines, "\n"), false, true,
+				color.NRGBA{
+					R: 255,
+					G: 0,
+					B: 0,
+					A: 255,
+				})
+		}
+	}
+
 	if g.state == Playback || g.state == DebugCrash {
-		pos := g.ScreenToGame(g.virtualPointerPos)
+		pos := g.ScreenToGame(g.drawnPointerPos)
 		DrawSprite(screen, g.imgCursor,
 			float64(pos.X), float64(pos.Y),
 			50.0, 50.0)
 	}
+
+	if g.state == Playback && g.playbackPaused && g.breakpointHit != "" {
+		g.DrawText(screen, "Breakpoint: "+g.breakpointHit, false, false,
+			color.NRGBA{
+				R: 255,
+				G: 0,
+				B: 0,
+				A: 255,
+			})
+	}
+
+	if g.state == DebugCrash {
+		g.DrawCrashInfo(screen)
+	}
+
+	if g.state == Playback && len(g.session.Playthroughs) > 0 {
+		g.DrawSessionInfo(screen)
+	}
+
+	if g.state == Playback {
+		g.DrawAnnotations(screen)
+	}
+
+	// Show when the player had the game paused and for how long. The whole
+	// pause is a single frame of the playback.
+	if (g.state == Playback || g.state == DebugCrash) &&
+		g.frameIdx < int64(len(g.playthrough.History)) &&
+		g.playthrough.History[g.frameIdx].PausedFrames > 0 {
+		text := "PAUSED " + FrameToTimecode(
+			g.playthrough.History[g.frameIdx].PausedFrames)
+		g.DrawText(worldScreen, text, true, true, color.NRGBA{
+			R: 255,
+			G: 0,
+			B: 0,
+			A: 255,
+		})
+	}
 }
 
-func (g *Gui) DrawScore(screen *ebiten.Image, score int64, middleX float64) {
+// DrawCrashInfo shows why the World crashed and how far the current frame is
+// from the input that crashed it.
+func (g *Gui) DrawCrashInfo(screen *ebiten.Image) {
+	msg := fmt.Sprintf("%s\ncrash frame: %d\nframe: %d\n",
+		g.crash.CrashMsg, g.crash.CrashFrameIdx, g.frameIdx)
+	if remaining := g.crash.CrashFrameIdx - g.frameIdx; remaining > 0 {
+		msg += fmt.Sprintf("frames until crash input: %d", remaining)
+	} else if remaining == 0 {
+		msg += "next input crashes"
+	} else {
+		msg += "crash input executed"
+	}
+	if g.crashPhase != AllPhases {
+		msg += fmt.Sprintf("\nafter phase: %v", g.crashPhase)
+	}
+	text.Draw(screen, msg, g.debugFont, 10, 30, color.NRGBA{
+		R: 255,
+		G: 0,
+		B: 0,
+		A: 255,
+	})
+}
+
+// DrawAnnotations shows the notes on the current frame as callouts, and the
+// note being typed.
+func (g *Gui) DrawAnnotations(screen *ebiten.Image) {
+	var lines []string
+	for _, n := range g.annotations.Shown(g.playthrough.Id.String(),
+		g.frameIdx) {
+		lines = append(lines, fmt.Sprintf("%s (frame %d)", n.Text,
+			n.FrameIdx))
+	}
+	if g.typingNote {
+		lines = append(lines, "note: "+g.noteText+"_")
+	}
+
+	y := 80
+	for _, line := range lines {
+		bounds := text.BoundString(g.debugFont, line)
+		FillRect(screen, NewRectangleI(10, int64(y), int64(bounds.Dx()+20),
+			int64(bounds.Dy()+20)), color.NRGBA{
+			R: 255,
+			G: 240,
+			B: 150,
+			A: 230,
+		})
+		text.Draw(screen, line, g.debugFont, 20, y+10-bounds.Min.Y,
+			color.NRGBA{
+				R: 0,
+				G: 0,
+				B: 0,
+				A: 255,
+			})
+		y += bounds.Dy() + 30
+	}
+}
+
+// DrawSessionInfo shows which game of the session is played back and how the
+// player got to it.
+func (g *Gui) DrawSessionInfo(screen *ebiten.Image) {
+	msg := fmt.Sprintf("game %d/%d", g.sessionGameIdx+1,
+		len(g.session.Playthroughs))
+	if t := g.session.StartedBy(g.sessionGameIdx); t != nil {
+		msg += fmt.Sprintf(", started by %v at session frame %d", t.Cause,
+			t.FrameIdx)
+	}
+	text.Draw(screen, msg, g.debugFont, 10, screen.Bounds().Dy()-20,
+		color.NRGBA{
+			R: 255,
+			G: 0,
+			B: 0,
+			A: 255,
+		})
+}
+
+func (g *Gui) DrawScore(screen *ebiten.Image, score int64, middleX float64,
+	y float64) {
 	digits := GetDigitArray(score)
 
 	// Get total length of the final score image.
@@ -193,7 +558,7 @@ func (g *Gui) DrawScore(screen *ebiten.Image, score int64, middleX float64) {
 	for _, d := range digits {
 		b := g.imgDigit[d].Bounds()
 		finalW := float64(b.Dx() * 60 / b.Dy())
-		DrawSprite(screen, g.imgDigit[d], scoreX, 56, finalW, 60)
+		DrawSprite(screen, g.imgDigit[d], scoreX, y, finalW, 60)
 		scoreX += finalW + marginBetweenDigits
 	}
 }
@@ -204,6 +569,26 @@ func (g *Gui) DrawPausedScreen(screen *ebiten.Image) {
 
 func (g *Gui) DrawGameOverScreen(screen *ebiten.Image) {
 	DrawSpriteStretched(screen, g.imgGameOverScreen)
+
+	// Compare the final score with the personal best from before this
+	// playthrough, in the same order as on the play screen: best on the left,
+	// current on the right.
+	g.DrawScore(screen, g.previousBestScore, 444, gameOverScreenScoresY)
+	g.DrawScore(screen, g.world.Score, 886, gameOverScreenScoresY)
+
+	area := gameOverScreenHintsArea
+	for _, hint := range g.hints {
+		g.DrawText(SubImage(screen, area), hint, true, true,
+			color.NRGBA{
+				R: 255,
+				G: 255,
+				B: 255,
+				A: 255,
+			})
+		height := area.Height()
+		area.Min.Y += height
+		area.Max.Y += height
+	}
 }
 
 func (g *Gui) DrawGameWonScreen(screen *ebiten.Image) {
@@ -231,14 +616,114 @@ func (g *Gui) DrawDebugControlsHorizontal(screen *ebiten.Image) {
 	bar := SubImage(screen, debugPlayBar)
 	DrawSpriteStretched(bar, g.imgPlayBar)
 
+	g.DrawPlayBarEvents(bar)
+
+	// Loop.
+	if g.loopOut > g.loopIn {
+		nFrames := int64(len(g.playthrough.History))
+		x1 := g.loopIn * debugPlayBar.Width() / (nFrames - 1)
+		x2 := g.loopOut * debugPlayBar.Width() / (nFrames - 1)
+		FillRect(bar, NewRectangleI(x1, 0, max(x2-x1, 1),
+			debugPlayBar.Height()), color.NRGBA{
+			R: 0,
+			G: 200,
+			B: 200,
+			A: 100,
+		})
+	}
+
+	// Bookmarks.
+	for _, frameIdx := range g.bookmarks.Frames {
+		x := frameIdx * debugPlayBar.Width() /
+			int64(len(g.playthrough.History)-1)
+		mark := SubImage(bar, NewRectangleI(x-2, 0, 4, debugPlayBar.Height()))
+		mark.Fill(color.NRGBA{
+			R: 255,
+			G: 0,
+			B: 0,
+			A: 255,
+		})
+	}
+
 	// Playback bar cursor.
 	cursorWidth := float64(debugPlayBar.Height())
 	cursorHeight := float64(debugPlayBar.Height())
 	factor := float64(g.frameIdx) / float64(len(g.playthrough.History)-1)
 	cursorX := factor*float64(debugPlayBar.Width()) - cursorWidth/2
 	DrawSprite(bar, g.imgPlaybackCursor, cursorX, 0, cursorWidth, cursorHeight)
+
+	// Time, so that the playback can be matched with what the player says
+	// happened "around minute 3".
+	msg := FrameToTimecode(g.frameIdx) + " / " +
+		FrameToTimecode(int64(len(g.playthrough.History)))
+	if g.seekDigits != "" {
+		msg = "go to: " + g.seekDigits + "_"
+	}
+	text.Draw(bar, msg, g.debugFont, int(debugPlayBar.Width())-200,
+		int(debugPlayBar.Height())-10, color.NRGBA{
+			R: 0,
+			G: 0,
+			B: 0,
+			A: 255,
+		})
 }
 
+// DrawPlayBarEvents shows where the action is in the playthrough: the
+// density of merges as a heat gradient and coming ups and score jumps as
+// ticks.
+func (g *Gui) DrawPlayBarEvents(bar *ebiten.Image) {
+	nFrames := int64(len(g.playthrough.History))
+	width := debugPlayBar.Width()
+	height := debugPlayBar.Height()
+	if nFrames < 2 {
+		return
+	}
+
+	// Merges per column of the bar.
+	heat := make([]int64, width)
+	maxHeat := int64(0)
+	for _, frameIdx := range g.jumpFrames[MergeJump].Frames {
+		x := min(frameIdx*width/(nFrames-1), width-1)
+		heat[x]++
+		maxHeat = max(maxHeat, heat[x])
+	}
+	for x := range heat {
+		if heat[x] == 0 {
+			continue
+		}
+		FillRect(bar, NewRectangleI(int64(x), height/2, 1, height/2),
+			color.NRGBA{
+				R: 255,
+				G: 140,
+				B: 0,
+				A: uint8(64 + 191*heat[x]/maxHeat),
+			})
+	}
+
+	ticks := func(frames []int64, c color.Color) {
+		for _, frameIdx := range frames {
+			x := frameIdx * width / (nFrames - 1)
+			tick := SubImage(bar, NewRectangleI(x-1, 0, 2, height/2))
+			tick.Fill(c)
+		}
+	}
+	ticks(g.jumpFrames[ComingUpJump].Frames, color.NRGBA{
+		R: 0,
+		G: 0,
+		B: 255,
+		A: 255,
+	})
+	ticks(g.jumpFrames[ScoreJump].Frames, color.NRGBA{
+		R: 0,
+		G: 160,
+		B: 0,
+		A: 255,
+	})
+}
+
+// DrawDebugControlsVertical shows how the uploads are going, so that it is
+// clear during a playtest whether the data reaches the server, see
+// UploadMetrics.
 func (g *Gui) DrawDebugControlsVertical(uiScreen *ebiten.Image) {
 	uiScreen.Fill(color.NRGBA{
 		R: 0,
@@ -246,6 +731,13 @@ func (g *Gui) DrawDebugControlsVertical(uiScreen *ebiten.Image) {
 		B: 255,
 		A: 255,
 	})
+	g.DrawText(uiScreen, strings.Join(g.UploadMetricsLines(), "\n"), false,
+		false, color.NRGBA{
+			R: 255,
+			G: 255,
+			B: 255,
+			A: 255,
+		})
 }
 
 func (g *Gui) DrawBricks(worldScreen *ebiten.Image, s BrickState) {
@@ -254,7 +746,10 @@ func (g *Gui) DrawBricks(worldScreen *ebiten.Image, s BrickState) {
 			continue
 		}
 		pos := b.PixelPos
-		img := g.imgBrick[b.Val]
+		img := g.BrickImage(b.Val)
+		if b.Stone {
+			img = g.imgStone
+		}
 		DrawSprite(worldScreen, img, float64(pos.X), float64(pos.Y),
 			float64(BrickPixelSize),
 			float64(BrickPixelSize))
@@ -264,26 +759,63 @@ func (g *Gui) DrawBricks(worldScreen *ebiten.Image, s BrickState) {
 				float64(BrickPixelSize),
 				float64(BrickPixelSize))
 		}
-		if b.ChainedTo > 0 && b.State != Follower {
-			c1 := b.Bounds.Center()
-			c2 := g.world.GetBrick(b.ChainedTo).Bounds.Center()
-			c := c1.Plus(c2).DivBy(2)
-			b2 := g.world.GetBrick(b.ChainedTo)
-			if b2.CanonicalPos.X == b.CanonicalPos.X+1 {
-				minX := float64(c.X) - float64(ChainWidth)/2
-				minY := float64(c.Y) - float64(ChainHeight)/2
-				DrawSprite(worldScreen, g.imgChainH,
-					minX, minY, float64(ChainWidth), float64(ChainHeight))
-			} else {
-				minX := float64(c.X) - float64(ChainHeight)/2
-				minY := float64(c.Y) - float64(ChainWidth)/2
-				DrawSprite(worldScreen, g.imgChainV,
-					minX, minY, float64(ChainHeight), float64(ChainWidth))
+		if b.Group > 0 && b.State != Follower {
+			// Draw all the chains of the group when drawing its leader.
+			for _, l := range g.world.GetChainGroup(b.Group).Links {
+				b1 := g.world.GetBrick(l.Brick1)
+				b2 := g.world.GetBrick(l.Brick2)
+				c1 := b1.Bounds.Center()
+				c2 := b2.Bounds.Center()
+				c := c1.Plus(c2).DivBy(2)
+				if b2.CanonicalPos.Y == b1.CanonicalPos.Y {
+					minX := float64(c.X) - float64(ChainWidth)/2
+					minY := float64(c.Y) - float64(ChainHeight)/2
+					DrawSprite(worldScreen, g.imgChainH,
+						minX, minY, float64(ChainWidth), float64(ChainHeight))
+				} else {
+					minX := float64(c.X) - float64(ChainHeight)/2
+					minY := float64(c.Y) - float64(ChainWidth)/2
+					DrawSprite(worldScreen, g.imgChainV,
+						minX, minY, float64(ChainHeight), float64(ChainWidth))
+				}
 			}
 		}
 	}
 }
 
+var brickStateNames = map[BrickState]string{
+	Canonical: "Canonical",
+	Dragged:   "Dragged",
+	Falling:   "Falling",
+	Follower:  "Follower",
+}
+
+// DrawBrickInternals draws the slot grid, the Bounds of each brick and the
+// values in each brick that matter for debugging the World, over the bricks.
+func (g *Gui) DrawBrickInternals(worldScreen *ebiten.Image) {
+	gray := color.NRGBA{R: 128, G: 128, B: 128, A: 255}
+	red := color.NRGBA{R: 255, G: 0, B: 0, A: 255}
+	black := color.NRGBA{R: 0, G: 0, B: 0, A: 255}
+
+	for y := int64(0); y < NRows; y++ {
+		for x := int64(0); x < NCols; x++ {
+			pos := CanonicalPosToPixelPos(Pt{x, y})
+			DrawRectOutline(worldScreen, NewRectangle(pos,
+				pos.Plus(Pt{BrickPixelSize, BrickPixelSize})), 1, gray)
+		}
+	}
+
+	for _, b := range g.world.Bricks {
+		DrawRectOutline(worldScreen, b.Bounds, 2, red)
+		msg := fmt.Sprintf("#%d %s\nVal %d\nSpeed %d\nGroup %d\n"+
+			"Canon %d,%d", b.Id, brickStateNames[b.State], b.Val,
+			b.FallingSpeed, b.Group, b.CanonicalPos.X, b.CanonicalPos.Y)
+		m := worldScreen.Bounds().Min
+		text.Draw(worldScreen, msg, g.debugFont, m.X+int(b.Bounds.Min.X)+6,
+			m.Y+int(b.Bounds.Min.Y)+20, black)
+	}
+}
+
 func (g *Gui) DrawText(screen *ebiten.Image, message string, centerX bool, centerY bool, color color.Color) {
 	// Remember that text there is an origin point for the text.
 	// That origin point is kind of the lower-left corner of the bounds of the
@@ -322,3 +854,42 @@ func (g *Gui) UpdateWindowSize() {
 	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
 	ebiten.SetWindowTitle("Clone1")
 }
+
+// DrawCompareWorld draws g.compareWorld the same way g.world is drawn.
+func (g *Gui) DrawCompareWorld(gameScreen *ebiten.Image) {
+	// DrawPlayScreen draws g.world and g.playthrough, so swap them for the
+	// compared ones while drawing.
+	g.world, g.compareWorld = g.compareWorld, g.world
+	g.playthrough, g.comparePlaythrough = g.comparePlaythrough, g.playthrough
+	defer func() {
+		g.world, g.compareWorld = g.compareWorld, g.world
+		g.playthrough, g.comparePlaythrough = g.comparePlaythrough,
+			g.playthrough
+	}()
+	g.DrawPlayScreen(gameScreen)
+}
+
+// DrawGifExportFrame draws the current frame of e into e.Image, exactly like
+// the playback would draw it.
+func (g *Gui) DrawGifExportFrame(e *GifExport) {
+	state, paused, pointerPos := g.state, g.playbackPaused, g.drawnPointerPos
+	g.world, e.World = e.World, g.world
+	g.playthrough, e.Playthrough = e.Playthrough, g.playthrough
+	g.frameIdx, e.FrameIdx = e.FrameIdx, g.frameIdx
+	defer func() {
+		g.world, e.World = e.World, g.world
+		g.playthrough, e.Playthrough = e.Playthrough, g.playthrough
+		g.frameIdx, e.FrameIdx = e.FrameIdx, g.frameIdx
+		g.state, g.playbackPaused, g.drawnPointerPos = state, paused,
+			pointerPos
+	}()
+
+	g.state = Playback
+	g.playbackPaused = false
+	if g.frameIdx > 0 {
+		g.drawnPointerPos = g.WorldToScreen(
+			g.playthrough.History[g.frameIdx-1].Pos)
+	}
+	e.Image.Clear()
+	g.DrawPlayScreen(e.Image)
+}
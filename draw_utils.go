@@ -2,8 +2,11 @@ package main
 
 import (
 	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
 	"image"
 	"image/color"
+	"math"
+	"time"
 )
 
 // DrawSprite draws img on screen.
@@ -82,3 +85,52 @@ func DrawPixel(screen *ebiten.Image, pt Pt, color color.Color) {
 		}
 	}
 }
+
+// How long it takes a smoothly moving point to cover about two thirds of the
+// distance to its target, see EaseTowards.
+const easeTime = 30 * time.Millisecond
+
+// EaseTowards moves from towards to for the time elapsed since the last move.
+// The closer it gets, the slower it moves, so it follows a target that jumps
+// around without jumping itself. It depends only on the time, not on how
+// often it is called.
+func EaseTowards(from, to Pt, elapsed time.Duration) Pt {
+	k := 1 - math.Exp(-float64(elapsed)/float64(easeTime))
+	return Pt{
+		from.X + int64(math.Round(float64(to.X-from.X)*k)),
+		from.Y + int64(math.Round(float64(to.Y-from.Y)*k)),
+	}
+}
+
+// FillRect fills r with color blended over what is already on screen, unlike
+// Fill, which replaces it. This is what translucent colors need.
+func FillRect(screen *ebiten.Image, r Rectangle, color color.Color) {
+	m := screen.Bounds().Min
+	vector.DrawFilledRect(screen, float32(m.X+int(r.Min.X)),
+		float32(m.Y+int(r.Min.Y)), float32(r.Width()), float32(r.Height()),
+		color, false)
+}
+
+// FillCircle fills the circle that fits in r, like FillRect.
+func FillCircle(screen *ebiten.Image, r Rectangle, color color.Color) {
+	m := screen.Bounds().Min
+	radius := float32(min(r.Width(), r.Height())) / 2
+	vector.DrawFilledCircle(screen,
+		float32(m.X+int(r.Min.X))+float32(r.Width())/2,
+		float32(m.Y+int(r.Min.Y))+float32(r.Height())/2,
+		radius, color, true)
+}
+
+// DrawRectOutline draws the edges of r, thickness pixels thick, on the inside
+// of r.
+func DrawRectOutline(screen *ebiten.Image, r Rectangle, thickness int64,
+	color color.Color) {
+	w := r.Max.X - r.Min.X
+	h := r.Max.Y - r.Min.Y
+	SubImage(screen, NewRectangleI(r.Min.X, r.Min.Y, w, thickness)).Fill(color)
+	SubImage(screen, NewRectangleI(r.Min.X, r.Max.Y-thickness, w,
+		thickness)).Fill(color)
+	SubImage(screen, NewRectangleI(r.Min.X, r.Min.Y, thickness, h)).Fill(color)
+	SubImage(screen, NewRectangleI(r.Max.X-thickness, r.Min.Y, thickness,
+		h)).Fill(color)
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestEaseTowards(t *testing.T) {
+	from := Pt{100, 100}
+	to := Pt{400, 1000}
+	assert.Equal(t, from, EaseTowards(from, to, 0))
+	assert.Equal(t, to, EaseTowards(from, to, time.Second))
+
+	// Halfway there after about 0.7 easeTime.
+	half := EaseTowards(from, to, easeTime*693/1000)
+	assert.InDelta(t, 250, half.X, 1)
+	assert.InDelta(t, 550, half.Y, 1)
+
+	// Moving in two steps gets as far as moving in one, up to rounding.
+	p := EaseTowards(from, to, 10*time.Millisecond)
+	p = EaseTowards(p, to, 10*time.Millisecond)
+	q := EaseTowards(from, to, 20*time.Millisecond)
+	assert.InDelta(t, q.X, p.X, 1)
+	assert.InDelta(t, q.Y, p.Y, 1)
+}
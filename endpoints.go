@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+)
+
+// Endpoints are the server that the game talks to and the data it is kept
+// in. Development builds shouldn't mix their data with the players', so they
+// use the staging endpoints: the same server, but every request says it is
+// for staging and the server keeps its data in the staging tables, see Table
+// in auth-clone1.php. Which Endpoints a playthrough went to is in its
+// Metadata.
+type Endpoints struct {
+	// "production" or "staging", sent along with every request.
+	Name string
+	// The server of the PHP endpoints and the gRPC server.
+	Url     string
+	GrpcUrl string
+}
+
+var productionEndpoints = Endpoints{
+	Name:    "production",
+	Url:     "https://playful-patterns.com",
+	GrpcUrl: "https://playful-patterns.com:8443",
+}
+
+var stagingEndpoints = Endpoints{
+	Name:    "staging",
+	Url:     productionEndpoints.Url,
+	GrpcUrl: productionEndpoints.GrpcUrl,
+}
+
+// SelectEndpoints returns the Endpoints named name. An empty name is staging
+// in builds with staging_enabled and in developer mode, and production
+// otherwise. A url or grpcUrl that isn't empty replaces the one of the
+// Endpoints, e.g. for a self-hosted copy of the server.
+func SelectEndpoints(name string, url string, grpcUrl string,
+	devMode bool) (e Endpoints) {
+	if name == "" {
+		name = productionEndpoints.Name
+		if stagingEnabled || devMode {
+			name = stagingEndpoints.Name
+		}
+	}
+	switch name {
+	case productionEndpoints.Name:
+		e = productionEndpoints
+	case stagingEndpoints.Name:
+		e = stagingEndpoints
+	default:
+		panic(fmt.Errorf("unknown endpoints: %s", name))
+	}
+	if url != "" {
+		e.Url = url
+	}
+	if grpcUrl != "" {
+		e.GrpcUrl = grpcUrl
+	}
+	return
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/hajimehoshi/ebiten/v2"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"io"
+)
+
+// A playthrough can be exported to an animated GIF during playback, to share
+// bug reproductions and highlights with people who don't have the
+// executable. The export steps its own copy of the World and draws it
+// offscreen with the same code that draws the playback, so the GIF looks
+// exactly like the playback but doesn't depend on what the playback is doing
+// (paused, seeking, stopped at a breakpoint).
+
+const (
+	// Only every gifFrameStep-th frame goes into the GIF, otherwise the file
+	// gets too big. 60 FPS / 3 = 20 FPS, which is smooth enough.
+	gifFrameStep = 3
+	// The delay between GIF frames, in hundredths of a second.
+	gifFrameDelay = 100 * gifFrameStep / 60
+	// Frames are scaled down to this width, keeping the aspect ratio.
+	gifMaxWidth = 360
+	// How many frames of the playthrough the export goes through in one
+	// Update, so that the window stays responsive during a long export.
+	gifExportFramesPerUpdate = 60
+)
+
+// GifWriter writes an animated GIF one frame at a time, so that a long
+// playthrough doesn't have to fit in memory. image/gif only encodes all the
+// frames at once, so each frame is encoded as a GIF of its own and only its
+// image block is written out.
+type GifWriter struct {
+	w      io.Writer
+	scale  int
+	scaled *image.RGBA
+	frame  *image.Paletted
+	buf    bytes.Buffer
+}
+
+// NewGifWriter writes the GIF header to w, for frames of width x height
+// pixels which get scaled down to at most gifMaxWidth.
+func NewGifWriter(w io.Writer, width, height int) *GifWriter {
+	scale := max(1, (width+gifMaxWidth-1)/gifMaxWidth)
+	g := &GifWriter{
+		w:      w,
+		scale:  scale,
+		scaled: image.NewRGBA(image.Rect(0, 0, width/scale, height/scale)),
+	}
+	g.frame = image.NewPaletted(g.scaled.Rect, palette.Plan9)
+
+	// Logical screen without a global color table, every frame has its own.
+	header := []byte("GIF89a")
+	header = binary.LittleEndian.AppendUint16(header, uint16(width/scale))
+	header = binary.LittleEndian.AppendUint16(header, uint16(height/scale))
+	header = append(header, 0, 0, 0)
+	// Loop forever.
+	header = append(header, 0x21, 0xff, 0x0b)
+	header = append(header, "NETSCAPE2.0"...)
+	header = append(header, 0x03, 0x01, 0x00, 0x00, 0x00)
+	_, err := w.Write(header)
+	Check(err)
+	return g
+}
+
+// AddFrame scales img down, converts it to the GIF palette and writes it.
+func (g *GifWriter) AddFrame(img *image.RGBA) {
+	b := img.Bounds()
+	for y := range g.scaled.Rect.Dy() {
+		for x := range g.scaled.Rect.Dx() {
+			g.scaled.SetRGBA(x, y,
+				img.RGBAAt(b.Min.X+x*g.scale, b.Min.Y+y*g.scale))
+		}
+	}
+	draw.Draw(g.frame, g.frame.Rect, g.scaled, image.Point{}, draw.Src)
+
+	g.buf.Reset()
+	Check(gif.EncodeAll(&g.buf, &gif.GIF{
+		Image: []*image.Paletted{g.frame},
+		Delay: []int{gifFrameDelay},
+	}))
+	// Skip the header of the single frame GIF (13 bytes, as it has no global
+	// color table) and its trailer.
+	data := g.buf.Bytes()
+	_, err := g.w.Write(data[13 : len(data)-1])
+	Check(err)
+}
+
+// Close writes the GIF trailer.
+func (g *GifWriter) Close() {
+	_, err := g.w.Write([]byte{0x3b})
+	Check(err)
+}
+
+// GifExport is an export of a Playthrough to a GIF file, in progress.
+type GifExport struct {
+	Playthrough Playthrough
+	World       World
+	// The frame of the Playthrough that World is at.
+	FrameIdx int64
+	// The offscreen image the frames are drawn into.
+	Image  *ebiten.Image
+	Pixels []byte
+	File   io.WriteCloser
+	Writer *GifWriter
+}
+
+func NewGifExport(p Playthrough, filename string) *GifExport {
+	e := &GifExport{
+		Playthrough: p,
+		World:       NewWorldFromPlaythrough(p),
+		Image:       ebiten.NewImage(int(GameWidth), int(GameHeight)),
+		Pixels:      make([]byte, 4*GameWidth*GameHeight),
+		File:        CreateFile(filename),
+	}
+	e.Writer = NewGifWriter(e.File, int(GameWidth), int(GameHeight))
+	return e
+}
+
+// Done returns true if all the frames of the Playthrough were exported.
+func (e *GifExport) Done() bool {
+	return e.FrameIdx > int64(len(e.Playthrough.History))
+}
+
+// Progress returns the percentage of the Playthrough exported so far.
+func (e *GifExport) Progress() int64 {
+	return min(100, e.FrameIdx*100/max(1, int64(len(e.Playthrough.History))))
+}
+
+// AddFrame reads the offscreen image, in which the current frame was drawn,
+// and writes it to the GIF.
+func (e *GifExport) AddFrame() {
+	e.Image.ReadPixels(e.Pixels)
+	e.Writer.AddFrame(&image.RGBA{
+		Pix:    e.Pixels,
+		Stride: 4 * int(GameWidth),
+		Rect:   image.Rect(0, 0, int(GameWidth), int(GameHeight)),
+	})
+}
+
+// Step moves World to the next frame. Past the last frame it finishes the
+// GIF.
+func (e *GifExport) Step() {
+	if e.FrameIdx < int64(len(e.Playthrough.History)) {
+		e.World.Step(e.Playthrough.History[e.FrameIdx])
+	} else {
+		e.Writer.Close()
+		Check(e.File.Close())
+	}
+	e.FrameIdx++
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"image"
+	"image/color"
+	"image/gif"
+	"testing"
+)
+
+func TestGifWriter(t *testing.T) {
+	buf := new(bytes.Buffer)
+	w := NewGifWriter(buf, 2*gifMaxWidth, 100)
+	img := image.NewRGBA(image.Rect(0, 0, 2*gifMaxWidth, 100))
+	for i := range 4 {
+		img.SetRGBA(0, 0, color.RGBA{R: uint8(i * 80), A: 255})
+		w.AddFrame(img)
+	}
+	w.Close()
+
+	decoded, err := gif.DecodeAll(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	assert.Len(t, decoded.Image, 4)
+	assert.Equal(t, gifMaxWidth, decoded.Config.Width)
+	assert.Equal(t, 50, decoded.Config.Height)
+	assert.Equal(t, image.Rect(0, 0, gifMaxWidth, 50), decoded.Image[0].Rect)
+	assert.Equal(t, gifFrameDelay, decoded.Delay[0])
+	assert.Equal(t, 0, decoded.LoopCount)
+	// The frames are written as they are, not as the last image.
+	r0, _, _, _ := decoded.Image[0].At(0, 0).RGBA()
+	r3, _, _, _ := decoded.Image[3].At(0, 0).RGBA()
+	assert.Less(t, r0, r3)
+}
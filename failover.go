@@ -0,0 +1,272 @@
+package main
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"sync"
+)
+
+// How many calls in a row may fail before a FailoverSink switches to its
+// next endpoint.
+const maxEndpointFailures = 3
+
+// FailoverSink sends everything to the first of its endpoints, e.g. the
+// server, until that fails maxEndpointFailures times in a row, e.g. because
+// it is down for maintenance. Then it switches to the next one, e.g. a
+// mirror, and so on, going back to the first after the last. This way the
+// data keeps coming while a server is down, it is only somewhere else. Each
+// switch goes to onSwitch, so that it is known where the data went.
+type FailoverSink struct {
+	names    []string
+	sinks    []TelemetrySink
+	onSwitch func(from string, to string, err error)
+
+	mutex     sync.Mutex
+	current   int
+	nFailures int
+	// The playthroughs initialized so far. The data of a playthrough can only
+	// go to an endpoint that knows it, so an endpoint that takes over is told
+	// about it first.
+	playthroughs map[uuid.UUID]*failoverPlaythrough
+}
+
+type failoverPlaythrough struct {
+	initialize func(sink TelemetrySink) error
+	// Which endpoints know the playthrough.
+	initialized []bool
+}
+
+// NewFailoverSink returns the FailoverSink of sinks, which are named by names
+// for onSwitch. onSwitch may be nil.
+func NewFailoverSink(names []string, sinks []TelemetrySink,
+	onSwitch func(from string, to string, err error)) *FailoverSink {
+	return &FailoverSink{
+		names:        names,
+		sinks:        sinks,
+		onSwitch:     onSwitch,
+		playthroughs: map[uuid.UUID]*failoverPlaythrough{},
+	}
+}
+
+// Endpoint is the name of the endpoint that gets the calls now.
+func (s *FailoverSink) Endpoint() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.names[s.current]
+}
+
+func (s *FailoverSink) endpoint() (int, TelemetrySink) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.current, s.sinks[s.current]
+}
+
+// done counts the result of a call to endpoint i and switches to the next
+// endpoint if i failed too many times in a row.
+func (s *FailoverSink) done(i int, err error) {
+	s.mutex.Lock()
+	if i != s.current {
+		// Another call already switched.
+		s.mutex.Unlock()
+		return
+	}
+	if err == nil {
+		s.nFailures = 0
+		s.mutex.Unlock()
+		return
+	}
+	s.nFailures++
+	if s.nFailures < maxEndpointFailures {
+		s.mutex.Unlock()
+		return
+	}
+	s.nFailures = 0
+	s.current = (s.current + 1) % len(s.sinks)
+	from, to := s.names[i], s.names[s.current]
+	s.mutex.Unlock()
+	if s.onSwitch != nil {
+		s.onSwitch(from, to, err)
+	}
+}
+
+// call makes a call with the current endpoint.
+func (s *FailoverSink) call(f func(sink TelemetrySink) error) error {
+	i, sink := s.endpoint()
+	err := f(sink)
+	s.done(i, err)
+	return err
+}
+
+// callPlaythrough is call for the data of the playthrough id, which is first
+// initialized on the current endpoint if it doesn't know it yet.
+func (s *FailoverSink) callPlaythrough(id uuid.UUID,
+	f func(sink TelemetrySink) error) error {
+	i, sink := s.endpoint()
+	s.mutex.Lock()
+	p := s.playthroughs[id]
+	known := p == nil || p.initialized[i]
+	s.mutex.Unlock()
+	var err error
+	if !known {
+		err = p.initialize(sink)
+		if err == nil {
+			s.mutex.Lock()
+			p.initialized[i] = true
+			s.mutex.Unlock()
+		}
+	}
+	if err == nil {
+		err = f(sink)
+	}
+	s.done(i, err)
+	return err
+}
+
+func (s *FailoverSink) InitializePlaythrough(user string,
+	releaseVersion int64, simulationVersion int64, inputVersion int64,
+	id uuid.UUID) error {
+	s.mutex.Lock()
+	s.playthroughs[id] = &failoverPlaythrough{
+		initialize: func(sink TelemetrySink) error {
+			return sink.InitializePlaythrough(user, releaseVersion,
+				simulationVersion, inputVersion, id)
+		},
+		initialized: make([]bool, len(s.sinks)),
+	}
+	s.mutex.Unlock()
+	return s.callPlaythrough(id, func(TelemetrySink) error { return nil })
+}
+
+func (s *FailoverSink) UploadPlaythrough(user string, releaseVersion int64,
+	simulationVersion int64, inputVersion int64, id uuid.UUID,
+	data []byte) error {
+	return s.callPlaythrough(id, func(sink TelemetrySink) error {
+		return sink.UploadPlaythrough(user, releaseVersion,
+			simulationVersion, inputVersion, id, data)
+	})
+}
+
+// AppendPlaythrough answers with the size on the current endpoint, so after
+// a switch the recording stream starts over on the new endpoint.
+func (s *FailoverSink) AppendPlaythrough(user string, id uuid.UUID,
+	offset int64, delta []byte) (n int64, err error) {
+	err = s.callPlaythrough(id, func(sink TelemetrySink) error {
+		n, err = sink.AppendPlaythrough(user, id, offset, delta)
+		return err
+	})
+	return
+}
+
+func (s *FailoverSink) UploadPlaythroughChunk(user string, id uuid.UUID,
+	digest string, total int64, offset int64, chunk []byte) (n int64,
+	err error) {
+	err = s.callPlaythrough(id, func(sink TelemetrySink) error {
+		n, err = sink.UploadPlaythroughChunk(user, id, digest, total, offset,
+			chunk)
+		return err
+	})
+	return
+}
+
+// ListPlaythroughs only lists the playthroughs on the current endpoint.
+func (s *FailoverSink) ListPlaythroughs(user string, count int64) (list string,
+	err error) {
+	err = s.call(func(sink TelemetrySink) error {
+		list, err = sink.ListPlaythroughs(user, count)
+		return err
+	})
+	return
+}
+
+func (s *FailoverSink) GetPlaythrough(user string, id uuid.UUID) (data []byte,
+	err error) {
+	err = s.call(func(sink TelemetrySink) error {
+		data, err = sink.GetPlaythrough(user, id)
+		return err
+	})
+	return
+}
+
+func (s *FailoverSink) SetUserData(user string, data string) error {
+	return s.call(func(sink TelemetrySink) error {
+		return sink.SetUserData(user, data)
+	})
+}
+
+func (s *FailoverSink) GetUserData(user string) (data string, err error) {
+	err = s.call(func(sink TelemetrySink) error {
+		data, err = sink.GetUserData(user)
+		return err
+	})
+	return
+}
+
+func (s *FailoverSink) SubmitScore(user string, name string,
+	releaseVersion int64, score int64) error {
+	return s.call(func(sink TelemetrySink) error {
+		return sink.SubmitScore(user, name, releaseVersion, score)
+	})
+}
+
+func (s *FailoverSink) GetLeaderboard(releaseVersion int64,
+	count int64) (board string, err error) {
+	err = s.call(func(sink TelemetrySink) error {
+		board, err = sink.GetLeaderboard(releaseVersion, count)
+		return err
+	})
+	return
+}
+
+func (s *FailoverSink) GetMotd(releaseVersion int64) (motd string,
+	err error) {
+	err = s.call(func(sink TelemetrySink) error {
+		motd, err = sink.GetMotd(releaseVersion)
+		return err
+	})
+	return
+}
+
+func (s *FailoverSink) GetRemoteConfig(releaseVersion int64) (config string,
+	err error) {
+	err = s.call(func(sink TelemetrySink) error {
+		config, err = sink.GetRemoteConfig(releaseVersion)
+		return err
+	})
+	return
+}
+
+func (s *FailoverSink) Ping() error {
+	return s.call(func(sink TelemetrySink) error {
+		return sink.Ping()
+	})
+}
+
+func (s *FailoverSink) LogEvents(events string) error {
+	return s.call(func(sink TelemetrySink) error {
+		return sink.LogEvents(events)
+	})
+}
+
+func (s *FailoverSink) Log(user string, releaseVersion int64,
+	simulationVersion int64, inputVersion int64, id uuid.UUID, level string,
+	message string, data []byte) error {
+	return s.call(func(sink TelemetrySink) error {
+		return sink.Log(user, releaseVersion, simulationVersion,
+			inputVersion, id, level, message, data)
+	})
+}
+
+// EndpointSwitched records that the data goes to another endpoint from now
+// on, in the breadcrumbs and in the log of the endpoint that takes over.
+// Unlike Log, it doesn't depend on LogNonErrors: the switches tell where the
+// data of a session ended up.
+func (g *Gui) EndpointSwitched(from string, to string, err error) {
+	message := fmt.Sprintf("telemetry switched from %s to %s: %v", from, to,
+		err)
+	g.AddBreadcrumb("telemetry", "%s", message)
+	ch := g.uploadLogChannel
+	if g.UploadsAllowed() && len(ch) < cap(ch) {
+		ch <- logData{g.username, ReleaseVersion, SimulationVersion,
+			InputVersion, uuid.Nil, "warning", message}
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+)
+
+// downSink is a FileSink whose server can go down.
+type downSink struct {
+	FileSink
+	down *bool
+}
+
+func (s downSink) err() error {
+	if *s.down {
+		return errors.New("server down for maintenance")
+	}
+	return nil
+}
+
+func (s downSink) Ping() error {
+	if err := s.err(); err != nil {
+		return err
+	}
+	return s.FileSink.Ping()
+}
+
+func (s downSink) UploadPlaythrough(user string, releaseVersion int64,
+	simulationVersion int64, inputVersion int64, id uuid.UUID,
+	data []byte) error {
+	if err := s.err(); err != nil {
+		return err
+	}
+	return s.FileSink.UploadPlaythrough(user, releaseVersion,
+		simulationVersion, inputVersion, id, data)
+}
+
+func TestFailoverSink(t *testing.T) {
+	down := false
+	primary := downSink{NewFileSink(t.TempDir(), SystemClock{}), &down}
+	mirror := NewFileSink(t.TempDir(), SystemClock{})
+	var switches []string
+	s := NewFailoverSink([]string{"primary", "mirror"},
+		[]TelemetrySink{primary, mirror},
+		func(from string, to string, err error) {
+			switches = append(switches, from+" -> "+to+": "+err.Error())
+		})
+
+	id := uuid.New()
+	assert.Nil(t, s.InitializePlaythrough("user", 1, 2, 3, id))
+
+	// A failure now and then doesn't switch.
+	down = true
+	for range maxEndpointFailures - 1 {
+		assert.NotNil(t, s.Ping())
+	}
+	down = false
+	assert.Nil(t, s.Ping())
+	assert.Equal(t, "primary", s.Endpoint())
+
+	// Failing too many times in a row does.
+	down = true
+	for range maxEndpointFailures {
+		assert.NotNil(t, s.UploadPlaythrough("user", 1, 2, 3, id,
+			[]byte("abc")))
+	}
+	assert.Equal(t, "mirror", s.Endpoint())
+	assert.Equal(t, []string{
+		"primary -> mirror: server down for maintenance"}, switches)
+
+	// The mirror is told about the playthrough before it gets its data.
+	_, err := os.Stat(mirror.playthroughPath(id))
+	assert.NotNil(t, err)
+	assert.Nil(t, s.UploadPlaythrough("user", 1, 2, 3, id, []byte("abc")))
+	data, err := os.ReadFile(mirror.playthroughPath(id))
+	assert.Nil(t, err)
+	assert.Equal(t, "abc", string(data))
+
+	// A recording stream starts over on the mirror.
+	n, err := s.AppendPlaythrough("user", id, 10, []byte("def"))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), n)
+}
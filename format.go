@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// A file written by Serialize starts with fileMagic and fileFormatVersion,
+// then the compressed playthrough, see Compress. The header tells a clone1
+// file from anything else before trying to decompress it, so that loading the
+// wrong file gives an error that says what the file is, instead of failing
+// somewhere inside the decompression.
+// Files written before the header existed start right away with the
+// compressed playthrough and they are still read.
+// fileFormatVersion changes if what follows the header is no longer
+// something that Decompress can read.
+var fileMagic = []byte("CLONE1")
+
+const fileFormatVersion byte = 1
+
+// FileFormat is what a file holds, as far as its first bytes tell.
+type FileFormat int64
+
+const (
+	UnknownFile FileFormat = iota
+	// A playthrough with the header.
+	PlaythroughFile
+	// A playthrough written before the header existed, or a session.
+	LegacyFile
+	RecordingStreamFile
+	EncryptedFile
+	// A playthrough exported by ToJSON.
+	JSONFile
+)
+
+func (f FileFormat) String() string {
+	switch f {
+	case PlaythroughFile:
+		return "playthrough"
+	case LegacyFile:
+		return "legacy playthrough without a header"
+	case RecordingStreamFile:
+		return "recording stream"
+	case EncryptedFile:
+		return "encrypted playthrough"
+	case JSONFile:
+		return "JSON export"
+	default:
+		return "unknown"
+	}
+}
+
+func SniffFormat(data []byte) FileFormat {
+	switch {
+	case bytes.HasPrefix(data, fileMagic):
+		return PlaythroughFile
+	case bytes.HasPrefix(data, []byte("PK")):
+		return LegacyFile
+	case len(data) == 0:
+		return UnknownFile
+	}
+	switch data[0] {
+	case zstdFormat, rawFormat:
+		return LegacyFile
+	case recordingStreamFormat, recordingStreamFormatV3,
+		recordingStreamFormatV2:
+		return RecordingStreamFile
+	case encryptedFormat:
+		return EncryptedFile
+	}
+	if trimmed := bytes.TrimLeft(data, " \t\r\n"); len(trimmed) > 0 &&
+		trimmed[0] == '{' {
+		return JSONFile
+	}
+	return UnknownFile
+}
+
+func addFileHeader(data []byte) []byte {
+	header := append(bytes.Clone(fileMagic), fileFormatVersion)
+	return append(header, data...)
+}
+
+// stripFileHeader returns the compressed playthrough in data, which is a
+// playthrough file with or without the header. Anything else is an error that
+// says what data is and what to do with it.
+func stripFileHeader(data []byte) []byte {
+	switch f := SniffFormat(data); f {
+	case PlaythroughFile:
+		if len(data) <= len(fileMagic) {
+			Check(fmt.Errorf("corrupted recording, it ends right after " +
+				"its header"))
+		}
+		version := data[len(fileMagic)]
+		if version != fileFormatVersion {
+			Check(fmt.Errorf("this playthrough has format version %d, "+
+				"this executable only reads version %d - it was probably "+
+				"written by a newer executable", version,
+				fileFormatVersion))
+		}
+		return data[len(fileMagic)+1:]
+	case LegacyFile:
+		return data
+	case EncryptedFile:
+		Check(fmt.Errorf("this is an %s, decrypt it first with the "+
+			"decrypt command", f))
+	case JSONFile:
+		Check(fmt.Errorf("this is a %s, convert it first with the "+
+			"from-json command", f))
+	default:
+		Check(fmt.Errorf("this is not a clone1 playthrough"))
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSniffFormat(t *testing.T) {
+	p := DeserializePlaythrough(ReadFile("data/demo.clone1"))
+	publicKey, _ := GenerateKeys()
+	var stream closedBuffer
+	NewRecordingStream(&stream, &p).Close()
+
+	assert.Equal(t, PlaythroughFile, SniffFormat(p.Serialize()))
+	assert.Equal(t, LegacyFile, SniffFormat(Zip(p.SerializeUncompressed())))
+	assert.Equal(t, RecordingStreamFile, SniffFormat(stream.Bytes()))
+	assert.Equal(t, EncryptedFile,
+		SniffFormat(Encrypt(p.Serialize(), ParseKey(publicKey))))
+	assert.Equal(t, JSONFile, SniffFormat(p.ToJSON()))
+	assert.Equal(t, UnknownFile, SniffFormat([]byte("not a recording")))
+	assert.Equal(t, UnknownFile, SniffFormat(nil))
+}
+
+func TestDeserializePlaythrough_WrongFormat(t *testing.T) {
+	p := DeserializePlaythrough(ReadFile("data/demo.clone1"))
+	errorMsg := func(data []byte) (msg string) {
+		defer func() {
+			msg = fmt.Sprint(recover())
+		}()
+		DeserializePlaythrough(data)
+		return
+	}
+
+	// Files without the header are still read.
+	assert.Equal(t, p, DeserializePlaythrough(Zip(p.SerializeUncompressed())))
+
+	assert.Contains(t, errorMsg(p.ToJSON()), "from-json")
+	publicKey, _ := GenerateKeys()
+	assert.Contains(t,
+		errorMsg(Encrypt(p.Serialize(), ParseKey(publicKey))), "decrypt")
+	assert.Contains(t, errorMsg([]byte("not a recording")),
+		"not a clone1 playthrough")
+
+	data := p.Serialize()
+	data[len(fileMagic)] = fileFormatVersion + 1
+	assert.Contains(t, errorMsg(data), "newer executable")
+}
@@ -1,7 +1,7 @@
 package main
 
 import (
-	"fmt"
+	"math"
 )
 
 func Min(x int64, y int64) int64 {
@@ -117,15 +117,6 @@ func (r *Rectangle) Intersects(other Rectangle) bool {
 		r.Max.Y > other.Min.Y
 }
 
-// linePointsBufferSize is an arbitrary limit for GetLinePoints. Change its
-// value to accommodate your needs. The only concern is to have something that
-// doesn't eat up RAM unnecessarily but is good enough for everything the game
-// needs.
-const linePointsBufferSize = 10000
-
-// linePointsBuffer is a buffer allocated only once and reused by GetLinePoints.
-var linePointsBuffer = make([]Pt, linePointsBufferSize)
-
 // GetLinePoints computes a list of points that lie between the start and end
 // of a line. The points all have integer coordinates and they are continuous
 // (pixel k touches pixel k-1). This algorithm is useful if you want to draw a
@@ -135,13 +126,11 @@ var linePointsBuffer = make([]Pt, linePointsBufferSize)
 // GetLinePoints does the standard approximation that you might see in something
 // like Windows Paint.
 // Important: the points are ordered and go from line start to line end.
+// The World doesn't call GetLinePoints, it computes only the points it needs
+// with linePoint. This way it keeps no buffer shared between Worlds and
+// several Worlds can run in parallel.
 func GetLinePoints(start Pt, end Pt, nMaxPts int64) []Pt {
-	if nMaxPts > linePointsBufferSize {
-		panic(fmt.Errorf("got nMaxPts = %d but can only handle at most %d "+
-			"points", nMaxPts, linePointsBufferSize))
-	}
-
-	n := int64(0)
+	var pts []Pt
 	x1 := start.X
 	y1 := start.Y
 	x2 := end.X
@@ -153,9 +142,7 @@ func GetLinePoints(start Pt, end Pt, nMaxPts int64) []Pt {
 	// line.
 	if dx == 0 && dy == 0 {
 		// If start and end are the same, return a single point.
-		linePointsBuffer[n] = start
-		n++
-		return linePointsBuffer[:n]
+		return append(pts, start)
 	}
 
 	if Abs(dx) > Abs(dy) {
@@ -168,25 +155,23 @@ func GetLinePoints(start Pt, end Pt, nMaxPts int64) []Pt {
 		// within nMaxPts. The condition for x must be x != x2 because we don't
 		// know if inc is 1 or -1 so we cannot do x <= x2 or x >= x2. So, just
 		// increase x2 by inc.
-		for x := x1; x != x2 && n < nMaxPts; x += inc {
+		for x := x1; x != x2 && int64(len(pts)) < nMaxPts; x += inc {
 			// I intentionally don't compute dy/dx once and reuse it because
 			// that would mean doing floating point operations. I want to do
 			// only integer operations.
 			y := y1 + (x-x1)*dy/dx
-			linePointsBuffer[n] = Pt{x, y}
-			n++
+			pts = append(pts, Pt{x, y})
 		}
 	} else {
 		// The comments for X apply here as well, with X and Y interchanged.
 		inc := dy / Abs(dy)
 		y2 += inc
-		for y := y1; y != y2 && n < nMaxPts; y += inc {
+		for y := y1; y != y2 && int64(len(pts)) < nMaxPts; y += inc {
 			x := x1 + (y-y1)*dx/dy
-			linePointsBuffer[n] = Pt{x, y}
-			n++
+			pts = append(pts, Pt{x, y})
 		}
 	}
-	return linePointsBuffer[:n]
+	return pts
 }
 
 // RectIntersectsRects is a utility function that checks if a rectangle
@@ -200,14 +185,57 @@ func RectIntersectsRects(r Rectangle, rects []Rectangle) bool {
 	return false
 }
 
-// moveRectBufferSize is an arbitrary limit for MoveRect. Change its
-// value to accommodate your needs. The only concern is to have something that
-// doesn't eat up RAM unnecessarily but is good enough for everything the game
-// needs.
-const moveRectBufferSize = 100
+// linePoint returns the point at index k in the line from start to end, the
+// same point that GetLinePoints returns at index k.
+func linePoint(start Pt, end Pt, k int64) Pt {
+	dx := end.X - start.X
+	dy := end.Y - start.Y
+	if Abs(dx) > Abs(dy) {
+		inc := dx / Abs(dx)
+		return Pt{start.X + k*inc, start.Y + k*inc*dy/dx}
+	}
+	if dy == 0 {
+		return start
+	}
+	inc := dy / Abs(dy)
+	return Pt{start.X + k*inc*dx/dy, start.Y + k*inc}
+}
 
-// moveRectBuffer is a buffer allocated only once and reused by MoveRect.
-var moveRectBuffer = make([]Rectangle, moveRectBufferSize)
+// lineEnd returns the last point that GetLinePoints(start, end, nMaxPts)
+// returns, without computing the others. nMaxPts must be at least 1.
+func lineEnd(start Pt, end Pt, nMaxPts int64) Pt {
+	nSteps := max(Abs(end.X-start.X), Abs(end.Y-start.Y))
+	return linePoint(start, end, min(nSteps, nMaxPts-1))
+}
+
+// lineStepsInRange returns the interval [kMin, kMax] of steps k >= 0 for which
+// floor(k*a/b) is between lo and hi, inclusive, with the sign of dir. This is
+// how a coordinate advances along a line computed by GetLinePoints: a is how
+// much the coordinate changes over the whole line, b is the number of steps in
+// the line and dir is the direction, only its sign matters. The interval is
+// empty if kMin > kMax.
+func lineStepsInRange(a, b, dir, lo, hi int64) (kMin, kMax int64) {
+	if dir < 0 {
+		lo, hi = -hi, -lo
+	}
+	if a == 0 {
+		// The coordinate never changes.
+		if lo <= 0 && hi >= 0 {
+			return 0, math.MaxInt64
+		}
+		return 1, 0
+	}
+	if hi < 0 {
+		return 1, 0
+	}
+	// floor(k*a/b) <= hi is the same as k*a < (hi+1)*b.
+	kMax = ((hi+1)*b - 1) / a
+	// floor(k*a/b) >= lo is the same as k*a >= lo*b.
+	if lo > 0 {
+		kMin = (lo*b + a - 1) / a
+	}
+	return
+}
 
 // MoveRect computes a rectangle newR the size of r as if r was moved in a
 // straight line towards targetPos until:
@@ -216,51 +244,54 @@ var moveRectBuffer = make([]Rectangle, moveRectBufferSize)
 // - it intersected an obstacle
 // The position of the rectangle is r.Min. If r can reach the targetPos,
 // then newR.Min == targetPos.
+// The rectangle goes through the pixels that GetLinePoints returns, but they
+// are not checked one by one. Along the line, each coordinate of r only ever
+// increases or only ever decreases, so the steps in which r overlaps an
+// obstacle on an axis are an interval. For each obstacle, the first step in
+// which r overlaps it on both axes is computed directly.
 func MoveRect(r Rectangle, targetPos Pt, nMaxPixels int64,
 	obstacles []Rectangle) (newR Rectangle, nPixelsLeft int64) {
+	dx := targetPos.X - r.Min.X
+	dy := targetPos.Y - r.Min.Y
+	// The number of steps from r.Min to targetPos. The first pixel in the
+	// line is the current position, which we do not consider a movement.
+	nSteps := Max(Abs(dx), Abs(dy))
+	if nSteps == 0 {
+		return r, nMaxPixels
+	}
+	lastStep := Min(nSteps, nMaxPixels)
 
-	// Compute the pixels along the line from the start position to the target
-	// position. We do nMaxPixels+1 because the first pixel in the line is the
-	// current position, which we do not consider a movement.
-	pts := GetLinePoints(r.Min, targetPos, nMaxPixels+1)
-
-	// Filter out obstacles that cannot be relevant:
-	// - compute a large rectangle that is the minimum rectangle that includes
-	// both the start and end rectangle
-	// - any obstacle that does not intersect this large rectangle cannot
-	// intersect r during its movement
-	// This optimization is really only relevant if there's more than 2 points
-	// in pts, otherwise we might as well let RectIntersectsRects execute once
-	// for all obstacles.
+	// Find the first step in which r intersects an obstacle. r at
+	// position p intersects an obstacle o on the X axis if
+	// o.Min.X < p.X + width and p.X < o.Max.X, meaning that p.X is between
+	// o.Min.X - width + 1 and o.Max.X - 1. The same goes for the Y axis.
 	rSize := Pt{r.Width(), r.Height()}
-	if len(pts) > 2 {
-		endRect := NewRectangle(pts[len(pts)-1], pts[len(pts)-1].Plus(rSize))
-		largeRect := NewRectangle(
-			Pt{Min(r.Min.X, endRect.Min.X), Min(r.Min.Y, endRect.Min.Y)},
-			Pt{Max(r.Max.X, endRect.Max.X), Max(r.Max.Y, endRect.Max.Y)})
-
-		n := 0
-		for i := range obstacles {
-			if largeRect.Intersects(obstacles[i]) {
-				moveRectBuffer[n] = obstacles[i]
-				n++
-			}
+	hit := lastStep + 1
+	// An obstacle that doesn't intersect the smallest rectangle that includes
+	// both the start and end rectangle cannot intersect r during its movement.
+	// Checking this first is much cheaper than computing the steps.
+	end := linePoint(r.Min, targetPos, lastStep)
+	largeRect := Rectangle{
+		Pt{Min(r.Min.X, end.X), Min(r.Min.Y, end.Y)},
+		Pt{Max(r.Min.X, end.X) + rSize.X, Max(r.Min.Y, end.Y) + rSize.Y}}
+	for _, o := range obstacles {
+		if !largeRect.Intersects(o) {
+			continue
 		}
-		obstacles = moveRectBuffer[:n]
-	}
-
-	// Move the rectangle pixel by pixel and check if it collides with any of
-	// the obstacles.
-	var i int64
-	for i = 1; i < int64(len(pts)); i++ {
-		r = NewRectangle(pts[i], pts[i].Plus(rSize))
-		if RectIntersectsRects(r, obstacles) {
-			break
+		kMinX, kMaxX := lineStepsInRange(Abs(dx), nSteps, dx,
+			o.Min.X-rSize.X+1-r.Min.X, o.Max.X-1-r.Min.X)
+		kMinY, kMaxY := lineStepsInRange(Abs(dy), nSteps, dy,
+			o.Min.Y-rSize.Y+1-r.Min.Y, o.Max.Y-1-r.Min.Y)
+		kMin := max(kMinX, kMinY, 1)
+		kMax := min(kMaxX, kMaxY, lastStep)
+		if kMin <= kMax && kMin < hit {
+			hit = kMin
 		}
 	}
 
-	// At this point, pts[i-1] is the last valid position either because
+	// The step before the hit is the last valid position either because
 	// we reached the target, or we travelled the maximum number of pixels
-	// or we hit an obstacle at pt[i].
-	return NewRectangle(pts[i-1], pts[i-1].Plus(rSize)), nMaxPixels - i + 1
+	// or we hit an obstacle.
+	pos := linePoint(r.Min, targetPos, hit-1)
+	return NewRectangle(pos, pos.Plus(rSize)), nMaxPixels - hit + 1
 }
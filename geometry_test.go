@@ -7,6 +7,10 @@ import (
 )
 
 // BenchmarkMoveRect-12    	   96234	     12439 ns/op
+// After computing the first hit of each obstacle directly instead of moving
+// pixel by pixel (on a different machine, with asserts disabled, where the
+// pixel by pixel version took 594 ns/op):
+// BenchmarkMoveRect    	11250590	       124.8 ns/op
 func BenchmarkMoveRect(b *testing.B) {
 	brickSize := Pt{100, 100}
 
@@ -339,3 +343,106 @@ func OneTestMoveRect(t *testing.T, r Rectangle, targetPos Pt, nMaxPixels int64,
 	newR2 := Rectangle{pos2, pos2.Plus(newR.Size())}
 	assert.True(t, RectIntersectsRects(newR2, obstacles))
 }
+
+// moveRectIterativeBuffer is a buffer allocated only once and reused by
+// moveRectIterative.
+var moveRectIterativeBuffer = make([]Rectangle, 100)
+
+// moveRectIterative is the original implementation of MoveRect, which moves
+// the rectangle pixel by pixel. MoveRect must give exactly the same results.
+func moveRectIterative(r Rectangle, targetPos Pt, nMaxPixels int64,
+	obstacles []Rectangle) (newR Rectangle, nPixelsLeft int64) {
+
+	// Compute the pixels along the line from the start position to the target
+	// position. We do nMaxPixels+1 because the first pixel in the line is the
+	// current position, which we do not consider a movement.
+	pts := GetLinePoints(r.Min, targetPos, nMaxPixels+1)
+
+	// Filter out obstacles that cannot be relevant:
+	// - compute a large rectangle that is the minimum rectangle that includes
+	// both the start and end rectangle
+	// - any obstacle that does not intersect this large rectangle cannot
+	// intersect r during its movement
+	// This optimization is really only relevant if there's more than 2 points
+	// in pts, otherwise we might as well let RectIntersectsRects execute once
+	// for all obstacles.
+	rSize := Pt{r.Width(), r.Height()}
+	if len(pts) > 2 {
+		endRect := NewRectangle(pts[len(pts)-1], pts[len(pts)-1].Plus(rSize))
+		largeRect := NewRectangle(
+			Pt{Min(r.Min.X, endRect.Min.X), Min(r.Min.Y, endRect.Min.Y)},
+			Pt{Max(r.Max.X, endRect.Max.X), Max(r.Max.Y, endRect.Max.Y)})
+
+		n := 0
+		for i := range obstacles {
+			if largeRect.Intersects(obstacles[i]) {
+				moveRectIterativeBuffer[n] = obstacles[i]
+				n++
+			}
+		}
+		obstacles = moveRectIterativeBuffer[:n]
+	}
+
+	// Move the rectangle pixel by pixel and check if it collides with any of
+	// the obstacles.
+	var i int64
+	for i = 1; i < int64(len(pts)); i++ {
+		r = NewRectangle(pts[i], pts[i].Plus(rSize))
+		if RectIntersectsRects(r, obstacles) {
+			break
+		}
+	}
+
+	// At this point, pts[i-1] is the last valid position either because
+	// we reached the target, or we travelled the maximum number of pixels
+	// or we hit an obstacle at pt[i].
+	return NewRectangle(pts[i-1], pts[i-1].Plus(rSize)), nMaxPixels - i + 1
+}
+
+func TestLineEnd(t *testing.T) {
+	start := Pt{3, -7}
+	for x := int64(-40); x <= 40; x++ {
+		for y := int64(-40); y <= 40; y++ {
+			for _, nMaxPts := range []int64{1, 2, 13, 100} {
+				pts := GetLinePoints(start, Pt{x, y}, nMaxPts)
+				assert.Equal(t, pts[len(pts)-1],
+					lineEnd(start, Pt{x, y}, nMaxPts))
+			}
+		}
+	}
+}
+
+// TestMoveRectMatchesIterative compares MoveRect with moveRectIterative for
+// every direction and distance around a few sets of obstacles.
+func TestMoveRectMatchesIterative(t *testing.T) {
+	var rand Rand
+	rand.RSeed(0)
+	for range 20 {
+		var obstacles []Rectangle
+		for range rand.RInt(0, 6) {
+			pos := Pt{rand.RInt(-20, 20), rand.RInt(-20, 20)}
+			size := Pt{rand.RInt(0, 10), rand.RInt(0, 10)}
+			obstacles = append(obstacles, NewRectangle(pos, pos.Plus(size)))
+		}
+		size := Pt{rand.RInt(1, 10), rand.RInt(1, 10)}
+		pos := Pt{rand.RInt(-20, 20), rand.RInt(-20, 20)}
+		r := NewRectangle(pos, pos.Plus(size))
+		for x := int64(-30); x <= 30; x++ {
+			for y := int64(-30); y <= 30; y++ {
+				for _, nMaxPixels := range []int64{0, 1, 7, 25, 100} {
+					target := Pt{x, y}
+					expectedR, expectedLeft := moveRectIterative(r, target,
+						nMaxPixels, obstacles)
+					actualR, actualLeft := MoveRect(r, target, nMaxPixels,
+						obstacles)
+					if expectedR != actualR || expectedLeft != actualLeft {
+						t.Fatalf("r: %v target: %v nMaxPixels: %d "+
+							"obstacles: %v expected: %v %d got: %v %d", r,
+							target, nMaxPixels, obstacles, expectedR,
+							expectedLeft, actualR, actualLeft)
+					}
+				}
+			}
+		}
+	}
+}
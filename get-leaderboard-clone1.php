@@ -0,0 +1,50 @@
+<?php
+require_once "./auth-clone1.php";
+$servername = "172.232.206.74";
+$username = "playfulp_temp";
+$password = "comeonthough";
+$dbname = "playfulp_clone1";
+
+// Returns the best scores for a release, best first, one per line: the user,
+// the name shown for the user and the score, separated by tabs. The scores
+// are sent by submit-score-clone1.php.
+
+function LogInfo($message) {
+    // 	file_put_contents("./get-leaderboard-clone1.log", "INFO: " . $message . "\n", FILE_APPEND);
+}
+
+function LogError($message) {
+	file_put_contents("./get-leaderboard-clone1.log", "ERROR: " . $message . "\n", FILE_APPEND);
+    http_response_code(513);
+	die();
+}
+
+LogInfo("Start.");
+if ($_SERVER['REQUEST_METHOD'] == 'POST') {
+    VerifyRequest();
+    $table = Table("scores");
+    LogInfo("Attempt to connect to database.");
+    $conn = new mysqli($servername, $username, $password, $dbname);
+    if ($conn->connect_error) {
+        LogError("Connection failed: " . $conn->connect_error);
+    }
+    LogInfo("Connection succeeded!");
+
+    $release_version = intval($_POST['release_version']);
+    $count = intval($_POST['count']);
+    LogInfo("We got release_version: " . $release_version . ", count: " . $count);
+    $sql = "SELECT user, name, score FROM $table WHERE release_version = $release_version " .
+        "ORDER BY score DESC, moment ASC LIMIT $count";
+    try {
+        $result = $conn->query($sql);
+    } catch(Exception $e) {
+        LogError("Error querying: " . $e->getMessage());
+    }
+
+    while ($row = $result->fetch_assoc()) {
+        echo $row["user"] . "\t" . $row["name"] . "\t" . $row["score"] . "\n";
+    }
+    $conn->close();
+}
+LogInfo("End.");
+?>
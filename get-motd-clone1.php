@@ -0,0 +1,24 @@
+<?php
+require_once "./auth-clone1.php";
+// Returns the message of the day shown on the home screen of the game.
+// The message is whatever is in motd-clone1.txt, next to this script. Edit or
+// delete that file to change or remove the message.
+
+function LogInfo($message) {
+    // 	file_put_contents("./get-motd-clone1.log", "INFO: " . $message . "\n", FILE_APPEND);
+}
+
+LogInfo("Start.");
+if ($_SERVER['REQUEST_METHOD'] == 'POST') {
+    VerifyRequest();
+    $release_version = $_POST['release_version'];
+    LogInfo("We got release_version: " . $release_version);
+    $filename = "./motd-clone1.txt";
+    if (file_exists($filename)) {
+        echo file_get_contents($filename);
+    } else {
+        echo "";
+    }
+}
+LogInfo("End.");
+?>
@@ -0,0 +1,52 @@
+<?php
+require_once "./auth-clone1.php";
+$servername = "172.232.206.74";
+$username = "playfulp_temp";
+$password = "comeonthough";
+$dbname = "playfulp_clone1";
+
+// Returns the data of a playthrough, as it was uploaded, for the recordings
+// screen of the game. Only the user who played it gets it.
+
+function LogInfo($message) {
+    // 	file_put_contents("./get-playthrough-clone1.log", "INFO: " . $message . "\n", FILE_APPEND);
+}
+
+function LogError($message) {
+	file_put_contents("./get-playthrough-clone1.log", "ERROR: " . $message . "\n", FILE_APPEND);
+    http_response_code(513);
+	die();
+}
+
+LogInfo("Start.");
+if ($_SERVER['REQUEST_METHOD'] == 'POST') {
+    VerifyRequest();
+    $table = Table("playthroughs");
+    LogInfo("Attempt to connect to database.");
+    $conn = new mysqli($servername, $username, $password, $dbname);
+    if ($conn->connect_error) {
+        LogError("Connection failed: " . $conn->connect_error);
+    }
+    LogInfo("Connection succeeded!");
+
+    $user = $conn->real_escape_string($_POST['user']);
+    $id = $conn->real_escape_string($_POST['id']);
+    LogInfo("We got user: " . $user . ", id: " . $id);
+    $sql = "SELECT playthrough FROM $table WHERE user = '$user' AND id = '$id'";
+    try {
+        $result = $conn->query($sql);
+    } catch(Exception $e) {
+        LogError("Error querying: " . $e->getMessage());
+    }
+
+    if ($result->num_rows > 0) {
+        $row = $result->fetch_assoc();
+        header("Content-Type: application/octet-stream");
+        echo $row["playthrough"];
+    } else {
+        LogError("Unknown playthrough.");
+    }
+    $conn->close();
+}
+LogInfo("End.");
+?>
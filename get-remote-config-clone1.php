@@ -0,0 +1,28 @@
+<?php
+require_once "./auth-clone1.php";
+// Returns the remote config of the game, in YAML, see RemoteConfig.
+// The config is whatever is in remote-config-clone1-<release_version>.yaml,
+// next to this script, or in remote-config-clone1.yaml if there is no file
+// for the release. Delete both files to go back to the values of the release.
+
+function LogInfo($message) {
+    // 	file_put_contents("./get-remote-config-clone1.log", "INFO: " . $message . "\n", FILE_APPEND);
+}
+
+LogInfo("Start.");
+if ($_SERVER['REQUEST_METHOD'] == 'POST') {
+    VerifyRequest();
+    $release_version = intval($_POST['release_version']);
+    LogInfo("We got release_version: " . $release_version);
+    $filename = "./remote-config-clone1-" . $release_version . ".yaml";
+    if (!file_exists($filename)) {
+        $filename = "./remote-config-clone1.yaml";
+    }
+    if (file_exists($filename)) {
+        echo file_get_contents($filename);
+    } else {
+        echo "";
+    }
+}
+LogInfo("End.");
+?>
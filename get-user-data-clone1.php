@@ -1,4 +1,5 @@
 <?php
+require_once "./auth-clone1.php";
 $servername = "172.232.206.74";
 $username = "playfulp_temp";
 $password = "comeonthough";
@@ -10,6 +11,8 @@ function LogInfo($message) {
 
 LogInfo("Start.");
 if ($_SERVER['REQUEST_METHOD'] == 'POST') {
+    VerifyRequest();
+    $table = Table("user_data");
     LogInfo("Attempt to connect to database.");
 
     $conn = new mysqli($servername, $username, $password, $dbname);
@@ -20,7 +23,7 @@ if ($_SERVER['REQUEST_METHOD'] == 'POST') {
         
         $user = $_POST['user'];
         LogInfo("We got user: " . $user);
-        $sql = "SELECT data FROM user_data WHERE user = '$user'";
+        $sql = "SELECT data FROM $table WHERE user = '$user'";
         
         try {
             $result = $conn->query($sql);
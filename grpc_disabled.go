@@ -0,0 +1,18 @@
+//go:build !grpc_enabled || js
+
+package main
+
+import (
+	"errors"
+)
+
+// Builds without grpc_enabled talk to the PHP endpoints, unless the Config
+// says otherwise.
+const defaultTelemetry = "http"
+
+// NewGrpcSink needs gRPC, which only native builds with grpc_enabled have.
+func NewGrpcSink(rawUrl string, environment string,
+	fallback TelemetrySink) TelemetrySink {
+	panic(errors.New("the grpc telemetry sink needs a native build with " +
+		"grpc_enabled"))
+}
@@ -0,0 +1,280 @@
+//go:build grpc_enabled && !js
+
+package main
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/marisvali/clone1/telemetrypb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"net/url"
+	"time"
+)
+
+// Native builds with grpc_enabled talk to the gRPC server, unless the Config
+// says otherwise.
+const defaultTelemetry = "grpc"
+
+// How long an rpc may take before it counts as failed.
+const grpcTimeout = 30 * time.Second
+
+// GrpcSink is the TelemetrySink of a server with the Telemetry service of
+// telemetrypb. The rpcs that the server doesn't implement yet go to
+// fallback, so that a backend can move off the PHP endpoints one rpc at a
+// time.
+type GrpcSink struct {
+	client   telemetrypb.TelemetryClient
+	fallback TelemetrySink
+}
+
+// NewGrpcSink returns the sink of the server at rawUrl, e.g.
+// https://example.com:8443. The connection is encrypted for https, and
+// pinned like the HTTP requests, and not for http, which is only meant for a
+// server on the same machine. Every rpc has the environment in its
+// metadata, like the requests of an HttpSink.
+func NewGrpcSink(rawUrl string, environment string,
+	fallback TelemetrySink) TelemetrySink {
+	u, err := url.Parse(rawUrl)
+	Check(err)
+	creds := insecure.NewCredentials()
+	port := "80"
+	if u.Scheme == "https" {
+		creds = credentials.NewTLS(serverTlsConfig())
+		port = "443"
+	}
+	if u.Port() != "" {
+		port = u.Port()
+	}
+	conn, err := grpc.NewClient(u.Hostname()+":"+port,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithChainUnaryInterceptor(environmentRpc(environment),
+			signRpc))
+	Check(err)
+	return GrpcSink{telemetrypb.NewTelemetryClient(conn), fallback}
+}
+
+// environmentRpc adds the environment to the metadata of every rpc.
+func environmentRpc(environment string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req any, reply any,
+		cc *grpc.ClientConn, invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption) error {
+		if environment != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, "environment",
+				environment)
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// signRpc signs every rpc, like makeHttpRequest signs every request. The
+// method is the only field and the request, marshaled deterministically, is
+// the only file, see SignRequest. The signature goes in the metadata.
+func signRpc(ctx context.Context, method string, req any, reply any,
+	cc *grpc.ClientConn, invoker grpc.UnaryInvoker,
+	opts ...grpc.CallOption) error {
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(
+		req.(proto.Message))
+	Check(err)
+	auth := SignRequest(releaseSecret, ReleaseVersion, time.Now(),
+		map[string]string{"method": method},
+		map[string][]byte{"request": data})
+	for k, v := range auth {
+		ctx = metadata.AppendToOutgoingContext(ctx, k, v)
+	}
+	ctx, cancel := context.WithTimeout(ctx, grpcTimeout)
+	defer cancel()
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+// unimplemented is true if err says that the server doesn't have the rpc.
+func unimplemented(err error) bool {
+	return status.Code(err) == codes.Unimplemented
+}
+
+func (s GrpcSink) InitializePlaythrough(user string, releaseVersion int64,
+	simulationVersion int64, inputVersion int64, id uuid.UUID) error {
+	_, err := s.client.InitializePlaythrough(context.Background(),
+		&telemetrypb.InitializePlaythroughRequest{
+			User:              user,
+			ReleaseVersion:    releaseVersion,
+			SimulationVersion: simulationVersion,
+			InputVersion:      inputVersion,
+			Id:                id.String()})
+	if unimplemented(err) {
+		return s.fallback.InitializePlaythrough(user, releaseVersion,
+			simulationVersion, inputVersion, id)
+	}
+	return err
+}
+
+func (s GrpcSink) UploadPlaythrough(user string, releaseVersion int64,
+	simulationVersion int64, inputVersion int64, id uuid.UUID,
+	data []byte) error {
+	_, err := s.client.UploadPlaythrough(context.Background(),
+		&telemetrypb.UploadPlaythroughRequest{
+			User:              user,
+			ReleaseVersion:    releaseVersion,
+			SimulationVersion: simulationVersion,
+			InputVersion:      inputVersion,
+			Id:                id.String(),
+			Data:              data})
+	if unimplemented(err) {
+		return s.fallback.UploadPlaythrough(user, releaseVersion,
+			simulationVersion, inputVersion, id, data)
+	}
+	return err
+}
+
+func (s GrpcSink) AppendPlaythrough(user string, id uuid.UUID, offset int64,
+	delta []byte) (int64, error) {
+	r, err := s.client.AppendPlaythrough(context.Background(),
+		&telemetrypb.AppendPlaythroughRequest{
+			User:   user,
+			Id:     id.String(),
+			Offset: offset,
+			Delta:  delta})
+	if unimplemented(err) {
+		return s.fallback.AppendPlaythrough(user, id, offset, delta)
+	}
+	return r.GetSize(), err
+}
+
+func (s GrpcSink) UploadPlaythroughChunk(user string, id uuid.UUID,
+	digest string, total int64, offset int64, chunk []byte) (int64, error) {
+	r, err := s.client.UploadPlaythroughChunk(context.Background(),
+		&telemetrypb.UploadPlaythroughChunkRequest{
+			User:   user,
+			Id:     id.String(),
+			Digest: digest,
+			Total:  total,
+			Offset: offset,
+			Chunk:  chunk})
+	if unimplemented(err) {
+		return s.fallback.UploadPlaythroughChunk(user, id, digest, total,
+			offset, chunk)
+	}
+	return r.GetSize(), err
+}
+
+func (s GrpcSink) ListPlaythroughs(user string, count int64) (string, error) {
+	r, err := s.client.ListPlaythroughs(context.Background(),
+		&telemetrypb.ListPlaythroughsRequest{User: user, Count: count})
+	if unimplemented(err) {
+		return s.fallback.ListPlaythroughs(user, count)
+	}
+	return r.GetText(), err
+}
+
+func (s GrpcSink) GetPlaythrough(user string, id uuid.UUID) ([]byte, error) {
+	r, err := s.client.GetPlaythrough(context.Background(),
+		&telemetrypb.GetPlaythroughRequest{User: user, Id: id.String()})
+	if unimplemented(err) {
+		return s.fallback.GetPlaythrough(user, id)
+	}
+	return r.GetData(), err
+}
+
+func (s GrpcSink) SetUserData(user string, data string) error {
+	_, err := s.client.SetUserData(context.Background(),
+		&telemetrypb.SetUserDataRequest{User: user, Data: data})
+	if unimplemented(err) {
+		return s.fallback.SetUserData(user, data)
+	}
+	return err
+}
+
+func (s GrpcSink) GetUserData(user string) (string, error) {
+	r, err := s.client.GetUserData(context.Background(),
+		&telemetrypb.GetUserDataRequest{User: user})
+	if unimplemented(err) {
+		return s.fallback.GetUserData(user)
+	}
+	return r.GetText(), err
+}
+
+func (s GrpcSink) SubmitScore(user string, name string, releaseVersion int64,
+	score int64) error {
+	_, err := s.client.SubmitScore(context.Background(),
+		&telemetrypb.SubmitScoreRequest{
+			User:           user,
+			Name:           name,
+			ReleaseVersion: releaseVersion,
+			Score:          score})
+	if unimplemented(err) {
+		return s.fallback.SubmitScore(user, name, releaseVersion, score)
+	}
+	return err
+}
+
+func (s GrpcSink) GetLeaderboard(releaseVersion int64,
+	count int64) (string, error) {
+	r, err := s.client.GetLeaderboard(context.Background(),
+		&telemetrypb.GetLeaderboardRequest{
+			ReleaseVersion: releaseVersion,
+			Count:          count})
+	if unimplemented(err) {
+		return s.fallback.GetLeaderboard(releaseVersion, count)
+	}
+	return r.GetText(), err
+}
+
+func (s GrpcSink) GetMotd(releaseVersion int64) (string, error) {
+	r, err := s.client.GetMotd(context.Background(),
+		&telemetrypb.ReleaseRequest{ReleaseVersion: releaseVersion})
+	if unimplemented(err) {
+		return s.fallback.GetMotd(releaseVersion)
+	}
+	return r.GetText(), err
+}
+
+func (s GrpcSink) GetRemoteConfig(releaseVersion int64) (string, error) {
+	r, err := s.client.GetRemoteConfig(context.Background(),
+		&telemetrypb.ReleaseRequest{ReleaseVersion: releaseVersion})
+	if unimplemented(err) {
+		return s.fallback.GetRemoteConfig(releaseVersion)
+	}
+	return r.GetText(), err
+}
+
+func (s GrpcSink) Ping() error {
+	_, err := s.client.Ping(context.Background(), &telemetrypb.Empty{})
+	if unimplemented(err) {
+		return s.fallback.Ping()
+	}
+	return err
+}
+
+func (s GrpcSink) LogEvents(events string) error {
+	_, err := s.client.LogEvents(context.Background(),
+		&telemetrypb.LogEventsRequest{Events: events})
+	if unimplemented(err) {
+		return s.fallback.LogEvents(events)
+	}
+	return err
+}
+
+func (s GrpcSink) Log(user string, releaseVersion int64,
+	simulationVersion int64, inputVersion int64, id uuid.UUID, level string,
+	message string, data []byte) error {
+	_, err := s.client.Log(context.Background(),
+		&telemetrypb.LogRequest{
+			User:              user,
+			ReleaseVersion:    releaseVersion,
+			SimulationVersion: simulationVersion,
+			InputVersion:      inputVersion,
+			Id:                id.String(),
+			Level:             level,
+			Message:           message,
+			Data:              data})
+	if unimplemented(err) {
+		return s.fallback.Log(user, releaseVersion, simulationVersion,
+			inputVersion, id, level, message, data)
+	}
+	return err
+}
@@ -0,0 +1,112 @@
+//go:build grpc_enabled && !js
+
+package main
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/marisvali/clone1/telemetrypb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// partialServer is a server that has only moved some rpcs off the PHP
+// endpoints.
+type partialServer struct {
+	telemetrypb.UnimplementedTelemetryServer
+	sink  FileSink
+	pings []metadata.MD
+}
+
+func (s *partialServer) SetUserData(_ context.Context,
+	r *telemetrypb.SetUserDataRequest) (*telemetrypb.Empty, error) {
+	return &telemetrypb.Empty{}, s.sink.SetUserData(r.User, r.Data)
+}
+
+func (s *partialServer) GetUserData(_ context.Context,
+	r *telemetrypb.GetUserDataRequest) (*telemetrypb.TextResponse, error) {
+	text, err := s.sink.GetUserData(r.User)
+	return &telemetrypb.TextResponse{Text: text}, err
+}
+
+func (s *partialServer) Ping(ctx context.Context,
+	_ *telemetrypb.Empty) (*telemetrypb.Empty, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	s.pings = append(s.pings, md)
+	return &telemetrypb.Empty{}, nil
+}
+
+func newTestGrpcSink(t *testing.T, server *partialServer,
+	fallback TelemetrySink) GrpcSink {
+	listener := bufconn.Listen(1024 * 1024)
+	s := grpc.NewServer()
+	telemetrypb.RegisterTelemetryServer(s, server)
+	go func() { _ = s.Serve(listener) }()
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context,
+			_ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithChainUnaryInterceptor(environmentRpc("staging"), signRpc))
+	assert.Nil(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return GrpcSink{telemetrypb.NewTelemetryClient(conn), fallback}
+}
+
+func TestGrpcSink(t *testing.T) {
+	server := &partialServer{
+		sink: NewFileSink(t.TempDir(), SystemClock{})}
+	fallback := NewFileSink(t.TempDir(), SystemClock{})
+	s := newTestGrpcSink(t, server, fallback)
+
+	// The server has the user data.
+	assert.Nil(t, s.SetUserData("user", "BestScore: 10\n"))
+	data, err := server.sink.GetUserData("user")
+	assert.Nil(t, err)
+	assert.Equal(t, "BestScore: 10\n", data)
+	data, err = s.GetUserData("user")
+	assert.Nil(t, err)
+	assert.Equal(t, "BestScore: 10\n", data)
+
+	// The rest still goes to the fallback.
+	id := uuid.New()
+	assert.Nil(t, s.InitializePlaythrough("user", 1, 2, 3, id))
+	n, err := s.AppendPlaythrough("user", id, 0, []byte("abc"))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), n)
+	assert.Nil(t, s.SubmitScore("user", "name", 1, 10))
+	board, err := fallback.GetLeaderboard(1, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, "user\tname\t10\n", board)
+}
+
+func TestGrpcSink_Signed(t *testing.T) {
+	defer func(secret string) { releaseSecret = secret }(releaseSecret)
+	releaseSecret = "secret"
+	server := &partialServer{
+		sink: NewFileSink(t.TempDir(), SystemClock{})}
+	s := newTestGrpcSink(t, server, NopSink{})
+
+	// The server can check the signature the same way the PHP endpoints do.
+	assert.Nil(t, s.Ping())
+	assert.Equal(t, 1, len(server.pings))
+	md := server.pings[0]
+	assert.Equal(t, []string{"staging"}, md.Get("environment"))
+	moment, err := strconv.ParseInt(md.Get(authMomentField)[0], 10, 64)
+	assert.Nil(t, err)
+	expected := SignRequest("secret", ReleaseVersion, time.Unix(moment, 0),
+		map[string]string{"method": telemetrypb.Telemetry_Ping_FullMethodName},
+		map[string][]byte{"request": nil})
+	assert.Equal(t, expected[authSignatureField],
+		md.Get(authSignatureField)[0])
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// The Gui pings the server in the background and shows the result as a small
+// icon on the home and play screens, so that playtesters know whether their
+// games are uploaded or whether they need to send the recordings by hand.
+
+type ServerHealth int64
+
+const (
+	// No ping has come back yet.
+	HealthUnknown ServerHealth = iota
+	HealthOnline
+	// The server answers slowly, or it just failed to answer once.
+	HealthDegraded
+	HealthOffline
+)
+
+var serverHealthNames = map[ServerHealth]string{
+	HealthUnknown:  "unknown",
+	HealthOnline:   "online",
+	HealthDegraded: "degraded",
+	HealthOffline:  "offline",
+}
+
+func (h ServerHealth) String() string {
+	if name, ok := serverHealthNames[h]; ok {
+		return name
+	}
+	return fmt.Sprintf("ServerHealth(%d)", int64(h))
+}
+
+const healthCheckInterval = 30 * time.Second
+
+// A ping slower than healthSlowLatency means the server is degraded.
+const healthSlowLatency = 3 * time.Second
+
+// The server is offline after healthOfflineFailures pings in a row fail.
+const healthOfflineFailures = 2
+
+// HealthMonitor turns the results of the pings into a ServerHealth.
+type HealthMonitor struct {
+	failures int64
+}
+
+// Update takes the result of the latest ping, which took latency, and returns
+// the health of the server.
+func (m *HealthMonitor) Update(latency time.Duration, err error) ServerHealth {
+	if err != nil {
+		m.failures++
+		if m.failures >= healthOfflineFailures {
+			return HealthOffline
+		}
+		return HealthDegraded
+	}
+	m.failures = 0
+	if latency > healthSlowLatency {
+		return HealthDegraded
+	}
+	return HealthOnline
+}
+
+// CheckServerHealth pings the server every healthCheckInterval and sends the
+// health of the server to ch.
+func (g *Gui) CheckServerHealth(ch chan<- ServerHealth) {
+	var m HealthMonitor
+	for {
+		start := time.Now()
+		err := g.telemetry.Ping()
+		ch <- m.Update(time.Since(start), err)
+		time.Sleep(healthCheckInterval)
+	}
+}
+
+// ShownHealth is the health on the icon. Games aren't uploaded if uploads are
+// off, which is the same as the server being offline, for the player.
+func (g *Gui) ShownHealth() ServerHealth {
+	if !g.UploadingPlaythroughs() {
+		return HealthOffline
+	}
+	return g.serverHealth
+}
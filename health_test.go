@@ -0,0 +1,37 @@
+package main
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestHealthMonitor(t *testing.T) {
+	var m HealthMonitor
+	offline := errors.New("offline")
+	assert.Equal(t, HealthOnline, m.Update(100*time.Millisecond, nil))
+	assert.Equal(t, HealthDegraded, m.Update(healthSlowLatency+1, nil))
+
+	// One failure may be a hiccup, two in a row mean offline.
+	assert.Equal(t, HealthDegraded, m.Update(0, offline))
+	assert.Equal(t, HealthOffline, m.Update(0, offline))
+	assert.Equal(t, HealthOffline, m.Update(0, offline))
+
+	// The first answer brings it back.
+	assert.Equal(t, HealthOnline, m.Update(100*time.Millisecond, nil))
+	assert.Equal(t, HealthDegraded, m.Update(0, offline))
+}
+
+func TestGui_ShownHealth(t *testing.T) {
+	var g Gui
+	g.serverHealth = HealthOnline
+
+	// Games that aren't uploaded need to be sent by hand, whatever the
+	// server says.
+	assert.Equal(t, HealthOffline, g.ShownHealth())
+	g.UploadPlaybackToHttp = true
+	assert.Equal(t, HealthOnline, g.ShownHealth())
+	g.remoteConfig.DisableUploads = true
+	assert.Equal(t, HealthOffline, g.ShownHealth())
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+)
+
+// Post-game hints
+// ---------------
+//
+// While a game is played, the World is watched frame by frame for situations
+// in which the player could have done better. When the game is over, the
+// result is a short list of tips which the game over screen shows.
+//
+// The analysis only looks at the World, the same way the player did. It
+// doesn't try to find the best move, it just points out simple things that are
+// easy to understand and to act on:
+// - a pair of bricks with the same value that stayed on the board for a long
+// time without being merged
+// - new rows that came up early because there were no merges left on the board
+
+// IgnoredMergeMinFrames is the minimum number of frames a possible merge has
+// to be ignored for, before it's worth mentioning.
+const IgnoredMergeMinFrames = 20 * 60
+
+// MaxHints is the maximum number of hints shown after a game. More than this
+// and the player will read none of them.
+const MaxHints = 2
+
+type mergeCandidate struct {
+	Id1 int64
+	Id2 int64
+}
+
+type ignoredMerge struct {
+	// Frames are counted without the pauses, the player can't be blamed for
+	// ignoring a merge while the game was paused.
+	FirstFrameIdx int64
+	Val           int64
+}
+
+// HintTracker gathers what the hints are about, one frame at a time, while
+// the game is played. This way the hints are ready as soon as the game is
+// over, instead of replaying the whole game then. The zero value is ready to
+// use.
+type HintTracker struct {
+	// For each pair of bricks that can be merged, the first frame in which it
+	// could be merged.
+	ignored map[mergeCandidate]ignoredMerge
+	// Buffers, so that watching a frame doesn't allocate.
+	current map[mergeCandidate]bool
+	byVal   [][]*Brick
+
+	longest       ignoredMerge
+	longestFrames int64
+	nEarlyRows    int
+	playedFrames  int64
+}
+
+// Step watches w right before it steps through input.
+func (h *HintTracker) Step(w *World, input PlayerInput) {
+	if input.PausedFrames > 0 {
+		return
+	}
+	h.findPairs(w)
+	if w.State == Regular && !w.TimerDisabled &&
+		w.NoMoreMergesArePossible() {
+		// The World will start a coming up event in this frame.
+		h.nEarlyRows++
+	}
+	h.playedFrames++
+}
+
+// Hints returns at most MaxHints hints, the most useful first, for the game
+// that ended with w.
+func (h *HintTracker) Hints(w *World) (hints []string) {
+	h.findPairs(w)
+	// Pairs that could still be merged at the end were ignored until the end.
+	for _, m := range h.ignored {
+		h.update(m)
+	}
+
+	if h.longestFrames >= IgnoredMergeMinFrames {
+		hints = append(hints, fmt.Sprintf(
+			"You ignored a possible %d+%d merge for %d seconds.",
+			h.longest.Val, h.longest.Val, h.longestFrames/60))
+	}
+	if h.nEarlyRows > 0 {
+		hints = append(hints, fmt.Sprintf(
+			"New rows came up early %d times because no merges were left.",
+			h.nEarlyRows))
+	}
+	if len(hints) > MaxHints {
+		hints = hints[:MaxHints]
+	}
+	return
+}
+
+// findPairs updates h.ignored with the pairs that can be merged right now.
+// Only bricks with the same value can be merged, so only those are paired.
+func (h *HintTracker) findPairs(w *World) {
+	if h.ignored == nil {
+		h.ignored = map[mergeCandidate]ignoredMerge{}
+		h.current = map[mergeCandidate]bool{}
+	}
+	for int64(len(h.byVal)) <= w.MaxBrickValue {
+		h.byVal = append(h.byVal, nil)
+	}
+	for i := range h.byVal {
+		h.byVal[i] = h.byVal[i][:0]
+	}
+	for i := range w.Bricks {
+		b := &w.Bricks[i]
+		if w.CanMerge(b) {
+			h.byVal[b.Val] = append(h.byVal[b.Val], b)
+		}
+	}
+
+	clear(h.current)
+	for _, bricks := range h.byVal {
+		for i := range bricks {
+			for j := i + 1; j < len(bricks); j++ {
+				b1 := bricks[i]
+				b2 := bricks[j]
+				if b1.Group != 0 && b1.Group == b2.Group {
+					continue
+				}
+				c := mergeCandidate{min(b1.Id, b2.Id), max(b1.Id, b2.Id)}
+				h.current[c] = true
+				if _, ok := h.ignored[c]; !ok {
+					h.ignored[c] = ignoredMerge{h.playedFrames, b1.Val}
+				}
+			}
+		}
+	}
+
+	// The pairs that can't be merged anymore were either merged or ruined
+	// by another merge. Either way, this is how long they were ignored.
+	for c, m := range h.ignored {
+		if !h.current[c] {
+			h.update(m)
+			delete(h.ignored, c)
+		}
+	}
+}
+
+// update remembers m if it was ignored for longer than the longest one so
+// far. Map iteration order is random so ties are broken by value, to always
+// get the same hint for the same playthrough.
+func (h *HintTracker) update(m ignoredMerge) {
+	frames := h.playedFrames - m.FirstFrameIdx
+	if frames > h.longestFrames ||
+		(frames == h.longestFrames && m.Val > h.longest.Val) {
+		h.longestFrames = frames
+		h.longest = m
+	}
+}
+
+// GenerateHints replays p and returns the hints that a HintTracker would have
+// given while p was played.
+func GenerateHints(p Playthrough) []string {
+	w := NewWorldFromPlaythrough(p)
+	var h HintTracker
+	for _, input := range p.History {
+		if w.State == Lost || w.State == Won {
+			break
+		}
+		h.Step(&w, input)
+		w.Step(input)
+	}
+	return h.Hints(&w)
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestGenerateHints_IgnoredMerge(t *testing.T) {
+	var p Playthrough
+	p.SimulationVersion = SimulationVersion
+	p.TimerDisabled = true
+	p.BricksParams = []BrickParams{
+		{Pos: CanonicalPosToPixelPos(Pt{0, 0}), Val: 8},
+		{Pos: CanonicalPosToPixelPos(Pt{5, 0}), Val: 8},
+	}
+	p.History = make([]PlayerInput, 40*60)
+	assert.Equal(t, []string{"You ignored a possible 8+8 merge for 40 seconds."},
+		GenerateHints(p))
+
+	// A short game doesn't get any hints.
+	p.History = p.History[:60]
+	assert.Empty(t, GenerateHints(p))
+}
+
+func TestHintTracker_Pauses(t *testing.T) {
+	var p Playthrough
+	p.SimulationVersion = SimulationVersion
+	p.TimerDisabled = true
+	p.BricksParams = []BrickParams{
+		{Pos: CanonicalPosToPixelPos(Pt{0, 0}), Val: 8},
+		{Pos: CanonicalPosToPixelPos(Pt{5, 0}), Val: 8},
+		{Pos: CanonicalPosToPixelPos(Pt{3, 0}), Stone: true},
+	}
+	w := NewWorldFromPlaythrough(p)
+	var h HintTracker
+	step := func(input PlayerInput) {
+		h.Step(&w, input)
+		w.Step(input)
+	}
+	for range 10 * 60 {
+		step(PlayerInput{})
+	}
+	// A long pause doesn't count as ignoring the merge.
+	step(PlayerInput{PausedFrames: 60 * 60})
+	for range 15 * 60 {
+		step(PlayerInput{})
+	}
+	assert.Equal(t, []string{"You ignored a possible 8+8 merge for 25 seconds."},
+		h.Hints(&w))
+}
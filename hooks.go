@@ -0,0 +1,70 @@
+package main
+
+// WorldHooks are functions the World calls at the end of a Step, for the
+// things that happened during that Step. They let the Gui, the VisWorld,
+// sounds and analytics react to what happens in the World, instead of
+// checking the state of the World after every Step.
+// The hooks are not part of the state of the World. They are not in
+// StateBytes and they must not change the World, otherwise the same inputs
+// would no longer give the same World depending on who is listening.
+type WorldHooks struct {
+	OnMerge       []func(e WorldEvent)
+	OnStateChange []func(from WorldState, to WorldState)
+	OnGameOver    []func(final WorldState)
+	OnPhaseDone   []func(p StepPhase)
+}
+
+// OnMerge registers f to be called for each merge, with the MergeEvent.
+func (w *World) OnMerge(f func(e WorldEvent)) {
+	w.Hooks.OnMerge = append(w.Hooks.OnMerge, f)
+}
+
+// OnStateChange registers f to be called when a Step ends in a different
+// WorldState than it started in.
+func (w *World) OnStateChange(f func(from WorldState, to WorldState)) {
+	w.Hooks.OnStateChange = append(w.Hooks.OnStateChange, f)
+}
+
+// OnGameOver registers f to be called once, when the game ends. final is Lost
+// or Won.
+func (w *World) OnGameOver(f func(final WorldState)) {
+	w.Hooks.OnGameOver = append(w.Hooks.OnGameOver, f)
+}
+
+// OnPhaseDone registers f to be called right after each phase of a Regular
+// step. Unlike the other hooks, it is called in the middle of the Step, so it
+// is only meant for measuring the phases, e.g. by a Profiler.
+func (w *World) OnPhaseDone(f func(p StepPhase)) {
+	w.Hooks.OnPhaseDone = append(w.Hooks.OnPhaseDone, f)
+}
+
+// PhaseDone calls the OnPhaseDone hooks for phase p.
+func (w *World) PhaseDone(p StepPhase) {
+	for _, f := range w.Hooks.OnPhaseDone {
+		f(p)
+	}
+}
+
+// RunHooks calls the hooks for the last Step, which started in state from.
+func (w *World) RunHooks(from WorldState) {
+	for _, e := range w.Events {
+		if e.Type != MergeEvent {
+			continue
+		}
+		for _, f := range w.Hooks.OnMerge {
+			f(e)
+		}
+	}
+
+	if w.State == from {
+		return
+	}
+	for _, f := range w.Hooks.OnStateChange {
+		f(from, w.State)
+	}
+	if w.State == Lost || w.State == Won {
+		for _, f := range w.Hooks.OnGameOver {
+			f(w.State)
+		}
+	}
+}
@@ -4,22 +4,120 @@ package main
 
 import (
 	"bytes"
+	"compress/gzip"
+	"errors"
 	"fmt"
 	"github.com/google/uuid"
 	"io"
 	"mime/multipart"
 	"net/http"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
+const httpEnabled = true
+
+// Shared by every request, so that the connections to the server are reused.
+// See serverTlsConfig.
+var httpClient = newHttpClient(httpTimeout)
+
+// No request waits for longer than this, so that a server that stopped
+// answering doesn't hold up the game or a worker forever. Uploads are sent in
+// chunks, which fit in this time even on a slow connection.
+const httpTimeout = 20 * time.Second
+
+// Request bodies smaller than this aren't worth compressing.
+const minGzipSize = 1024
+
+// HttpSink is the TelemetrySink of a server with the PHP endpoints of
+// playful-patterns.com, e.g. a self-hosted copy of it.
+type HttpSink struct {
+	// Where the endpoints are, without the trailing slash.
+	BaseUrl string
+	// The Name of the Endpoints, sent with every request, see Endpoints.
+	Environment string
+	// Whether the server decompresses request bodies, see acceptsGzip.
+	gzip *gzipSupport
+}
+
+type gzipSupport struct {
+	mutex    sync.Mutex
+	probed   bool
+	accepted bool
+}
+
+func NewHttpSink(baseUrl string, environment string) TelemetrySink {
+	return HttpSink{BaseUrl: strings.TrimSuffix(baseUrl, "/"),
+		Environment: environment, gzip: &gzipSupport{}}
+}
+
+// httpStatusError is the error of a request that the server answered with a
+// status other than 200.
+type httpStatusError int
+
+func (e httpStatusError) Error() string {
+	return fmt.Sprintf("http request failed: %d", int(e))
+}
+
+// acceptsGzip is true if the server decompresses the bodies of requests. The
+// sink asks with a compressed ping, which only gets the probe back if the
+// server could read it, see ping-clone1.php. It asks until it gets an
+// answer, any answer: a server that can't read the ping may also answer with
+// an error.
+func (s HttpSink) acceptsGzip() bool {
+	s.gzip.mutex.Lock()
+	defer s.gzip.mutex.Unlock()
+	if !s.gzip.probed {
+		response, err := makeHttpRequest(s.BaseUrl+"/ping-clone1.php",
+			map[string]string{"probe": "gzip"}, map[string][]byte{}, true)
+		var status httpStatusError
+		if err == nil || errors.As(err, &status) {
+			s.gzip.probed = true
+			s.gzip.accepted = strings.TrimSpace(response) == "gzip"
+		}
+	}
+	return s.gzip.accepted
+}
+
+// request is makeHttpRequest with the environment of the sink and with the
+// body compressed, if it is big enough and the server accepts it. If the
+// server turns out not to accept it after all, the request is sent again
+// without compression.
+func (s HttpSink) request(url string, fields map[string]string,
+	files map[string][]byte) (string, error) {
+	if s.Environment != "" {
+		fields["environment"] = s.Environment
+	}
+	size := 0
+	for _, v := range fields {
+		size += len(v)
+	}
+	for _, v := range files {
+		size += len(v)
+	}
+	compress := size >= minGzipSize && s.gzip != nil && s.acceptsGzip()
+	response, err := makeHttpRequest(url, fields, files, compress)
+	if compress && err == httpStatusError(http.StatusUnsupportedMediaType) {
+		s.gzip.mutex.Lock()
+		s.gzip.accepted = false
+		s.gzip.mutex.Unlock()
+		return makeHttpRequest(url, fields, files, false)
+	}
+	return response, err
+}
+
 // makeHttpRequest makes a POST HTTP request to an endpoint and returns the
 // body of the response as a string. It returns an error if the call to the
 // server fails. Other errors are considered programmer errors and cause a
-// panic.
+// panic. The request is signed, see SignRequest. If compress is true, the
+// body is sent with gzip.
 func makeHttpRequest(
 	url string,
 	fields map[string]string,
 	files map[string][]byte,
+	compress bool,
 ) (string, error) {
 	// Create a buffer to write our multipart form data.
 	var requestBody bytes.Buffer
@@ -28,6 +126,12 @@ func makeHttpRequest(
 		err := writer.WriteField(k, v)
 		Check(err)
 	}
+	auth := SignRequest(releaseSecret, ReleaseVersion, time.Now(), fields,
+		files)
+	for k, v := range auth {
+		err := writer.WriteField(k, v)
+		Check(err)
+	}
 	for k, v := range files {
 		part, err := writer.CreateFormFile(k, k)
 		Check(err)
@@ -36,33 +140,46 @@ func makeHttpRequest(
 	}
 	err := writer.Close()
 	Check(err)
+	body := requestBody.Bytes()
+	if compress {
+		var compressed bytes.Buffer
+		w, err := gzip.NewWriterLevel(&compressed, gzip.BestSpeed)
+		Check(err)
+		_, err = w.Write(body)
+		Check(err)
+		Check(w.Close())
+		body = compressed.Bytes()
+	}
 
 	// Create a POST request with the multipart form data.
-	request, err := http.NewRequest("POST", url, &requestBody)
+	request, err := http.NewRequest("POST", url, bytes.NewReader(body))
 	Check(err)
 	request.Header.Set("content-type", writer.FormDataContentType())
+	if compress {
+		request.Header.Set("content-encoding", "gzip")
+	}
 
 	// Perform the request.
-	client := &http.Client{}
-	response, err := client.Do(request)
+	response, err := httpClient.Do(request)
 	if err != nil {
 		return "", err
 	}
+	defer func() { _ = response.Body.Close() }()
 	if response.StatusCode != 200 {
-		return "", fmt.Errorf("http request failed: %d", response.StatusCode)
+		return "", httpStatusError(response.StatusCode)
 	}
 	data, err := io.ReadAll(response.Body)
 	Check(err)
 	return string(data), nil
 }
 
-func InitializeIdInDbHttp(user string,
+func (s HttpSink) InitializePlaythrough(user string,
 	releaseVersion int64,
 	simulationVersion int64,
 	inputVersion int64,
 	id uuid.UUID) error {
-	url := "https://playful-patterns.com/submit-playthrough-clone1.php"
-	_, err := makeHttpRequest(url,
+	url := s.BaseUrl + "/submit-playthrough-clone1.php"
+	_, err := s.request(url,
 		map[string]string{
 			"user":               user,
 			"release_version":    strconv.FormatInt(releaseVersion, 10),
@@ -73,13 +190,13 @@ func InitializeIdInDbHttp(user string,
 	return err
 }
 
-func UploadDataToDbHttp(user string,
+func (s HttpSink) UploadPlaythrough(user string,
 	releaseVersion int64,
 	simulationVersion int64,
 	inputVersion int64,
 	id uuid.UUID, data []byte) error {
-	url := "https://playful-patterns.com/submit-playthrough-clone1.php"
-	_, err := makeHttpRequest(url,
+	url := s.BaseUrl + "/submit-playthrough-clone1.php"
+	_, err := s.request(url,
 		map[string]string{
 			"user":               user,
 			"release_version":    strconv.FormatInt(releaseVersion, 10),
@@ -90,22 +207,130 @@ func UploadDataToDbHttp(user string,
 	return err
 }
 
-func SetUserDataHttp(user string, data string) error {
-	url := "https://playful-patterns.com/set-user-data-clone1.php"
-	_, err := makeHttpRequest(url,
+func (s HttpSink) AppendPlaythrough(user string, id uuid.UUID, offset int64,
+	delta []byte) (int64, error) {
+	url := s.BaseUrl + "/append-playthrough-clone1.php"
+	response, err := s.request(url,
+		map[string]string{
+			"user":   user,
+			"id":     id.String(),
+			"offset": strconv.FormatInt(offset, 10)},
+		map[string][]byte{"delta": delta})
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(response), 10, 64)
+}
+
+func (s HttpSink) UploadPlaythroughChunk(user string, id uuid.UUID,
+	digest string, total int64, offset int64, chunk []byte) (int64, error) {
+	url := s.BaseUrl + "/upload-playthrough-chunk-clone1.php"
+	response, err := s.request(url,
+		map[string]string{
+			"user":   user,
+			"id":     id.String(),
+			"digest": digest,
+			"total":  strconv.FormatInt(total, 10),
+			"offset": strconv.FormatInt(offset, 10)},
+		map[string][]byte{"chunk": chunk})
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(response), 10, 64)
+}
+
+func (s HttpSink) ListPlaythroughs(user string, count int64) (string, error) {
+	url := s.BaseUrl + "/list-playthroughs-clone1.php"
+	return s.request(url,
+		map[string]string{
+			"user":  user,
+			"count": strconv.FormatInt(count, 10)},
+		map[string][]byte{})
+}
+
+func (s HttpSink) GetPlaythrough(user string, id uuid.UUID) ([]byte, error) {
+	url := s.BaseUrl + "/get-playthrough-clone1.php"
+	response, err := s.request(url,
+		map[string]string{"user": user, "id": id.String()},
+		map[string][]byte{})
+	if err != nil {
+		return nil, err
+	}
+	return []byte(response), nil
+}
+
+func (s HttpSink) SetUserData(user string, data string) error {
+	url := s.BaseUrl + "/set-user-data-clone1.php"
+	_, err := s.request(url,
 		map[string]string{"user": user, "data": data},
 		map[string][]byte{})
 	return err
 }
 
-func GetUserDataHttp(user string) (string, error) {
-	url := "https://playful-patterns.com/get-user-data-clone1.php"
-	return makeHttpRequest(url,
+func (s HttpSink) GetUserData(user string) (string, error) {
+	url := s.BaseUrl + "/get-user-data-clone1.php"
+	return s.request(url,
 		map[string]string{"user": user},
 		map[string][]byte{})
 }
 
-func LogHttp(user string,
+func (s HttpSink) GetMotd(releaseVersion int64) (string, error) {
+	url := s.BaseUrl + "/get-motd-clone1.php"
+	return s.request(url,
+		map[string]string{
+			"release_version": strconv.FormatInt(releaseVersion, 10)},
+		map[string][]byte{})
+}
+
+func (s HttpSink) Ping() error {
+	url := s.BaseUrl + "/ping-clone1.php"
+	_, err := s.request(url, map[string]string{}, map[string][]byte{})
+	return err
+}
+
+// SubmitScore sends the best score of user for releaseVersion to the
+// leaderboard, where it is shown with name. The server keeps the best score it
+// got for each user.
+func (s HttpSink) SubmitScore(user string, name string, releaseVersion int64,
+	score int64) error {
+	url := s.BaseUrl + "/submit-score-clone1.php"
+	_, err := s.request(url,
+		map[string]string{
+			"user":            user,
+			"name":            name,
+			"release_version": strconv.FormatInt(releaseVersion, 10),
+			"score":           strconv.FormatInt(score, 10)},
+		map[string][]byte{})
+	return err
+}
+
+func (s HttpSink) GetLeaderboard(releaseVersion int64,
+	count int64) (string, error) {
+	url := s.BaseUrl + "/get-leaderboard-clone1.php"
+	return s.request(url,
+		map[string]string{
+			"release_version": strconv.FormatInt(releaseVersion, 10),
+			"count":           strconv.FormatInt(count, 10)},
+		map[string][]byte{})
+}
+
+func (s HttpSink) GetRemoteConfig(releaseVersion int64) (string, error) {
+	url := s.BaseUrl + "/get-remote-config-clone1.php"
+	return s.request(url,
+		map[string]string{
+			"release_version": strconv.FormatInt(releaseVersion, 10)},
+		map[string][]byte{})
+}
+
+func (s HttpSink) LogEvents(events string) error {
+	url := s.BaseUrl + "/log-events-clone1.php"
+	_, err := s.request(url,
+		map[string]string{"events": events},
+		map[string][]byte{})
+	return err
+}
+
+func (s HttpSink) Log(user string,
 	releaseVersion int64,
 	simulationVersion int64,
 	inputVersion int64,
@@ -113,8 +338,8 @@ func LogHttp(user string,
 	level string,
 	message string,
 	data []byte) error {
-	url := "https://playful-patterns.com/log-clone1.php"
-	_, err := makeHttpRequest(url,
+	url := s.BaseUrl + "/log-clone1.php"
+	_, err := s.request(url,
 		map[string]string{
 			"user":               user,
 			"release_version":    strconv.FormatInt(releaseVersion, 10),
@@ -0,0 +1,91 @@
+//go:build http_enabled
+
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// gzipServer is a server of the PHP endpoints that may or may not decompress
+// the bodies of requests.
+type gzipServer struct {
+	decompress bool
+	// Answers compressed uploads with 415 even though it decompressed the
+	// ping.
+	rejectUploads bool
+	uploads       []string
+	data          []byte
+	environment   string
+}
+
+func (s *gzipServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	encoding := r.Header.Get("content-encoding")
+	if encoding == "gzip" && s.decompress {
+		body, err := gzip.NewReader(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		r.Body = body
+	}
+	if r.URL.Path == "/submit-playthrough-clone1.php" {
+		if encoding == "gzip" && s.rejectUploads {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+		s.uploads = append(s.uploads, encoding)
+	}
+	// Like PHP, a body that can't be read looks like an empty request.
+	_ = r.ParseMultipartForm(1 << 20)
+	if r.URL.Path == "/ping-clone1.php" {
+		if r.FormValue("probe") == "gzip" {
+			_, _ = w.Write([]byte("gzip"))
+		} else {
+			_, _ = w.Write([]byte("ok"))
+		}
+		return
+	}
+	if f, _, err := r.FormFile("playthrough"); err == nil {
+		s.data, _ = io.ReadAll(f)
+		s.environment = r.FormValue("environment")
+	}
+}
+
+func uploadWithGzip(t *testing.T, server *gzipServer, size int) {
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	s := NewHttpSink(ts.URL, "staging")
+	data := bytes.Repeat([]byte("abc"), size/3)
+	assert.Nil(t, s.UploadPlaythrough("user", 1, 2, 3, uuid.New(), data))
+	assert.Equal(t, data, server.data)
+	assert.Equal(t, "staging", server.environment)
+}
+
+func TestHttpSink_Gzip(t *testing.T) {
+	// Big bodies are compressed if the server can read them.
+	server := &gzipServer{decompress: true}
+	uploadWithGzip(t, server, 10*minGzipSize)
+	assert.Equal(t, []string{"gzip"}, server.uploads)
+
+	// But not small ones.
+	server = &gzipServer{decompress: true}
+	uploadWithGzip(t, server, minGzipSize/2)
+	assert.Equal(t, []string{""}, server.uploads)
+
+	// A server that can't read them gets them as they are.
+	server = &gzipServer{}
+	uploadWithGzip(t, server, 10*minGzipSize)
+	assert.Equal(t, []string{""}, server.uploads)
+
+	// And so does one that changes its mind.
+	server = &gzipServer{decompress: true, rejectUploads: true}
+	uploadWithGzip(t, server, 10*minGzipSize)
+	assert.Equal(t, []string{""}, server.uploads)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"github.com/goccy/go-yaml"
+	"github.com/google/uuid"
+)
+
+// A player is identified by an anonymous Id, made up the first time the game
+// runs on a device (or in a browser) and stored there, see StoreString. The
+// uploads and the UserData of the player are all under this Id, so they
+// belong together from one run to the next.
+// The DisplayName is optional and is what other players see, e.g. on the
+// leaderboard. It isn't part of the identity: changing it doesn't lose the
+// UserData. In the browser, it defaults to the name the page gives the game.
+
+const identityStorageKey = "identity.yaml"
+
+type Identity struct {
+	Id          string `yaml:"Id"`
+	DisplayName string `yaml:"DisplayName"`
+}
+
+// LoadIdentity returns the stored Identity, or a new one if none was stored
+// yet.
+func LoadIdentity() Identity {
+	s, found := LoadStoredString(identityStorageKey)
+	i, created := ResolveIdentity(s, found)
+	if created {
+		data, err := yaml.Marshal(i)
+		Check(err)
+		StoreString(identityStorageKey, string(data))
+	}
+	if i.DisplayName == "" {
+		i.DisplayName = defaultDisplayName()
+	}
+	return i
+}
+
+// ResolveIdentity reads a stored Identity. If there is none, or it has no
+// valid Id, e.g. because the file was edited by hand, it returns a new
+// Identity with a random Id and created is true. The DisplayName of the
+// stored Identity is kept either way.
+func ResolveIdentity(stored string, found bool) (i Identity, created bool) {
+	if found {
+		// A stored Identity that can't be read is as good as none.
+		_ = yaml.Unmarshal([]byte(stored), &i)
+		if _, err := uuid.Parse(i.Id); err == nil {
+			return i, false
+		}
+	}
+	i.Id = uuid.New().String()
+	return i, true
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestResolveIdentity(t *testing.T) {
+	// The first run makes up an Id.
+	i, created := ResolveIdentity("", false)
+	assert.True(t, created)
+	_, err := uuid.Parse(i.Id)
+	assert.NoError(t, err)
+
+	// Later runs keep it.
+	stored := "Id: 0b6e1d3c-4f2a-4b7e-9a55-2f4e0c1d8a90\nDisplayName: ana\n"
+	i, created = ResolveIdentity(stored, true)
+	assert.False(t, created)
+	assert.Equal(t, Identity{"0b6e1d3c-4f2a-4b7e-9a55-2f4e0c1d8a90", "ana"}, i)
+
+	// A broken Id is replaced, the DisplayName stays.
+	i, created = ResolveIdentity("Id: vali-dev\nDisplayName: ana\n", true)
+	assert.True(t, created)
+	assert.NotEqual(t, "vali-dev", i.Id)
+	assert.Equal(t, "ana", i.DisplayName)
+
+	// So is a file that can't be read at all.
+	i, created = ResolveIdentity("{{{", true)
+	assert.True(t, created)
+	_, err = uuid.Parse(i.Id)
+	assert.NoError(t, err)
+}
+
+func TestLoadIdentity(t *testing.T) {
+	t.Chdir(t.TempDir())
+	i := LoadIdentity()
+	assert.Equal(t, i, LoadIdentity())
+}
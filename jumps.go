@@ -0,0 +1,77 @@
+package main
+
+// JumpKind is a kind of moment that playback can jump to.
+type JumpKind int64
+
+const (
+	MergeJump JumpKind = iota
+	ComingUpJump
+	SpawnJump
+	StateChangeJump
+	// A frame in which the score goes up at least as much as it does when
+	// the two biggest bricks merge. A merge gives as many points as the value
+	// of the merged bricks, so this is either a new biggest brick, several
+	// merges at once or a push now bonus.
+	ScoreJump
+	NJumpKinds
+)
+
+var jumpKindNames = map[JumpKind]string{
+	MergeJump:       "merge",
+	ComingUpJump:    "coming up",
+	SpawnJump:       "row spawn",
+	StateChangeJump: "state change",
+	ScoreJump:       "score jump",
+}
+
+func (k JumpKind) String() string {
+	return jumpKindNames[k]
+}
+
+// JumpFrames are the frames of a playthrough in which something notable
+// happens, by kind. A frame is listed if its input causes the thing to
+// happen, so jumping to it shows the World right before it happens and one
+// more step shows it happening.
+// They are the same as bookmarks, except they are computed instead of
+// chosen, so they are kept as Bookmarks to go through them the same way.
+type JumpFrames [NJumpKinds]Bookmarks
+
+// FindJumpFrames replays p to find its JumpFrames. If the World crashes,
+// only the frames before the crash are found.
+func FindJumpFrames(p Playthrough) (f JumpFrames) {
+	defer func() {
+		_ = recover()
+	}()
+
+	w := NewWorldFromPlaythrough(p)
+	for i := range int64(len(p.History)) {
+		before := w.State
+		scoreBefore := w.Score
+		maxValBefore := w.CurrentMaxVal()
+		w.Step(p.History[i])
+		add := func(k JumpKind) {
+			frames := f[k].Frames
+			if len(frames) == 0 || frames[len(frames)-1] != i {
+				f[k].Frames = append(frames, i)
+			}
+		}
+		for _, e := range w.Events {
+			if e.Type == MergeEvent {
+				add(MergeJump)
+			}
+			if e.Type == RowSpawnedEvent {
+				add(SpawnJump)
+			}
+		}
+		if w.Score-scoreBefore >= maxValBefore {
+			add(ScoreJump)
+		}
+		if w.State != before {
+			add(StateChangeJump)
+			if w.State == ComingUp {
+				add(ComingUpJump)
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestFindJumpFrames(t *testing.T) {
+	playthrough := DeserializePlaythrough(ReadFile("data/demo.clone1"))
+	f := FindJumpFrames(playthrough)
+	for k := range NJumpKinds {
+		assert.NotEmpty(t, f[k].Frames, k.String())
+	}
+
+	// Each frame's input causes what the frame is listed for.
+	w := NewWorldFromPlaythrough(playthrough)
+	for i := range f[MergeJump].Frames[0] {
+		w.Step(playthrough.History[i])
+	}
+	assert.Equal(t, Regular, w.State)
+	w.Step(playthrough.History[f[MergeJump].Frames[0]])
+	assert.Equal(t, MergeEvent, w.Events[0].Type)
+
+	// Every coming up is a state change.
+	for _, frameIdx := range f[ComingUpJump].Frames {
+		assert.Contains(t, f[StateChangeJump].Frames, frameIdx)
+	}
+}
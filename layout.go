@@ -20,13 +20,20 @@ const PlayMarginUp = int64(426)
 const PlayMarginDown = int64(133)
 const GameWidth = PlayAreaWidth + PlayMarginLeft + PlayMarginRight
 const GameHeight = PlayAreaHeight + PlayMarginUp + PlayMarginDown
-const DebugWidth = 0
+const DebugWidth = 600
 const DebugHeight = 100
 
 // The areas below are all relative to the game area and known at compile time.
 var homeScreenMenuButton = NewRectangleI(38, 38, 137, 137)
+var healthIconArea = NewRectangleI(GameWidth-60, 20, 36, 36)
+var homeScreenMotdCard = NewRectangleI(60, 630, GameWidth-120, 320)
+var homeScreenMotdLineHeight = int64(45)
+var homeScreenLeaderboardButton = NewRectangleI(75, 1580, 500, 120)
+var homeScreenRecordingsButton = NewRectangleI(595, 1580, 500, 120)
+var homeScreenConsentButton = NewRectangleI(335, 1720, 500, 80)
 var playScreenMenuButton = NewRectangleI(467, 1277, 237, 237)
 var playScreenTimerArea = NewRectangleI(270, 264, 690, 20)
+var playScreenPushNowButton = NewRectangleI(1010, 216, 115, 115)
 var playScreenWorldArea = NewRectangleI(
 	PlayMarginLeft,
 	PlayMarginUp,
@@ -38,8 +45,21 @@ var pausedScreenRestartButton = NewRectangleI(303, 990, 137, 137)
 var pausedScreenHomeButton = NewRectangleI(303, 1172, 137, 137)
 var gameOverScreenRestartButton = NewRectangleI(303, 1114, 137, 137)
 var gameOverScreenHomeButton = NewRectangleI(303, 1296, 137, 137)
+var gameOverScreenScoresY = float64(1020)
+var gameOverScreenHintsArea = NewRectangleI(60, 300, GameWidth-120, 45)
 var gameWonScreenRestartButton = NewRectangleI(332, 1236, 137, 137)
 var gameWonScreenHomeButton = NewRectangleI(699, 1236, 137, 137)
+var leaderboardScreenCard = NewRectangleI(60, 300, GameWidth-120, 960)
+var leaderboardScreenLineHeight = int64(60)
+var leaderboardScreenBackButton = NewRectangleI(335, 1400, 500, 120)
+var recordingsScreenCard = leaderboardScreenCard
+var recordingsScreenLineHeight = leaderboardScreenLineHeight
+var recordingsScreenBackButton = leaderboardScreenBackButton
+var playbackBackButton = NewRectangleI(38, 38, 300, 120)
+var consentScreenCard = leaderboardScreenCard
+var consentScreenLineHeight = leaderboardScreenLineHeight
+var consentScreenYesButton = NewRectangleI(75, 1400, 500, 120)
+var consentScreenNoButton = NewRectangleI(595, 1400, 500, 120)
 
 // The areas below are relative to a debug area and are known at compile time.
 var debugPlayButton = NewRectangleI(0, 0, DebugHeight, DebugHeight)
@@ -110,12 +130,19 @@ func (g *Gui) Layout(outsideWidth, outsideHeight int) (screenWidth, screenHeight
 	// So, if aspectRatio(rectangleA) < aspectRatio(rectangleB), I will have
 	// rectangleA.width == rectangleB.width.
 	// I want game to fit inside screen, so screen is A and game is B.
+	g.outsideWidth = int64(outsideWidth)
+	g.outsideHeight = int64(outsideHeight)
 	outsideAspectRatio := float64(outsideWidth) / float64(outsideHeight)
 	screenAspectRatio := outsideAspectRatio
 	gameWidth := GameWidth
 	gameHeight := GameHeight
-	if g.enableDebugAreas {
+	if g.compareMode {
+		gameWidth += GameWidth
+	}
+	if g.ShowingUploadMetrics() {
 		gameWidth += DebugWidth
+	}
+	if g.enableDebugAreas {
 		gameHeight += DebugHeight
 	}
 	gameAspectRatio := float64(gameWidth) / float64(gameHeight)
@@ -134,6 +161,9 @@ func (g *Gui) Layout(outsideWidth, outsideHeight int) (screenWidth, screenHeight
 	g.gameArea.Min.Y = 0
 	g.gameArea.Max.X = g.gameArea.Min.X + GameWidth
 	g.gameArea.Max.Y = g.gameArea.Min.Y + GameHeight
+	g.compareGameArea = g.gameArea
+	g.compareGameArea.Min.X += GameWidth
+	g.compareGameArea.Max.X += GameWidth
 
 	// Define the debug areas relative to the total screen area.
 	g.horizontalDebugArea = NewRectangleI(
@@ -143,13 +173,20 @@ func (g *Gui) Layout(outsideWidth, outsideHeight int) (screenWidth, screenHeight
 		DebugHeight)
 
 	g.verticalDebugArea = NewRectangleI(
-		GameWidth,
+		g.gameArea.Min.X+gameWidth-DebugWidth,
 		g.gameArea.Min.Y,
 		DebugWidth,
 		GameHeight)
 	return
 }
 
+// ShowingUploadMetrics is true if the vertical debug area is shown, see
+// DrawDebugControlsVertical. Unlike the horizontal one, it is also shown
+// while playing in developer mode, e.g. during playtests.
+func (g *Gui) ShowingUploadMetrics() bool {
+	return g.enableDebugAreas || g.devModeEnabled
+}
+
 func (g *Gui) ScreenToGame(pt Pt) Pt {
 	return pt.Minus(g.gameArea.Min)
 }
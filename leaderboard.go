@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The leaderboard shows the best scores of the players of the current
+// ReleaseVersion. Scores from other releases aren't comparable, the rules may
+// have changed. The server keeps the personal best of every player, which the
+// game sends along with the UserData, see UploadUserData.
+
+// How many scores the leaderboard screen shows.
+const leaderboardSize = 10
+
+type LeaderboardEntry struct {
+	// The Id of the player's Identity.
+	User string
+	// The DisplayName of the player, empty if they have none.
+	Name  string
+	Score int64
+}
+
+// DisplayName is what the leaderboard shows for the player of e.
+func (e LeaderboardEntry) DisplayName() string {
+	if e.Name != "" {
+		return e.Name
+	}
+	// Enough of the Id to tell players apart.
+	return "player " + e.User[:min(8, len(e.User))]
+}
+
+// Leaderboard is what the leaderboard screen shows, once the server answers.
+type Leaderboard struct {
+	Entries []LeaderboardEntry
+	// The player's rank, starting from 1, see LeaderboardRank.
+	Rank int64
+	Err  error
+}
+
+// LoadLeaderboard gets the top scores of releaseVersion from sink and ranks
+// bestScore among them. It blocks until the server answers, so the Gui calls
+// it on another goroutine.
+func LoadLeaderboard(sink TelemetrySink, releaseVersion int64, user string,
+	bestScore int64) (l Leaderboard) {
+	var s string
+	l.Err = blockingRetryPolicy.Do(func() (err error) {
+		s, err = sink.GetLeaderboard(releaseVersion, leaderboardSize)
+		return
+	})
+	if l.Err != nil {
+		return
+	}
+	l.Entries, l.Err = ParseLeaderboard(s)
+	l.Rank = LeaderboardRank(l.Entries, user, bestScore)
+	return
+}
+
+// ParseLeaderboard reads what get-leaderboard-clone1.php returns: one entry
+// per line, the user, the name and the score separated by tabs, best score
+// first.
+func ParseLeaderboard(s string) (entries []LeaderboardEntry, err error) {
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		columns := strings.Split(line, "\t")
+		if len(columns) != 3 {
+			return nil, fmt.Errorf("invalid leaderboard line: %q", line)
+		}
+		var e LeaderboardEntry
+		e.User = columns[0]
+		e.Name = columns[1]
+		e.Score, err = strconv.ParseInt(columns[2], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid leaderboard line: %q", line)
+		}
+		entries = append(entries, e)
+	}
+	return
+}
+
+// FormatLeaderboard is the opposite of ParseLeaderboard.
+func FormatLeaderboard(entries []LeaderboardEntry) string {
+	var b strings.Builder
+	for _, e := range entries {
+		b.WriteString(fmt.Sprintf("%s\t%s\t%d\n", e.User, e.Name, e.Score))
+	}
+	return b.String()
+}
+
+// LeaderboardRank returns the rank of the player on the leaderboard, starting
+// from 1. If the player isn't on it, e.g. because the best score wasn't
+// uploaded yet, it is the rank that bestScore would have. It is 0 if the
+// player has no score yet.
+func LeaderboardRank(entries []LeaderboardEntry, user string,
+	bestScore int64) int64 {
+	for i, e := range entries {
+		if e.User == user {
+			return int64(i) + 1
+		}
+	}
+	if bestScore <= 0 {
+		return 0
+	}
+	rank := int64(1)
+	for _, e := range entries {
+		if e.Score > bestScore {
+			rank++
+		}
+	}
+	return rank
+}
+
+// Lines returns the text of the leaderboard screen, one line per entry, and
+// which line is the player's, -1 if none. If the player isn't among the
+// entries, a line with their rank and bestScore is added at the end.
+func (l *Leaderboard) Lines(user string, bestScore int64) (lines []string,
+	highlighted int) {
+	highlighted = -1
+	if l.Err != nil {
+		return []string{"can't get the leaderboard right now"}, highlighted
+	}
+	if len(l.Entries) == 0 {
+		return []string{"no scores yet"}, highlighted
+	}
+	for i, e := range l.Entries {
+		lines = append(lines, fmt.Sprintf("%d. %s   %d", i+1, e.DisplayName(),
+			e.Score))
+		if e.User == user {
+			highlighted = i
+		}
+	}
+	if highlighted < 0 && l.Rank > 0 {
+		lines = append(lines, "", fmt.Sprintf("%d. you   %d", l.Rank,
+			bestScore))
+		highlighted = len(lines) - 1
+	}
+	return
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseLeaderboard(t *testing.T) {
+	entries, err := ParseLeaderboard("u1\tana\t1200\n" +
+		"0b6e1d3c-4f2a-4b7e-9a55-2f4e0c1d8a90\t\t800\n")
+	assert.NoError(t, err)
+	assert.Equal(t, []LeaderboardEntry{{"u1", "ana", 1200},
+		{"0b6e1d3c-4f2a-4b7e-9a55-2f4e0c1d8a90", "", 800}}, entries)
+
+	// Players without a DisplayName are shown by their Id.
+	assert.Equal(t, "ana", entries[0].DisplayName())
+	assert.Equal(t, "player 0b6e1d3c", entries[1].DisplayName())
+
+	entries, err = ParseLeaderboard("")
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+
+	_, err = ParseLeaderboard("u1\tana 1200\n")
+	assert.Error(t, err)
+	_, err = ParseLeaderboard("u1\tana\tlots\n")
+	assert.Error(t, err)
+}
+
+func TestLeaderboardRank(t *testing.T) {
+	entries := []LeaderboardEntry{{"u1", "ana", 1200}, {"u2", "bob", 900},
+		{"u3", "cid", 500}}
+
+	// On the leaderboard.
+	assert.Equal(t, int64(2), LeaderboardRank(entries, "u2", 900))
+
+	// The best score wasn't uploaded yet, or it is too low to be listed.
+	assert.Equal(t, int64(3), LeaderboardRank(entries, "u4", 600))
+	assert.Equal(t, int64(4), LeaderboardRank(entries, "u4", 100))
+
+	// No score yet.
+	assert.Equal(t, int64(0), LeaderboardRank(entries, "u4", 0))
+}
+
+func TestLeaderboard_Lines(t *testing.T) {
+	l := Leaderboard{
+		Entries: []LeaderboardEntry{{"u1", "ana", 1200}, {"u2", "bob", 900}},
+		Rank:    2}
+	lines, highlighted := l.Lines("u2", 900)
+	assert.Equal(t, []string{"1. ana   1200", "2. bob   900"}, lines)
+	assert.Equal(t, 1, highlighted)
+
+	// The player isn't listed, their rank goes at the end.
+	l.Rank = 3
+	lines, highlighted = l.Lines("u4", 100)
+	assert.Equal(t, []string{"1. ana   1200", "2. bob   900", "",
+		"3. you   100"}, lines)
+	assert.Equal(t, 3, highlighted)
+
+	l = Leaderboard{Err: errors.New("offline")}
+	_, highlighted = l.Lines("u4", 100)
+	assert.Equal(t, -1, highlighted)
+}
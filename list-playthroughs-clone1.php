@@ -0,0 +1,56 @@
+<?php
+require_once "./auth-clone1.php";
+$servername = "172.232.206.74";
+$username = "playfulp_temp";
+$password = "comeonthough";
+$dbname = "playfulp_clone1";
+
+// Returns the most recent playthroughs of a user, most recent first, one per
+// line: the id, the start, the release, simulation and input versions and
+// the size of the playthrough, separated by tabs. The game lists them on its
+// recordings screen and gets the one the player picks from
+// get-playthrough-clone1.php.
+
+function LogInfo($message) {
+    // 	file_put_contents("./list-playthroughs-clone1.log", "INFO: " . $message . "\n", FILE_APPEND);
+}
+
+function LogError($message) {
+	file_put_contents("./list-playthroughs-clone1.log", "ERROR: " . $message . "\n", FILE_APPEND);
+    http_response_code(513);
+	die();
+}
+
+LogInfo("Start.");
+if ($_SERVER['REQUEST_METHOD'] == 'POST') {
+    VerifyRequest();
+    $table = Table("playthroughs");
+    LogInfo("Attempt to connect to database.");
+    $conn = new mysqli($servername, $username, $password, $dbname);
+    if ($conn->connect_error) {
+        LogError("Connection failed: " . $conn->connect_error);
+    }
+    LogInfo("Connection succeeded!");
+
+    $user = $conn->real_escape_string($_POST['user']);
+    $count = intval($_POST['count']);
+    LogInfo("We got user: " . $user . ", count: " . $count);
+    $sql = "SELECT id, start_moment, release_version, " .
+        "COALESCE(simulation_version, -1) AS simulation_version, " .
+        "COALESCE(input_version, -1) AS input_version, " .
+        "LENGTH(COALESCE(playthrough, '')) AS size FROM $table " .
+        "WHERE user = '$user' ORDER BY start_moment DESC LIMIT $count";
+    try {
+        $result = $conn->query($sql);
+    } catch(Exception $e) {
+        LogError("Error querying: " . $e->getMessage());
+    }
+
+    while ($row = $result->fetch_assoc()) {
+        echo $row["id"] . "\t" . $row["start_moment"] . "\t" . $row["release_version"] . "\t" .
+            $row["simulation_version"] . "\t" . $row["input_version"] . "\t" . $row["size"] . "\n";
+    }
+    $conn->close();
+}
+LogInfo("End.");
+?>
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"github.com/hajimehoshi/ebiten/v2"
 	"golang.org/x/image/font"
 	"golang.org/x/image/font/gofont/goregular"
 	"golang.org/x/image/font/opentype"
@@ -18,6 +19,7 @@ func (g *Gui) LoadGuiData() {
 	// want to crash as soon as possible. We might be in the browser, in which
 	// case we want to see an error in the developer console instead of a page
 	// that keeps trying to load and reports nothing.
+	g.AddBreadcrumb("assets", "loading the gui data from %T", g.FSys)
 	previousVal := CheckCrashes
 	if _, ok := g.FSys.(fs.FS); ok {
 		CheckCrashes = false
@@ -30,11 +32,12 @@ func (g *Gui) LoadGuiData() {
 			LoadYAML(g.FSys, "data/config.yaml", &g.Config)
 		}
 		g.imgBlank = LoadImage(g.FSys, "data/gui/blank.png")
-		for i := int64(1); i <= 30; i++ {
-			filename := fmt.Sprintf("data/gui/%02d.png", i)
-			g.imgBrick[i] = LoadImage(g.FSys, filename)
-		}
+		// The other brick images are loaded along with the Worlds that need
+		// them, see SetWorld.
+		g.imgBrick = g.imgBrick[:0]
+		g.LoadBrickImages(g.world.MaxBrickValue)
 		g.imgBrickFrame = LoadImage(g.FSys, "data/gui/brick-frame.png")
+		g.imgStone = LoadImage(g.FSys, "data/gui/stone.png")
 		for i := int64(0); i <= 9; i++ {
 			filename := fmt.Sprintf("data/gui/digit%d.png", i)
 			g.imgDigit[i] = LoadImage(g.FSys, filename)
@@ -45,6 +48,7 @@ func (g *Gui) LoadGuiData() {
 		g.imgPlaybackPlay = LoadImage(g.FSys, "data/gui/playback-play.png")
 		g.imgPlayBar = LoadImage(g.FSys, "data/gui/playbar.png")
 		g.imgTimer = LoadImage(g.FSys, "data/gui/timer.png")
+		g.imgPushNow = LoadImage(g.FSys, "data/gui/push-now.png")
 		g.imgHomeScreen = LoadImage(g.FSys, "data/gui/screen-home.png")
 		g.imgScreenPlay = LoadImage(g.FSys, "data/gui/screen-play.png")
 		g.imgPausedScreen = LoadImage(g.FSys, "data/gui/screen-paused.png")
@@ -61,8 +65,16 @@ func (g *Gui) LoadGuiData() {
 	}
 	CheckCrashes = previousVal
 
+	// Without a demo, attract mode is simply disabled, see UpdateHomeScreen.
+	demo, err := LoadDemoPlaythrough(g.FSys)
+	g.demoPlaythrough = demo
+	if err != nil {
+		g.AddBreadcrumb("assets", "attract mode disabled: %v", err)
+	}
+
 	g.visWorld = NewVisWorld(g.Animations)
 	g.UpdateWindowSize()
+	g.AddBreadcrumb("assets", "loaded the gui data")
 
 	// Load the Arial font.
 	fontData, err := opentype.Parse(goregular.TTF)
@@ -74,4 +86,64 @@ func (g *Gui) LoadGuiData() {
 		Hinting: font.HintingVertical,
 	})
 	Check(err)
+
+	// Small enough to fit a few lines of text inside a brick.
+	g.debugFont, err = opentype.NewFace(fontData, &opentype.FaceOptions{
+		Size:    18,
+		DPI:     72,
+		Hinting: font.HintingVertical,
+	})
+	Check(err)
+}
+
+// LoadBrickImages makes sure there is an image for every brick value up to
+// maxVal. Images that are already loaded are kept. There are images up to
+// MaxSupportedBrickValue and NewWorld refuses levels that go higher.
+func (g *Gui) LoadBrickImages(maxVal int64) {
+	if len(g.imgBrick) == 0 {
+		// Stones have no value and no image of their own.
+		g.imgBrick = append(g.imgBrick, nil)
+	}
+	if int64(len(g.imgBrick)) <= maxVal {
+		g.AddBreadcrumb("assets", "loading brick images up to %d", maxVal)
+	}
+	for i := int64(len(g.imgBrick)); i <= maxVal; i++ {
+		filename := fmt.Sprintf("data/gui/%02d.png", i)
+		g.imgBrick = append(g.imgBrick, LoadImage(g.FSys, filename))
+	}
+}
+
+// SetWorld makes w the World that is played or watched, after loading the
+// brick images up to its MaxBrickValue.
+func (g *Gui) SetWorld(w World) {
+	g.LoadBrickImages(w.MaxBrickValue)
+	g.world = w
+}
+
+func (g *Gui) BrickImage(val int64) *ebiten.Image {
+	g.LoadBrickImages(val)
+	return g.imgBrick[val]
+}
+
+// LoadDemoPlaythrough reads the playthrough that attract mode replays. It
+// returns an error instead of crashing if the demo can't be read or can't be
+// replayed by this simulation, which happens whenever the SimulationVersion
+// or InputVersion changes and the demo isn't recorded again. Losing attract
+// mode until then is much better than crashing on the home screen.
+func LoadDemoPlaythrough(fsys FS) (p Playthrough, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			p = Playthrough{}
+			err = fmt.Errorf("can't read the demo: %v", r)
+		}
+	}()
+	data, err := fsys.ReadFile("data/demo.clone1")
+	Check(err)
+	p = DeserializePlaythrough(data)
+	if p.SimulationVersion != SimulationVersion {
+		return Playthrough{}, fmt.Errorf("the demo was recorded with "+
+			"SimulationVersion %d, this is %d", p.SimulationVersion,
+			SimulationVersion)
+	}
+	return
 }
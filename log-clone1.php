@@ -1,4 +1,5 @@
 <?php
+require_once "./auth-clone1.php";
 $servername = "172.232.206.74";
 $username = "playfulp_temp";
 $password = "comeonthough";
@@ -16,6 +17,8 @@ function LogError($message) {
 
 LogInfo("Start.");
 if ($_SERVER['REQUEST_METHOD'] == 'POST') {
+    VerifyRequest();
+    $table = Table("logs");
     LogInfo("Attempt to connect to database.");
 
     $conn = new mysqli($servername, $username, $password, $dbname);
@@ -36,7 +39,7 @@ if ($_SERVER['REQUEST_METHOD'] == 'POST') {
         $fileTmpPath = $file['tmp_name'];
         $fileContent = mysqli_real_escape_string($conn, file_get_contents($fileTmpPath));
 
-        $sql = "INSERT INTO logs(moment, user, release_version, simulation_version, input_version, id, level, message, playthrough) " .
+        $sql = "INSERT INTO $table(moment, user, release_version, simulation_version, input_version, id, level, message, playthrough) " .
                             "VALUES (now(), '$user', '$release_version', '$simulation_version', '$input_version', '$id', '$level', '$message', '$fileContent')";
         try {
             $conn->query($sql);
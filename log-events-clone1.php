@@ -0,0 +1,64 @@
+<?php
+require_once "./auth-clone1.php";
+$servername = "172.232.206.74";
+$username = "playfulp_temp";
+$password = "comeonthough";
+$dbname = "playfulp_clone1";
+
+// Stores a batch of analytics events, see AnalyticsEvent. The batch is JSON:
+// {"user": ..., "release_version": ..., "events": [{"name": ..., ...}, ...]}
+
+function LogInfo($message) {
+    // 	file_put_contents("./log-events-clone1.log", "INFO: " . $message . "\n", FILE_APPEND);
+}
+
+function LogError($message) {
+	file_put_contents("./log-events-clone1.log", "ERROR: " . $message . "\n", FILE_APPEND);
+    http_response_code(513);
+	die();
+}
+
+LogInfo("Start.");
+if ($_SERVER['REQUEST_METHOD'] == 'POST') {
+    VerifyRequest();
+    $table = Table("events");
+    $batch = json_decode($_POST['events'], true);
+    if ($batch === null || !isset($batch['events'])) {
+        LogError("Invalid batch: " . $_POST['events']);
+    }
+
+    LogInfo("Attempt to connect to database.");
+    $conn = new mysqli($servername, $username, $password, $dbname);
+    if ($conn->connect_error) {
+        LogError("Connection failed: " . $conn->connect_error);
+    }
+    LogInfo("Connection succeeded!");
+
+    $user = $conn->real_escape_string($batch['user']);
+    $release_version = intval($batch['release_version']);
+    foreach ($batch['events'] as $event) {
+        $name = $conn->real_escape_string($event['name']);
+        $moment = intval($event['moment']);
+        $id = $conn->real_escape_string($event['playthrough_id']);
+        $frame_idx = intval($event['frame_idx']);
+        $score = intval($event['score']);
+        $outcome = $conn->real_escape_string($event['outcome'] ?? '');
+        $session_id = $conn->real_escape_string($event['session_id'] ?? '');
+        $duration_ms = intval($event['duration_ms'] ?? 0);
+        $games = intval($event['games'] ?? 0);
+        $device = $conn->real_escape_string($event['device'] ?? '');
+        $sql = "INSERT INTO $table(moment, user, release_version, name, id, frame_idx, score, outcome, " .
+            "session_id, duration_ms, games, device) " .
+            "VALUES (FROM_UNIXTIME($moment / 1000), '$user', $release_version, '$name', '$id', $frame_idx, $score, '$outcome', " .
+            "'$session_id', $duration_ms, $games, '$device')";
+        try {
+            $conn->query($sql);
+        } catch(Exception $e) {
+            LogError("Error inserting event: " . $e->getMessage());
+        }
+    }
+    LogInfo("Inserted " . count($batch['events']) . " events.");
+    $conn->close();
+}
+LogInfo("End.");
+?>
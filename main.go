@@ -8,6 +8,9 @@ import (
 	"golang.org/x/image/font"
 	_ "image/png"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -57,8 +60,31 @@ const (
 	GameWonScreen
 	Playback
 	DebugCrash
+	LeaderboardScreen
+	RecordingsScreen
+	ConsentScreen
 )
 
+var gameStateNames = map[GameState]string{
+	HomeScreen:        "HomeScreen",
+	PlayScreen:        "PlayScreen",
+	PausedScreen:      "PausedScreen",
+	GameOverScreen:    "GameOverScreen",
+	GameWonScreen:     "GameWonScreen",
+	Playback:          "Playback",
+	DebugCrash:        "DebugCrash",
+	LeaderboardScreen: "LeaderboardScreen",
+	RecordingsScreen:  "RecordingsScreen",
+	ConsentScreen:     "ConsentScreen",
+}
+
+func (s GameState) String() string {
+	if name, ok := gameStateNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("GameState(%d)", int64(s))
+}
+
 type Gui struct {
 	Config
 	UserData
@@ -67,8 +93,9 @@ type Gui struct {
 	world               World
 	FSys                FS
 	imgBlank            *ebiten.Image
-	imgBrick            [31]*ebiten.Image
+	imgBrick            []*ebiten.Image
 	imgBrickFrame       *ebiten.Image
+	imgStone            *ebiten.Image
 	imgDigit            [10]*ebiten.Image
 	imgFalling          *ebiten.Image
 	imgCursor           *ebiten.Image
@@ -78,6 +105,7 @@ type Gui struct {
 	imgPlayBar          *ebiten.Image
 	imgFrame            *ebiten.Image
 	imgTimer            *ebiten.Image
+	imgPushNow          *ebiten.Image
 	imgTopbar           *ebiten.Image
 	imgHomeScreen       *ebiten.Image
 	imgScreenPlay       *ebiten.Image
@@ -89,6 +117,7 @@ type Gui struct {
 	folderWatcher1      FolderWatcher
 	folderWatcher2      FolderWatcher
 	defaultFont         font.Face
+	debugFont           font.Face
 	playthrough         Playthrough
 	frameIdx            int64
 	state               GameState
@@ -97,6 +126,10 @@ type Gui struct {
 	debugMarginHeight   int64
 	playbackPaused      bool
 	pointer             PointerState
+	touchPointers       [MaxPointers]PointerState
+	touches             [MaxPointers]ebiten.TouchID
+	touchActive         [MaxPointers]bool
+	motd                Motd
 	pressedKeys         []ebiten.Key
 	justPressedKeys     []ebiten.Key // keys pressed in this frame
 	FrameSkipAltArrow   int64
@@ -105,11 +138,16 @@ type Gui struct {
 	enableDebugAreas    bool
 	accumulatedInput    PlayerInput // only relevant for SlowdownFactor > 1, see
 	// the implementation for a more detailed explanation
+	pausedFrameIdx        int64 // counts the frames of the PausedScreen
 	gameArea              Rectangle
 	horizontalDebugArea   Rectangle
 	verticalDebugArea     Rectangle
 	username              string
+	displayName           string
 	uploadUserDataChannel chan UserData
+	// The UserData on the server, merged with what was uploaded, see
+	// UploadUserData.
+	syncedUserDataChannel chan UserData
 	visWorld              VisWorld
 	devModeEnabled        bool
 	uploadDataChannel     chan uploadData
@@ -117,6 +155,140 @@ type Gui struct {
 	panicMsg              string
 	uploadLogChannel      chan logData
 	lastFrameTime         time.Time
+	clock                 Clock
+	// The personal best at the start of the current playthrough, so that the
+	// game over screen can compare the final score against it.
+	previousBestScore int64
+	// Attract mode: a demo playthrough is replayed behind the home screen
+	// after the player has been idle on the home screen for a while.
+	demoPlaythrough Playthrough
+	attractMode     bool
+	attractFrameIdx int64
+	homeIdleFrames  int64
+	imgAttract      *ebiten.Image
+	// Tips for the player, computed when the game is over.
+	hints []string
+	// Watches the game being played, for the hints.
+	hintTracker HintTracker
+	// Copies of the World taken every playbackSnapshotInterval frames while
+	// going through g.playthrough, so that going back in time only replays
+	// the frames since the closest snapshot.
+	playbackSnapshots []World
+	// The FrameHash of every frame of g.playthrough as it was played live.
+	// Only kept if a recording is saved on errors, so that the recording can
+	// be checked against what actually happened.
+	hashTrace HashTrace
+	// The bookmarks of the playthrough being played back.
+	bookmarks Bookmarks
+	// The conditions that pause playback and the one that paused it last.
+	breakpoints   []Breakpoint
+	breakpointHit string
+	// Not nil while a playthrough is being exported to a GIF.
+	gifExport *GifExport
+	// Draw the internals of the bricks over them, see DrawBrickInternals.
+	showBrickInternals bool
+	// Side-by-side playback: a second playthrough is played in lockstep with
+	// g.playthrough and drawn on the right of it, in compareGameArea.
+	compareMode        bool
+	comparePlaythrough Playthrough
+	compareWorld       World
+	compareSnapshots   []World
+	compareFrameIdx    int64
+	compareGameArea    Rectangle
+	// What made the World crash in DebugCrash mode, found by replaying the
+	// playthrough before Check stops crashing.
+	crash ReplayResult
+	// Stepping the current frame one phase at a time in DebugCrash mode. The
+	// World after the phase is crashPhaseWorld, g.world stays before the
+	// frame. crashPhase is AllPhases when no frame is stepped by phase.
+	crashPhase      StepPhase
+	crashPhaseWorld World
+	// Everything the player did since the game started, recorded along with
+	// g.playthrough. In Playback, the session being played back, if a
+	// session was loaded, and the game of it in g.playthrough.
+	session         Session
+	sessionFrameIdx int64
+	sessionGameIdx  int64
+	// The frames of the playthrough being played back that the jump keys go
+	// to.
+	jumpFrames JumpFrames
+	// The time typed so far to seek to it in playback, see TimecodeToFrame.
+	seekDigits string
+	// Slows the game down further than SlowdownFactor, changed with hotkeys
+	// while playing in developer mode. 0 means no extra slowdown.
+	slowMotion int64
+	// Notes on frames of the playthroughs played back, and the note being
+	// typed, if typingNote.
+	annotations Annotations
+	typingNote  bool
+	noteText    string
+	// Playback loops from loopIn to loopOut, if loopOut is after loopIn.
+	loopIn  int64
+	loopOut int64
+	// Where the virtual pointer is drawn, see EaseTowards.
+	drawnPointerPos Pt
+	// Not nil if ProfileSteps.
+	profiler *Profiler
+	// The size of the window, as last received by Layout.
+	outsideWidth  int64
+	outsideHeight int64
+	// Where g.playthrough is saved while it is played, if RecordToFile.
+	recordingStream *RecordingStream
+	// The leaderboard on the leaderboard screen, nil until the server
+	// answers on leaderboardChannel.
+	leaderboard        *Leaderboard
+	leaderboardChannel chan Leaderboard
+	// The recordings on the recordings screen, nil until the server answers
+	// on recordingsChannel, and the one being downloaded, if any.
+	recordings        *Recordings
+	recordingsChannel chan Recordings
+	downloadChannel   chan DownloadedRecording
+	// What playing back a recording from the recordings screen replaced, to
+	// restore it afterwards. Nil if no such recording is played back.
+	beforeRecording *beforeRecording
+	// Whether the player lets the game upload, see Consent, and where the
+	// consent screen goes once they answer.
+	consent     Consent
+	consentNext GameState
+	// The values that the server may change without a new release, see
+	// LoadRemoteConfig.
+	remoteConfig RemoteConfig
+	// Where the data goes, see SelectEndpoints.
+	endpoints Endpoints
+	// Where everything for the server goes, see NewTelemetrySink. It is nil
+	// until the Config is loaded.
+	telemetry TelemetrySink
+	// Where analytics events go, if UploadAnalyticsToHttp, see
+	// UploadAnalytics.
+	analyticsChannel chan AnalyticsEvent
+	// The session that the analytics events belong to, see StartSession.
+	sessionId    uuid.UUID
+	sessionStart time.Time
+	sessionGames int64
+	// The health of the server, as last received on healthChannel, see
+	// CheckServerHealth.
+	serverHealth  ServerHealth
+	healthChannel chan ServerHealth
+	// Where FetchMotd sends the message of the day, see ReceiveMotd.
+	motdChannel chan MotdResponse
+	// The last things that happened, for crash reports, see CrashReport.
+	breadcrumbs Breadcrumbs
+	// Which crash reports are sent to the server.
+	errorReports ErrorReportLimiter
+	// How the uploads of the background workers went, for the debug area.
+	uploadMetrics UploadMetrics
+	// Runs the background workers, see Supervisor. workerFailures has the
+	// last failure of each worker, for the debug overlay. workerReports has
+	// the stack traces of the failures, waiting for a crash report.
+	supervisor     Supervisor
+	workerFailures map[string]WorkerFailure
+	workerReports  <-chan string
+	// Closed when the workers that upload data are done, after their
+	// channels are closed, see Shutdown.
+	uploadsDone   <-chan struct{}
+	userDataDone  <-chan struct{}
+	logsDone      <-chan struct{}
+	analyticsDone <-chan struct{}
 }
 
 type uploadData struct {
@@ -125,25 +297,75 @@ type uploadData struct {
 	simulationVersion int64
 	inputVersion      int64
 	playthrough       *Playthrough
+	// The playthrough is over and won't get any more inputs.
+	final bool
 }
 
 type Config struct {
-	SlowdownFactor        int64  `yaml:"SlowdownFactor"`
-	StartState            string `yaml:"StartState"`
-	PlaybackFile          string `yaml:"PlaybackFile"`
-	RecordToFile          bool   `yaml:"RecordToFile"`
-	RecordToFileOnError   bool   `yaml:"RecordToFileOnError"`
-	RecordingFile         string `yaml:"RecordingFile"`
-	LoadTest              bool   `yaml:"LoadTest"`
-	TestFile              string `yaml:"TestFile"`
-	AllowOverlappingDrags bool   `yaml:"AllowOverlappingDrags"`
-	DisplayFPS            bool   `yaml:"DisplayFPS"`
-	UploadPlaybackToHttp  bool   `yaml:"UploadPlaybackToHttp"`
-	LogNonErrors          bool   `yaml:"LogNonErrors"`
+	SlowdownFactor        int64      `yaml:"SlowdownFactor"`
+	StartState            string     `yaml:"StartState"`
+	PlaybackFile          string     `yaml:"PlaybackFile"`
+	RecordToFile          bool       `yaml:"RecordToFile"`
+	RecordToFileOnError   bool       `yaml:"RecordToFileOnError"`
+	RecordingFile         string     `yaml:"RecordingFile"`
+	LoadTest              bool       `yaml:"LoadTest"`
+	TestFile              string     `yaml:"TestFile"`
+	AllowOverlappingDrags bool       `yaml:"AllowOverlappingDrags"`
+	DisplayFPS            bool       `yaml:"DisplayFPS"`
+	UploadPlaybackToHttp  bool       `yaml:"UploadPlaybackToHttp"`
+	UploadAnalyticsToHttp bool       `yaml:"UploadAnalyticsToHttp"`
+	LogNonErrors          bool       `yaml:"LogNonErrors"`
+	SeedPolicy            SeedPolicy `yaml:"SeedPolicy"`
+	CuratedSeeds          []int64    `yaml:"CuratedSeeds"`
+	Seed                  int64      `yaml:"Seed"`
+	Endless               bool       `yaml:"Endless"`
+	PushNow               bool       `yaml:"PushNow"`
+	AttractModeDelay      int64      `yaml:"AttractModeDelay"`
+	// Conditions that pause playback, see ParseBreakpoint.
+	Breakpoints []string `yaml:"Breakpoints"`
+	// A second playthrough, played back next to PlaybackFile in lockstep.
+	CompareFile string `yaml:"CompareFile"`
+	// Save the HashTrace of the recording in RecordingFile-trace, along
+	// with the recording.
+	RecordHashTrace bool `yaml:"RecordHashTrace"`
+	// Include the events of each frame in the HashTrace, see
+	// FrameHashWithEvents.
+	HashTraceEvents bool `yaml:"HashTraceEvents"`
+	// Measure every World.Step while playing and save the results in
+	// profile.txt and profile.csv when the game ends, see Profiler.
+	ProfileSteps bool `yaml:"ProfileSteps"`
+	// How recordings and uploaded playthroughs are compressed.
+	CompressionParams `yaml:",inline"`
+	// If set, uploaded playthroughs are encrypted for this key, see
+	// Encrypt. It is a public key made by GenerateKeys.
+	UploadPublicKey string `yaml:"UploadPublicKey"`
+	// How many bytes per second uploaded playthroughs may take, on average.
+	// 0 means no limit. See UploadLimiter.
+	UploadMaxBytesPerSecond int64 `yaml:"UploadMaxBytesPerSecond"`
+	// Where the game sends its data, see NewTelemetrySink: "http", "grpc",
+	// "file" or "none". TelemetryUrl is the server of the PHP endpoints, for
+	// "http" and for the rpcs that the "grpc" server doesn't implement.
+	// TelemetryGrpcUrl is the gRPC server and TelemetryFolder the folder,
+	// for "file".
+	Telemetry        string `yaml:"Telemetry"`
+	TelemetryUrl     string `yaml:"TelemetryUrl"`
+	TelemetryGrpcUrl string `yaml:"TelemetryGrpcUrl"`
+	TelemetryFolder  string `yaml:"TelemetryFolder"`
+	// Servers of the PHP endpoints that take over, in order, when the sink
+	// keeps failing, see FailoverSink.
+	TelemetryMirrorUrls []string `yaml:"TelemetryMirrorUrls"`
+	// "production" or "staging", see SelectEndpoints. TelemetryUrl and
+	// TelemetryGrpcUrl replace the urls of the Endpoints.
+	Endpoints string `yaml:"Endpoints"`
 }
 
 type UserData struct {
-	BestScore int64 `yaml:"BestScore"`
+	// Counts the changes of the UserData, see MergeUserData.
+	Revision         int64 `yaml:"Revision"`
+	BestScore        int64 `yaml:"BestScore"`
+	BestEndlessScore int64 `yaml:"BestEndlessScore"`
+	// The last games played, oldest first, see AddScore.
+	RecentScores []ScoreRecord `yaml:"RecentScores"`
 }
 
 type logData struct {
@@ -161,6 +383,7 @@ type PointerState struct {
 	JustPressed  bool
 	JustReleased bool
 	Pos          Pt
+	Device       InputDevice
 }
 
 type Animations struct {
@@ -169,21 +392,243 @@ type Animations struct {
 }
 
 func main() {
+	// The self-test is meant to be run from the command line, by the release
+	// tool or by hand, on machines that may not even have a display. So it
+	// runs before anything related to the GUI is initialized.
+	if len(os.Args) == 2 && os.Args[1] == "self-test" {
+		nFailed := SelfTest(os.DirFS(".").(FS), "regression-tests")
+		if nFailed > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+	// Same for exporting the simulation constants, which is meant for
+	// analysis tools.
+	if len(os.Args) == 2 && os.Args[1] == "export-constants" {
+		fmt.Println(string(ExportSimulationConstants()))
+		return
+	}
+
+	// Same for replaying a playthrough, which is meant for scripts. With
+	// -trace, the HashTrace of the replay is saved next to the playthrough.
+	// With -events as well, the trace also covers the events of each frame,
+	// see FrameHashWithEvents. With -json, the report is a ReplayReport,
+	// which says why if the playthrough can't even be read.
+	if len(os.Args) >= 3 && os.Args[1] == "replay" {
+		asJson := slices.Contains(os.Args[3:], "-json")
+		if asJson {
+			defer func() {
+				if r := recover(); r != nil {
+					report := ReplayReport{Error: fmt.Sprintf("%v", r)}
+					fmt.Println(string(report.JSON()))
+					os.Exit(1)
+				}
+			}()
+		}
+		p := DeserializePlaythrough(ReadFile(os.Args[2]))
+		var r ReplayResult
+		if slices.Contains(os.Args[3:], "-events") {
+			r = ReplayWithEvents(p)
+		} else {
+			r = Replay(p)
+		}
+		if asJson {
+			fmt.Println(string(NewReplayReport(r).JSON()))
+		} else {
+			fmt.Print(r)
+		}
+		if slices.Contains(os.Args[3:], "-trace") {
+			WriteFile(os.Args[2]+"-trace", r.Trace.Serialize(
+				slices.Contains(os.Args[3:], "-events")))
+		}
+		if r.CrashFrameIdx >= 0 || r.FinalHashDiffers {
+			os.Exit(1)
+		}
+		return
+	}
+	// Same for measuring how long each step of a playthrough takes.
+	if len(os.Args) == 3 && os.Args[1] == "profile" {
+		var p Profiler
+		playthrough := DeserializePlaythrough(ReadFile(os.Args[2]))
+		w := NewWorldFromPlaythrough(playthrough)
+		for _, input := range playthrough.History {
+			p.Step(&w, input)
+		}
+		fmt.Print(p.Report())
+		WriteFile(os.Args[2]+"-profile.csv", p.CSV())
+		return
+	}
+	// Same for aggregating the playthroughs in a directory tree, e.g. the
+	// ones downloaded by the download tool, by version.
+	if len(os.Args) == 3 && os.Args[1] == "stats" {
+		stats, failed := StatsTree(os.Args[2])
+		fmt.Print(StatsReport(stats))
+		if len(failed) > 0 {
+			fmt.Printf("\n%d recordings couldn't be read\n", len(failed))
+		}
+		return
+	}
+	// Same for converting playthroughs to JSON and back, for tools that don't
+	// read the binary format. Each file is converted next to itself.
+	if len(os.Args) >= 3 && os.Args[1] == "to-json" {
+		for _, file := range os.Args[2:] {
+			p := DeserializePlaythrough(ReadFile(file))
+			WriteFile(file+".json", p.ToJSON())
+		}
+		return
+	}
+	if len(os.Args) >= 3 && os.Args[1] == "from-json" {
+		for _, file := range os.Args[2:] {
+			p := PlaythroughFromJSON(ReadFile(file))
+			out := strings.TrimSuffix(file, ".json")
+			if out == file {
+				out += ".clone1"
+			}
+			WriteFile(out, p.Serialize())
+		}
+		return
+	}
+	// Same for upgrading a whole directory of playthroughs, like the
+	// regression tests or the downloaded ones, to the current InputVersion.
+	// The pattern defaults to *.clone1, downloaded playthroughs need another
+	// one, e.g. "*.clone1-*-*".
+	if (len(os.Args) == 3 || len(os.Args) == 4) && os.Args[1] == "migrate" {
+		pattern := "*.clone1"
+		if len(os.Args) == 4 {
+			pattern = os.Args[3]
+		}
+		_, nFailed := MigrateDir(os.DirFS(".").(FS), os.Args[2], pattern)
+		if nFailed > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+	// Same for cutting recordings into pieces, for making regression tests.
+	// trim keeps the frames from start to end, split saves each game of a
+	// session on its own.
+	if len(os.Args) == 5 && os.Args[1] == "trim" {
+		start, err := strconv.ParseInt(os.Args[3], 10, 64)
+		Check(err)
+		end, err := strconv.ParseInt(os.Args[4], 10, 64)
+		Check(err)
+		p := DeserializePlaythrough(ReadFile(os.Args[2]))
+		t := p.Trim(start, end)
+		WriteFile(fmt.Sprintf("%s-%d-%d.clone1",
+			strings.TrimSuffix(os.Args[2], ".clone1"), start, end),
+			t.Serialize())
+		return
+	}
+	if len(os.Args) == 3 && os.Args[1] == "split" {
+		s := DeserializeSession(ReadFile(os.Args[2]))
+		for i, p := range s.Split() {
+			WriteFile(fmt.Sprintf("%s-%02d.clone1", os.Args[2], i+1),
+				p.Serialize())
+		}
+		return
+	}
+	// Same for bringing a whole tree of downloaded recordings to the current
+	// format and naming scheme.
+	if len(os.Args) == 3 && os.Args[1] == "convert" {
+		_, nFailed := ConvertTree(os.Args[2])
+		if nFailed > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+	// Same for stitching together the error recordings of the same game. The
+	// pattern defaults to error-*.clone1.
+	if (len(os.Args) == 3 || len(os.Args) == 4) && os.Args[1] == "merge" {
+		pattern := "error-*.clone1"
+		if len(os.Args) == 4 {
+			pattern = os.Args[3]
+		}
+		_, nFailed := MergeDir(os.DirFS(".").(FS), os.Args[2], pattern)
+		if nFailed > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+	// Same for the keys that uploads are encrypted with. keygen prints a new
+	// pair of keys, decrypt decrypts downloaded playthroughs in place, with
+	// the private key from the environment. The pattern defaults to
+	// *.clone1*, which matches all the naming schemes of the downloads.
+	if len(os.Args) == 2 && os.Args[1] == "keygen" {
+		publicKey, privateKey := GenerateKeys()
+		fmt.Printf("UploadPublicKey: %s\n", publicKey)
+		fmt.Printf("%s=%s\n", privateKeyEnvVar, privateKey)
+		return
+	}
+	if (len(os.Args) == 3 || len(os.Args) == 4) && os.Args[1] == "decrypt" {
+		pattern := "*.clone1*"
+		if len(os.Args) == 4 {
+			pattern = os.Args[3]
+		}
+		_, nFailed := DecryptDir(os.DirFS(".").(FS), os.Args[2], pattern,
+			PrivateKeyFromEnv())
+		if nFailed > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+	// Same for comparing two HashTraces, e.g. of the same playthrough
+	// recorded on two machines or replayed by two versions of the World.
+	if len(os.Args) == 4 && os.Args[1] == "compare-traces" {
+		a, aEvents := DeserializeHashTrace(ReadFile(os.Args[2]))
+		b, bEvents := DeserializeHashTrace(ReadFile(os.Args[3]))
+		// They would differ at the first frame with events, even if the
+		// Worlds are the same.
+		if aEvents != bEvents {
+			fmt.Println("traces can't be compared, only one of them " +
+				"covers the events")
+			os.Exit(1)
+		}
+		frameIdx := CompareHashTraces(a, b)
+		if frameIdx >= 0 {
+			fmt.Printf("traces diverge at frame %d\n", frameIdx)
+			os.Exit(1)
+		}
+		fmt.Printf("traces match for %d frames\n", len(a))
+		return
+	}
+
 	var g Gui
 	defer g.HandlePanic()
+	g.clock = SystemClock{}
 	// ebiten.SetWindowSize(900, 900)
 	ebiten.SetWindowPosition(1000, 100)
 
 	g.playthrough.InputVersion = InputVersion
+	g.session.InputVersion = InputVersion
 	g.playthrough.SimulationVersion = SimulationVersion
 	g.playthrough.ReleaseVersion = ReleaseVersion
 
-	g.username = getUsername()
+	identity := LoadIdentity()
+	g.username = identity.Id
+	g.displayName = identity.DisplayName
+	g.consent = LoadConsent()
 	// A channel size of 10 means the channel will buffer 10 inputs before
 	// it is full. Hopefully, this is enough to compensate for most hitches in
-	// uploads.
-	g.uploadUserDataChannel = make(chan UserData, 10)
-	go g.UploadUserData(g.username, g.uploadUserDataChannel)
+	// uploads. The workers get their channel once, because Shutdown closes it
+	// and clears the field.
+	userDataChannel := make(chan UserData, 10)
+	g.uploadUserDataChannel = userDataChannel
+	g.syncedUserDataChannel = make(chan UserData, 10)
+	// The crash report reads the playthrough, which only the main loop may
+	// do, so the failures are reported from there, see UpdateWorkerFailures.
+	workerReports := make(chan string, 10)
+	g.workerReports = workerReports
+	g.supervisor = Supervisor{
+		Failures: make(chan WorkerFailure, 10),
+		Report: func(errorMsg string) {
+			select {
+			case workerReports <- errorMsg:
+			default:
+			}
+		},
+	}
+	g.userDataDone = g.supervisor.Go("user data", func() {
+		g.UploadUserData(g.username, g.displayName, userDataChannel)
+	})
 	g.FrameSkipAltArrow = 1
 	g.FrameSkipShiftArrow = 10
 	g.FrameSkipArrow = 1
@@ -210,34 +655,123 @@ func main() {
 			filePassedForPlayback = true
 		}
 	}
+	// Two files are played back side by side.
+	if len(os.Args) == 3 {
+		filePassedForPlayback = true
+	}
 
 	g.LoadGuiData()
+	g.endpoints = SelectEndpoints(g.Endpoints, g.TelemetryUrl,
+		g.TelemetryGrpcUrl, g.devModeEnabled)
+	g.AddBreadcrumb("telemetry", "endpoints: %s", g.endpoints.Name)
+	g.telemetry = NewTelemetrySink(g.Telemetry, g.endpoints,
+		g.TelemetryFolder, g.clock)
+	if len(g.TelemetryMirrorUrls) > 0 {
+		names := []string{"primary"}
+		sinks := []TelemetrySink{g.telemetry}
+		for _, url := range g.TelemetryMirrorUrls {
+			names = append(names, url)
+			sinks = append(sinks, NewHttpSink(url, g.endpoints.Name))
+		}
+		g.telemetry = NewFailoverSink(names, sinks, g.EndpointSwitched)
+	}
 
 	if g.UploadPlaybackToHttp {
 		// A channel size of 10 means the channel will buffer 10 inputs before
 		// it is full. Hopefully, this is enough to compensate for most hitches
 		// in uploads.
-		g.uploadDataChannel = make(chan uploadData, 10)
-		go g.UploadPlaythroughs(g.uploadDataChannel)
+		dataChannel := make(chan uploadData, 10)
+		g.uploadDataChannel = dataChannel
+		g.uploadsDone = g.supervisor.Go("uploads", func() {
+			g.UploadPlaythroughs(dataChannel)
+		})
+		g.healthChannel = make(chan ServerHealth, 1)
+		g.supervisor.Go("health", func() {
+			g.CheckServerHealth(g.healthChannel)
+		})
 	}
-	g.UserData = LoadUserData(g.username)
+	if g.UploadAnalyticsToHttp {
+		analyticsChannel := make(chan AnalyticsEvent, 100)
+		g.analyticsChannel = analyticsChannel
+		g.analyticsDone = g.supervisor.Go("analytics", func() {
+			g.UploadAnalytics(g.username, analyticsChannel)
+		})
+	}
+	g.StartSession()
+	onPageHidden(g.PageHidden)
+	g.UserData = LoadUserData(g.telemetry, g.username)
+	g.remoteConfig = LoadRemoteConfig(g.telemetry,
+		g.playthrough.ReleaseVersion)
+	g.motd = LoadCachedMotd()
+	// The worker gets copies of what it needs, as g.playthrough changes
+	// while it runs.
+	motdChannel := make(chan MotdResponse, 1)
+	g.motdChannel = motdChannel
+	sink, releaseVersion := g.telemetry, g.playthrough.ReleaseVersion
+	announcement := g.remoteConfig.Announcement
+	g.supervisor.Go("motd", func() {
+		FetchMotd(sink, releaseVersion, announcement, motdChannel)
+	})
+
+	logChannel := make(chan logData, 1000)
+	g.uploadLogChannel = logChannel
+	g.logsDone = g.supervisor.Go("logs", func() {
+		g.UploadLogs(logChannel)
+	})
 
-	g.uploadLogChannel = make(chan logData, 1000)
-	go g.UploadLogs(g.uploadLogChannel)
+	if g.ProfileSteps {
+		g.profiler = &Profiler{}
+	}
 
 	if filePassedForPlayback {
 		g.StartState = "Playback"
 		g.PlaybackFile = os.Args[1]
+		if len(os.Args) == 3 {
+			g.CompareFile = os.Args[2]
+		}
 	}
 
 	if g.StartState == "Playback" || filePassedForPlayback {
 		g.state = Playback
 		g.enableDebugAreas = true
-		g.playthrough = DeserializePlaythrough(ReadFile(g.PlaybackFile))
-		g.world = NewWorldFromPlaythrough(g.playthrough)
+		if strings.HasSuffix(g.PlaybackFile, "-session") {
+			g.session = DeserializeSession(ReadFile(g.PlaybackFile))
+			g.sessionGameIdx = g.session.NextGame(-1, 1)
+			if g.sessionGameIdx < 0 {
+				Check(fmt.Errorf("no game to play back in %s",
+					g.PlaybackFile))
+			}
+			g.playthrough = g.session.Playthroughs[g.sessionGameIdx]
+		} else {
+			g.playthrough = DeserializePlaythrough(ReadFile(g.PlaybackFile))
+		}
+		g.bookmarks = LoadBookmarks(g.PlaybackFile)
+		g.jumpFrames = FindJumpFrames(g.playthrough)
+		g.annotations = LoadAnnotations()
+		g.SetWorld(NewWorldFromPlaythrough(g.playthrough))
+		if g.CompareFile != "" {
+			g.compareMode = true
+			g.comparePlaythrough = DeserializePlaythrough(
+				ReadFile(g.CompareFile))
+			g.compareWorld = NewWorldFromPlaythrough(g.comparePlaythrough)
+		}
+		for _, s := range g.Breakpoints {
+			b, err := ParseBreakpoint(s)
+			Check(err)
+			g.breakpoints = append(g.breakpoints, b)
+		}
+		ResetBreakpoints(g.breakpoints, &g.world)
 	} else if g.StartState == "DebugCrash" {
 		g.state = DebugCrash
 		g.enableDebugAreas = true
+		// Debug the last crash if no playthrough is given.
+		if g.PlaybackFile == "" {
+			g.PlaybackFile = LatestErrorRecording(os.DirFS(".").(FS))
+			if g.PlaybackFile == "" {
+				Check(fmt.Errorf("no PlaybackFile and no error recording " +
+					"to debug"))
+			}
+		}
 		// Don't crash when we are debugging the crash. This is useful if the
 		// crash was caused by one of my asserts:
 		// - world.Step() crashed during the last frame, because my assert
@@ -245,17 +779,23 @@ func main() {
 		// - Now Check() doesn't crash anymore.
 		// - I can have the world.Step() with the bug execute, and I can see the
 		// results visually
-		CheckCrashes = false
 		g.playthrough = DeserializePlaythrough(ReadFile(g.PlaybackFile))
-		g.world = NewWorldFromPlaythrough(g.playthrough)
+		g.crash = Replay(g.playthrough)
+		CheckCrashes = false
+		g.SetWorld(NewWorldFromPlaythrough(g.playthrough))
 	} else if g.StartState == "Play" {
 		g.state = PlayScreen
 		if g.LoadTest {
-			var test Test
-			LoadYAML(g.FSys, g.TestFile, &test)
+			test := LoadTest(g.FSys, g.TestFile)
 			g.playthrough.Level = test.GetLevel()
 		}
 		g.InitializeWorldToNewGame()
+		// Nothing is uploaded until the player answers, so ask before the
+		// first game.
+		if !g.consent.Answered {
+			g.OpenConsent(PlayScreen)
+			g.state = ConsentScreen
+		}
 	} else {
 		panic(fmt.Errorf("invalid g.StartState: %s", g.StartState))
 	}
@@ -266,62 +806,286 @@ func main() {
 	// visually, maybe place a breakpoint and inspect the state of the world
 	// in the debugger, and then when I'm ready, trigger the bug.
 	if g.state == DebugCrash {
-		g.frameIdx = int64(len(g.playthrough.History)) - 1
+		if g.crash.CrashFrameIdx < 0 {
+			g.crash.CrashFrameIdx = int64(len(g.playthrough.History)) - 1
+			g.crash.CrashMsg = "no crash when replaying, stopped at last input"
+		}
+		g.frameIdx = g.crash.CrashFrameIdx
+		g.crashPhase = AllPhases
 		for i := range g.frameIdx {
-			g.world.Step(g.playthrough.History[i])
+			g.StepPlayback(i)
 		}
 	}
 
+	// Closing the window ends the session in Update, see CloseWindow.
+	ebiten.SetWindowClosingHandled(true)
 	err := ebiten.RunGame(&g)
 	Check(err)
+	g.Shutdown()
 }
 
 func (g *Gui) InitializeWorldToNewGame() {
+	g.UpdateSessionGame()
 	g.playthrough.Id = uuid.New()
-	g.playthrough.Seed = time.Now().UnixNano()
+	g.playthrough.Seed = ChooseSeed(g.SeedPolicy, g.clock, g.CuratedSeeds,
+		g.Seed)
 	g.playthrough.History = g.playthrough.History[:0]
+	g.playthrough.Metadata = NewMetadata(g.clock, g.outsideWidth,
+		g.outsideHeight)
 	g.playthrough.AllowOverlappingDrags = g.AllowOverlappingDrags
-	if g.UploadPlaybackToHttp {
-		for i := 1; i < 3; i++ {
-			// This might fail, but we really do not care that much. The game
-			// should not be interrupted by this function failing. If it does
-			// fail, just try a couple more times, then give up.
-			err := InitializeIdInDbHttp(g.username,
+	g.playthrough.Mode = Classic
+	if g.Endless {
+		g.playthrough.Mode = Endless
+	}
+	g.playthrough.PushNowEnabled = g.PushNow
+	g.remoteConfig.ApplyTo(&g.playthrough.Level)
+	g.playthrough.Metadata.RemoteConfig = g.remoteConfig.String()
+	g.playthrough.Metadata.OptedOut = !g.UploadsAllowed()
+	g.playthrough.Metadata.Endpoints = g.endpoints.Name
+	g.previousBestScore = *g.BestScoreForMode(g.playthrough.Mode)
+	if g.UploadingPlaythroughs() {
+		// This might fail, but we really do not care that much. The game
+		// should not be interrupted by this function failing. If it does
+		// fail, just try a couple more times, then give up.
+		_ = blockingRetryPolicy.Do(func() error {
+			return g.telemetry.InitializePlaythrough(g.username,
 				g.playthrough.ReleaseVersion,
 				g.playthrough.SimulationVersion,
 				g.playthrough.InputVersion,
 				g.playthrough.Id)
-			if err == nil {
-				break
-			}
-		}
+		})
+	}
+	g.AddBreadcrumb("game", "new game %v, seed %d, mode %d",
+		g.playthrough.Id, g.playthrough.Seed, g.playthrough.Mode)
+	g.SetWorld(NewWorldFromPlaythrough(g.playthrough))
+	g.world.OnGameOver(g.GameOver)
+	g.hintTracker = HintTracker{}
+	g.ListenForAnalytics()
+	if g.profiler != nil {
+		g.profiler.Reset()
+	}
+	g.hashTrace = g.hashTrace[:0]
+	g.AppendHashTrace()
+	if g.RecordToFile {
+		g.session.Playthroughs = append(g.session.Playthroughs,
+			*g.playthrough.Clone())
+		g.StartRecordingStream()
 	}
-	g.world = NewWorldFromPlaythrough(g.playthrough)
 }
 
 func (g *Gui) ResetWorld() {
+	g.UpdateSessionGame()
 	// Create a new world, with a new Id and a new playthrough History, but the
 	// same Seed.
 	g.playthrough.Id = uuid.New()
 	g.playthrough.History = g.playthrough.History[:0]
+	g.playthrough.Metadata = NewMetadata(g.clock, g.outsideWidth,
+		g.outsideHeight)
+	g.playthrough.Metadata.RemoteConfig = g.remoteConfig.String()
+	g.playthrough.Metadata.OptedOut = !g.UploadsAllowed()
+	g.playthrough.Metadata.Endpoints = g.endpoints.Name
 	g.playthrough.AllowOverlappingDrags = g.AllowOverlappingDrags
-	if g.UploadPlaybackToHttp {
-		for i := 1; i < 3; i++ {
-			// This might fail, but we really do not care that much. The game
-			// should not be interrupted by this function failing. If it does
-			// fail, just try a couple more times, then give up.
-			err := InitializeIdInDbHttp(g.username,
+	g.previousBestScore = *g.BestScoreForMode(g.playthrough.Mode)
+	if g.UploadingPlaythroughs() {
+		// This might fail, but we really do not care that much. The game
+		// should not be interrupted by this function failing. If it does
+		// fail, just try a couple more times, then give up.
+		_ = blockingRetryPolicy.Do(func() error {
+			return g.telemetry.InitializePlaythrough(g.username,
 				g.playthrough.ReleaseVersion,
 				g.playthrough.SimulationVersion,
 				g.playthrough.InputVersion,
 				g.playthrough.Id)
-			if err == nil {
-				break
-			}
+		})
+	}
+	g.AddBreadcrumb("game", "new game %v, seed %d, mode %d",
+		g.playthrough.Id, g.playthrough.Seed, g.playthrough.Mode)
+	g.SetWorld(NewWorldFromPlaythrough(g.playthrough))
+	g.world.OnGameOver(g.GameOver)
+	g.hintTracker = HintTracker{}
+	g.ListenForAnalytics()
+	if g.profiler != nil {
+		g.profiler.Reset()
+	}
+	g.hashTrace = g.hashTrace[:0]
+	g.AppendHashTrace()
+	if g.RecordToFile {
+		g.session.Playthroughs = append(g.session.Playthroughs,
+			*g.playthrough.Clone())
+		g.StartRecordingStream()
+	}
+}
+
+// StartRecordingStream starts saving g.playthrough to RecordingFile, from
+// scratch.
+func (g *Gui) StartRecordingStream() {
+	if g.recordingStream != nil {
+		g.recordingStream.Close()
+	}
+	g.recordingStream = NewRecordingStream(CreateFile(g.RecordingFile),
+		&g.playthrough)
+}
+
+// RecordInput adds input to g.playthrough and, if recording, to RecordingFile,
+// along with the Moment it was recorded.
+// The FinalHash of g.playthrough is unknown until the World steps through
+// input, see StepWorld.
+func (g *Gui) RecordInput(input PlayerInput) {
+	input.Moment = g.clock.Now().UnixMilli()
+	g.playthrough.History = append(g.playthrough.History, input)
+	g.playthrough.FinalHash = FrameHash{}
+	if g.recordingStream != nil {
+		g.recordingStream.Append(input)
+	}
+}
+
+// RecordPausedFrame adds a frame to the pause at the end of g.playthrough or,
+// if the game was playing until now, starts a new pause.
+func (g *Gui) RecordPausedFrame() {
+	h := g.playthrough.History
+	if n := len(h); n > 0 && h[n-1].PausedFrames > 0 {
+		h[n-1].PausedFrames++
+		if g.recordingStream != nil {
+			g.recordingStream.ExtendPause()
 		}
+		return
+	}
+	input := PlayerInput{PausedFrames: 1}
+	g.RecordInput(input)
+	g.StepWorld(input)
+	g.AppendHashTrace()
+}
+
+// ChangeState moves the Gui to state to and, if recording, remembers the
+// transition in g.session and saves it.
+func (g *Gui) ChangeState(to GameState, cause TransitionCause) {
+	g.AddBreadcrumb("state", "%v -> %v (%v)", g.state, to, cause)
+	if g.RecordToFile {
+		g.session.Transitions = append(g.session.Transitions, Transition{
+			FrameIdx:       g.sessionFrameIdx,
+			From:           g.state,
+			To:             to,
+			Cause:          cause,
+			PlaythroughIdx: int64(len(g.session.Playthroughs)) - 1,
+		})
+		g.UpdateSessionGame()
+		WriteFile(g.RecordingFile+"-session", g.session.Serialize())
+	}
+	if to == PausedScreen {
+		g.pausedFrameIdx = 0
+	}
+	g.state = to
+}
+
+// CloseWindow saves what would be lost when the program exits: the end of
+// the recording and of the session file, if recording, and the inputs of
+// the current game that weren't uploaded yet. The session_end event is sent
+// after RunGame returns, see EndAnalytics.
+func (g *Gui) CloseWindow() {
+	g.AddBreadcrumb("state", "window closed")
+	if g.recordingStream != nil {
+		g.recordingStream.Close()
+		g.recordingStream = nil
+	}
+	if g.RecordToFile {
+		g.UpdateSessionGame()
+		WriteFile(g.RecordingFile+"-session", g.session.Serialize())
+	}
+	if g.state == PlayScreen || g.state == PausedScreen {
+		g.uploadCurrentWorld()
 	}
-	g.world = NewWorldFromPlaythrough(g.playthrough)
 }
+
+// How long the program waits for the uploads that are still queued when it
+// exits.
+const shutdownTimeout = 3 * time.Second
+
+// Shutdown ends the session and closes the channels of the workers that
+// upload data, so that they return once they have sent what is still queued
+// (see EndAnalytics for the analytics). It waits for them, but at most
+// shutdownTimeout, the program can't take long to close. Whatever isn't sent
+// by then is lost.
+func (g *Gui) Shutdown() {
+	g.EndAnalytics()
+	if g.uploadDataChannel != nil {
+		close(g.uploadDataChannel)
+		g.uploadDataChannel = nil
+	}
+	if g.uploadUserDataChannel != nil {
+		close(g.uploadUserDataChannel)
+		g.uploadUserDataChannel = nil
+	}
+	if g.uploadLogChannel != nil {
+		close(g.uploadLogChannel)
+		g.uploadLogChannel = nil
+	}
+	deadline := time.After(shutdownTimeout)
+	for _, done := range []<-chan struct{}{g.uploadsDone, g.userDataDone,
+		g.logsDone, g.analyticsDone} {
+		if done == nil {
+			continue
+		}
+		select {
+		case <-done:
+		case <-deadline:
+			return
+		}
+	}
+}
+
+// PageHidden is called when the page of the WASM version is hidden: the tab
+// is closed or in the background, or the browser is minimized. The browser
+// doesn't wait for requests when the tab is closed, but it keeps running the
+// workers while the page is hidden, so this is the last good moment to queue
+// the inputs of the current game that weren't uploaded yet.
+func (g *Gui) PageHidden() {
+	if g.state == PlayScreen || g.state == PausedScreen {
+		g.uploadCurrentWorld()
+	}
+}
+
+// UpdateSessionGame copies the game being played into g.session. The copy
+// in g.session doesn't grow along with g.playthrough and g.playthrough is
+// reused for the next game.
+func (g *Gui) UpdateSessionGame() {
+	if n := len(g.session.Playthroughs); n > 0 {
+		g.session.Playthroughs[n-1] = *g.playthrough.Clone()
+	}
+}
+
+// SendCrashReport sends report, the crash report of the error errorMsg, to
+// the server. This is the only thing that will have any effect for errors
+// that happen in the browser, from WASM.
+// Errors are ignored, because if this fails and we are in WASM there is
+// nothing more we can do anyway to handle the error.
+// Not every error is sent, see ErrorReportLimiter, but the ones that are say
+// how often errors happened.
+func (g *Gui) SendCrashReport(errorMsg string, report string) {
+	if g.telemetry == nil {
+		g.telemetry = NewTelemetrySink("", productionEndpoints, "",
+			g.clock)
+	}
+	if !g.UploadsAllowed() {
+		return
+	}
+	send, count := g.errorReports.Allow(errorMsg)
+	if !send {
+		return
+	}
+	report += fmt.Sprintf("\n--- count ---\n"+
+		"this error: %d times, all errors: %d, not sent: %d\n",
+		count.Same, count.All, count.NotSent)
+	_ = g.telemetry.Log(
+		g.username,
+		g.playthrough.ReleaseVersion,
+		g.playthrough.SimulationVersion,
+		g.playthrough.InputVersion,
+		g.playthrough.Id,
+		"error",
+		report,
+		g.SerializeForUpload(&g.playthrough))
+}
+
 func (g *Gui) HandlePanic() {
 	r := recover()
 	if r == nil {
@@ -329,16 +1093,24 @@ func (g *Gui) HandlePanic() {
 		return
 	}
 	errorMsg := StackTrace(r)
+	report := g.CrashReport(errorMsg)
 
 	// Write to files first, as this should be more reliable than http.
+	// The input that caused the panic was recorded before stepping the World,
+	// but it may not be flushed yet.
+	if g.recordingStream != nil {
+		g.recordingStream.Close()
+		g.recordingStream = nil
+	}
 	if g.RecordToFileOnError {
-		WriteFile(g.RecordingFile, g.playthrough.Serialize())
-		timestamp := time.Now().Format("2006-01-02 15:04:05")
+		WriteFile(g.RecordingFile,
+			g.playthrough.SerializeWith(g.CompressionParams))
+		timestamp := g.clock.Now().Format("2006-01-02 15:04:05")
 		logMessage := fmt.Sprintf(
 			"----------------------------------------\n%s %s",
-			timestamp, errorMsg)
+			timestamp, report)
 		AppendToFile("clone1.log", logMessage)
-		timestamp = time.Now().Format("20060102-150405")
+		timestamp = g.clock.Now().Format("20060102-150405")
 		filename := fmt.Sprintf("error-%s.clone1", timestamp)
 		idx := 1
 		for {
@@ -348,22 +1120,18 @@ func (g *Gui) HandlePanic() {
 			idx++
 			filename = fmt.Sprintf("error-%s-%02d.clone1", timestamp, idx)
 		}
-		WriteFile(filename, g.playthrough.Serialize())
+		WriteFile(filename,
+			g.playthrough.SerializeWith(g.CompressionParams))
+		WriteFile(filename+"-trace",
+			g.hashTrace.Serialize(g.HashTraceEvents))
+		if g.RecordToFile {
+			g.UpdateSessionGame()
+			WriteFile(filename+"-session", g.session.Serialize())
+		}
+		AppendToFile("clone1.log", g.VerifyErrorRecording()+"\n")
 	}
 
-	// Log the error via HTTP (this is the only thing that will have any effect
-	// for errors that happen in the browser, from WASM).
-	// Ignore errors, because if this fails and we are in WASM there is nothing
-	// more we can do anyway to handle the error.
-	_ = LogHttp(
-		g.username,
-		g.playthrough.ReleaseVersion,
-		g.playthrough.SimulationVersion,
-		g.playthrough.InputVersion,
-		g.playthrough.Id,
-		"error",
-		errorMsg,
-		g.playthrough.Serialize())
+	g.SendCrashReport(errorMsg, report)
 
 	// Swallow the panic and display the error to the user. This is preferred
 	// because if an error happens, it will most likely be on someone's phone.
@@ -373,8 +1141,92 @@ func (g *Gui) HandlePanic() {
 	g.panicMsg = errorMsg[:min(len(errorMsg), 1300)]
 }
 
+// LatestErrorRecording returns the newest recording saved by HandlePanic in
+// the root of fsys, or "" if there is none. The names of the recordings
+// start with the time they were saved at, so the newest one is the last one
+// by name, except for the .clone1 extension which would put error-X.clone1
+// after error-X-02.clone1.
+func LatestErrorRecording(fsys FS) (latest string) {
+	for _, f := range GetFiles(fsys, ".", "error-*.clone1") {
+		if strings.TrimSuffix(f, ".clone1") >
+			strings.TrimSuffix(latest, ".clone1") {
+			latest = f
+		}
+	}
+	return
+}
+
+// KeepHashTrace says if g.hashTrace is kept while playing. It only makes
+// sense if the inputs are recorded and either the trace is saved along with
+// them or they are saved when an error happens.
+func (g *Gui) KeepHashTrace() bool {
+	return g.RecordToFile && g.RecordHashTrace ||
+		g.RecordToFileOnError && (g.RecordToFile || g.UploadPlaybackToHttp)
+}
+
+// AppendHashTrace adds the current frame to g.hashTrace, if it is kept, and
+// saves it if it is recorded.
+func (g *Gui) AppendHashTrace() {
+	if !g.KeepHashTrace() {
+		return
+	}
+	g.hashTrace = append(g.hashTrace, g.world.TraceHash(g.HashTraceEvents))
+	if g.RecordToFile && g.RecordHashTrace {
+		WriteFile(g.RecordingFile+"-trace",
+			g.hashTrace.Serialize(g.HashTraceEvents))
+	}
+}
+
+// VerifyErrorRecording replays the playthrough that is saved when an error
+// happens and compares it with the live game, frame by frame. If they
+// diverge, the recording can't reproduce the error and the cause is
+// non-determinism, not the World logic at the frame that crashed.
+// It returns a line for the log.
+func (g *Gui) VerifyErrorRecording() (msg string) {
+	// The replay may crash too, for the same reason the live game did.
+	defer func() {
+		if r := recover(); r != nil {
+			msg = fmt.Sprintf("replay of the recording crashed: %v", r)
+		}
+	}()
+
+	if len(g.hashTrace) == 0 {
+		return "no hash trace to verify the recording against"
+	}
+	frameIdx := VerifyPlaythrough(g.playthrough, g.hashTrace,
+		g.HashTraceEvents)
+	if frameIdx >= 0 {
+		return fmt.Sprintf("replay of the recording diverges from the live "+
+			"game at frame %d", frameIdx)
+	}
+	return fmt.Sprintf("replay of the recording matches the live game for "+
+		"%d frames", len(g.hashTrace))
+}
+
+// uploadUserData sends g.UserData to the server, unless that risks blocking.
+// The upload happens on another goroutine, so it gets its own copy of the
+// RecentScores. Every change of the UserData is uploaded, so this is where
+// it gets a new Revision.
+func (g *Gui) uploadUserData() {
+	g.Revision++
+	if !g.UploadsAllowed() {
+		return
+	}
+	if len(g.uploadUserDataChannel) < cap(g.uploadUserDataChannel) {
+		data := g.UserData
+		data.RecentScores = slices.Clone(data.RecentScores)
+		g.uploadUserDataChannel <- data
+	}
+}
+
 func (g *Gui) uploadCurrentWorld() {
-	if !g.UploadPlaybackToHttp {
+	g.uploadWorld(false)
+}
+
+// uploadWorld sends a clone of g.playthrough to UploadPlaythroughs. If final is
+// true, the playthrough is over and is uploaded whole, with its FinalHash.
+func (g *Gui) uploadWorld(final bool) {
+	if !g.UploadingPlaythroughs() {
 		return
 	}
 
@@ -387,32 +1239,113 @@ func (g *Gui) uploadCurrentWorld() {
 			g.playthrough.ReleaseVersion,
 			g.playthrough.SimulationVersion,
 			g.playthrough.InputVersion,
-			g.playthrough.Clone()}
+			g.playthrough.Clone(),
+			final}
 	}
 }
 
-func (g *Gui) UploadPlaythroughs(ch chan uploadData) {
-	defer g.HandlePanic()
+// UploadingPlaythroughs is true if playthroughs are uploaded to the server.
+// The remote config and the player can stop uploads that the config asks
+// for.
+func (g *Gui) UploadingPlaythroughs() bool {
+	return g.UploadPlaybackToHttp && !g.remoteConfig.DisableUploads &&
+		g.UploadsAllowed()
+}
 
+// SerializeForUpload serializes p the way it is sent to the server:
+// compressed with g.CompressionParams and, if g.UploadPublicKey is set, encrypted.
+func (g *Gui) SerializeForUpload(p *Playthrough) []byte {
+	data := p.SerializeWith(g.CompressionParams)
+	if g.UploadPublicKey != "" {
+		data = Encrypt(data, ParseKey(g.UploadPublicKey))
+	}
+	return data
+}
+
+// UploadPlaythroughs uploads the playthroughs it receives on ch. A playthrough
+// that is still being played is uploaded as a RecordingStream, in pieces: only
+// the part of the stream that the server doesn't have yet is sent, so the
+// uploads don't get bigger and bigger as the History grows. The server says
+// how much of the stream it has after each piece, which is where the next
+// piece starts. A piece that is lost is sent again as part of the next one.
+// A final playthrough is uploaded whole, compressed, replacing the stream.
+// Encrypted uploads are always whole, an encrypted stream can't be appended
+// to. Whole uploads that are too big for one request go in chunks, see
+// UploadChunks. Uploads are limited to g.UploadMaxBytesPerSecond, see
+// UploadLimiter. It returns once ch is closed and everything in it was
+// uploaded.
+func (g *Gui) UploadPlaythroughs(ch chan uploadData) {
+	limiter := UploadLimiter{BytesPerSecond: g.UploadMaxBytesPerSecond}
+	var streamId uuid.UUID
+	var streamUploaded int64
 	for {
 		// Receive a playthrough from the channel.
 		// Blocks until a playthrough is received.
-		data := <-ch
+		data, ok := <-ch
+		if !ok {
+			return
+		}
 
 		// Upload the data.
 		// This might fail, but we really do not care that much. The game should
 		// not be interrupted by this function failing. If it does fail, just
 		// try a couple more times, then give up.
-		for i := 1; i < 3; i++ {
-			err := UploadDataToDbHttp(data.user,
-				data.releaseVersion,
-				data.simulationVersion,
-				data.inputVersion,
-				data.playthrough.Id,
-				data.playthrough.Serialize())
-			if err == nil {
-				break
+		if !data.final && g.UploadPublicKey == "" {
+			if data.playthrough.Id != streamId {
+				streamId = data.playthrough.Id
+				streamUploaded = 0
 			}
+			stream := data.playthrough.SerializeStream()
+			if streamUploaded < 0 {
+				// The playthrough was already uploaded whole.
+				continue
+			}
+			if streamUploaded > int64(len(stream)) {
+				// The server has something else, start over.
+				streamUploaded = 0
+			}
+			_ = g.uploadMetrics.Do(playthroughsQueue, backgroundRetryPolicy,
+				func() error {
+					limiter.Wait(int64(len(stream)) - streamUploaded)
+					n, err := g.telemetry.AppendPlaythrough(data.user,
+						data.playthrough.Id, streamUploaded,
+						stream[streamUploaded:])
+					if err == nil {
+						streamUploaded = n
+					}
+					return err
+				})
+			continue
+		}
+
+		if data.final {
+			streamId = data.playthrough.Id
+			streamUploaded = -1
+		}
+		serialized := g.SerializeForUpload(data.playthrough)
+		if len(serialized) > uploadChunkSize {
+			digest := Sha256Hex(serialized)
+			g.uploadMetrics.Start(playthroughsQueue)
+			err := UploadChunks(serialized,
+				g.uploadMetrics.Policy(playthroughsQueue,
+					backgroundRetryPolicy), &limiter,
+				func(offset int64, chunk []byte) (int64, error) {
+					return g.telemetry.UploadPlaythroughChunk(data.user,
+						data.playthrough.Id, digest,
+						int64(len(serialized)), offset, chunk)
+				})
+			g.uploadMetrics.Done(playthroughsQueue, err)
+			continue
 		}
+		_ = g.uploadMetrics.Do(playthroughsQueue, backgroundRetryPolicy,
+			func() error {
+				limiter.Wait(int64(len(serialized)))
+				return g.telemetry.UploadPlaythrough(data.user,
+					data.releaseVersion,
+					data.simulationVersion,
+					data.inputVersion,
+					data.playthrough.Id,
+					serialized)
+			})
 	}
 }
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+)
+
+// MergePlaythroughs stitches together recordings of the same game, e.g. the
+// error recordings saved every time the game crashed, into one. Each recording
+// holds the History from the start of the game until it was saved, so the
+// shorter recordings must be the start of the longer ones.
+func MergePlaythroughs(ps []Playthrough) Playthrough {
+	if len(ps) == 0 {
+		Check(fmt.Errorf("no playthroughs to merge"))
+	}
+	ps = slices.Clone(ps)
+	sort.SliceStable(ps, func(i, j int) bool {
+		return len(ps[i].History) < len(ps[j].History)
+	})
+	for i := 1; i < len(ps); i++ {
+		a, b := &ps[i-1], &ps[i]
+		if a.Id != b.Id {
+			Check(fmt.Errorf("can't merge playthroughs %s and %s, they are "+
+				"different games", a.Id, b.Id))
+		}
+		if a.SimulationVersion != b.SimulationVersion ||
+			a.ReleaseVersion != b.ReleaseVersion {
+			Check(fmt.Errorf("can't merge recordings of %s made by "+
+				"different versions: %d/%d and %d/%d", a.Id,
+				a.SimulationVersion, a.ReleaseVersion, b.SimulationVersion,
+				b.ReleaseVersion))
+		}
+		if a.Seed != b.Seed || !reflect.DeepEqual(a.Level, b.Level) {
+			Check(fmt.Errorf("can't merge recordings of %s, they don't start "+
+				"from the same seed and level", a.Id))
+		}
+		if !slices.Equal(a.History, b.History[:len(a.History)]) {
+			Check(fmt.Errorf("can't merge recordings of %s, the inputs "+
+				"differ before frame %d", a.Id, len(a.History)))
+		}
+	}
+	return *ps[len(ps)-1].Clone()
+}
+
+// MergeDir merges the recordings in dir which match pattern and are of the
+// same game, see MergePlaythroughs. Each game with more than one recording is
+// saved in dir, in merged-<Id>.clone1. Games that can't be merged are
+// reported and skipped. It returns the number of games that were merged and
+// the number that failed.
+func MergeDir(fsys FS, dir string, pattern string) (nMerged int,
+	nFailed int) {
+	games := map[string][]Playthrough{}
+	var ids []string
+	for _, file := range GetFiles(fsys, dir, pattern) {
+		p := DeserializePlaythrough(ReadFile(file))
+		id := p.Id.String()
+		if games[id] == nil {
+			ids = append(ids, id)
+		}
+		games[id] = append(games[id], p)
+	}
+	for _, id := range ids {
+		if len(games[id]) < 2 {
+			continue
+		}
+		err := mergeGame(dir+"/merged-"+id+".clone1", games[id])
+		if err != nil {
+			fmt.Printf("FAIL %s: %v\n", id, err)
+			nFailed++
+		} else {
+			fmt.Printf("merged %d recordings of %s\n", len(games[id]), id)
+			nMerged++
+		}
+	}
+	return
+}
+
+func mergeGame(file string, ps []Playthrough) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	merged := MergePlaythroughs(ps)
+	WriteFile(file, merged.Serialize())
+	return nil
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMergePlaythroughs(t *testing.T) {
+	p := DeserializePlaythrough(ReadFile("data/demo.clone1"))
+	first := *p.Clone()
+	first.History = first.History[:100]
+	second := *p.Clone()
+	second.History = second.History[:500]
+
+	merged := MergePlaythroughs([]Playthrough{second, p, first})
+	assert.Equal(t, p.History, merged.History)
+
+	// A different game.
+	other := *first.Clone()
+	other.Seed++
+	assert.Panics(t, func() { MergePlaythroughs([]Playthrough{other, p}) })
+
+	// The same game, but the inputs don't agree.
+	other = *first.Clone()
+	other.NudgeInput(50, Pt{1, 0})
+	assert.Panics(t, func() { MergePlaythroughs([]Playthrough{other, p}) })
+
+	assert.Panics(t, func() { MergePlaythroughs(nil) })
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"runtime"
+)
+
+// Metadata describes the environment in which a Playthrough was recorded. It
+// plays no part in the simulation, it only exists so that downloaded
+// playthroughs can be grouped during analysis (e.g. browser vs desktop)
+// without looking them up in the database.
+// Metadata is optional: its zero value means it wasn't recorded, which is the
+// case for playthroughs made by tests or migrated from old InputVersions.
+type Metadata struct {
+	OS   string
+	Arch string
+	Wasm bool
+	// The size of the window, in pixels, when the playthrough started.
+	ScreenWidth  int64
+	ScreenHeight int64
+	BuildTags    []string
+	// When the playthrough started, in Unix nanoseconds.
+	StartMoment int64
+	// The RemoteConfig in effect when the playthrough started, see
+	// RemoteConfig.String.
+	RemoteConfig string
+	// True if the player had opted out of uploads when the playthrough
+	// started, see Consent, so it was only recorded on the device.
+	OptedOut bool
+	// The Name of the Endpoints that the playthrough was uploaded to.
+	Endpoints string
+}
+
+func NewMetadata(clock Clock, screenWidth int64, screenHeight int64) (
+	m Metadata) {
+	m.OS = runtime.GOOS
+	m.Arch = runtime.GOARCH
+	m.Wasm = runtime.GOOS == "js" && runtime.GOARCH == "wasm"
+	m.ScreenWidth = screenWidth
+	m.ScreenHeight = screenHeight
+	m.BuildTags = BuildTags()
+	m.StartMoment = clock.Now().UnixNano()
+	return
+}
+
+// BuildTags returns the build tags that the executable was built with, out of
+// the ones that change its behavior.
+func BuildTags() (tags []string) {
+	if assertEnabled {
+		tags = append(tags, "assert_enabled")
+	} else {
+		tags = append(tags, "assert_disabled")
+	}
+	if httpEnabled {
+		tags = append(tags, "http_enabled")
+	} else {
+		tags = append(tags, "http_disabled")
+	}
+	if stagingEnabled {
+		tags = append(tags, "staging_enabled")
+	}
+	return
+}
+
+// The ids of the fields of serialized Metadata.
+const (
+	metadataOS int64 = iota + 1
+	metadataArch
+	metadataWasm
+	metadataScreenWidth
+	metadataScreenHeight
+	metadataBuildTags
+	metadataStartMoment
+	metadataRemoteConfig
+	metadataOptedOut
+	metadataEndpoints
+)
+
+func SerializeMetadata(buf *bytes.Buffer, m *Metadata) {
+	SerializeField(buf, metadataOS, func(buf *bytes.Buffer) {
+		SerializeString(buf, m.OS)
+	})
+	SerializeField(buf, metadataArch, func(buf *bytes.Buffer) {
+		SerializeString(buf, m.Arch)
+	})
+	SerializeValueField(buf, metadataWasm, m.Wasm)
+	SerializeValueField(buf, metadataScreenWidth, m.ScreenWidth)
+	SerializeValueField(buf, metadataScreenHeight, m.ScreenHeight)
+	SerializeField(buf, metadataBuildTags, func(buf *bytes.Buffer) {
+		Serialize(buf, int64(len(m.BuildTags)))
+		for _, tag := range m.BuildTags {
+			SerializeString(buf, tag)
+		}
+	})
+	SerializeValueField(buf, metadataStartMoment, m.StartMoment)
+	SerializeField(buf, metadataRemoteConfig, func(buf *bytes.Buffer) {
+		SerializeString(buf, m.RemoteConfig)
+	})
+	SerializeValueField(buf, metadataOptedOut, m.OptedOut)
+	SerializeField(buf, metadataEndpoints, func(buf *bytes.Buffer) {
+		SerializeString(buf, m.Endpoints)
+	})
+}
+
+func DeserializeMetadata(buf *bytes.Buffer, m *Metadata) {
+	fields := DeserializeFields(buf)
+	DeserializeField(fields, metadataOS, func(buf *bytes.Buffer) {
+		DeserializeString(buf, &m.OS)
+	})
+	DeserializeField(fields, metadataArch, func(buf *bytes.Buffer) {
+		DeserializeString(buf, &m.Arch)
+	})
+	DeserializeValueField(fields, metadataWasm, &m.Wasm)
+	DeserializeValueField(fields, metadataScreenWidth, &m.ScreenWidth)
+	DeserializeValueField(fields, metadataScreenHeight, &m.ScreenHeight)
+	DeserializeField(fields, metadataBuildTags, func(buf *bytes.Buffer) {
+		var nTags int64
+		Deserialize(buf, &nTags)
+		for range nTags {
+			var tag string
+			DeserializeString(buf, &tag)
+			m.BuildTags = append(m.BuildTags, tag)
+		}
+	})
+	DeserializeValueField(fields, metadataStartMoment, &m.StartMoment)
+	DeserializeField(fields, metadataRemoteConfig, func(buf *bytes.Buffer) {
+		DeserializeString(buf, &m.RemoteConfig)
+	})
+	DeserializeValueField(fields, metadataOptedOut, &m.OptedOut)
+	DeserializeField(fields, metadataEndpoints, func(buf *bytes.Buffer) {
+		DeserializeString(buf, &m.Endpoints)
+	})
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestNewMetadata(t *testing.T) {
+	moment := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	m := NewMetadata(FixedClock{moment}, 800, 600)
+	assert.Equal(t, runtime.GOOS, m.OS)
+	assert.Equal(t, runtime.GOARCH, m.Arch)
+	assert.False(t, m.Wasm)
+	assert.Equal(t, int64(800), m.ScreenWidth)
+	assert.Equal(t, int64(600), m.ScreenHeight)
+	assert.Contains(t, m.BuildTags, "assert_enabled")
+	assert.Equal(t, moment.UnixNano(), m.StartMoment)
+}
+
+func TestPlaythrough_SerializeMetadata(t *testing.T) {
+	p := DeserializePlaythrough(ReadFile("data/demo.clone1"))
+	moment := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	p.Metadata = NewMetadata(FixedClock{moment}, 800, 600)
+	p.Metadata.RemoteConfig = RemoteConfig{Experiment: "slow timer"}.String()
+	p.Metadata.OptedOut = true
+	p.Metadata.Endpoints = "staging"
+	assert.Equal(t, p.Metadata, DeserializePlaythrough(p.Serialize()).Metadata)
+	assert.Equal(t, p.Metadata,
+		DeserializeUncompressed(p.SerializeLegacy()).Metadata)
+
+	// The Metadata plays no part in the simulation.
+	expected := RegressionId(p)
+	p.Metadata = Metadata{}
+	assert.Equal(t, expected, RegressionId(p))
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"github.com/marisvali/clone1/clone1pb"
+	"google.golang.org/protobuf/proto"
+	"hash/crc32"
+)
+
+// A migration upgrades the byte representation of a Playthrough from one
+// InputVersion to a newer one. It works on unzipped bytes, the ones that are
+// zipped by Serialize. Migrations are chained, so a migration only has to know
+// about two versions: the one it reads and the one it writes.
+type migration struct {
+	to      int64
+	migrate func(data []byte) []byte
+}
+
+// migrations holds the migration that upgrades each old InputVersion.
+// When the layout changes, the code that reads the old layout moves here, as
+// a new migration. Until InputVersion is released, its layout is not frozen,
+// so the migrations that produce it use Serialize. Playthroughs in the legacy
+// layout with the current InputVersion need no migration, see
+// DeserializeUncompressed.
+var migrations = map[int64]migration{
+	1:   {100, migrateFromV1},
+	99:  {100, migrateFromV99},
+	100: {101, migrateFromV100},
+	101: {102, migrateFromV101},
+}
+
+// MigratePlaythrough upgrades data, which are the unzipped bytes of a
+// Playthrough, to the current InputVersion.
+func MigratePlaythrough(data []byte) []byte {
+	for {
+		version := playthroughInputVersion(data)
+		if version == InputVersion {
+			return data
+		}
+		m, ok := migrations[version]
+		if !ok {
+			Check(fmt.Errorf("can't deserialize this playthrough - we are at "+
+				"InputVersion %d and playthrough was generated with "+
+				"InputVersion %d, for which there is no migration",
+				InputVersion, version))
+		}
+		data = m.migrate(data)
+	}
+}
+
+// NeedsMigration returns true if the playthrough in data was recorded with
+// an older InputVersion, in the legacy layout or without the file header. A
+// RecordingStream always needs it, to be rewritten in the compact format.
+func NeedsMigration(data []byte) bool {
+	if SniffFormat(data) != PlaythroughFile {
+		return true
+	}
+	data = Decompress(stripFileHeader(data))
+	return !IsProtoPlaythrough(data) ||
+		playthroughInputVersion(data) != InputVersion
+}
+
+// playthroughInputVersion returns the InputVersion of data, which are the
+// unzipped bytes of a Playthrough in either layout.
+func playthroughInputVersion(data []byte) (version int64) {
+	if IsProtoPlaythrough(data) {
+		return unmarshalProto(data).InputVersion
+	}
+	Deserialize(bytes.NewBuffer(data), &version)
+	return
+}
+
+// unmarshalProto returns the message in data, which are the unzipped bytes
+// of a Playthrough in the protobuf layout, without checking its InputVersion.
+// Migrations use it to read messages that deserializeProto refuses.
+func unmarshalProto(data []byte) *clone1pb.Playthrough {
+	var m clone1pb.Playthrough
+	err := proto.Unmarshal(VerifyChecksum(data)[len(protoMagic):], &m)
+	if err != nil {
+		Check(fmt.Errorf("corrupted recording, can't read it: %w", err))
+	}
+	return &m
+}
+
+// marshalProto is the reverse of unmarshalProto.
+func marshalProto(m *clone1pb.Playthrough) []byte {
+	msg, err := proto.Marshal(m)
+	Check(err)
+	buf := bytes.NewBuffer(append(bytes.Clone(protoMagic), msg...))
+	Serialize(buf, crc32.ChecksumIEEE(buf.Bytes()))
+	return buf.Bytes()
+}
+
+// MigrateDir rewrites, in the current InputVersion, all the playthroughs in
+// dir which match pattern and were recorded with an older InputVersion. Files
+// that can't be migrated are reported and left as they are. It returns the
+// number of files that were migrated and the number that failed.
+func MigrateDir(fsys FS, dir string, pattern string) (nMigrated int,
+	nFailed int) {
+	files := GetFiles(fsys, dir, pattern)
+	for _, file := range files {
+		migrated, err := migrateFile(file)
+		if err != nil {
+			fmt.Printf("FAIL %s: %v\n", file, err)
+			nFailed++
+		} else if migrated {
+			fmt.Printf("migrated %s\n", file)
+			nMigrated++
+		}
+	}
+	fmt.Printf("%d files, %d migrated, %d failed\n", len(files), nMigrated,
+		nFailed)
+	return
+}
+
+func migrateFile(file string) (migrated bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	data := ReadFile(file)
+	if !NeedsMigration(data) {
+		return false, nil
+	}
+	p := DeserializePlaythrough(data)
+	WriteFile(file, p.Serialize())
+	return true, nil
+}
+
+// migrateFromV1 upgrades the first InputVersion, in which bricks only had a
+// position and a value, and the player had a single pointer and could only
+// drag bricks and trigger the coming up of a new row. The Level fields added
+// since then keep their zero values, which mean the defaults.
+func migrateFromV1(data []byte) []byte {
+	buf := bytes.NewBuffer(data)
+	var p Playthrough
+	Deserialize(buf, &p.InputVersion)
+	Deserialize(buf, &p.SimulationVersion)
+	Deserialize(buf, &p.ReleaseVersion)
+
+	type brickParamsV1 struct {
+		Pos Pt
+		Val int64
+	}
+	var bricks []brickParamsV1
+	DeserializeSlice(buf, &bricks)
+	for _, b := range bricks {
+		p.BricksParams = append(p.BricksParams,
+			BrickParams{Pos: b.Pos, Val: b.Val})
+	}
+
+	DeserializeSlice(buf, &p.ChainsParams)
+	Deserialize(buf, &p.TimerDisabled)
+	Deserialize(buf, &p.AllowOverlappingDrags)
+	Deserialize(buf, &p.Id)
+	Deserialize(buf, &p.Seed)
+
+	type playerInputV1 struct {
+		Pos             Pt
+		JustPressed     bool
+		JustReleased    bool
+		TriggerComingUp bool
+	}
+	var history []playerInputV1
+	DeserializeSlice(buf, &history)
+	p.History = make([]PlayerInput, len(history))
+	for i, h := range history {
+		p.History[i].Pos = h.Pos
+		p.History[i].JustPressed = h.JustPressed
+		p.History[i].JustReleased = h.JustReleased
+		p.History[i].TriggerComingUp = h.TriggerComingUp
+	}
+
+	p.InputVersion = 100
+	return p.SerializeUncompressed()
+}
+
+// migrateFromV99 upgrades the InputVersion of the first release, whose
+// PlayerInput had no Device. The release saved playthroughs in the same
+// layout as InputVersion 1. Development builds later saved InputVersion 99
+// in the protobuf layout as well, with a Device already, so those only need
+// the new InputVersion. Their Device is UnknownDevice where it wasn't
+// recorded, which is exactly what it is.
+func migrateFromV99(data []byte) []byte {
+	if !IsProtoPlaythrough(data) {
+		return migrateFromV1(data)
+	}
+	return setProtoInputVersion(data, 100)
+}
+
+// migrateFromV100 upgrades the InputVersion in which PlayerInput had a single
+// pointer. InputVersion 100 was only ever saved in the protobuf layout, where
+// the other pointers are a field of their own, so the playthrough only needs
+// the new InputVersion.
+func migrateFromV100(data []byte) []byte {
+	return setProtoInputVersion(data, 101)
+}
+
+// migrateFromV101 upgrades the InputVersion in which a pause had an input for
+// every frame. Each run of paused inputs becomes a single input, which counts
+// the frames.
+func migrateFromV101(data []byte) []byte {
+	m := unmarshalProto(data)
+	history := make([]*clone1pb.PlayerInput, 0, len(m.History))
+	for _, in := range m.History {
+		if !in.Paused {
+			history = append(history, in)
+		} else if n := len(history); n > 0 && history[n-1].PausedFrames > 0 {
+			history[n-1].PausedFrames++
+		} else {
+			history = append(history, &clone1pb.PlayerInput{PausedFrames: 1,
+				Moment: in.Moment})
+		}
+	}
+	m.History = history
+	m.InputVersion = 102
+	return marshalProto(m)
+}
+
+// setProtoInputVersion is the migration between two InputVersions that have
+// the same protobuf layout.
+func setProtoInputVersion(data []byte, version int64) []byte {
+	m := unmarshalProto(data)
+	m.InputVersion = version
+	return marshalProto(m)
+}
+
+// SetSimulationVersion returns the playthrough file in data with its
+// SimulationVersion set to version and nothing else changed. Unlike
+// deserializing and serializing it again, this keeps a playthrough recorded
+// with an older InputVersion in its layout, so the regression tests keep
+// testing the migrations, see TestWorld_ConvertRegressionTests.
+func SetSimulationVersion(data []byte, version int64) []byte {
+	raw := Decompress(stripFileHeader(data))
+	if IsProtoPlaythrough(raw) {
+		m := unmarshalProto(raw)
+		m.SimulationVersion = version
+		raw = marshalProto(m)
+	} else if playthroughInputVersion(raw) == 1 {
+		// The SimulationVersion comes right after the InputVersion.
+		binary.LittleEndian.PutUint64(raw[8:16], uint64(version))
+	} else {
+		Check(fmt.Errorf("can't set the SimulationVersion of a playthrough "+
+			"in the legacy layout of InputVersion %d",
+			playthroughInputVersion(raw)))
+	}
+
+	// Files without the header are plain zip files.
+	if SniffFormat(data) != PlaythroughFile {
+		return Zip(raw)
+	}
+	return addFileHeader(Compress(raw, CompressionParams{}))
+}
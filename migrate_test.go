@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+)
+
+func TestMigratePlaythrough(t *testing.T) {
+	// The regression tests were recorded with the first InputVersion. After
+	// migrating them, they must still replay the same.
+	tests := GetFiles(os.DirFS(".").(FS), "regression-tests", "*.clone1")
+	for _, test := range tests {
+		data := ReadFile(test)
+		assert.True(t, NeedsMigration(data))
+		p := DeserializePlaythrough(data)
+		assert.Equal(t, int64(InputVersion), p.InputVersion)
+		expected := string(ReadFile(test + "-hash"))
+		assert.Equal(t, expected, RegressionId(p), test)
+
+		// Once migrated, the playthrough is serialized in the current
+		// InputVersion and doesn't need to be migrated again.
+		data = p.Serialize()
+		assert.False(t, NeedsMigration(data))
+		assert.Equal(t, p, DeserializePlaythrough(data))
+	}
+}
+
+func TestSetSimulationVersion(t *testing.T) {
+	// A regression test stays in the layout of the first InputVersion.
+	data := ReadFile("regression-tests/regression-MoveBrick.clone1")
+	converted := SetSimulationVersion(data, 7)
+	assert.True(t, NeedsMigration(converted))
+	p := DeserializePlaythrough(data)
+	p.SimulationVersion = 7
+	assert.Equal(t, p, DeserializePlaythrough(converted))
+
+	// A playthrough in the current layout.
+	p = DeserializePlaythrough(ReadFile("data/demo.clone1"))
+	data = p.Serialize()
+	converted = SetSimulationVersion(data, 7)
+	assert.False(t, NeedsMigration(converted))
+	p.SimulationVersion = 7
+	assert.Equal(t, p, DeserializePlaythrough(converted))
+}
+
+func TestMigratePlaythrough_UnknownVersion(t *testing.T) {
+	p := DeserializePlaythrough(ReadFile("data/demo.clone1"))
+	p.InputVersion = 12345
+	data := p.Serialize()
+	assert.Panics(t, func() { DeserializePlaythrough(data) })
+}
+
+func TestMigratePlaythrough_V99(t *testing.T) {
+	// The release saved InputVersion 99 in the layout of InputVersion 1.
+	buf := new(bytes.Buffer)
+	Serialize(buf, int64(99))
+	Serialize(buf, int64(SimulationVersion))
+	Serialize(buf, int64(5))
+	type brickParamsV1 struct {
+		Pos Pt
+		Val int64
+	}
+	SerializeSlice(buf, []brickParamsV1{{Pt{10, 20}, 3}})
+	SerializeSlice(buf, []ChainParams{})
+	Serialize(buf, true)
+	Serialize(buf, false)
+	Serialize(buf, uuid.New())
+	Serialize(buf, int64(42))
+	type playerInputV1 struct {
+		Pos             Pt
+		JustPressed     bool
+		JustReleased    bool
+		TriggerComingUp bool
+	}
+	SerializeSlice(buf, []playerInputV1{{Pt{1, 2}, true, false, false},
+		{Pt{3, 4}, false, true, true}})
+	released := Zip(buf.Bytes())
+	assert.True(t, NeedsMigration(released))
+	p := DeserializePlaythrough(released)
+	assert.Equal(t, int64(InputVersion), p.InputVersion)
+	assert.Equal(t, int64(42), p.Seed)
+	assert.True(t, p.TimerDisabled)
+	assert.Equal(t, []BrickParams{{Pos: Pt{10, 20}, Val: 3}}, p.BricksParams)
+	assert.Equal(t, []PlayerInput{
+		{Pos: Pt{1, 2}, JustPressed: true},
+		{Pos: Pt{3, 4}, JustReleased: true, TriggerComingUp: true}},
+		p.History)
+
+	// Development builds also saved InputVersion 99 as protobuf.
+	p = DeserializePlaythrough(ReadFile("data/demo.clone1"))
+	p.InputVersion = 99
+	data := p.Serialize()
+	assert.True(t, NeedsMigration(data))
+	migrated := DeserializePlaythrough(data)
+	p.InputVersion = InputVersion
+	assert.Equal(t, p, migrated)
+}
+
+func TestMigratePlaythrough_V100(t *testing.T) {
+	p := DeserializePlaythrough(ReadFile("data/demo.clone1"))
+	p.InputVersion = 100
+	data := p.Serialize()
+	assert.True(t, NeedsMigration(data))
+	migrated := DeserializePlaythrough(data)
+	p.InputVersion = InputVersion
+	assert.Equal(t, p, migrated)
+}
+
+func TestMigratePlaythrough_V101(t *testing.T) {
+	// InputVersion 101 had an input for every frame of a pause.
+	p := DeserializePlaythrough(ReadFile("data/demo.clone1"))
+	p.InputVersion = 101
+	p.History = []PlayerInput{{Pos: Pt{1, 1}}, {Moment: 20}, {Moment: 30},
+		{Pos: Pt{2, 2}}, {}}
+	m := p.ToProto()
+	for _, i := range []int{1, 2, 4} {
+		m.History[i].Paused = true
+	}
+	data := addFileHeader(Compress(marshalProto(m),
+		CompressionParams{Compression: ZipCompression}))
+	assert.True(t, NeedsMigration(data))
+	migrated := DeserializePlaythrough(data)
+	assert.Equal(t, int64(InputVersion), migrated.InputVersion)
+	assert.Equal(t, []PlayerInput{{Pos: Pt{1, 1}},
+		{PausedFrames: 2, Moment: 20}, {Pos: Pt{2, 2}}, {PausedFrames: 1}},
+		migrated.History)
+}
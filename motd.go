@@ -0,0 +1,70 @@
+package main
+
+import "os"
+
+// The message of the day (MOTD) is a short text the server can show on the
+// home screen. It is meant for telling playtesters about things like new
+// builds. The last message received is cached in a local file, so that it is
+// still shown when the server can't be reached. An empty message shows
+// nothing.
+
+const motdCacheFile = "motd.yaml"
+
+type Motd struct {
+	Message string `yaml:"Message"`
+	// The player closed the card showing Message. A new message shows up
+	// again even if the previous one was dismissed.
+	Dismissed bool `yaml:"Dismissed"`
+}
+
+// LoadCachedMotd returns the message cached by the last run, if there is one.
+// The current message is fetched in the background, see FetchMotd, so that
+// the home screen doesn't wait for a server that may be slow or unreachable.
+func LoadCachedMotd() (m Motd) {
+	if FileExists(os.DirFS(".").(FS), motdCacheFile) {
+		LoadYAML(os.DirFS(".").(FS), motdCacheFile, &m)
+	}
+	return
+}
+
+// MotdResponse is the answer of the server to GetMotd.
+type MotdResponse struct {
+	Message string
+	Err     error
+}
+
+// FetchMotd gets the current message from sink and sends it to motdChannel.
+// An announcement from the RemoteConfig takes the place of the message from
+// sink.
+func FetchMotd(sink TelemetrySink, releaseVersion int64, announcement string,
+	motdChannel chan MotdResponse) {
+	if announcement != "" {
+		motdChannel <- MotdResponse{announcement, nil}
+		return
+	}
+	message, err := sink.GetMotd(releaseVersion)
+	motdChannel <- MotdResponse{message, err}
+}
+
+// ReceiveMotd shows the message fetched by FetchMotd, once it arrives.
+func (g *Gui) ReceiveMotd() {
+	select {
+	case r := <-g.motdChannel:
+		// The message is optional, so don't insist if the request failed.
+		m := UpdateMotd(g.motd, r.Message, r.Err)
+		if m != g.motd {
+			g.motd = m
+			SaveYAML(motdCacheFile, m)
+		}
+	default:
+	}
+}
+
+// UpdateMotd decides what to show, given the cached message and the result of
+// asking the server for the current message.
+func UpdateMotd(cached Motd, message string, err error) Motd {
+	if err != nil || message == cached.Message {
+		return cached
+	}
+	return Motd{Message: message}
+}
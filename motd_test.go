@@ -0,0 +1,46 @@
+package main
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestUpdateMotd(t *testing.T) {
+	cached := Motd{Message: "Build 12 is out.", Dismissed: true}
+
+	// Offline, the cached message stays, dismissed or not.
+	assert.Equal(t, cached, UpdateMotd(cached, "", errors.New("offline")))
+
+	// The same message stays dismissed.
+	assert.Equal(t, cached, UpdateMotd(cached, "Build 12 is out.", nil))
+
+	// A new message shows up again.
+	assert.Equal(t, Motd{Message: "Build 13 is out."},
+		UpdateMotd(cached, "Build 13 is out.", nil))
+
+	// The server can take the message down.
+	assert.Equal(t, Motd{}, UpdateMotd(cached, "", nil))
+}
+
+func TestGui_ReceiveMotd(t *testing.T) {
+	var g Gui
+	g.motd = Motd{Message: "Build 12 is out.", Dismissed: true}
+	g.motdChannel = make(chan MotdResponse, 1)
+
+	// The cached message shows until the server answers.
+	g.ReceiveMotd()
+	assert.Equal(t, Motd{Message: "Build 12 is out.", Dismissed: true},
+		g.motd)
+
+	// The announcement of the RemoteConfig doesn't need the server.
+	FetchMotd(NopSink{}, ReleaseVersion, "Build 12 is out.", g.motdChannel)
+	g.ReceiveMotd()
+	assert.Equal(t, Motd{Message: "Build 12 is out.", Dismissed: true},
+		g.motd)
+
+	g.motdChannel <- MotdResponse{"", errors.New("offline")}
+	g.ReceiveMotd()
+	assert.Equal(t, Motd{Message: "Build 12 is out.", Dismissed: true},
+		g.motd)
+}
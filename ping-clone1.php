@@ -0,0 +1,24 @@
+<?php
+require_once "./auth-clone1.php";
+// Answers "ok" to the health checks of the game, see CheckServerHealth. It
+// checks the signature of the request like every other script, so that a
+// release whose requests are rejected doesn't look online.
+
+function LogInfo($message) {
+    // 	file_put_contents("./ping-clone1.log", "INFO: " . $message . "\n", FILE_APPEND);
+}
+
+LogInfo("Start.");
+if ($_SERVER['REQUEST_METHOD'] == 'POST') {
+    VerifyRequest();
+    // A compressed ping with a probe asks whether the bodies of requests may
+    // be compressed. If PHP can read the probe, the server decompressed the
+    // body (see clone1.htaccess) and the game may compress its requests.
+    if (isset($_POST['probe']) && $_POST['probe'] == "gzip") {
+        echo "gzip";
+    } else {
+        echo "ok";
+    }
+}
+LogInfo("End.");
+?>
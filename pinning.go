@@ -0,0 +1,46 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"slices"
+)
+
+// Test builds that are given to players outside the team can pin the keys of
+// the server (see pinning_enabled.go), so that a proxy with its own trusted
+// certificate can't read or change what the game uploads. Only the native
+// builds do it, the browser checks the certificates of the WASM version
+// itself.
+
+// PublicKeyPin is the pin of cert: the sha256 of its public key, in base64,
+// like the pin-sha256 of HPKP. It stays the same when a certificate is
+// renewed with the same key. The pin of the server's certificate is the
+// output of:
+// openssl s_client -connect playful-patterns.com:443 </dev/null |
+// openssl x509 -pubkey -noout | openssl pkey -pubin -outform der |
+// openssl dgst -sha256 -binary | base64
+func PublicKeyPin(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// VerifyPinnedKeys returns a tls.Config.VerifyConnection that only accepts a
+// server if one of the certificates of its verified chain has one of pins.
+// The usual checks of the certificates still happen first. Pinning the key of
+// the CA as well as the server's lets the server get a new key without a new
+// release.
+func VerifyPinnedKeys(pins []string) func(tls.ConnectionState) error {
+	return func(cs tls.ConnectionState) error {
+		for _, chain := range cs.VerifiedChains {
+			for _, cert := range chain {
+				if slices.Contains(pins, PublicKeyPin(cert)) {
+					return nil
+				}
+			}
+		}
+		return errors.New("the certificate of the server is not pinned")
+	}
+}
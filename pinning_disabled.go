@@ -0,0 +1,21 @@
+//go:build !pinning_enabled || js
+
+package main
+
+import (
+	"crypto/tls"
+	"net/http"
+	"time"
+)
+
+// serverTlsConfig is the TLS config of the connections to the server. Builds
+// without pinning_enabled accept every certificate the system trusts.
+func serverTlsConfig() *tls.Config {
+	return &tls.Config{}
+}
+
+// newHttpClient returns the client of makeHttpRequest, which gives up on a
+// request after timeout.
+func newHttpClient(timeout time.Duration) *http.Client {
+	return &http.Client{Timeout: timeout}
+}
@@ -0,0 +1,36 @@
+//go:build pinning_enabled && !js
+
+package main
+
+import (
+	"crypto/tls"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// The pins of the keys that the server may have, separated by commas, see
+// PublicKeyPin. Like releaseSecret, they are set when the release is built:
+// go build -tags pinning_enabled -ldflags "-X main.pinnedKeys=..."
+// See cmd/release, which takes them from the CLONE1_PINNED_KEYS environment
+// variable.
+var pinnedKeys = ""
+
+// serverTlsConfig is the TLS config of the connections to the server. Builds
+// with pinning_enabled only accept the pinned keys, so without pins they
+// can't reach any server.
+func serverTlsConfig() *tls.Config {
+	var pins []string
+	if pinnedKeys != "" {
+		pins = strings.Split(pinnedKeys, ",")
+	}
+	return &tls.Config{VerifyConnection: VerifyPinnedKeys(pins)}
+}
+
+// newHttpClient returns the client of makeHttpRequest, which gives up on a
+// request after timeout.
+func newHttpClient(timeout time.Duration) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = serverTlsConfig()
+	return &http.Client{Transport: transport, Timeout: timeout}
+}
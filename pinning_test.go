@@ -0,0 +1,28 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestVerifyPinnedKeys(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	get := func(pins []string) error {
+		transport := server.Client().Transport.(*http.Transport).Clone()
+		transport.TLSClientConfig.VerifyConnection = VerifyPinnedKeys(pins)
+		response, err := (&http.Client{Transport: transport}).Get(server.URL)
+		if err == nil {
+			_ = response.Body.Close()
+		}
+		return err
+	}
+
+	pin := PublicKeyPin(server.Certificate())
+	assert.Nil(t, get([]string{"AAAA", pin}))
+	assert.NotNil(t, get([]string{"AAAA"}))
+	assert.NotNil(t, get(nil))
+}
@@ -2,14 +2,20 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"github.com/google/uuid"
+	"hash/crc32"
 	"slices"
 )
 
 // InputVersion is the version of the byte representation of the Playthrough
 // structure. If the Playthrough structure changes such that serializing it
 // produces a different array of bytes, then InputVersion must change as well.
+// Adding a field is the exception: a Playthrough is a protobuf message (see
+// proto.go), so old executables skip the fields they don't know about and new
+// executables leave the fields that are missing at their zero values.
+// Changing what an existing field holds is not an exception.
 // InputVersion is meant to track changes to saved playthroughs. I want
 // SimulationVersion to indicate an abstract simulation. However, when we
 // record playthroughs, we can't be abstract anymore, we need actual bytes.
@@ -19,7 +25,7 @@ import (
 // Playthrough structure and translating it to the new one.
 // Out of the 3 versions (ReleaseVersion, SimulationVersion and InputVersion),
 // the InputVersion is the one expected to change the least often.
-const InputVersion = 99
+const InputVersion = 102
 
 // Playthrough represents all the input sent to a World during the execution
 // of a level. Given this input and a compatible simulation, the same output
@@ -29,49 +35,329 @@ type Playthrough struct {
 	SimulationVersion int64
 	ReleaseVersion    int64
 	Level
-	Id      uuid.UUID
-	Seed    int64
-	History []PlayerInput
+	Id       uuid.UUID
+	Seed     int64
+	History  []PlayerInput
+	Metadata Metadata
+	// The FrameHash of the World after the last input in History, as the
+	// executable that recorded the playthrough computed it. Replaying the
+	// playthrough anywhere else must end with the same hash, see Replay. It is
+	// zero if it is not known, e.g. for a recording of a crash, which stops in
+	// the middle of a frame.
+	FinalHash FrameHash
 }
 
 func (p *Playthrough) Serialize() []byte {
+	return p.SerializeWith(CompressionParams{Compression: ZipCompression})
+}
+
+// SerializeWith is the same as Serialize, but compressed with c.
+func (p *Playthrough) SerializeWith(c CompressionParams) []byte {
+	return addFileHeader(Compress(p.SerializeUncompressed(), c))
+}
+
+// The ids of the fields of a Playthrough in the legacy layout, see
+// SerializeField. The InputVersion comes first and has no id, so that it can
+// be read without knowing anything else about the layout.
+const (
+	playthroughSimulationVersion int64 = iota + 1
+	playthroughReleaseVersion
+	playthroughLevel
+	playthroughId
+	playthroughSeed
+	playthroughHistory
+	playthroughMetadata
+	playthroughFinalHash
+)
+
+// SerializeUncompressed returns the bytes that Serialize compresses: the
+// protobuf layout, followed by a checksum.
+func (p *Playthrough) SerializeUncompressed() []byte {
+	buf := bytes.NewBuffer(serializeProto(p))
+	Serialize(buf, crc32.ChecksumIEEE(buf.Bytes()))
+	return buf.Bytes()
+}
+
+// SerializeLegacy is SerializeUncompressed in the layout that was used before
+// protobuf. Playthroughs are no longer saved like this, but the ones that
+// were can still be read, and this is what the reader is tested with.
+func (p *Playthrough) SerializeLegacy() []byte {
 	buf := new(bytes.Buffer)
 	Serialize(buf, p.InputVersion)
-	Serialize(buf, p.SimulationVersion)
-	Serialize(buf, p.ReleaseVersion)
-	SerializeSlice(buf, p.BricksParams)
-	SerializeSlice(buf, p.ChainsParams)
-	Serialize(buf, p.TimerDisabled)
-	Serialize(buf, p.AllowOverlappingDrags)
-	Serialize(buf, p.Id)
-	Serialize(buf, p.Seed)
-	SerializeSlice(buf, p.History)
-	return Zip(buf.Bytes())
+	SerializeValueField(buf, playthroughSimulationVersion,
+		p.SimulationVersion)
+	SerializeValueField(buf, playthroughReleaseVersion, p.ReleaseVersion)
+	SerializeField(buf, playthroughLevel, func(buf *bytes.Buffer) {
+		SerializeLevel(buf, &p.Level)
+	})
+	SerializeValueField(buf, playthroughId, p.Id)
+	SerializeValueField(buf, playthroughSeed, p.Seed)
+	SerializeField(buf, playthroughHistory, func(buf *bytes.Buffer) {
+		SerializeSlice(buf, toLegacyInputs(p.History))
+	})
+	SerializeField(buf, playthroughMetadata, func(buf *bytes.Buffer) {
+		SerializeMetadata(buf, &p.Metadata)
+	})
+	SerializeValueField(buf, playthroughFinalHash, p.FinalHash)
+	Serialize(buf, crc32.ChecksumIEEE(buf.Bytes()))
+	return buf.Bytes()
+}
+
+// ToJSON is the same as Serialize, but in JSON, for tools that are not
+// written in Go and would rather not read the binary format. The fields keep
+// their Go names and enums are numbers.
+func (p *Playthrough) ToJSON() []byte {
+	data, err := json.Marshal(p)
+	Check(err)
+	return data
+}
+
+// PlaythroughFromJSON reads a Playthrough written by ToJSON.
+func PlaythroughFromJSON(data []byte) (p Playthrough) {
+	Check(json.Unmarshal(data, &p))
+	if p.InputVersion != InputVersion {
+		Check(fmt.Errorf("can't read this playthrough - we are at "+
+			"InputVersion %d and playthrough was generated with InputVersion "+
+			"version %d",
+			InputVersion, p.InputVersion))
+	}
+	return
 }
 
 func (p *Playthrough) Clone() *Playthrough {
 	clone := *p
 	clone.History = slices.Clone(p.History)
+	clone.Metadata.BuildTags = slices.Clone(p.Metadata.BuildTags)
 	return &clone
 }
 
+// The following functions edit the History, for crafting minimal regression
+// tests from real recordings. They don't care if the result makes sense, e.g.
+// deleting the frame in which a brick was released leaves the brick dragged
+// until the next release.
+// The World no longer ends the way it did in the recording, so they clear the
+// FinalHash.
+
+func (p *Playthrough) DeleteInput(frameIdx int64) {
+	p.History = slices.Delete(p.History, int(frameIdx), int(frameIdx)+1)
+	p.FinalHash = FrameHash{}
+}
+
+// InsertInput inserts input before frameIdx, which becomes frameIdx+1.
+func (p *Playthrough) InsertInput(frameIdx int64, input PlayerInput) {
+	p.History = slices.Insert(p.History, int(frameIdx), input)
+	p.FinalHash = FrameHash{}
+}
+
+// NudgeInput moves the position of the first pointer at frameIdx by d.
+func (p *Playthrough) NudgeInput(frameIdx int64, d Pt) {
+	p.History[frameIdx].Pos = p.History[frameIdx].Pos.Plus(d)
+	p.FinalHash = FrameHash{}
+}
+
+// PausedFrames returns the number of frames in which the game was paused.
+func (p *Playthrough) PausedFrames() (n int64) {
+	for i := range p.History {
+		n += p.History[i].PausedFrames
+	}
+	return
+}
+
+// PlayedFrames returns the number of frames in which the player was actually
+// playing, which is how long the playthrough took minus the pauses.
+func (p *Playthrough) PlayedFrames() (n int64) {
+	for i := range p.History {
+		if p.History[i].PausedFrames == 0 {
+			n++
+		}
+	}
+	return
+}
+
+// DeserializePlaythrough reads a Playthrough written by Serialize or
+// SerializeWith, or a RecordingStream. Playthroughs recorded with an older
+// InputVersion are migrated to the current one first.
 func DeserializePlaythrough(data []byte) (p Playthrough) {
-	buf := bytes.NewBuffer(Unzip(data))
-	Deserialize(buf, &p.InputVersion)
-	if p.InputVersion != InputVersion {
-		Check(fmt.Errorf("can't deserialize this playthrough - we are at "+
-			"InputVersion %d and playthrough was generated with InputVersion "+
-			"version %d",
-			InputVersion, p.InputVersion))
+	if IsRecordingStream(data) {
+		return RecoverPlaythrough(data)
+	}
+	return DeserializeUncompressed(decompressRecording(stripFileHeader(data)))
+}
+
+// decompressRecording is Decompress, with an error that says what most likely
+// happened, e.g. a download that was cut short.
+func decompressRecording(data []byte) []byte {
+	defer func() {
+		if r := recover(); r != nil {
+			Check(fmt.Errorf("corrupted recording, can't decompress it: %v",
+				r))
+		}
+	}()
+	return Decompress(data)
+}
+
+// DeserializeUncompressed reads the bytes written by SerializeUncompressed or
+// SerializeLegacy.
+func DeserializeUncompressed(data []byte) (p Playthrough) {
+	data = VerifyChecksum(MigratePlaythrough(data))
+	if IsProtoPlaythrough(data) {
+		return deserializeProto(data)
 	}
-	Deserialize(buf, &p.SimulationVersion)
-	Deserialize(buf, &p.ReleaseVersion)
-	DeserializeSlice(buf, &p.BricksParams)
-	DeserializeSlice(buf, &p.ChainsParams)
-	Deserialize(buf, &p.TimerDisabled)
-	Deserialize(buf, &p.AllowOverlappingDrags)
-	Deserialize(buf, &p.Id)
-	Deserialize(buf, &p.Seed)
-	DeserializeSlice(buf, &p.History)
+	buf := bytes.NewBuffer(data)
+	Deserialize(buf, &p.InputVersion)
+	fields := DeserializeFields(buf)
+	DeserializeValueField(fields, playthroughSimulationVersion,
+		&p.SimulationVersion)
+	DeserializeValueField(fields, playthroughReleaseVersion,
+		&p.ReleaseVersion)
+	DeserializeField(fields, playthroughLevel, func(buf *bytes.Buffer) {
+		DeserializeLevel(buf, &p.Level)
+	})
+	DeserializeValueField(fields, playthroughId, &p.Id)
+	DeserializeValueField(fields, playthroughSeed, &p.Seed)
+	DeserializeField(fields, playthroughHistory, func(buf *bytes.Buffer) {
+		var history []playerInputLegacy
+		DeserializeSlice(buf, &history)
+		p.History = fromLegacyInputs(history)
+	})
+	DeserializeField(fields, playthroughMetadata, func(buf *bytes.Buffer) {
+		DeserializeMetadata(buf, &p.Metadata)
+	})
+	DeserializeValueField(fields, playthroughFinalHash, &p.FinalHash)
 	return
 }
+
+// playerInputLegacy is a PlayerInput as it was saved in the legacy layout,
+// before it had a Moment. It has a Paused input for every frame of a pause.
+type playerInputLegacy struct {
+	Pos                Pt
+	JustPressed        bool
+	JustReleased       bool
+	OtherPointers      [MaxPointers - 1]PointerInput
+	TriggerComingUp    bool
+	TriggerGravityFlip bool
+	CancelDrag         bool
+	RotateChain        bool
+	PushNow            bool
+	Paused             bool
+	Device             InputDevice
+}
+
+func toLegacyInputs(history []PlayerInput) []playerInputLegacy {
+	legacy := make([]playerInputLegacy, 0, len(history))
+	for _, in := range history {
+		if in.PausedFrames > 0 {
+			for range in.PausedFrames {
+				legacy = append(legacy, playerInputLegacy{Paused: true})
+			}
+			continue
+		}
+		legacy = append(legacy, playerInputLegacy{in.Pos, in.JustPressed,
+			in.JustReleased, in.OtherPointers, in.TriggerComingUp,
+			in.TriggerGravityFlip, in.CancelDrag, in.RotateChain, in.PushNow,
+			false, in.Device})
+	}
+	return legacy
+}
+
+func fromLegacyInputs(legacy []playerInputLegacy) []PlayerInput {
+	if legacy == nil {
+		return nil
+	}
+	history := make([]PlayerInput, 0, len(legacy))
+	for _, in := range legacy {
+		history = appendPerFrameInput(history, PlayerInput{Pos: in.Pos,
+			JustPressed: in.JustPressed, JustReleased: in.JustReleased,
+			OtherPointers: in.OtherPointers, TriggerComingUp: in.TriggerComingUp,
+			TriggerGravityFlip: in.TriggerGravityFlip, CancelDrag: in.CancelDrag,
+			RotateChain: in.RotateChain, PushNow: in.PushNow,
+			Device: in.Device}, in.Paused)
+	}
+	return history
+}
+
+// appendPerFrameInput appends in to history, for the layouts that have an
+// input for every frame of a pause: if paused, in extends the pause at the
+// end of history, or starts a new one, instead of being appended.
+func appendPerFrameInput(history []PlayerInput, in PlayerInput,
+	paused bool) []PlayerInput {
+	if !paused {
+		return append(history, in)
+	}
+	if n := len(history); n > 0 && history[n-1].PausedFrames > 0 {
+		history[n-1].PausedFrames++
+		return history
+	}
+	return append(history, PlayerInput{PausedFrames: 1, Moment: in.Moment})
+}
+
+// The ids of the fields of a Level in the legacy layout.
+const (
+	levelBricksParams int64 = iota + 1
+	levelChainsParams
+	levelTimerDisabled
+	levelAllowOverlappingDrags
+	levelDifficultyParams
+	levelMode
+	levelPushNowEnabled
+	levelMaxBrickValue
+	levelMaxInitialBrickValue
+)
+
+// SerializeLevel writes every field of the Level in the legacy layout, see
+// SerializeLegacy. A field added to Level must be added to levelToProto and
+// levelFromProto instead.
+func SerializeLevel(buf *bytes.Buffer, l *Level) {
+	SerializeField(buf, levelBricksParams, func(buf *bytes.Buffer) {
+		SerializeSlice(buf, l.BricksParams)
+	})
+	SerializeField(buf, levelChainsParams, func(buf *bytes.Buffer) {
+		SerializeSlice(buf, l.ChainsParams)
+	})
+	SerializeValueField(buf, levelTimerDisabled, l.TimerDisabled)
+	SerializeValueField(buf, levelAllowOverlappingDrags,
+		l.AllowOverlappingDrags)
+	SerializeValueField(buf, levelDifficultyParams, l.DifficultyParams)
+	SerializeValueField(buf, levelMode, l.Mode)
+	SerializeValueField(buf, levelPushNowEnabled, l.PushNowEnabled)
+	SerializeValueField(buf, levelMaxBrickValue, l.MaxBrickValue)
+	SerializeValueField(buf, levelMaxInitialBrickValue,
+		l.MaxInitialBrickValue)
+}
+
+func DeserializeLevel(buf *bytes.Buffer, l *Level) {
+	fields := DeserializeFields(buf)
+	DeserializeField(fields, levelBricksParams, func(buf *bytes.Buffer) {
+		DeserializeSlice(buf, &l.BricksParams)
+	})
+	DeserializeField(fields, levelChainsParams, func(buf *bytes.Buffer) {
+		DeserializeSlice(buf, &l.ChainsParams)
+	})
+	DeserializeValueField(fields, levelTimerDisabled, &l.TimerDisabled)
+	DeserializeValueField(fields, levelAllowOverlappingDrags,
+		&l.AllowOverlappingDrags)
+	DeserializeValueField(fields, levelDifficultyParams, &l.DifficultyParams)
+	DeserializeValueField(fields, levelMode, &l.Mode)
+	DeserializeValueField(fields, levelPushNowEnabled, &l.PushNowEnabled)
+	DeserializeValueField(fields, levelMaxBrickValue, &l.MaxBrickValue)
+	DeserializeValueField(fields, levelMaxInitialBrickValue,
+		&l.MaxInitialBrickValue)
+}
+
+// VerifyChecksum checks the CRC32 that SerializeUncompressed and
+// SerializeLegacy put at the end of data and returns data without it.
+func VerifyChecksum(data []byte) []byte {
+	const checksumSize = 4
+	if len(data) < checksumSize {
+		Check(fmt.Errorf("corrupted recording, it only has %d bytes",
+			len(data)))
+	}
+	payload := data[:len(data)-checksumSize]
+	var checksum uint32
+	Deserialize(bytes.NewReader(data[len(payload):]), &checksum)
+	if crc32.ChecksumIEEE(payload) != checksum {
+		Check(fmt.Errorf("corrupted recording, the checksum doesn't match - " +
+			"the file was probably cut short or damaged"))
+	}
+	return payload
+}
@@ -1,6 +1,9 @@
 package main
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 type Pt struct {
 	X int64
@@ -65,9 +68,12 @@ func (p Pt) MarshalYAML() ([]byte, error) {
 	return []byte(s), nil
 }
 
+// UnmarshalYAML reads what MarshalYAML writes, with or without spaces, as the
+// YAML library may reformat it when it writes a whole file.
 func (p *Pt) UnmarshalYAML(b []byte) error {
 	s := string(b)
-	n, err := fmt.Sscanf(s, "[ %d, %d ]", &p.X, &p.Y)
+	n, err := fmt.Sscanf(strings.ReplaceAll(s, " ", ""), "[%d,%d]", &p.X,
+		&p.Y)
 	if n != 2 {
 		Check(fmt.Errorf("failed to get exactly 2 int64 from string %s", s))
 	}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// StepTiming is how long one World.Step took, in wall time.
+type StepTiming struct {
+	FrameIdx int64
+	Total    time.Duration
+	// Each phase of a Regular step. The first phase also includes what the
+	// step does before it. All are 0 for a step that isn't Regular.
+	Phases [AllPhases]time.Duration
+	// How crowded the World was, which is what usually makes a step slow.
+	NBricks int64
+	State   WorldState
+}
+
+// Profiler measures every World.Step it runs, so that hitches can be traced
+// back to specific frames and World configurations. Measuring doesn't change
+// the World.
+type Profiler struct {
+	Timings  []StepTiming
+	frameIdx int64
+	lastMark time.Time
+	current  StepTiming
+}
+
+// Step runs w.Step(input) and records how long it took.
+func (p *Profiler) Step(w *World, input PlayerInput) {
+	// New Worlds and clones come without hooks.
+	if len(w.Hooks.OnPhaseDone) == 0 {
+		w.OnPhaseDone(p.phaseDone)
+	}
+
+	p.current = StepTiming{FrameIdx: p.frameIdx}
+	start := time.Now()
+	p.lastMark = start
+	w.Step(input)
+	p.current.Total = time.Since(start)
+	p.current.NBricks = int64(len(w.Bricks))
+	p.current.State = w.State
+	p.Timings = append(p.Timings, p.current)
+	p.frameIdx++
+}
+
+func (p *Profiler) phaseDone(phase StepPhase) {
+	now := time.Now()
+	p.current.Phases[phase] = now.Sub(p.lastMark)
+	p.lastMark = now
+}
+
+// Reset forgets the timings, e.g. when a new game starts.
+func (p *Profiler) Reset() {
+	p.Timings = p.Timings[:0]
+	p.frameIdx = 0
+}
+
+// The upper limits of the buckets of the histogram in Report. The last bucket
+// has no upper limit.
+var profileBuckets = []time.Duration{
+	100 * time.Microsecond,
+	250 * time.Microsecond,
+	500 * time.Microsecond,
+	time.Millisecond,
+	2 * time.Millisecond,
+	4 * time.Millisecond,
+	8 * time.Millisecond,
+	16 * time.Millisecond,
+}
+
+// Report is a summary of the timings for reading: a histogram of the step
+// times, the average and maximum of each phase and the slowest steps.
+func (p *Profiler) Report() string {
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "steps: %d\n\nstep time histogram:\n", len(p.Timings))
+	counts := make([]int, len(profileBuckets)+1)
+	for _, t := range p.Timings {
+		i, _ := slices.BinarySearch(profileBuckets, t.Total)
+		counts[i]++
+	}
+	for i, count := range counts {
+		if i < len(profileBuckets) {
+			fmt.Fprintf(buf, "  < %-8v %d\n", profileBuckets[i], count)
+		} else {
+			fmt.Fprintf(buf, "  >= %-7v %d\n", profileBuckets[i-1], count)
+		}
+	}
+
+	fmt.Fprintf(buf, "\nphases (average, max):\n")
+	for phase := range AllPhases {
+		var sum, maxDuration time.Duration
+		for _, t := range p.Timings {
+			sum += t.Phases[phase]
+			maxDuration = max(maxDuration, t.Phases[phase])
+		}
+		avg := time.Duration(0)
+		if len(p.Timings) > 0 {
+			avg = sum / time.Duration(len(p.Timings))
+		}
+		fmt.Fprintf(buf, "  %-22v %v, %v\n", phase, avg, maxDuration)
+	}
+
+	fmt.Fprintf(buf, "\nslowest steps:\n")
+	slowest := slices.Clone(p.Timings)
+	slices.SortFunc(slowest, func(a, b StepTiming) int {
+		return int(b.Total - a.Total)
+	})
+	for _, t := range slowest[:min(len(slowest), 10)] {
+		fmt.Fprintf(buf, "  frame %d: %v, %d bricks, %v\n", t.FrameIdx,
+			t.Total, t.NBricks, t.State)
+	}
+	return buf.String()
+}
+
+// CSV is every timing, one step per line, for plotting and for matching
+// frames with a playback.
+func (p *Profiler) CSV() []byte {
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "frame,total_ns")
+	for phase := range AllPhases {
+		fmt.Fprintf(buf, ",%v_ns", phase)
+	}
+	fmt.Fprintf(buf, ",bricks,state\n")
+	for _, t := range p.Timings {
+		fmt.Fprintf(buf, "%d,%d", t.FrameIdx, t.Total.Nanoseconds())
+		for _, d := range t.Phases {
+			fmt.Fprintf(buf, ",%d", d.Nanoseconds())
+		}
+		fmt.Fprintf(buf, ",%d,%v\n", t.NBricks, t.State)
+	}
+	return buf.Bytes()
+}
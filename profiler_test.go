@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProfiler(t *testing.T) {
+	playthrough := DeserializePlaythrough(ReadFile("data/demo.clone1"))
+	playthrough.History = playthrough.History[:2000]
+
+	var p Profiler
+	w := NewWorldFromPlaythrough(playthrough)
+	for _, input := range playthrough.History {
+		p.Step(&w, input)
+	}
+
+	// Measuring doesn't change the World.
+	expected := NewWorldFromPlaythrough(playthrough)
+	for _, input := range playthrough.History {
+		expected.Step(input)
+	}
+	assert.Equal(t, expected.StateBytes(), w.StateBytes())
+
+	assert.Len(t, p.Timings, 2000)
+	assert.Equal(t, int64(1999), p.Timings[1999].FrameIdx)
+	measuredPhases := false
+	for _, timing := range p.Timings {
+		assert.Greater(t, timing.Total, time.Duration(0))
+		if timing.State == Regular && timing.Phases[MergePhase] > 0 {
+			measuredPhases = true
+		}
+	}
+	assert.True(t, measuredPhases)
+
+	assert.True(t, strings.HasPrefix(p.Report(), "steps: 2000\n"))
+	assert.Equal(t, 2001, bytes.Count(p.CSV(), []byte("\n")))
+
+	p.Reset()
+	assert.Empty(t, p.Timings)
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/marisvali/clone1/clone1pb"
+	"google.golang.org/protobuf/proto"
+)
+
+// A serialized Playthrough is a protobuf message, see
+// clone1pb/playthrough.proto, so that the server and the analysis tools, in
+// any language, can read it with the same schema as the game. Its
+// uncompressed bytes are protoMagic, then the message, then the checksum of
+// both (see VerifyChecksum). protoMagic can't be the start of the legacy
+// layout, which starts with the InputVersion as an int64 and so has a 0 as
+// its second byte.
+var protoMagic = []byte("C1PB")
+
+// IsProtoPlaythrough returns true if data, which are the uncompressed bytes
+// of a Playthrough, are in the protobuf layout, as opposed to the legacy one
+// written by SerializeLegacy.
+func IsProtoPlaythrough(data []byte) bool {
+	return bytes.HasPrefix(data, protoMagic)
+}
+
+func serializeProto(p *Playthrough) []byte {
+	msg, err := proto.Marshal(p.ToProto())
+	Check(err)
+	return append(bytes.Clone(protoMagic), msg...)
+}
+
+func deserializeProto(data []byte) (p Playthrough) {
+	var m clone1pb.Playthrough
+	err := proto.Unmarshal(data[len(protoMagic):], &m)
+	if err != nil {
+		Check(fmt.Errorf("corrupted recording, can't read it: %w", err))
+	}
+	if m.InputVersion != InputVersion {
+		Check(fmt.Errorf("can't deserialize this playthrough - we are at "+
+			"InputVersion %d and playthrough was generated with "+
+			"InputVersion %d", InputVersion, m.InputVersion))
+	}
+	return PlaythroughFromProto(&m)
+}
+
+// ToProto returns p as a protobuf message.
+func (p *Playthrough) ToProto() *clone1pb.Playthrough {
+	m := &clone1pb.Playthrough{
+		InputVersion:      p.InputVersion,
+		SimulationVersion: p.SimulationVersion,
+		ReleaseVersion:    p.ReleaseVersion,
+		Level:             levelToProto(&p.Level),
+		Id:                p.Id[:],
+		Seed:              p.Seed,
+		Metadata:          metadataToProto(&p.Metadata),
+	}
+	if p.FinalHash != (FrameHash{}) {
+		m.FinalHash = p.FinalHash[:]
+	}
+	m.History = make([]*clone1pb.PlayerInput, len(p.History))
+	for i := range p.History {
+		m.History[i] = playerInputToProto(&p.History[i])
+	}
+	return m
+}
+
+// PlaythroughFromProto returns the Playthrough in m.
+func PlaythroughFromProto(m *clone1pb.Playthrough) (p Playthrough) {
+	p.InputVersion = m.InputVersion
+	p.SimulationVersion = m.SimulationVersion
+	p.ReleaseVersion = m.ReleaseVersion
+	p.Level = levelFromProto(m.Level)
+	if len(m.Id) > 0 {
+		id, err := uuid.FromBytes(m.Id)
+		Check(err)
+		p.Id = id
+	}
+	p.Seed = m.Seed
+	p.Metadata = metadataFromProto(m.Metadata)
+	if len(m.FinalHash) > 0 {
+		if len(m.FinalHash) != len(p.FinalHash) {
+			Check(fmt.Errorf("invalid final hash, it has %d bytes instead "+
+				"of %d", len(m.FinalHash), len(p.FinalHash)))
+		}
+		copy(p.FinalHash[:], m.FinalHash)
+	}
+	if len(m.History) > 0 {
+		p.History = make([]PlayerInput, len(m.History))
+		for i := range m.History {
+			p.History[i] = playerInputFromProto(m.History[i])
+		}
+	}
+	return
+}
+
+func ptToProto(p Pt) *clone1pb.Pt {
+	return &clone1pb.Pt{X: p.X, Y: p.Y}
+}
+
+func ptFromProto(m *clone1pb.Pt) Pt {
+	return Pt{m.GetX(), m.GetY()}
+}
+
+func levelToProto(l *Level) *clone1pb.Level {
+	m := &clone1pb.Level{
+		TimerDisabled:         l.TimerDisabled,
+		AllowOverlappingDrags: l.AllowOverlappingDrags,
+		Mode:                  clone1pb.GameMode(l.Mode),
+		PushNowEnabled:        l.PushNowEnabled,
+		MaxBrickValue:         l.MaxBrickValue,
+		MaxInitialBrickValue:  l.MaxInitialBrickValue,
+		DifficultyParams: &clone1pb.DifficultyParams{
+			TimerCooldownBase:       l.TimerCooldownBase,
+			TimerCooldownPerVal:     l.TimerCooldownPerVal,
+			NewRowMaxValMargin:      l.NewRowMaxValMargin,
+			ChainsMinVal:            l.ChainsMinVal,
+			ValsPerChain:            l.ValsPerChain,
+			ComingUpDeceleration:    l.ComingUpDeceleration,
+			MaxChainLength:          l.MaxChainLength,
+			EndlessSpeedupPerRow:    l.EndlessSpeedupPerRow,
+			EndlessMinTimerCooldown: l.EndlessMinTimerCooldown,
+			PushNowBonusPerSec:      l.PushNowBonusPerSec,
+			GravityFlipDuration:     l.GravityFlipDuration,
+		},
+	}
+	for _, b := range l.BricksParams {
+		m.BricksParams = append(m.BricksParams, &clone1pb.BrickParams{
+			Pos: ptToProto(b.Pos), Val: b.Val, Stone: b.Stone})
+	}
+	for _, c := range l.ChainsParams {
+		m.ChainsParams = append(m.ChainsParams,
+			&clone1pb.ChainParams{Brick1: c.Brick1, Brick2: c.Brick2})
+	}
+	return m
+}
+
+func levelFromProto(m *clone1pb.Level) (l Level) {
+	l.TimerDisabled = m.GetTimerDisabled()
+	l.AllowOverlappingDrags = m.GetAllowOverlappingDrags()
+	l.Mode = GameMode(m.GetMode())
+	l.PushNowEnabled = m.GetPushNowEnabled()
+	l.MaxBrickValue = m.GetMaxBrickValue()
+	l.MaxInitialBrickValue = m.GetMaxInitialBrickValue()
+	d := m.GetDifficultyParams()
+	l.DifficultyParams = DifficultyParams{
+		TimerCooldownBase:       d.GetTimerCooldownBase(),
+		TimerCooldownPerVal:     d.GetTimerCooldownPerVal(),
+		NewRowMaxValMargin:      d.GetNewRowMaxValMargin(),
+		ChainsMinVal:            d.GetChainsMinVal(),
+		ValsPerChain:            d.GetValsPerChain(),
+		ComingUpDeceleration:    d.GetComingUpDeceleration(),
+		MaxChainLength:          d.GetMaxChainLength(),
+		EndlessSpeedupPerRow:    d.GetEndlessSpeedupPerRow(),
+		EndlessMinTimerCooldown: d.GetEndlessMinTimerCooldown(),
+		PushNowBonusPerSec:      d.GetPushNowBonusPerSec(),
+		GravityFlipDuration:     d.GetGravityFlipDuration(),
+	}
+	for _, b := range m.GetBricksParams() {
+		l.BricksParams = append(l.BricksParams, BrickParams{
+			Pos: ptFromProto(b.Pos), Val: b.Val, Stone: b.Stone})
+	}
+	for _, c := range m.GetChainsParams() {
+		l.ChainsParams = append(l.ChainsParams,
+			ChainParams{c.Brick1, c.Brick2})
+	}
+	return
+}
+
+func playerInputToProto(in *PlayerInput) *clone1pb.PlayerInput {
+	m := &clone1pb.PlayerInput{
+		Pos:                ptToProto(in.Pos),
+		JustPressed:        in.JustPressed,
+		JustReleased:       in.JustReleased,
+		TriggerComingUp:    in.TriggerComingUp,
+		TriggerGravityFlip: in.TriggerGravityFlip,
+		CancelDrag:         in.CancelDrag,
+		RotateChain:        in.RotateChain,
+		PushNow:            in.PushNow,
+		PausedFrames:       in.PausedFrames,
+		Device:             clone1pb.InputDevice(in.Device),
+		Moment:             in.Moment,
+	}
+	for _, o := range in.OtherPointers {
+		m.OtherPointers = append(m.OtherPointers, &clone1pb.PointerInput{
+			Pos:          ptToProto(o.Pos),
+			JustPressed:  o.JustPressed,
+			JustReleased: o.JustReleased,
+		})
+	}
+	return m
+}
+
+func playerInputFromProto(m *clone1pb.PlayerInput) (in PlayerInput) {
+	in.Pos = ptFromProto(m.Pos)
+	in.JustPressed = m.JustPressed
+	in.JustReleased = m.JustReleased
+	in.TriggerComingUp = m.TriggerComingUp
+	in.TriggerGravityFlip = m.TriggerGravityFlip
+	in.CancelDrag = m.CancelDrag
+	in.RotateChain = m.RotateChain
+	in.PushNow = m.PushNow
+	in.PausedFrames = m.PausedFrames
+	in.Device = InputDevice(m.Device)
+	in.Moment = m.Moment
+	if len(m.OtherPointers) > len(in.OtherPointers) {
+		Check(fmt.Errorf("an input has %d pointers, at most %d are "+
+			"supported", len(m.OtherPointers)+1, MaxPointers))
+	}
+	for i, o := range m.OtherPointers {
+		in.OtherPointers[i] = PointerInput{ptFromProto(o.Pos), o.JustPressed,
+			o.JustReleased}
+	}
+	return
+}
+
+func metadataToProto(md *Metadata) *clone1pb.Metadata {
+	return &clone1pb.Metadata{
+		Os:           md.OS,
+		Arch:         md.Arch,
+		Wasm:         md.Wasm,
+		ScreenWidth:  md.ScreenWidth,
+		ScreenHeight: md.ScreenHeight,
+		BuildTags:    md.BuildTags,
+		StartMoment:  md.StartMoment,
+		RemoteConfig: md.RemoteConfig,
+		OptedOut:     md.OptedOut,
+		Endpoints:    md.Endpoints,
+	}
+}
+
+func metadataFromProto(m *clone1pb.Metadata) (md Metadata) {
+	md.OS = m.GetOs()
+	md.Arch = m.GetArch()
+	md.Wasm = m.GetWasm()
+	md.ScreenWidth = m.GetScreenWidth()
+	md.ScreenHeight = m.GetScreenHeight()
+	md.BuildTags = m.GetBuildTags()
+	md.StartMoment = m.GetStartMoment()
+	md.RemoteConfig = m.GetRemoteConfig()
+	md.OptedOut = m.GetOptedOut()
+	md.Endpoints = m.GetEndpoints()
+	return
+}
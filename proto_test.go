@@ -0,0 +1,33 @@
+package main
+
+import (
+	"github.com/marisvali/clone1/clone1pb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"testing"
+)
+
+func TestPlaythrough_Proto(t *testing.T) {
+	p := DeserializePlaythrough(ReadFile("data/demo.clone1"))
+	p.FinalHash = FrameHash{1, 2, 3}
+	p.History[10].OtherPointers[0] = PointerInput{Pt{5, 6}, true, false}
+
+	// Other tools read the message with the generated code, without going
+	// through package main.
+	data := p.SerializeUncompressed()
+	assert.True(t, IsProtoPlaythrough(data))
+	var m clone1pb.Playthrough
+	assert.NoError(t, proto.Unmarshal(data[len(protoMagic):len(data)-4], &m))
+	assert.Equal(t, p.Seed, m.Seed)
+	assert.Equal(t, len(p.History), len(m.History))
+	assert.Equal(t, int64(5), m.History[10].OtherPointers[0].Pos.X)
+	assert.Equal(t, p, PlaythroughFromProto(&m))
+
+	// A Playthrough in the legacy layout reads the same.
+	legacy := DeserializePlaythrough(Zip(p.SerializeLegacy()))
+	assert.Equal(t, p.History, legacy.History)
+	assert.Equal(t, p.Level.DifficultyParams, legacy.Level.DifficultyParams)
+	assert.Equal(t, RegressionId(p), RegressionId(legacy))
+	assert.True(t, NeedsMigration(Zip(p.SerializeLegacy())))
+	assert.False(t, NeedsMigration(p.Serialize()))
+}
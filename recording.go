@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// A RecordingStream saves a playthrough while it is being played, by
+// appending each PlayerInput to a file. Rewriting the whole playthrough every
+// frame is slow and, if the game dies while writing, the file is lost. A
+// stream that is cut short loses at most the inputs that weren't flushed yet,
+// see RecoverPlaythrough.
+// The layout of a stream is:
+// - recordingStreamFormat
+// - the length of the header, then the header: the Playthrough without its
+// History, in the uncompressed layout of SerializeUncompressed
+// - every PlayerInput, one after another, each with the same size
+// A pause is only written once it is over, since it gets longer every frame
+// until then, see ExtendPause.
+type RecordingStream struct {
+	w      io.WriteCloser
+	buf    *bufio.Writer
+	nSince int64
+	pause  PlayerInput
+}
+
+// The format byte of a RecordingStream, see Compress for the other ones.
+// Streams in recordingStreamFormatV2 were written before PlayerInput had a
+// Moment, their inputs are the size of a playerInputLegacy. Streams in
+// recordingStreamFormatV3 have an input for every frame of a pause, their
+// inputs are the size of a playerInputV3.
+const (
+	recordingStreamFormatV2 byte = 2
+	recordingStreamFormatV3 byte = 3
+	recordingStreamFormat   byte = 6
+)
+
+// playerInputV3 is a PlayerInput as it was saved in recordingStreamFormatV3.
+type playerInputV3 struct {
+	Pos                Pt
+	JustPressed        bool
+	JustReleased       bool
+	OtherPointers      [MaxPointers - 1]PointerInput
+	TriggerComingUp    bool
+	TriggerGravityFlip bool
+	CancelDrag         bool
+	RotateChain        bool
+	PushNow            bool
+	Paused             bool
+	Device             InputDevice
+	Moment             int64
+}
+
+// The inputs are flushed to the file once every second of play, and when the
+// game crashes.
+const recordingStreamFlushInterval = 60
+
+func NewRecordingStream(w io.WriteCloser, p *Playthrough) *RecordingStream {
+	s := RecordingStream{w: w, buf: bufio.NewWriter(w)}
+	header := *p
+	header.History = nil
+	headerBytes := header.SerializeUncompressed()
+	Serialize(s.buf, recordingStreamFormat)
+	Serialize(s.buf, int64(len(headerBytes)))
+	Serialize(s.buf, headerBytes)
+	for _, input := range p.History {
+		Serialize(s.buf, input)
+	}
+	s.Flush()
+	return &s
+}
+
+// SerializeStream returns p in the layout of a RecordingStream. The stream of
+// a playthrough that got more inputs since starts with the stream of the
+// playthrough before it, so it can be uploaded in pieces, see
+// Gui.UploadPlaythroughs. The FinalHash is left out of the header for that
+// reason, it changes every frame. So is a pause at the end of the History,
+// which gets longer every frame until it is over.
+func (p *Playthrough) SerializeStream() []byte {
+	var buf bytes.Buffer
+	header := *p
+	header.FinalHash = FrameHash{}
+	if n := len(p.History); n > 0 && p.History[n-1].PausedFrames > 0 {
+		header.History = p.History[:n-1]
+	}
+	NewRecordingStream(nopCloser{&buf}, &header).Close()
+	return buf.Bytes()
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+func (s *RecordingStream) Append(input PlayerInput) {
+	s.endPause()
+	if input.PausedFrames > 0 {
+		s.pause = input
+		return
+	}
+	s.write(input)
+}
+
+// ExtendPause adds a frame to the pause that was appended last.
+func (s *RecordingStream) ExtendPause() {
+	Assert(s.pause.PausedFrames > 0)
+	s.pause.PausedFrames++
+}
+
+func (s *RecordingStream) endPause() {
+	if s.pause.PausedFrames > 0 {
+		s.write(s.pause)
+		s.pause = PlayerInput{}
+	}
+}
+
+func (s *RecordingStream) write(input PlayerInput) {
+	Serialize(s.buf, input)
+	s.nSince++
+	if s.nSince >= recordingStreamFlushInterval {
+		s.Flush()
+	}
+}
+
+func (s *RecordingStream) Flush() {
+	Check(s.buf.Flush())
+	s.nSince = 0
+}
+
+func (s *RecordingStream) Close() {
+	s.endPause()
+	s.Flush()
+	Check(s.w.Close())
+}
+
+func IsRecordingStream(data []byte) bool {
+	return len(data) > 0 && (data[0] == recordingStreamFormat ||
+		data[0] == recordingStreamFormatV3 ||
+		data[0] == recordingStreamFormatV2)
+}
+
+// RecoverPlaythrough reads a RecordingStream, even if it was cut short while
+// writing an input. The input that was only partially written is dropped.
+func RecoverPlaythrough(data []byte) (p Playthrough) {
+	buf := bytes.NewBuffer(data[1:])
+	var headerLen int64
+	Deserialize(buf, &headerLen)
+	if headerLen > int64(buf.Len()) {
+		Check(fmt.Errorf("can't recover playthrough, the header was cut "+
+			"short: %d bytes out of %d", buf.Len(), headerLen))
+	}
+	p = DeserializeUncompressed(buf.Next(int(headerLen)))
+
+	if data[0] == recordingStreamFormatV2 {
+		legacy := make([]playerInputLegacy,
+			buf.Len()/binary.Size(playerInputLegacy{}))
+		Deserialize(buf, legacy)
+		p.History = fromLegacyInputs(legacy)
+		return
+	}
+	if data[0] == recordingStreamFormatV3 {
+		v3 := make([]playerInputV3, buf.Len()/binary.Size(playerInputV3{}))
+		Deserialize(buf, v3)
+		for _, in := range v3 {
+			p.History = appendPerFrameInput(p.History, PlayerInput{
+				Pos: in.Pos, JustPressed: in.JustPressed,
+				JustReleased: in.JustReleased, OtherPointers: in.OtherPointers,
+				TriggerComingUp:    in.TriggerComingUp,
+				TriggerGravityFlip: in.TriggerGravityFlip,
+				CancelDrag:         in.CancelDrag, RotateChain: in.RotateChain,
+				PushNow: in.PushNow, Device: in.Device, Moment: in.Moment},
+				in.Paused)
+		}
+		return
+	}
+	inputSize := binary.Size(PlayerInput{})
+	n := buf.Len() / inputSize
+	p.History = make([]PlayerInput, n)
+	Deserialize(buf, p.History)
+	return
+}
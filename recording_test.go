@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/stretchr/testify/assert"
+	"slices"
+	"testing"
+)
+
+type closedBuffer struct {
+	bytes.Buffer
+}
+
+func (b *closedBuffer) Close() error {
+	return nil
+}
+
+func TestRecordingStream(t *testing.T) {
+	p := DeserializePlaythrough(ReadFile("data/demo.clone1"))
+	var file closedBuffer
+	recorded := p
+	recorded.History = nil
+	s := NewRecordingStream(&file, &recorded)
+	for _, input := range p.History {
+		s.Append(input)
+	}
+	s.Close()
+	assert.Equal(t, p, DeserializePlaythrough(file.Bytes()))
+
+	// Migrating a stream rewrites it in the compact format.
+	assert.True(t, NeedsMigration(file.Bytes()))
+	assert.False(t, NeedsMigration(p.Serialize()))
+}
+
+func TestRecoverPlaythrough(t *testing.T) {
+	p := DeserializePlaythrough(ReadFile("data/demo.clone1"))
+	var file closedBuffer
+	s := NewRecordingStream(&file, &p)
+	s.Close()
+	data := file.Bytes()
+
+	// The game died in the middle of writing the last input.
+	recovered := RecoverPlaythrough(data[:len(data)-3])
+	assert.Equal(t, p.History[:len(p.History)-1], recovered.History)
+	assert.Equal(t, p.Level, recovered.Level)
+	assert.Equal(t, p.Id, recovered.Id)
+
+	// The game died before writing the first input.
+	recovered = RecoverPlaythrough(data[:len(data)-len(p.History)*
+		binary.Size(PlayerInput{})])
+	assert.Empty(t, recovered.History)
+	assert.Equal(t, p.Level, recovered.Level)
+
+	// The game died before the header was written.
+	assert.Panics(t, func() { RecoverPlaythrough(data[:20]) })
+}
+
+func TestRecordingStream_Flush(t *testing.T) {
+	var p Playthrough
+	p.InputVersion = InputVersion
+	var file closedBuffer
+	s := NewRecordingStream(&file, &p)
+	for range recordingStreamFlushInterval - 1 {
+		s.Append(PlayerInput{})
+	}
+	assert.Empty(t, DeserializePlaythrough(file.Bytes()).History)
+	s.Append(PlayerInput{})
+	assert.Len(t, DeserializePlaythrough(file.Bytes()).History,
+		recordingStreamFlushInterval)
+}
+
+func TestRecoverPlaythrough_FormatV2(t *testing.T) {
+	// A stream written before inputs had a Moment.
+	p := DeserializePlaythrough(ReadFile("data/demo.clone1"))
+	header := p
+	header.History = nil
+	headerBytes := header.SerializeUncompressed()
+	buf := new(bytes.Buffer)
+	Serialize(buf, recordingStreamFormatV2)
+	Serialize(buf, int64(len(headerBytes)))
+	Serialize(buf, headerBytes)
+	Serialize(buf, toLegacyInputs(p.History))
+	assert.Equal(t, p.History, DeserializePlaythrough(buf.Bytes()).History)
+}
+
+func TestSerializeStream_Pieces(t *testing.T) {
+	p := DeserializePlaythrough(ReadFile("data/demo.clone1"))
+	half := p.Clone()
+	half.History = half.History[:len(half.History)/2]
+	half.FinalHash = FrameHash{1}
+	first := half.SerializeStream()
+	full := p.SerializeStream()
+
+	// The stream of the whole playthrough continues the stream of the first
+	// half, so the server only needs the rest of it.
+	assert.Equal(t, first, full[:len(first)])
+	uploaded := append(slices.Clone(first), full[len(first):]...)
+	expected := p
+	expected.FinalHash = FrameHash{}
+	assert.Equal(t, expected, DeserializePlaythrough(uploaded))
+}
+
+func TestRecordingStream_Pause(t *testing.T) {
+	var p Playthrough
+	p.InputVersion = InputVersion
+	var file closedBuffer
+	s := NewRecordingStream(&file, &p)
+	s.Append(PlayerInput{Pos: Pt{1, 1}})
+	s.Append(PlayerInput{PausedFrames: 1})
+	s.ExtendPause()
+	s.ExtendPause()
+	s.Flush()
+
+	// The pause isn't written until it is over.
+	assert.Equal(t, []PlayerInput{{Pos: Pt{1, 1}}},
+		DeserializePlaythrough(file.Bytes()).History)
+	s.Append(PlayerInput{Pos: Pt{2, 2}})
+	s.Close()
+	assert.Equal(t, []PlayerInput{{Pos: Pt{1, 1}}, {PausedFrames: 3},
+		{Pos: Pt{2, 2}}}, DeserializePlaythrough(file.Bytes()).History)
+}
+
+func TestRecoverPlaythrough_FormatV3(t *testing.T) {
+	// A stream written when a pause had an input for every frame.
+	p := DeserializePlaythrough(ReadFile("data/demo.clone1"))
+	header := p
+	header.History = nil
+	headerBytes := header.SerializeUncompressed()
+	buf := new(bytes.Buffer)
+	Serialize(buf, recordingStreamFormatV3)
+	Serialize(buf, int64(len(headerBytes)))
+	Serialize(buf, headerBytes)
+	Serialize(buf, []playerInputV3{{Pos: Pt{1, 1}, Moment: 10},
+		{Paused: true, Moment: 20}, {Paused: true, Moment: 30},
+		{Pos: Pt{2, 2}, JustPressed: true, Moment: 40}})
+	assert.Equal(t, []PlayerInput{{Pos: Pt{1, 1}, Moment: 10},
+		{PausedFrames: 2, Moment: 20},
+		{Pos: Pt{2, 2}, JustPressed: true, Moment: 40}},
+		DeserializePlaythrough(buf.Bytes()).History)
+}
+
+func TestSerializeStream_Pause(t *testing.T) {
+	p := DeserializePlaythrough(ReadFile("data/demo.clone1"))
+	p.History = append(p.History[:100], PlayerInput{PausedFrames: 5})
+	paused := p.SerializeStream()
+	p.History[100].PausedFrames = 50
+	assert.Equal(t, paused, p.SerializeStream())
+
+	// The pause is only part of the stream once it is over, with its final
+	// length.
+	p.History = append(p.History, PlayerInput{})
+	full := p.SerializeStream()
+	assert.Equal(t, paused, full[:len(paused)])
+	assert.Equal(t, p.History, DeserializePlaythrough(full).History)
+}
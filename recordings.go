@@ -0,0 +1,178 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"strconv"
+	"strings"
+)
+
+// The recordings screen lists the playthroughs that the player uploaded and
+// plays back the one they tap, like a .clone1 file given on the command line.
+// So players can look at their own games again without anyone sending files
+// around.
+
+// How many recordings the recordings screen lists, most recent first.
+const recordingsScreenSize = 12
+
+// How the server writes when a playthrough started.
+const recordingStartLayout = "2006-01-02 15:04:05"
+
+type RecordingEntry struct {
+	Id uuid.UUID
+	// When the playthrough started, in recordingStartLayout, in the time of
+	// the server.
+	Start             string
+	ReleaseVersion    int64
+	SimulationVersion int64
+	InputVersion      int64
+	// How many bytes of the playthrough the server has.
+	Size int64
+}
+
+// Playable is true if the recording can be played back by this build. The
+// inputs of older versions are migrated, but a different SimulationVersion
+// would play them out differently.
+func (e RecordingEntry) Playable() bool {
+	return e.SimulationVersion == SimulationVersion && e.Size > 0
+}
+
+// Line is what the recordings screen shows for e.
+func (e RecordingEntry) Line() string {
+	// To the minute.
+	start := e.Start[:min(len(e.Start), len("2006-01-02 15:04"))]
+	line := fmt.Sprintf("%s   %d KB", start, (e.Size+1023)/1024)
+	if e.SimulationVersion != SimulationVersion {
+		line += "   (old version)"
+	} else if e.Size == 0 {
+		line += "   (empty)"
+	}
+	return line
+}
+
+// ParseRecordings reads what list-playthroughs-clone1.php returns: one
+// playthrough per line, most recent first, with the id, the start, the
+// release, simulation and input versions and the size separated by tabs.
+func ParseRecordings(s string) (entries []RecordingEntry, err error) {
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		columns := strings.Split(line, "\t")
+		if len(columns) != 6 {
+			return nil, fmt.Errorf("invalid recording line: %q", line)
+		}
+		var e RecordingEntry
+		e.Id, err = uuid.Parse(columns[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid recording line: %q", line)
+		}
+		e.Start = columns[1]
+		for i, v := range []*int64{&e.ReleaseVersion, &e.SimulationVersion,
+			&e.InputVersion, &e.Size} {
+			*v, err = strconv.ParseInt(columns[i+2], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid recording line: %q", line)
+			}
+		}
+		entries = append(entries, e)
+	}
+	return
+}
+
+// FormatRecordings is the opposite of ParseRecordings.
+func FormatRecordings(entries []RecordingEntry) string {
+	var b strings.Builder
+	for _, e := range entries {
+		b.WriteString(fmt.Sprintf("%s\t%s\t%d\t%d\t%d\t%d\n", e.Id, e.Start,
+			e.ReleaseVersion, e.SimulationVersion, e.InputVersion, e.Size))
+	}
+	return b.String()
+}
+
+// Recordings is what the recordings screen shows, once the server answers.
+type Recordings struct {
+	Entries []RecordingEntry
+	Err     error
+	// Set while the recording that the player chose is downloaded, and if
+	// that failed.
+	Downloading bool
+	DownloadErr error
+}
+
+// LoadRecordings gets the recordings of user from sink. It blocks until the
+// server answers, so the Gui calls it on another goroutine.
+func LoadRecordings(sink TelemetrySink, user string) (r Recordings) {
+	var s string
+	r.Err = blockingRetryPolicy.Do(func() (err error) {
+		s, err = sink.ListPlaythroughs(user, recordingsScreenSize)
+		return
+	})
+	if r.Err != nil {
+		return
+	}
+	r.Entries, r.Err = ParseRecordings(s)
+	return
+}
+
+// Lines returns the text of the recordings screen, one line per entry.
+func (r *Recordings) Lines() []string {
+	if r.Err != nil {
+		return []string{"can't get your recordings right now"}
+	}
+	if len(r.Entries) == 0 {
+		return []string{"no recordings yet"}
+	}
+	var lines []string
+	for _, e := range r.Entries {
+		lines = append(lines, e.Line())
+	}
+	if r.Downloading {
+		lines = append(lines, "", "downloading...")
+	} else if r.DownloadErr != nil {
+		lines = append(lines, "", "can't play it back right now")
+	}
+	return lines
+}
+
+// beforeRecording is what playing back a recording from the recordings screen
+// replaces, see PlayRecording.
+type beforeRecording struct {
+	playthrough      Playthrough
+	playbackFile     string
+	enableDebugAreas bool
+}
+
+// DownloadedRecording is a recording that the player chose, once the server
+// sent it.
+type DownloadedRecording struct {
+	Playthrough Playthrough
+	Err         error
+}
+
+// LoadRecording gets the playthrough id of user from sink. Like
+// LoadRecordings, it blocks. A playthrough that can't be read, e.g. because
+// it was encrypted when it was uploaded, is an error and not a crash.
+func LoadRecording(sink TelemetrySink, user string,
+	id uuid.UUID) (d DownloadedRecording) {
+	var data []byte
+	d.Err = blockingRetryPolicy.Do(func() (err error) {
+		data, err = sink.GetPlaythrough(user, id)
+		return
+	})
+	if d.Err != nil {
+		return
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			d.Err = fmt.Errorf("can't read the recording: %v", r)
+		}
+	}()
+	if len(data) == 0 {
+		return DownloadedRecording{Err: errors.New("the recording is empty")}
+	}
+	d.Playthrough = DeserializePlaythrough(data)
+	return
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestParseRecordings(t *testing.T) {
+	id := uuid.MustParse("0b6e1d3c-4f2a-4b7e-9a55-2f4e0c1d8a90")
+	entries := []RecordingEntry{
+		{id, "2026-03-01 18:20:05", ReleaseVersion, SimulationVersion,
+			InputVersion, 3000},
+		{uuid.Nil, "2025-11-12 09:01:59", 1, SimulationVersion - 1, 1, 512}}
+	parsed, err := ParseRecordings(FormatRecordings(entries))
+	assert.NoError(t, err)
+	assert.Equal(t, entries, parsed)
+
+	parsed, err = ParseRecordings("")
+	assert.NoError(t, err)
+	assert.Empty(t, parsed)
+
+	_, err = ParseRecordings("not-an-id\t2026-03-01 18:20:05\t1\t2\t3\t4\n")
+	assert.Error(t, err)
+	_, err = ParseRecordings(id.String() + "\t2026-03-01 18:20:05\t1\t2\t3\n")
+	assert.Error(t, err)
+}
+
+func TestRecordings_Lines(t *testing.T) {
+	r := Recordings{Entries: []RecordingEntry{
+		{uuid.New(), "2026-03-01 18:20:05", 1, SimulationVersion, 1, 3000},
+		{uuid.New(), "2026-02-27 07:45:00", 1, SimulationVersion - 1, 1, 10},
+		{uuid.New(), "2026-02-20 21:00:00", 1, SimulationVersion, 1, 0}}}
+	assert.Equal(t, []string{"2026-03-01 18:20   3 KB",
+		"2026-02-27 07:45   1 KB   (old version)",
+		"2026-02-20 21:00   0 KB   (empty)"}, r.Lines())
+	assert.True(t, r.Entries[0].Playable())
+	assert.False(t, r.Entries[1].Playable())
+	assert.False(t, r.Entries[2].Playable())
+
+	r.Downloading = true
+	assert.Equal(t, "downloading...", r.Lines()[4])
+
+	assert.Equal(t, []string{"no recordings yet"}, (&Recordings{}).Lines())
+}
+
+func TestLoadRecording(t *testing.T) {
+	s := NewFileSink(t.TempDir(), SystemClock{})
+	p := DeserializePlaythrough(ReadFile("data/demo.clone1"))
+	p.History = p.History[:100]
+	id := uuid.New()
+	assert.Nil(t, s.InitializePlaythrough("user", ReleaseVersion,
+		SimulationVersion, InputVersion, id))
+	assert.Nil(t, s.UploadPlaythrough("user", ReleaseVersion,
+		SimulationVersion, InputVersion, id, p.Serialize()))
+
+	// Only the player's own recordings are listed.
+	assert.Nil(t, s.InitializePlaythrough("other", ReleaseVersion,
+		SimulationVersion, InputVersion, uuid.New()))
+	r := LoadRecordings(s, "user")
+	assert.NoError(t, r.Err)
+	assert.Equal(t, 1, len(r.Entries))
+	assert.Equal(t, id, r.Entries[0].Id)
+	assert.True(t, r.Entries[0].Playable())
+
+	d := LoadRecording(s, "user", id)
+	assert.NoError(t, d.Err)
+	assert.Equal(t, p.History, d.Playthrough.History)
+
+	// Garbage is an error, not a crash.
+	bad := uuid.New()
+	assert.Nil(t, s.InitializePlaythrough("user", ReleaseVersion,
+		SimulationVersion, InputVersion, bad))
+	assert.Nil(t, s.UploadPlaythrough("user", ReleaseVersion,
+		SimulationVersion, InputVersion, bad, []byte("garbage")))
+	assert.Error(t, LoadRecording(s, "user", bad).Err)
+}
+
+func TestGui_PlayRecording(t *testing.T) {
+	var g Gui
+	g.FSys = &embeddedFiles
+	g.clock = FixedClock{time.UnixMilli(1700000000000)}
+	g.state = RecordingsScreen
+	current := DeserializePlaythrough(ReadFile("data/demo.clone1"))
+	current.History = current.History[:10]
+	g.playthrough = current
+	recording := DeserializePlaythrough(ReadFile("data/demo.clone1"))
+	recording.History = recording.History[:100]
+
+	g.PlayRecording(recording)
+	assert.Equal(t, Playback, g.state)
+	assert.Equal(t, recording.Id.String()+".clone1", g.PlaybackFile)
+	assert.True(t, g.enableDebugAreas)
+
+	// Going back restores what was there before.
+	g.CloseRecording(EscapePressed)
+	assert.Equal(t, RecordingsScreen, g.state)
+	assert.Nil(t, g.beforeRecording)
+	assert.Equal(t, current.History, g.playthrough.History)
+	assert.Equal(t, "", g.PlaybackFile)
+	assert.False(t, g.enableDebugAreas)
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
+	"fmt"
 )
 
 // StateBytes is an array of bytes that represent the current state of the
@@ -84,6 +85,16 @@ func (w *World) StateBytes() []byte {
 	return buf.Bytes()
 }
 
+// EventsBytes returns the events of the last Step as bytes, so that they can
+// be hashed just like the state, see FrameHashWithEvents. They are not part of
+// StateBytes on purpose: including them would change the RegressionId and
+// the FinalHash of every recorded playthrough.
+func (w *World) EventsBytes() []byte {
+	buf := new(bytes.Buffer)
+	SerializeSlice(buf, w.Events)
+	return buf.Bytes()
+}
+
 // RegressionId returns a string which uniquely identifies the playthrough.
 // It is a hash of all the states of the World. It is meant to check if the
 // state of the World at each frame in the playthrough is the same after a
@@ -134,3 +145,156 @@ func RegressionId(p Playthrough) string {
 	hashHex := hex.EncodeToString(hashBytes)
 	return hashHex
 }
+
+// SelfTest replays every regression test found in dir and checks that each
+// playthrough produces the RegressionId stored next to it. It returns the
+// number of tests that failed. Failures are reported to stdout, including
+// failures to read or deserialize a test, instead of crashing on the first
+// one.
+// The point is to be able to check that a freshly built executable runs the
+// simulation exactly like the code that recorded the tests, on the actual
+// platform and with the actual build tags of the release.
+func SelfTest(fsys FS, dir string) (nFailed int) {
+	tests := GetFiles(fsys, dir, "*.clone1")
+	for _, test := range tests {
+		err := selfTestPlaythrough(fsys, test)
+		if err != nil {
+			fmt.Printf("FAIL %s: %v\n", test, err)
+			nFailed++
+		} else {
+			fmt.Printf("ok   %s\n", test)
+		}
+	}
+	fmt.Printf("%d tests, %d failed\n", len(tests), nFailed)
+	return
+}
+
+func selfTestPlaythrough(fsys FS, test string) (err error) {
+	// Any Check that fails while reading or replaying the playthrough panics.
+	// Turn the panic into an error for this test only.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	data, err := fsys.ReadFile(test)
+	Check(err)
+	expected, err := fsys.ReadFile(test + "-hash")
+	Check(err)
+	p := DeserializePlaythrough(data)
+	actual := RegressionId(p)
+	if actual != string(expected) {
+		return fmt.Errorf("expected %s got %s", expected, actual)
+	}
+	return nil
+}
+
+// FrameHash is the hash of the StateBytes of the World at one frame.
+type FrameHash [sha256.Size]byte
+
+// HashTrace is the FrameHash of every frame of a playthrough. The first
+// element is the World before any input, element i is the World after the
+// first i inputs. Unlike a RegressionId, which only says that something
+// changed, a HashTrace says at which frame it changed.
+type HashTrace []FrameHash
+
+func (w *World) FrameHash() FrameHash {
+	return sha256.Sum256(w.StateBytes())
+}
+
+// FrameHashWithEvents is FrameHash, but it also covers the events of the last
+// Step. A HashTrace made of these catches a change in the events even if the
+// state stays the same, e.g. a merge that is reported for the wrong brick.
+// It is the same as FrameHash in the frames without events.
+func (w *World) FrameHashWithEvents() FrameHash {
+	if len(w.Events) == 0 {
+		return w.FrameHash()
+	}
+	return sha256.Sum256(append(w.StateBytes(), w.EventsBytes()...))
+}
+
+// TraceHash is the hash of the World in a HashTrace: FrameHashWithEvents if
+// withEvents, FrameHash otherwise. Two traces can only be compared if they
+// were made with the same withEvents.
+func (w *World) TraceHash(withEvents bool) FrameHash {
+	if withEvents {
+		return w.FrameHashWithEvents()
+	}
+	return w.FrameHash()
+}
+
+// ComputeHashTrace replays p and returns the HashTrace of every frame, see
+// TraceHash for withEvents.
+func ComputeHashTrace(p Playthrough, withEvents bool) (t HashTrace) {
+	w := NewWorldFromPlaythrough(p)
+	t = append(t, w.TraceHash(withEvents))
+	for i := range p.History {
+		w.Step(p.History[i])
+		t = append(t, w.TraceHash(withEvents))
+	}
+	return
+}
+
+// A serialized HashTrace starts with hashTraceMagic and a byte that says if
+// the trace was made withEvents, see TraceHash. Traces saved before the
+// events existed have no header and never cover the events.
+const hashTraceMagic = "C1HT"
+
+func (t HashTrace) Serialize(withEvents bool) []byte {
+	buf := new(bytes.Buffer)
+	buf.WriteString(hashTraceMagic)
+	Serialize(buf, withEvents)
+	SerializeSlice(buf, t)
+	return buf.Bytes()
+}
+
+func DeserializeHashTrace(data []byte) (t HashTrace, withEvents bool) {
+	buf := bytes.NewBuffer(data)
+	if bytes.HasPrefix(data, []byte(hashTraceMagic)) {
+		buf.Next(len(hashTraceMagic))
+		Deserialize(buf, &withEvents)
+	}
+	DeserializeSlice(buf, (*[]FrameHash)(&t))
+	return
+}
+
+// CompareHashTraces returns the index of the first frame at which traces a
+// and b differ, or -1 if they are identical. If one trace is a prefix of the
+// other, they differ at the first frame that only the longer one has.
+func CompareHashTraces(a, b HashTrace) int64 {
+	for i := range min(len(a), len(b)) {
+		if a[i] != b[i] {
+			return int64(i)
+		}
+	}
+	if len(a) != len(b) {
+		return int64(min(len(a), len(b)))
+	}
+	return -1
+}
+
+// VerifyPlaythrough replays p headlessly and checks every frame against
+// trace. It returns the index of the first frame at which the World differs
+// from the trace, or -1 if all the frames match.
+// The trace may be shorter than the playthrough, in which case only the
+// frames it covers are replayed. This is the case for recordings of crashes,
+// where the trace stops before the input that caused the crash. A trace
+// longer than the playthrough diverges at the first frame the playthrough
+// doesn't have.
+// withEvents must be the one the trace was made with, see TraceHash.
+func VerifyPlaythrough(p Playthrough, trace HashTrace, withEvents bool) int64 {
+	w := NewWorldFromPlaythrough(p)
+	for i := range trace {
+		if i > 0 {
+			if i > len(p.History) {
+				return int64(i)
+			}
+			w.Step(p.History[i-1])
+		}
+		if w.TraceHash(withEvents) != trace[i] {
+			return int64(i)
+		}
+	}
+	return -1
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"github.com/goccy/go-yaml"
+	"os"
+)
+
+// The remote config holds the few values that can change without a new
+// release: an announcement, a kill-switch for uploads and timer values for
+// tuning experiments. Everything else belongs to the release. The game gets
+// the remote config from the server at startup and caches it in a local file,
+// so that it keeps the same values when the server can't be reached. A remote
+// config that can't be read is ignored and the game uses the values of the
+// release.
+
+const remoteConfigCacheFile = "remote-config.yaml"
+
+type RemoteConfig struct {
+	// The name of the experiment that the values are for, so that
+	// playthroughs can be grouped by it during analysis.
+	Experiment string `yaml:"Experiment"`
+	// Shown on the home screen, in place of the message of the day.
+	Announcement string `yaml:"Announcement"`
+	// Stop uploading playthroughs, e.g. when the server can't keep up.
+	DisableUploads bool `yaml:"DisableUploads"`
+	// Replace the timer values of DifficultyParams in new games, if not 0.
+	TimerCooldownBase   int64 `yaml:"TimerCooldownBase"`
+	TimerCooldownPerVal int64 `yaml:"TimerCooldownPerVal"`
+}
+
+// LoadRemoteConfig gets the remote config from sink, or the cached one if sink
+// can't be reached or sends something invalid.
+func LoadRemoteConfig(sink TelemetrySink,
+	releaseVersion int64) (c RemoteConfig) {
+	if FileExists(os.DirFS(".").(FS), remoteConfigCacheFile) {
+		data, err := os.ReadFile(remoteConfigCacheFile)
+		if err == nil {
+			c, _ = ParseRemoteConfig(string(data))
+		}
+	}
+	// The remote config is optional, so don't insist if the request fails.
+	s, err := sink.GetRemoteConfig(releaseVersion)
+	if err != nil {
+		return
+	}
+	newC, err := ParseRemoteConfig(s)
+	if err != nil {
+		return
+	}
+	if newC != c {
+		SaveYAML(remoteConfigCacheFile, newC)
+	}
+	return newC
+}
+
+// ParseRemoteConfig reads a remote config and checks that its values are
+// safe to use.
+func ParseRemoteConfig(s string) (c RemoteConfig, err error) {
+	err = yaml.Unmarshal([]byte(s), &c)
+	if err != nil {
+		return RemoteConfig{}, err
+	}
+	if c.TimerCooldownBase < 0 || c.TimerCooldownPerVal < 0 {
+		return RemoteConfig{}, fmt.Errorf("invalid timer values in the "+
+			"remote config: %d, %d", c.TimerCooldownBase,
+			c.TimerCooldownPerVal)
+	}
+	return
+}
+
+// ApplyTo changes the Level of a new game according to the remote config.
+func (c RemoteConfig) ApplyTo(l *Level) {
+	if c.TimerCooldownBase == 0 && c.TimerCooldownPerVal == 0 {
+		return
+	}
+	// A zero DifficultyParams means the defaults, see Level.
+	if l.DifficultyParams == (DifficultyParams{}) {
+		l.DifficultyParams = DefaultDifficultyParams()
+	}
+	if c.TimerCooldownBase != 0 {
+		l.TimerCooldownBase = c.TimerCooldownBase
+	}
+	if c.TimerCooldownPerVal != 0 {
+		l.TimerCooldownPerVal = c.TimerCooldownPerVal
+	}
+}
+
+// String returns the remote config as it is recorded in the Metadata of a
+// playthrough, empty if there is no remote config.
+func (c RemoteConfig) String() string {
+	if c == (RemoteConfig{}) {
+		return ""
+	}
+	data, err := yaml.Marshal(c)
+	Check(err)
+	return string(data)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseRemoteConfig(t *testing.T) {
+	c, err := ParseRemoteConfig("Experiment: slow timer\n" +
+		"DisableUploads: true\n" +
+		"TimerCooldownBase: 900\n")
+	assert.NoError(t, err)
+	assert.Equal(t, RemoteConfig{Experiment: "slow timer",
+		DisableUploads: true, TimerCooldownBase: 900}, c)
+
+	// The server has no remote config.
+	c, err = ParseRemoteConfig("")
+	assert.NoError(t, err)
+	assert.Equal(t, RemoteConfig{}, c)
+
+	// Values that would break the game are rejected.
+	_, err = ParseRemoteConfig("TimerCooldownBase: -1\n")
+	assert.Error(t, err)
+	_, err = ParseRemoteConfig("TimerCooldownBase: [1, 2]\n")
+	assert.Error(t, err)
+}
+
+func TestRemoteConfig_ApplyTo(t *testing.T) {
+	// Without timer values, the Level is left alone.
+	var l Level
+	RemoteConfig{Announcement: "hi"}.ApplyTo(&l)
+	assert.Equal(t, Level{}, l)
+
+	// Only the timer values change, the rest stays at the defaults.
+	c := RemoteConfig{TimerCooldownPerVal: 7}
+	c.ApplyTo(&l)
+	expected := DefaultDifficultyParams()
+	expected.TimerCooldownPerVal = 7
+	assert.Equal(t, expected, l.DifficultyParams)
+}
+
+func TestRemoteConfig_String(t *testing.T) {
+	assert.Equal(t, "", RemoteConfig{}.String())
+	c := RemoteConfig{Experiment: "slow timer", TimerCooldownBase: 900}
+	parsed, err := ParseRemoteConfig(c.String())
+	assert.NoError(t, err)
+	assert.Equal(t, c, parsed)
+}
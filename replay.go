@@ -0,0 +1,111 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// ReplayResult is what a headless replay of a playthrough found out.
+type ReplayResult struct {
+	NFrames      int64
+	FinalScore   int64
+	FinalState   WorldState
+	RegressionId string
+	// The frame in which the World crashed, for example because an assert
+	// failed, and why. A crash while creating the World counts as frame 0.
+	// CrashFrameIdx is -1 if the World didn't crash, in which case the other
+	// fields describe the end of the playthrough. Otherwise, they describe
+	// the World right before the crash and RegressionId is empty.
+	CrashFrameIdx int64
+	CrashMsg      string
+	// The HashTrace of the replay, up to the crash if there was one. Made of
+	// FrameHashWithEvents by ReplayWithEvents, of FrameHash otherwise.
+	Trace HashTrace `json:"-"`
+	// Whether the playthrough has a FinalHash and, if so, whether the World
+	// ended with a different one, which means the simulation is not
+	// deterministic across the two executables.
+	HasFinalHash     bool
+	FinalHashDiffers bool
+}
+
+// Replay runs p without a Gui, so that playthroughs can be checked by
+// scripts and on servers without a display.
+func Replay(p Playthrough) ReplayResult {
+	return replay(p, false)
+}
+
+// ReplayWithEvents is Replay, with the events of each frame in the Trace.
+func ReplayWithEvents(p Playthrough) ReplayResult {
+	return replay(p, true)
+}
+
+func replay(p Playthrough, withEvents bool) (r ReplayResult) {
+	r.NFrames = int64(len(p.History))
+	r.CrashFrameIdx = -1
+
+	var w World
+	frameIdx := int64(0)
+	defer func() {
+		if e := recover(); e != nil {
+			r.CrashFrameIdx = frameIdx
+			r.CrashMsg = fmt.Sprintf("%v", e)
+			r.FinalScore = w.Score
+			r.FinalState = w.State
+		}
+	}()
+
+	// This is RegressionId, done here so that the playthrough only runs once.
+	hash := sha256.New()
+	w = NewWorldFromPlaythrough(p)
+	hash.Write(w.StateBytes())
+	r.Trace = append(r.Trace, w.TraceHash(withEvents))
+	for frameIdx = range r.NFrames {
+		w.Step(p.History[frameIdx])
+		hash.Write(w.StateBytes())
+		r.Trace = append(r.Trace, w.TraceHash(withEvents))
+	}
+
+	r.FinalScore = w.Score
+	r.FinalState = w.State
+	r.RegressionId = hex.EncodeToString(hash.Sum(nil))
+	r.HasFinalHash = p.FinalHash != FrameHash{}
+	r.FinalHashDiffers = r.HasFinalHash && p.FinalHash != w.FrameHash()
+	return
+}
+
+func (r ReplayResult) String() string {
+	s := fmt.Sprintf("frames: %d\nscore: %d\nstate: %v\n", r.NFrames,
+		r.FinalScore, r.FinalState)
+	if r.CrashFrameIdx >= 0 {
+		return s + fmt.Sprintf("crashed at frame %d: %s\n", r.CrashFrameIdx,
+			r.CrashMsg)
+	}
+	s += fmt.Sprintf("regression id: %s\n", r.RegressionId)
+	if r.FinalHashDiffers {
+		s += "final hash: differs from the recording\n"
+	} else if r.HasFinalHash {
+		s += "final hash: matches the recording\n"
+	}
+	return s
+}
+
+// ReplayReport is what the replay command prints with -json, for scripts
+// like the download tool. Error is set if the playthrough couldn't be read,
+// in which case there was no replay and the rest is empty.
+type ReplayReport struct {
+	ReplayResult
+	FinalState string
+	Error      string
+}
+
+func (r ReplayReport) JSON() []byte {
+	data, err := json.Marshal(r)
+	Check(err)
+	return data
+}
+
+func NewReplayReport(r ReplayResult) ReplayReport {
+	return ReplayReport{ReplayResult: r, FinalState: r.FinalState.String()}
+}
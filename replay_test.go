@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestReplay(t *testing.T) {
+	p := DeserializePlaythrough(ReadFile("data/demo.clone1"))
+	r := Replay(p)
+	assert.Equal(t, int64(-1), r.CrashFrameIdx)
+	assert.Equal(t, int64(len(p.History)), r.NFrames)
+	assert.Equal(t, RegressionId(p), r.RegressionId)
+	assert.Positive(t, r.FinalScore)
+	assert.Contains(t, r.String(), r.RegressionId)
+
+	// A World that crashes is reported, not propagated.
+	p.DifficultyParams = DifficultyParams{ValsPerChain: 1}
+	r = Replay(p)
+	assert.Equal(t, int64(0), r.CrashFrameIdx)
+	assert.NotEmpty(t, r.CrashMsg)
+	assert.Empty(t, r.RegressionId)
+	assert.Contains(t, r.String(), "crashed at frame 0")
+}
+
+func TestReplay_FinalHash(t *testing.T) {
+	p := DeserializePlaythrough(ReadFile("data/demo.clone1"))
+	assert.False(t, Replay(p).HasFinalHash)
+
+	w := NewWorldFromPlaythrough(p)
+	for _, input := range p.History {
+		w.Step(input)
+	}
+	p.FinalHash = w.FrameHash()
+	p = DeserializePlaythrough(p.Serialize())
+	r := Replay(p)
+	assert.True(t, r.HasFinalHash)
+	assert.False(t, r.FinalHashDiffers)
+	assert.Contains(t, r.String(), "final hash: matches")
+
+	// A different executable ended up somewhere else.
+	p.FinalHash[0]++
+	r = Replay(p)
+	assert.True(t, r.FinalHashDiffers)
+	assert.Contains(t, r.String(), "final hash: differs")
+}
+
+func TestReplayReport_JSON(t *testing.T) {
+	p := DeserializePlaythrough(ReadFile("data/demo.clone1"))
+	r := Replay(p)
+	var report ReplayReport
+	require.NoError(t, json.Unmarshal(NewReplayReport(r).JSON(), &report))
+	assert.Equal(t, r.NFrames, report.NFrames)
+	assert.Equal(t, r.FinalScore, report.FinalScore)
+	assert.Equal(t, int64(-1), report.CrashFrameIdx)
+	assert.Equal(t, r.FinalState.String(), report.FinalState)
+	assert.Empty(t, report.Error)
+	// The trace is only for files, not for the report.
+	assert.NotContains(t, string(NewReplayReport(r).JSON()), "Trace")
+}
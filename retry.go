@@ -0,0 +1,82 @@
+package main
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// RetryPolicy decides how a failed HTTP request is retried. The delay before
+// each retry starts at InitialDelay and doubles with every attempt, up to
+// MaxDelay. Each delay is shortened by a random fraction of up to Jitter, so
+// that the games that lost their connection at the same time don't all come
+// back at the same time. The retries stop after MaxAttempts attempts or when
+// the next one would start after MaxElapsed, whichever comes first.
+// None of this ends up in a recording, so it doesn't go through the Clock.
+type RetryPolicy struct {
+	MaxAttempts  int64
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+	Jitter       float64
+	MaxElapsed   time.Duration
+	// If set, called before each retry with the error of the attempt that
+	// failed and how long the retry waits, see UploadMetrics.
+	OnRetry func(err error, delay time.Duration)
+
+	// Replaced by tests.
+	sleep func(time.Duration)
+	now   func() time.Time
+}
+
+// blockingRetryPolicy is for requests that the game waits for, e.g. before
+// a new game starts. The player shouldn't notice that the server is down.
+var blockingRetryPolicy = RetryPolicy{
+	MaxAttempts:  3,
+	InitialDelay: 100 * time.Millisecond,
+	MaxDelay:     400 * time.Millisecond,
+	Jitter:       0.5,
+	MaxElapsed:   time.Second,
+}
+
+// backgroundRetryPolicy is for requests made by the goroutines that upload
+// data, which can afford to wait for a server or a connection to come back.
+var backgroundRetryPolicy = RetryPolicy{
+	MaxAttempts:  6,
+	InitialDelay: 500 * time.Millisecond,
+	MaxDelay:     8 * time.Second,
+	Jitter:       0.5,
+	MaxElapsed:   30 * time.Second,
+}
+
+// Do calls f until it succeeds or the policy gives up, and returns the last
+// error of f.
+func (p RetryPolicy) Do(f func() error) (err error) {
+	sleep, now := p.sleep, p.now
+	if sleep == nil {
+		sleep = time.Sleep
+	}
+	if now == nil {
+		now = time.Now
+	}
+
+	start := now()
+	delay := p.InitialDelay
+	for attempt := int64(1); ; attempt++ {
+		err = f()
+		if err == nil || attempt >= p.MaxAttempts {
+			return
+		}
+		d := p.jittered(delay)
+		if now().Add(d).Sub(start) > p.MaxElapsed {
+			return
+		}
+		if p.OnRetry != nil {
+			p.OnRetry(err, d)
+		}
+		sleep(d)
+		delay = min(delay*2, p.MaxDelay)
+	}
+}
+
+func (p RetryPolicy) jittered(d time.Duration) time.Duration {
+	return d - time.Duration(float64(d)*p.Jitter*rand.Float64())
+}
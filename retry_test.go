@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// fakeTime is the time for a RetryPolicy that sleeps without waiting.
+type fakeTime struct {
+	t      time.Time
+	sleeps []time.Duration
+}
+
+func (f *fakeTime) policy(p RetryPolicy) RetryPolicy {
+	p.now = func() time.Time { return f.t }
+	p.sleep = func(d time.Duration) {
+		f.sleeps = append(f.sleeps, d)
+		f.t = f.t.Add(d)
+	}
+	return p
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	var f fakeTime
+	p := f.policy(RetryPolicy{
+		MaxAttempts:  5,
+		InitialDelay: time.Second,
+		MaxDelay:     3 * time.Second,
+		MaxElapsed:   time.Minute,
+	})
+	nCalls := 0
+	err := p.Do(func() error {
+		nCalls++
+		return errors.New("server down")
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 5, nCalls)
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second,
+		3 * time.Second, 3 * time.Second}, f.sleeps)
+}
+
+func TestRetryPolicy_StopsOnSuccess(t *testing.T) {
+	var f fakeTime
+	p := f.policy(backgroundRetryPolicy)
+	nCalls := 0
+	err := p.Do(func() error {
+		nCalls++
+		if nCalls < 3 {
+			return errors.New("server down")
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, nCalls)
+	assert.Len(t, f.sleeps, 2)
+}
+
+func TestRetryPolicy_MaxElapsedAndJitter(t *testing.T) {
+	var f fakeTime
+	p := f.policy(RetryPolicy{
+		MaxAttempts:  100,
+		InitialDelay: time.Second,
+		MaxDelay:     time.Second,
+		Jitter:       0.5,
+		MaxElapsed:   10 * time.Second,
+	})
+	_ = p.Do(func() error { return errors.New("server down") })
+	total := time.Duration(0)
+	for _, d := range f.sleeps {
+		assert.GreaterOrEqual(t, d, time.Second/2)
+		assert.LessOrEqual(t, d, time.Second)
+		total += d
+	}
+	assert.LessOrEqual(t, total, 10*time.Second)
+	assert.Greater(t, total, 9*time.Second)
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Session is everything that happened since the game was started, not only
+// what happened inside a World. It has every game played, as a Playthrough,
+// and every move between screens, like restarting from the pause menu. This
+// is what I need to reproduce problems that involve the menus.
+type Session struct {
+	InputVersion int64
+	Playthroughs []Playthrough
+	Transitions  []Transition
+}
+
+// TransitionCause is what made the Gui go from one GameState to another.
+type TransitionCause int64
+
+const (
+	PlayButtonPressed TransitionCause = iota
+	MenuButtonPressed
+	EscapePressed
+	ContinueButtonPressed
+	RestartButtonPressed
+	HomeButtonPressed
+	ResetKeyPressed
+	GameEnded
+	LeaderboardButtonPressed
+	RecordingsButtonPressed
+	RecordingPressed
+	ConsentButtonPressed
+)
+
+var transitionCauseNames = map[TransitionCause]string{
+	PlayButtonPressed:        "play button",
+	MenuButtonPressed:        "menu button",
+	EscapePressed:            "escape",
+	ContinueButtonPressed:    "continue button",
+	RestartButtonPressed:     "restart button",
+	HomeButtonPressed:        "home button",
+	ResetKeyPressed:          "reset key",
+	GameEnded:                "game ended",
+	LeaderboardButtonPressed: "leaderboard button",
+	RecordingsButtonPressed:  "recordings button",
+	RecordingPressed:         "recording",
+	ConsentButtonPressed:     "consent button",
+}
+
+func (c TransitionCause) String() string {
+	return transitionCauseNames[c]
+}
+
+// Transition is a change of GameState or the start of a new game. Restarting
+// a game changes the game but not the GameState.
+type Transition struct {
+	// The frame of the session in which the transition happened. Session
+	// frames count every Update, not only the ones that step the World.
+	FrameIdx int64
+	From     GameState
+	To       GameState
+	Cause    TransitionCause
+	// The game played after the transition, as an index in Playthroughs, or
+	// -1 if no game was started yet.
+	PlaythroughIdx int64
+}
+
+// StartedBy returns the transition that started game i, or nil if the
+// game was started without one, e.g. when the Gui starts directly in
+// PlayScreen.
+func (s *Session) StartedBy(i int64) *Transition {
+	for j := range s.Transitions {
+		if s.Transitions[j].PlaythroughIdx == i {
+			return &s.Transitions[j]
+		}
+	}
+	return nil
+}
+
+// NextGame returns the index of the first game after i, in the direction
+// dir (1 or -1), that has any input, or i if there is none. Games that were
+// abandoned before their first frame can't be played back.
+func (s *Session) NextGame(i int64, dir int64) int64 {
+	for j := i + dir; j >= 0 && j < int64(len(s.Playthroughs)); j += dir {
+		if len(s.Playthroughs[j].History) > 0 {
+			return j
+		}
+	}
+	return i
+}
+
+func (s *Session) Serialize() []byte {
+	buf := new(bytes.Buffer)
+	Serialize(buf, s.InputVersion)
+	Serialize(buf, int64(len(s.Playthroughs)))
+	for i := range s.Playthroughs {
+		SerializeSlice(buf, s.Playthroughs[i].Serialize())
+	}
+	SerializeSlice(buf, s.Transitions)
+	return Zip(buf.Bytes())
+}
+
+func DeserializeSession(data []byte) (s Session) {
+	buf := bytes.NewBuffer(Unzip(data))
+	Deserialize(buf, &s.InputVersion)
+	// Each Playthrough migrates itself from an older InputVersion, the rest
+	// of the session didn't change.
+	if s.InputVersion > InputVersion {
+		Check(fmt.Errorf("can't deserialize this session - we are at "+
+			"InputVersion %d and session was generated with InputVersion "+
+			"version %d",
+			InputVersion, s.InputVersion))
+	}
+	s.InputVersion = InputVersion
+	var nPlaythroughs int64
+	Deserialize(buf, &nPlaythroughs)
+	s.Playthroughs = make([]Playthrough, nPlaythroughs)
+	for i := range s.Playthroughs {
+		var data []byte
+		DeserializeSlice(buf, &data)
+		s.Playthroughs[i] = DeserializePlaythrough(data)
+	}
+	DeserializeSlice(buf, &s.Transitions)
+	return
+}
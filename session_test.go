@@ -0,0 +1,36 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSession(t *testing.T) {
+	demo := DeserializePlaythrough(ReadFile("data/demo.clone1"))
+	abandoned := *demo.Clone()
+	abandoned.History = nil
+
+	s := Session{InputVersion: InputVersion}
+	s.Playthroughs = []Playthrough{demo, abandoned, demo}
+	s.Transitions = []Transition{
+		{10, HomeScreen, PlayScreen, PlayButtonPressed, 0},
+		{500, PlayScreen, PausedScreen, EscapePressed, 0},
+		{520, PausedScreen, PlayScreen, RestartButtonPressed, 1},
+		{521, PlayScreen, PlayScreen, ResetKeyPressed, 2},
+	}
+
+	loaded := DeserializeSession(s.Serialize())
+	assert.Equal(t, s.Transitions, loaded.Transitions)
+	assert.Equal(t, len(s.Playthroughs), len(loaded.Playthroughs))
+	assert.Equal(t, demo.History, loaded.Playthroughs[2].History)
+	assert.Empty(t, loaded.Playthroughs[1].History)
+
+	assert.Equal(t, ResetKeyPressed, s.StartedBy(2).Cause)
+	assert.Nil(t, s.StartedBy(3))
+
+	// The abandoned game is skipped, there is nothing to play back.
+	assert.Equal(t, int64(0), s.NextGame(-1, 1))
+	assert.Equal(t, int64(2), s.NextGame(0, 1))
+	assert.Equal(t, int64(2), s.NextGame(2, 1))
+	assert.Equal(t, int64(0), s.NextGame(2, -1))
+}
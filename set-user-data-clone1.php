@@ -1,4 +1,5 @@
 <?php
+require_once "./auth-clone1.php";
 $servername = "172.232.206.74";
 $username = "playfulp_temp";
 $password = "comeonthough";
@@ -10,6 +11,8 @@ function LogInfo($message) {
 
 LogInfo("Start.");
 if ($_SERVER['REQUEST_METHOD'] == 'POST') {
+    VerifyRequest();
+    $table = Table("user_data");
     LogInfo("Attempt to connect to database.");
 
     $conn = new mysqli($servername, $username, $password, $dbname);
@@ -22,7 +25,7 @@ if ($_SERVER['REQUEST_METHOD'] == 'POST') {
         $data = $_POST['data'];
         LogInfo("We got user: " . $user);
         LogInfo("We got data: " . $data);
-        $sql = "REPLACE INTO user_data (user, data) VALUES ('$user', '$data')";
+        $sql = "REPLACE INTO $table (user, data) VALUES ('$user', '$data')";
         
         try {
             $conn->query($sql);
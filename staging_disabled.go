@@ -0,0 +1,8 @@
+//go:build !staging_enabled
+
+package main
+
+// Builds without staging_enabled send their data to the production
+// endpoints, unless the Config or developer mode say otherwise, see
+// SelectEndpoints.
+const stagingEnabled = false
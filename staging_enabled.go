@@ -0,0 +1,7 @@
+//go:build staging_enabled
+
+package main
+
+// Builds with staging_enabled send their data to the staging endpoints,
+// unless the Config says otherwise, see SelectEndpoints.
+const stagingEnabled = true
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"github.com/hajimehoshi/ebiten/v2"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// EndCause is how a playthrough ended.
+type EndCause int64
+
+const (
+	// The recording stops before the game was over, e.g. the player quit or
+	// restarted.
+	Abandoned EndCause = iota
+	Victory
+	// A new row came up and pushed the bricks over the top.
+	PushedOverTop
+	// A brick went over the top during regular play, e.g. after being
+	// adjusted to its canonical position.
+	BrickOverTop
+	// The replay crashed, so how the game ended is not known.
+	ReplayCrashed
+	nEndCauses
+)
+
+var endCauseNames = map[EndCause]string{
+	Abandoned:     "abandoned",
+	Victory:       "won",
+	PushedOverTop: "new row pushed bricks over the top",
+	BrickOverTop:  "brick went over the top",
+	ReplayCrashed: "replay crashed",
+}
+
+func (c EndCause) String() string {
+	return endCauseNames[c]
+}
+
+// PlaythroughStats are the numbers the stats command aggregates for one
+// playthrough. Only playthroughs recorded with the SimulationVersion of this
+// executable can be replayed, the others only have what can be read from the
+// recording itself.
+type PlaythroughStats struct {
+	ReleaseVersion    int64
+	SimulationVersion int64
+	PlayedFrames      int64
+	Replayed          bool
+	// These are only known if Replayed.
+	Score  int64
+	Merges int64
+	End    EndCause
+}
+
+// GetPlaythroughStats reads the stats of p, replaying it if possible.
+func GetPlaythroughStats(p Playthrough) (s PlaythroughStats) {
+	s.ReleaseVersion = p.ReleaseVersion
+	s.SimulationVersion = p.SimulationVersion
+	s.PlayedFrames = p.PlayedFrames()
+	if p.SimulationVersion != SimulationVersion {
+		return
+	}
+	s.Replayed = true
+
+	var w World
+	defer func() {
+		if r := recover(); r != nil {
+			s.Score = w.Score
+			s.End = ReplayCrashed
+		}
+	}()
+	w = NewWorldFromPlaythrough(p)
+	w.OnMerge(func(e WorldEvent) {
+		s.Merges++
+	})
+	w.OnGameOver(func(final WorldState) {
+		if final == Won {
+			s.End = Victory
+		} else if w.PreviousState == ComingUp {
+			// PreviousState is the state the losing Step ran in.
+			s.End = PushedOverTop
+		} else {
+			s.End = BrickOverTop
+		}
+	})
+	for _, input := range p.History {
+		w.Step(input)
+	}
+	s.Score = w.Score
+	return
+}
+
+// StatsTree reads the stats of all the recordings in the directory tree under
+// root, whatever their name (see recordingName), like the ones written by the
+// download tool. It also returns the recordings that couldn't be read.
+func StatsTree(root string) (stats []PlaythroughStats, failed []string) {
+	err := fs.WalkDir(os.DirFS(root), ".",
+		func(path string, d fs.DirEntry, err error) error {
+			Check(err)
+			if d.IsDir() || !recordingName.MatchString(d.Name()) {
+				return nil
+			}
+			file := filepath.Join(root, path)
+			s, err := statsFile(file)
+			if err != nil {
+				fmt.Printf("FAIL %s: %v\n", file, err)
+				failed = append(failed, file)
+				return nil
+			}
+			stats = append(stats, s)
+			return nil
+		})
+	Check(err)
+	return
+}
+
+func statsFile(file string) (s PlaythroughStats, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	return GetPlaythroughStats(DeserializePlaythrough(ReadFile(file))), nil
+}
+
+// StatsReport aggregates stats by release and simulation version: how long
+// the sessions are, how the scores are distributed, how fast the players
+// merge and how the games end.
+func StatsReport(stats []PlaythroughStats) string {
+	groups := map[[2]int64][]PlaythroughStats{}
+	for _, s := range stats {
+		key := [2]int64{s.ReleaseVersion, s.SimulationVersion}
+		groups[key] = append(groups[key], s)
+	}
+	keys := make([][2]int64, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b [2]int64) int {
+		return cmp.Or(cmp.Compare(a[0], b[0]), cmp.Compare(a[1], b[1]))
+	})
+
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "playthroughs: %d\n", len(stats))
+	for _, key := range keys {
+		group := groups[key]
+		fmt.Fprintf(buf, "\nrelease %d, simulation %d: %d playthroughs\n",
+			key[0], key[1], len(group))
+
+		played := int64(0)
+		for _, s := range group {
+			played += s.PlayedFrames
+		}
+		fmt.Fprintf(buf, "  average session length: %s\n",
+			FrameToTimecode(played/int64(len(group))))
+
+		var scores []int64
+		var merges, replayedFrames int64
+		var ends [nEndCauses]int
+		for _, s := range group {
+			if !s.Replayed {
+				continue
+			}
+			scores = append(scores, s.Score)
+			merges += s.Merges
+			replayedFrames += s.PlayedFrames
+			ends[s.End]++
+		}
+		if len(scores) == 0 {
+			fmt.Fprintf(buf, "  not replayed, this executable has "+
+				"simulation %d\n", SimulationVersion)
+			continue
+		}
+
+		slices.Sort(scores)
+		fmt.Fprintf(buf, "  score: min %d, median %d, 90th percentile %d, "+
+			"max %d\n", scores[0], percentile(scores, 50),
+			percentile(scores, 90), scores[len(scores)-1])
+		minutes := float64(replayedFrames) / ebiten.DefaultTPS / 60
+		if minutes > 0 {
+			fmt.Fprintf(buf, "  merges per minute: %.1f\n",
+				float64(merges)/minutes)
+		}
+		fmt.Fprintf(buf, "  end:\n")
+		for cause := range nEndCauses {
+			if ends[cause] > 0 {
+				fmt.Fprintf(buf, "    %-36v %d\n", cause, ends[cause])
+			}
+		}
+	}
+	return buf.String()
+}
+
+// percentile returns the p-th percentile of sorted, by the nearest-rank
+// method.
+func percentile(sorted []int64, p int) int64 {
+	rank := (p*len(sorted) + 99) / 100
+	return sorted[max(rank-1, 0)]
+}
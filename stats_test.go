@@ -0,0 +1,70 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestGetPlaythroughStats(t *testing.T) {
+	playthrough := DeserializePlaythrough(ReadFile("data/demo.clone1"))
+	playthrough.History = playthrough.History[:2000]
+
+	s := GetPlaythroughStats(playthrough)
+	assert.True(t, s.Replayed)
+	assert.Equal(t, playthrough.PlayedFrames(), s.PlayedFrames)
+	assert.Greater(t, s.Merges, int64(0))
+	assert.Equal(t, Abandoned, s.End)
+
+	w := NewWorldFromPlaythrough(playthrough)
+	for _, input := range playthrough.History {
+		w.Step(input)
+	}
+	assert.Equal(t, w.Score, s.Score)
+
+	// A playthrough of another simulation can't be replayed.
+	playthrough.SimulationVersion = SimulationVersion + 1
+	s = GetPlaythroughStats(playthrough)
+	assert.False(t, s.Replayed)
+	assert.Equal(t, playthrough.PlayedFrames(), s.PlayedFrames)
+	assert.Equal(t, int64(0), s.Merges)
+}
+
+func TestStatsReport(t *testing.T) {
+	minute := int64(60 * 60)
+	stats := []PlaythroughStats{
+		{ReleaseVersion: 2, SimulationVersion: SimulationVersion,
+			PlayedFrames: minute, Replayed: true, Score: 100, Merges: 30,
+			End: PushedOverTop},
+		{ReleaseVersion: 2, SimulationVersion: SimulationVersion,
+			PlayedFrames: 3 * minute, Replayed: true, Score: 300, Merges: 90,
+			End: Victory},
+		{ReleaseVersion: 1, SimulationVersion: SimulationVersion - 1,
+			PlayedFrames: minute},
+	}
+	report := StatsReport(stats)
+	assert.True(t, strings.HasPrefix(report, "playthroughs: 3\n"))
+
+	// Older versions come first.
+	older := strings.Index(report, "release 1, simulation")
+	newer := strings.Index(report, "release 2, simulation")
+	assert.True(t, older >= 0 && newer > older)
+	assert.Contains(t, report[older:newer], "not replayed")
+
+	assert.Contains(t, report[newer:], "average session length: 02:00\n")
+	assert.Contains(t, report[newer:], "score: min 100, median 100, "+
+		"90th percentile 300, max 300\n")
+	assert.Contains(t, report[newer:], "merges per minute: 30.0\n")
+	assert.Contains(t, report[newer:], "won")
+	assert.Contains(t, report[newer:], "new row pushed bricks over the top")
+	assert.NotContains(t, report[newer:], "abandoned")
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := []int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	assert.Equal(t, int64(5), percentile(sorted, 50))
+	assert.Equal(t, int64(9), percentile(sorted, 90))
+	assert.Equal(t, int64(10), percentile(sorted, 100))
+	assert.Equal(t, int64(1), percentile(sorted, 0))
+	assert.Equal(t, int64(7), percentile([]int64{7}, 50))
+}
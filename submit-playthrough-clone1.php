@@ -5,6 +5,7 @@
 <h1>Clone1 collection script</h1>
 
 <?php
+require_once "./auth-clone1.php";
 $servername = "172.232.206.74";
 $username = "playfulp_temp";
 $password = "comeonthough";
@@ -22,6 +23,8 @@ function LogError($message) {
 
 LogInfo("Start.");
 if ($_SERVER['REQUEST_METHOD'] == 'POST') {
+    VerifyRequest();
+    $table = Table("playthroughs");
     LogInfo("Attempt to connect to database.");
     $conn = new mysqli($servername, $username, $password, $dbname);
     if ($conn->connect_error) {
@@ -49,9 +52,9 @@ if ($_SERVER['REQUEST_METHOD'] == 'POST') {
             $fileContent = mysqli_real_escape_string($conn, file_get_contents($fileTmpPath));
             LogInfo("Read the file contents!");
             
-            $sql = "UPDATE playthroughs SET end_moment=now(), playthrough = '$fileContent' WHERE user = '$user' AND id = '$id'";
+            $sql = "UPDATE $table SET end_moment=now(), playthrough = '$fileContent' WHERE user = '$user' AND id = '$id'";
         } else {
-            $sql = "INSERT INTO playthroughs(start_moment, user, release_version, simulation_version, input_version, id) " .
+            $sql = "INSERT INTO $table(start_moment, user, release_version, simulation_version, input_version, id) " .
             "VALUES (now(), '$user', '$release_version', '$simulation_version', '$input_version', '$id')";
         }
         
@@ -0,0 +1,52 @@
+<?php
+require_once "./auth-clone1.php";
+$servername = "172.232.206.74";
+$username = "playfulp_temp";
+$password = "comeonthough";
+$dbname = "playfulp_clone1";
+
+// Keeps the best score of a user for a release, for get-leaderboard-clone1.php,
+// along with the name the leaderboard shows for the user. The table has a
+// unique key on (user, release_version). A score lower than the one already
+// stored is ignored, the name is always updated.
+
+function LogInfo($message) {
+    // 	file_put_contents("./submit-score-clone1.log", "INFO: " . $message . "\n", FILE_APPEND);
+}
+
+function LogError($message) {
+	file_put_contents("./submit-score-clone1.log", "ERROR: " . $message . "\n", FILE_APPEND);
+    http_response_code(513);
+	die();
+}
+
+LogInfo("Start.");
+if ($_SERVER['REQUEST_METHOD'] == 'POST') {
+    VerifyRequest();
+    $table = Table("scores");
+    LogInfo("Attempt to connect to database.");
+    $conn = new mysqli($servername, $username, $password, $dbname);
+    if ($conn->connect_error) {
+        LogError("Connection failed: " . $conn->connect_error);
+    }
+    LogInfo("Connection succeeded!");
+
+    $user = $conn->real_escape_string($_POST['user']);
+    // The leaderboard is sent one entry per line, with tabs between the
+    // columns.
+    $name = $conn->real_escape_string(str_replace(array("\t", "\r", "\n"), " ", $_POST['name'] ?? ''));
+    $release_version = intval($_POST['release_version']);
+    $score = intval($_POST['score']);
+    LogInfo("We got user: " . $user . ", release_version: " . $release_version . ", score: " . $score);
+    $sql = "INSERT INTO $table(moment, user, name, release_version, score) " .
+        "VALUES (now(), '$user', '$name', $release_version, $score) " .
+        "ON DUPLICATE KEY UPDATE moment = IF($score > score, now(), moment), score = GREATEST(score, $score), name = '$name'";
+    try {
+        $conn->query($sql);
+    } catch(Exception $e) {
+        LogError("Error inserting data: " . $e->getMessage());
+    }
+    $conn->close();
+}
+LogInfo("End.");
+?>
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Background workers are the goroutines that talk to the server while the
+// game runs: uploads, analytics, logs, the health checks. If one of them
+// panics, the game should carry on without it for a while and then try it
+// again, not stop. So they run under a Supervisor instead of deferring
+// HandlePanic themselves.
+
+// How long a worker that failed waits before it starts again. The delay
+// doubles with every failure, up to workerMaxRestartDelay.
+const workerRestartDelay = time.Second
+const workerMaxRestartDelay = time.Minute
+
+// A worker shows up on the debug overlay once it failed this many times.
+const workerFailuresShown = 2
+
+type WorkerFailure struct {
+	Worker string
+	// How many times the worker failed so far, including this time.
+	Count int64
+	// The first line of the error, the full stack goes in the crash report.
+	Error string
+}
+
+func (f WorkerFailure) String() string {
+	return fmt.Sprintf("%s failed %d times: %s", f.Worker, f.Count, f.Error)
+}
+
+// Supervisor runs workers and starts them again when they panic.
+type Supervisor struct {
+	// Where the failures of the workers are sent, if there is room.
+	Failures chan WorkerFailure
+	// Called with the stack trace of every failure, see StackTrace.
+	Report func(errorMsg string)
+
+	// Replaced by tests.
+	sleep func(time.Duration)
+}
+
+// Go runs worker on a new goroutine, named name for the failures. If worker
+// panics, it is reported and started again after a delay. If it returns,
+// that's the end of it and the returned channel is closed.
+func (s *Supervisor) Go(name string, worker func()) <-chan struct{} {
+	sleep := s.sleep
+	if sleep == nil {
+		sleep = time.Sleep
+	}
+	done := make(chan struct{})
+	go func() {
+		delay := workerRestartDelay
+		for count := int64(1); ; count++ {
+			errorMsg, failed := runWorker(worker)
+			if !failed {
+				close(done)
+				return
+			}
+			if s.Report != nil {
+				s.Report(errorMsg)
+			}
+			f := WorkerFailure{name, count,
+				strings.SplitN(errorMsg, "\n", 2)[0]}
+			select {
+			case s.Failures <- f:
+			default:
+			}
+			sleep(delay)
+			delay = min(delay*2, workerMaxRestartDelay)
+		}
+	}()
+	return done
+}
+
+// runWorker runs worker and returns its stack trace if it panics.
+func runWorker(worker func()) (errorMsg string, failed bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			errorMsg, failed = StackTrace(r), true
+		}
+	}()
+	worker()
+	return
+}
+
+// UpdateWorkerFailures takes the failures that the Supervisor sent since the
+// last frame and sends the crash reports for them.
+func (g *Gui) UpdateWorkerFailures() {
+	for {
+		select {
+		case f := <-g.supervisor.Failures:
+			if g.workerFailures == nil {
+				g.workerFailures = map[string]WorkerFailure{}
+			}
+			g.workerFailures[f.Worker] = f
+		case errorMsg := <-g.workerReports:
+			g.SendCrashReport(errorMsg, g.CrashReport(errorMsg))
+		default:
+			return
+		}
+	}
+}
+
+// WorkerFailureLines are the lines of the debug overlay about the workers
+// that keep failing, sorted by worker.
+func (g *Gui) WorkerFailureLines() (lines []string) {
+	for _, f := range g.workerFailures {
+		if f.Count >= workerFailuresShown {
+			lines = append(lines, f.String())
+		}
+	}
+	slices.Sort(lines)
+	return
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestSupervisor(t *testing.T) {
+	var reports []string
+	var sleeps []time.Duration
+	s := Supervisor{
+		Failures: make(chan WorkerFailure, 10),
+		Report:   func(errorMsg string) { reports = append(reports, errorMsg) },
+		sleep:    func(d time.Duration) { sleeps = append(sleeps, d) },
+	}
+
+	// The worker fails 3 times, then does its job and returns.
+	nRuns := 0
+	done := s.Go("uploads", func() {
+		nRuns++
+		if nRuns <= 3 {
+			panic("server sent garbage")
+		}
+	})
+	<-done
+
+	assert.Equal(t, 4, nRuns)
+	assert.Equal(t, 3, len(reports))
+	assert.Contains(t, reports[0], "server sent garbage")
+	assert.Equal(t, []time.Duration{workerRestartDelay,
+		2 * workerRestartDelay, 4 * workerRestartDelay}, sleeps)
+
+	var g Gui
+	g.supervisor = s
+	g.UpdateWorkerFailures()
+	assert.Equal(t, WorkerFailure{"uploads", 3, "server sent garbage"},
+		g.workerFailures["uploads"])
+	assert.Equal(t, []string{"uploads failed 3 times: server sent garbage"},
+		g.WorkerFailureLines())
+}
+
+func TestGui_WorkerFailureLines(t *testing.T) {
+	var g Gui
+	g.supervisor.Failures = make(chan WorkerFailure, 10)
+	g.supervisor.Failures <- WorkerFailure{"logs", 1, "once"}
+	g.supervisor.Failures <- WorkerFailure{"uploads", 2, "twice"}
+	g.supervisor.Failures <- WorkerFailure{"health", 5, "again"}
+	g.UpdateWorkerFailures()
+
+	// A single failure isn't worth showing.
+	assert.Equal(t, []string{"health failed 5 times: again",
+		"uploads failed 2 times: twice"}, g.WorkerFailureLines())
+}
+
+func TestGui_WorkerReports(t *testing.T) {
+	var g Gui
+	sink := NewFileSink(t.TempDir(), SystemClock{})
+	g.telemetry = sink
+	reports := make(chan string, 10)
+	g.workerReports = reports
+	reports <- "uploads broke"
+
+	// The report is sent from the main loop.
+	g.UpdateWorkerFailures()
+	log, err := sink.read(sink.path("log.txt"))
+	assert.Nil(t, err)
+	assert.Contains(t, log, "uploads broke")
+	assert.Empty(t, reports)
+}
+
+func TestGui_Shutdown(t *testing.T) {
+	var g Gui
+	g.clock = &FixedClock{time.UnixMilli(1700000000000)}
+	sink := NewFileSink(t.TempDir(), SystemClock{})
+	g.telemetry = sink
+	logChannel := make(chan logData, 10)
+	g.uploadLogChannel = logChannel
+	g.logsDone = g.supervisor.Go("logs", func() {
+		g.UploadLogs(logChannel)
+	})
+	g.analyticsChannel = make(chan AnalyticsEvent, 10)
+	ch := g.analyticsChannel
+	g.analyticsDone = g.supervisor.Go("analytics", func() {
+		g.UploadAnalytics("user", ch)
+	})
+
+	// What is still queued is sent before Shutdown returns.
+	g.uploadLogChannel <- logData{user: "user", level: "info",
+		message: "queued"}
+	g.Shutdown()
+	log, err := sink.read(sink.path("log.txt"))
+	assert.Nil(t, err)
+	assert.Contains(t, log, `"queued"`)
+	events, err := sink.read(sink.path("events.jsonl"))
+	assert.Nil(t, err)
+	assert.Contains(t, events, AnalyticsSessionEnd)
+	assert.Nil(t, g.uploadLogChannel)
+}
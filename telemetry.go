@@ -0,0 +1,439 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TelemetrySink is everything the game sends to or gets from a server:
+// playthroughs, logs, analytics events, user data, scores, the message of the
+// day and the remote config. The game only talks to the server through a
+// TelemetrySink, so that a self-hosted server or a different backend can take
+// the place of playful-patterns.com without changing the game.
+//
+// Every method may block and may fail. The game calls them on background
+// goroutines or with a RetryPolicy, and carries on without the server if they
+// fail.
+type TelemetrySink interface {
+	// InitializePlaythrough announces a new playthrough, before any of its
+	// data is uploaded.
+	InitializePlaythrough(user string, releaseVersion int64,
+		simulationVersion int64, inputVersion int64, id uuid.UUID) error
+	// UploadPlaythrough replaces the data of a playthrough.
+	UploadPlaythrough(user string, releaseVersion int64,
+		simulationVersion int64, inputVersion int64, id uuid.UUID,
+		data []byte) error
+	// AppendPlaythrough appends delta to the data of a playthrough, if the
+	// sink has exactly offset bytes of it. It returns how many bytes the sink
+	// has after the call, which is where the next delta starts.
+	AppendPlaythrough(user string, id uuid.UUID, offset int64,
+		delta []byte) (int64, error)
+	// UploadPlaythroughChunk is one piece of a playthrough that is too big
+	// for UploadPlaythrough, see UploadChunks. digest is the sha256 of the
+	// whole data, in hex, and total its size. The chunk is only kept if the
+	// sink has exactly offset bytes of that data. Once the sink has all of it,
+	// it replaces the data of the playthrough. It returns how many bytes of
+	// the data the sink has after the call, which is where the next chunk
+	// starts.
+	UploadPlaythroughChunk(user string, id uuid.UUID, digest string,
+		total int64, offset int64, chunk []byte) (int64, error)
+	// ListPlaythroughs returns the count most recent playthroughs of user, see
+	// ParseRecordings.
+	ListPlaythroughs(user string, count int64) (string, error)
+	// GetPlaythrough returns the data of a playthrough of user, as it was
+	// uploaded.
+	GetPlaythrough(user string, id uuid.UUID) ([]byte, error)
+	SetUserData(user string, data string) error
+	// GetUserData returns "" if there is no data for user.
+	GetUserData(user string) (string, error)
+	SubmitScore(user string, name string, releaseVersion int64,
+		score int64) error
+	// GetLeaderboard returns the count best scores of releaseVersion, see
+	// ParseLeaderboard.
+	GetLeaderboard(releaseVersion int64, count int64) (string, error)
+	GetMotd(releaseVersion int64) (string, error)
+	GetRemoteConfig(releaseVersion int64) (string, error)
+	// Ping checks that the sink answers, see CheckServerHealth.
+	Ping() error
+	// LogEvents sends a batch of analytics events, as JSON.
+	LogEvents(events string) error
+	Log(user string, releaseVersion int64, simulationVersion int64,
+		inputVersion int64, id uuid.UUID, level string, message string,
+		data []byte) error
+}
+
+// NewTelemetrySink returns the sink named kind: "http" for the PHP endpoints
+// of endpoints, "grpc" for a GrpcSink of its gRPC server, "file" for a
+// FileSink in folder, which takes its moments from clock, and "none" for a
+// NopSink. An empty kind is "grpc" in builds with grpc_enabled and "http" in
+// the others. Builds without http_enabled can't make requests, so "http" is a
+// NopSink there.
+func NewTelemetrySink(kind string, endpoints Endpoints, folder string,
+	clock Clock) TelemetrySink {
+	if kind == "" {
+		kind = defaultTelemetry
+	}
+	switch kind {
+	case "http":
+		return NewHttpSink(endpoints.Url, endpoints.Name)
+	case "grpc":
+		return NewGrpcSink(endpoints.GrpcUrl, endpoints.Name,
+			NewHttpSink(endpoints.Url, endpoints.Name))
+	case "file":
+		return NewFileSink(folder, clock)
+	case "none":
+		return NopSink{}
+	default:
+		panic(fmt.Errorf("unknown telemetry sink: %s", kind))
+	}
+}
+
+// NopSink drops everything it gets and has nothing to give, as if the server
+// were empty.
+type NopSink struct{}
+
+func (NopSink) InitializePlaythrough(string, int64, int64, int64,
+	uuid.UUID) error {
+	return nil
+}
+
+func (NopSink) UploadPlaythrough(string, int64, int64, int64, uuid.UUID,
+	[]byte) error {
+	return nil
+}
+
+func (NopSink) AppendPlaythrough(_ string, _ uuid.UUID, offset int64,
+	delta []byte) (int64, error) {
+	return offset + int64(len(delta)), nil
+}
+
+func (NopSink) UploadPlaythroughChunk(_ string, _ uuid.UUID, _ string,
+	_ int64, offset int64, chunk []byte) (int64, error) {
+	return offset + int64(len(chunk)), nil
+}
+
+func (NopSink) ListPlaythroughs(string, int64) (string, error) {
+	return "", nil
+}
+
+func (NopSink) GetPlaythrough(_ string, id uuid.UUID) ([]byte, error) {
+	return nil, fmt.Errorf("unknown playthrough: %v", id)
+}
+
+func (NopSink) SetUserData(string, string) error {
+	return nil
+}
+
+func (NopSink) GetUserData(string) (string, error) {
+	return "", nil
+}
+
+func (NopSink) SubmitScore(string, string, int64, int64) error {
+	return nil
+}
+
+func (NopSink) GetLeaderboard(int64, int64) (string, error) {
+	return "", nil
+}
+
+func (NopSink) GetMotd(int64) (string, error) {
+	return "", nil
+}
+
+func (NopSink) GetRemoteConfig(int64) (string, error) {
+	return "", nil
+}
+
+func (NopSink) Ping() error {
+	return nil
+}
+
+func (NopSink) LogEvents(string) error {
+	return nil
+}
+
+func (NopSink) Log(string, int64, int64, int64, uuid.UUID, string, string,
+	[]byte) error {
+	return nil
+}
+
+// FileSink keeps everything in a folder, for playing without a server or for
+// collecting playthroughs on a test machine. The folder is laid out like
+// this:
+//
+//	playthroughs/<id>.clone1     the data of each playthrough
+//	playthroughs/<id>.<digest>   the chunks of an upload, until it is done
+//	playthroughs.txt             the user, id, start and versions of each
+//	                             playthrough, one per line
+//	users/<user>.yaml            the UserData of each user
+//	scores-<release>.txt         the best score of each user, see
+//	                             ParseLeaderboard
+//	events.jsonl                 one batch of analytics events per line
+//	log.txt                      one log message per line
+//	logs/<id>.clone1             the playthrough sent with the last error
+//	motd.txt                     the message of the day, written by hand
+//	remote-config.yaml           the RemoteConfig, written by hand
+type FileSink struct {
+	Folder string
+	// Where the moments in playthroughs.txt and log.txt come from.
+	Clock Clock
+	// Scores are read, updated and written back, so submitting them must not
+	// overlap.
+	mutex *sync.Mutex
+}
+
+func NewFileSink(folder string, clock Clock) FileSink {
+	return FileSink{Folder: folder, Clock: clock, mutex: &sync.Mutex{}}
+}
+
+func (s FileSink) path(elem ...string) string {
+	return filepath.Join(append([]string{s.Folder}, elem...)...)
+}
+
+// write writes data to a file of the sink, creating the folders it needs.
+func (s FileSink) write(name string, data []byte, flag int) error {
+	err := os.MkdirAll(filepath.Dir(name), os.ModePerm)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|flag, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// read returns the contents of a file of the sink, or "" if it doesn't exist.
+func (s FileSink) read(name string) (string, error) {
+	data, err := os.ReadFile(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	return string(data), err
+}
+
+func (s FileSink) playthroughPath(id uuid.UUID) string {
+	return s.path("playthroughs", id.String()+".clone1")
+}
+
+func (s FileSink) InitializePlaythrough(user string, releaseVersion int64,
+	simulationVersion int64, inputVersion int64, id uuid.UUID) error {
+	line := strings.Join([]string{
+		user,
+		id.String(),
+		s.Clock.Now().Format(recordingStartLayout),
+		strconv.FormatInt(releaseVersion, 10),
+		strconv.FormatInt(simulationVersion, 10),
+		strconv.FormatInt(inputVersion, 10)}, "\t")
+	err := s.write(s.path("playthroughs.txt"), []byte(line+"\n"),
+		os.O_APPEND)
+	if err != nil {
+		return err
+	}
+	return s.write(s.playthroughPath(id), nil, os.O_TRUNC)
+}
+
+func (s FileSink) UploadPlaythrough(_ string, _ int64, _ int64, _ int64,
+	id uuid.UUID, data []byte) error {
+	return s.write(s.playthroughPath(id), data, os.O_TRUNC)
+}
+
+func (s FileSink) AppendPlaythrough(_ string, id uuid.UUID, offset int64,
+	delta []byte) (int64, error) {
+	name := s.playthroughPath(id)
+	size := int64(0)
+	info, err := os.Stat(name)
+	if err == nil {
+		size = info.Size()
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return 0, err
+	}
+	if size != offset {
+		return size, nil
+	}
+	err = s.write(name, delta, os.O_APPEND)
+	if err != nil {
+		return 0, err
+	}
+	return size + int64(len(delta)), nil
+}
+
+func (s FileSink) UploadPlaythroughChunk(_ string, id uuid.UUID,
+	digest string, total int64, offset int64, chunk []byte) (int64, error) {
+	final := s.playthroughPath(id)
+	name := s.path("playthroughs", id.String()+"."+digest)
+	size := int64(0)
+	info, err := os.Stat(name)
+	if err == nil {
+		size = info.Size()
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return 0, err
+	} else if data, err := os.ReadFile(final); err == nil &&
+		Sha256Hex(data) == digest {
+		// The upload is already done, the answer to its last chunk was lost.
+		return total, nil
+	}
+	if size == offset && offset+int64(len(chunk)) <= total {
+		err = s.write(name, chunk, os.O_APPEND)
+		if err != nil {
+			return 0, err
+		}
+		size += int64(len(chunk))
+	}
+	if size == total {
+		err = os.Rename(name, final)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return size, nil
+}
+
+// ListPlaythroughs lists the playthroughs of user in playthroughs.txt, with
+// the size of their data.
+func (s FileSink) ListPlaythroughs(user string, count int64) (string, error) {
+	index, err := s.read(s.path("playthroughs.txt"))
+	if err != nil {
+		return "", err
+	}
+	var entries []RecordingEntry
+	seen := map[string]bool{}
+	for _, line := range strings.Split(index, "\n") {
+		columns := strings.SplitN(line, "\t", 2)
+		// A playthrough is initialized again when its data goes to another
+		// server, see FailoverSink, the first time is when it started.
+		if len(columns) < 2 || columns[0] != user || seen[columns[1]] {
+			continue
+		}
+		seen[columns[1]] = true
+		parsed, err := ParseRecordings(columns[1] + "\t0")
+		if err != nil {
+			return "", err
+		}
+		e := parsed[0]
+		info, err := os.Stat(s.playthroughPath(e.Id))
+		if err == nil {
+			e.Size = info.Size()
+		}
+		entries = append(entries, e)
+	}
+	slices.Reverse(entries)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Start > entries[j].Start
+	})
+	return FormatRecordings(entries[:min(count, int64(len(entries)))]), nil
+}
+
+func (s FileSink) GetPlaythrough(_ string, id uuid.UUID) ([]byte, error) {
+	return os.ReadFile(s.playthroughPath(id))
+}
+
+func (s FileSink) SetUserData(user string, data string) error {
+	return s.write(s.path("users", user+".yaml"), []byte(data), os.O_TRUNC)
+}
+
+func (s FileSink) GetUserData(user string) (string, error) {
+	return s.read(s.path("users", user+".yaml"))
+}
+
+func (s FileSink) scoresPath(releaseVersion int64) string {
+	return s.path(fmt.Sprintf("scores-%d.txt", releaseVersion))
+}
+
+// SubmitScore keeps the best score of each user, like the server does.
+func (s FileSink) SubmitScore(user string, name string, releaseVersion int64,
+	score int64) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	data, err := s.read(s.scoresPath(releaseVersion))
+	if err != nil {
+		return err
+	}
+	entries, err := ParseLeaderboard(data)
+	if err != nil {
+		return err
+	}
+	found := false
+	for i := range entries {
+		if entries[i].User == user {
+			found = true
+			entries[i].Name = name
+			entries[i].Score = max(entries[i].Score, score)
+		}
+	}
+	if !found {
+		entries = append(entries, LeaderboardEntry{user, name, score})
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Score > entries[j].Score
+	})
+	return s.write(s.scoresPath(releaseVersion),
+		[]byte(FormatLeaderboard(entries)), os.O_TRUNC)
+}
+
+func (s FileSink) GetLeaderboard(releaseVersion int64,
+	count int64) (string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	data, err := s.read(s.scoresPath(releaseVersion))
+	if err != nil {
+		return "", err
+	}
+	entries, err := ParseLeaderboard(data)
+	if err != nil {
+		return "", err
+	}
+	return FormatLeaderboard(entries[:min(count, int64(len(entries)))]), nil
+}
+
+func (s FileSink) GetMotd(int64) (string, error) {
+	return s.read(s.path("motd.txt"))
+}
+
+func (s FileSink) GetRemoteConfig(int64) (string, error) {
+	return s.read(s.path("remote-config.yaml"))
+}
+
+func (s FileSink) Ping() error {
+	return os.MkdirAll(s.Folder, os.ModePerm)
+}
+
+func (s FileSink) LogEvents(events string) error {
+	return s.write(s.path("events.jsonl"), []byte(events+"\n"), os.O_APPEND)
+}
+
+func (s FileSink) Log(user string, releaseVersion int64,
+	simulationVersion int64, inputVersion int64, id uuid.UUID, level string,
+	message string, data []byte) error {
+	line := strings.Join([]string{
+		s.Clock.Now().Format(time.RFC3339),
+		user,
+		strconv.FormatInt(releaseVersion, 10),
+		strconv.FormatInt(simulationVersion, 10),
+		strconv.FormatInt(inputVersion, 10),
+		id.String(),
+		level,
+		strconv.Quote(message)}, "\t")
+	err := s.write(s.path("log.txt"), []byte(line+"\n"), os.O_APPEND)
+	if err != nil || len(data) == 0 {
+		return err
+	}
+	return s.write(s.path("logs", id.String()+".clone1"), data, os.O_TRUNC)
+}
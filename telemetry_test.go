@@ -0,0 +1,172 @@
+package main
+
+import (
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileSink_Playthrough(t *testing.T) {
+	s := NewFileSink(t.TempDir(), SystemClock{})
+	id := uuid.New()
+	assert.Nil(t, s.InitializePlaythrough("user", 1, 2, 3, id))
+
+	// Deltas only go through if they start where the data ends.
+	n, err := s.AppendPlaythrough("user", id, 0, []byte("abc"))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), n)
+	n, err = s.AppendPlaythrough("user", id, 1, []byte("xyz"))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), n)
+	n, err = s.AppendPlaythrough("user", id, 3, []byte("def"))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(6), n)
+	data, err := os.ReadFile(s.playthroughPath(id))
+	assert.Nil(t, err)
+	assert.Equal(t, "abcdef", string(data))
+
+	// The whole playthrough replaces the deltas.
+	assert.Nil(t, s.UploadPlaythrough("user", 1, 2, 3, id, []byte("final")))
+	data, err = os.ReadFile(s.playthroughPath(id))
+	assert.Nil(t, err)
+	assert.Equal(t, "final", string(data))
+}
+
+func TestFileSink_UserData(t *testing.T) {
+	s := NewFileSink(t.TempDir(), SystemClock{})
+	data, err := s.GetUserData("user")
+	assert.Nil(t, err)
+	assert.Equal(t, "", data)
+
+	assert.Nil(t, s.SetUserData("user", "BestScore: 10\n"))
+	data, err = s.GetUserData("user")
+	assert.Nil(t, err)
+	assert.Equal(t, "BestScore: 10\n", data)
+	assert.Equal(t, int64(10), LoadUserData(s, "user").BestScore)
+}
+
+func TestFileSink_Leaderboard(t *testing.T) {
+	s := NewFileSink(t.TempDir(), SystemClock{})
+	assert.Nil(t, s.SubmitScore("a", "alice", 1, 50))
+	assert.Nil(t, s.SubmitScore("b", "", 1, 70))
+	assert.Nil(t, s.SubmitScore("c", "carol", 1, 60))
+	// Only the best score of each player counts.
+	assert.Nil(t, s.SubmitScore("a", "alice", 1, 40))
+	assert.Nil(t, s.SubmitScore("c", "carol", 1, 80))
+	// Other releases have their own leaderboard.
+	assert.Nil(t, s.SubmitScore("d", "dan", 2, 100))
+
+	l := LoadLeaderboard(s, 1, "a", 50)
+	assert.Nil(t, l.Err)
+	assert.Equal(t, []LeaderboardEntry{
+		{"c", "carol", 80},
+		{"b", "", 70},
+		{"a", "alice", 50}}, l.Entries)
+	assert.Equal(t, int64(3), l.Rank)
+
+	top, err := s.GetLeaderboard(1, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, "c\tcarol\t80\nb\t\t70\n", top)
+}
+
+func TestFileSink_Logs(t *testing.T) {
+	folder := t.TempDir()
+	clock := FixedClock{time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)}
+	s := NewFileSink(folder, clock)
+	id := uuid.New()
+	assert.Nil(t, s.InitializePlaythrough("user", 1, 2, 3, id))
+	assert.Nil(t, s.LogEvents(`{"events":[]}`))
+	assert.Nil(t, s.LogEvents(`{"events":[1]}`))
+	assert.Nil(t, s.Log("user", 1, 2, 3, id, "error", "oops", []byte("data")))
+
+	events, err := os.ReadFile(filepath.Join(folder, "events.jsonl"))
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"events\":[]}\n{\"events\":[1]}\n", string(events))
+	log, err := os.ReadFile(filepath.Join(folder, "log.txt"))
+	assert.Nil(t, err)
+	assert.Equal(t, "2024-05-06T07:08:09Z\tuser\t1\t2\t3\t"+id.String()+
+		"\terror\t\"oops\"\n", string(log))
+	playthroughs, err := os.ReadFile(filepath.Join(folder, "playthroughs.txt"))
+	assert.Nil(t, err)
+	assert.Equal(t, "user\t"+id.String()+"\t2024-05-06 07:08:09\t1\t2\t3\n",
+		string(playthroughs))
+	data, err := os.ReadFile(filepath.Join(folder, "logs",
+		id.String()+".clone1"))
+	assert.Nil(t, err)
+	assert.Equal(t, "data", string(data))
+}
+
+func TestNopSink(t *testing.T) {
+	var s TelemetrySink = NopSink{}
+	// Deltas are accepted, so uploads don't retry them forever.
+	n, err := s.AppendPlaythrough("user", uuid.New(), 10, []byte("abc"))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(13), n)
+	assert.Equal(t, UserData{}, LoadUserData(s, "user"))
+	assert.Nil(t, LoadLeaderboard(s, 1, "user", 0).Entries)
+}
+
+func TestNewTelemetrySink(t *testing.T) {
+	clock := SystemClock{}
+	assert.Equal(t, NopSink{},
+		NewTelemetrySink("none", Endpoints{}, "", clock))
+	assert.Equal(t, "folder",
+		NewTelemetrySink("file", Endpoints{}, "folder", clock).(FileSink).Folder)
+	assert.Panics(t, func() {
+		NewTelemetrySink("carrier-pigeon", Endpoints{}, "", clock)
+	})
+}
+
+func TestFileSink_ListPlaythroughs(t *testing.T) {
+	s := NewFileSink(t.TempDir(), SystemClock{})
+	first, second := uuid.New(), uuid.New()
+	assert.Nil(t, s.InitializePlaythrough("user", 1, 2, 3, first))
+	assert.Nil(t, s.InitializePlaythrough("other", 1, 2, 3, uuid.New()))
+	assert.Nil(t, s.InitializePlaythrough("user", 1, 2, 3, second))
+	assert.Nil(t, s.UploadPlaythrough("user", 1, 2, 3, second, []byte("abc")))
+
+	// Most recent first, and only count of them.
+	entries, err := ParseRecordings(listPlaythroughs(t, s, "user", 10))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(entries))
+	assert.Equal(t, second, entries[0].Id)
+	assert.Equal(t, int64(3), entries[0].Size)
+	assert.Equal(t, first, entries[1].Id)
+	assert.Equal(t, int64(0), entries[1].Size)
+	entries, err = ParseRecordings(listPlaythroughs(t, s, "user", 1))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(entries))
+
+	data, err := s.GetPlaythrough("user", second)
+	assert.Nil(t, err)
+	assert.Equal(t, "abc", string(data))
+}
+
+func listPlaythroughs(t *testing.T, s FileSink, user string,
+	count int64) string {
+	list, err := s.ListPlaythroughs(user, count)
+	assert.Nil(t, err)
+	return list
+}
+
+func TestSelectEndpoints(t *testing.T) {
+	expected := productionEndpoints
+	if stagingEnabled {
+		expected = stagingEndpoints
+	}
+	assert.Equal(t, expected, SelectEndpoints("", "", "", false))
+	assert.Equal(t, stagingEndpoints, SelectEndpoints("", "", "", true))
+	assert.Equal(t, productionEndpoints,
+		SelectEndpoints("production", "", "", true))
+
+	// The urls can be replaced, the data still goes to staging.
+	e := SelectEndpoints("staging", "http://localhost", "", false)
+	assert.Equal(t, "staging", e.Name)
+	assert.Equal(t, "http://localhost", e.Url)
+	assert.Equal(t, stagingEndpoints.GrpcUrl, e.GrpcUrl)
+
+	assert.Panics(t, func() { SelectEndpoints("moon", "", "", false) })
+}
@@ -0,0 +1,6 @@
+// Package telemetrypb holds the gRPC service that GrpcSink talks to,
+// generated from telemetry.proto. It is separate from clone1pb so that only
+// builds with grpc_enabled depend on gRPC.
+package telemetrypb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative telemetry.proto
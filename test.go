@@ -1,9 +1,14 @@
 package main
 
-import "fmt"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
 
 type Test struct {
-	Bricks []TestBrick `yaml:"Bricks"`
+	Bricks     []TestBrick      `yaml:"Bricks"`
+	Difficulty DifficultyParams `yaml:"Difficulty"`
 }
 
 type TestBrick struct {
@@ -12,13 +17,53 @@ type TestBrick struct {
 	Offset      Pt     `yaml:"Offset"`
 	ChainedType string `yaml:"ChainedType"`
 	ChainedVal  int64  `yaml:"ChainedVal"`
+	Stone       bool   `yaml:"Stone"`
+}
+
+// testFrameSeparator separates a recording from one of its frames in a
+// TestFile, e.g. "recording.clone1@frame=1234".
+const testFrameSeparator = "@frame="
+
+// LoadTest reads a Test from a YAML file. Any difficulty parameter that is not
+// specified in the file keeps its default value.
+// The file can also be a frame of a recording, see LoadTestFromRecording.
+func LoadTest(fsys FS, filename string) (t Test) {
+	if recording, frame, ok := strings.Cut(filename,
+		testFrameSeparator); ok {
+		frameIdx, err := strconv.ParseInt(frame, 10, 64)
+		Check(err)
+		return LoadTestFromRecording(fsys, recording, frameIdx)
+	}
+	t.Difficulty = DefaultDifficultyParams()
+	LoadYAML(fsys, filename, &t)
+	return
+}
+
+// LoadTestFromRecording replays the recording up to frameIdx and returns the
+// bricks of the World at that frame as a Test, so that the situation in a bug
+// report can be played with, without saving it to a YAML file first.
+func LoadTestFromRecording(fsys FS, filename string, frameIdx int64) Test {
+	data, err := fsys.ReadFile(filename)
+	Check(err)
+	p := DeserializePlaythrough(data)
+	if frameIdx < 0 || frameIdx > int64(len(p.History)) {
+		Check(fmt.Errorf("can't load frame %d of %s, which has %d frames",
+			frameIdx, filename, len(p.History)))
+	}
+	w := NewWorldFromPlaythrough(p)
+	for i := range frameIdx {
+		w.Step(p.History[i])
+	}
+	return TestFromWorld(&w)
 }
 
 func (t *Test) GetLevel() (l Level) {
 	l.TimerDisabled = true
+	l.DifficultyParams = t.Difficulty
 	for _, b := range t.Bricks {
 		var bp BrickParams
 		bp.Val = b.Value
+		bp.Stone = b.Stone
 		bp.Pos = CanonicalPosToPixelPos(b.Pos)
 		bp.Pos.Add(b.Offset)
 		l.BricksParams = append(l.BricksParams, bp)
@@ -43,3 +88,47 @@ func (t *Test) GetLevel() (l Level) {
 	}
 	return
 }
+
+// TestFromWorld describes the bricks and chains of w as a Test, so that a
+// configuration seen in a recording can be saved and edited by hand.
+// Each brick is placed at the closest canonical position, plus the offset to
+// its actual position. A Test can only chain two bricks, so the bricks of
+// bigger chain groups are saved without their chains.
+func TestFromWorld(w *World) (t Test) {
+	t.Difficulty = w.DifficultyParams
+	for _, g := range w.ChainGroups {
+		if len(g.Links) != 1 {
+			continue
+		}
+		b1, b2 := w.GetBrick(g.Links[0].Brick1), w.GetBrick(g.Links[0].Brick2)
+		// The chained brick is the one to the right or on top.
+		if b1.PixelPos.X > b2.PixelPos.X || b1.PixelPos.Y < b2.PixelPos.Y {
+			b1, b2 = b2, b1
+		}
+		tb := testBrickAt(b1)
+		tb.ChainedVal = b2.Val
+		if b1.PixelPos.Y == b2.PixelPos.Y {
+			tb.ChainedType = "right"
+		} else {
+			tb.ChainedType = "top"
+		}
+		t.Bricks = append(t.Bricks, tb)
+	}
+	for i := range w.Bricks {
+		b := &w.Bricks[i]
+		if b.Group != 0 && len(w.GetChainGroup(b.Group).Links) == 1 {
+			// Saved along with the brick it is chained to.
+			continue
+		}
+		t.Bricks = append(t.Bricks, testBrickAt(b))
+	}
+	return
+}
+
+func testBrickAt(b *Brick) (tb TestBrick) {
+	tb.Value = b.Val
+	tb.Stone = b.Stone
+	tb.Pos = PixelPosToCanonicalPos(b.PixelPos)
+	tb.Offset = CanonicalPosToPixelPos(tb.Pos).To(b.PixelPos)
+	return
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"time"
+)
+
+// UploadLimiter spaces out uploads so that, on average, they don't send more
+// than BytesPerSecond. Each upload goes out at once if the connection is
+// free, but the next one waits until the previous ones would have taken
+// BytesPerSecond to send. This works the same in the browser, where the
+// request body can't be sent slowly, and it keeps the game from taking over
+// the player's connection during long sessions. An upload that is waiting
+// doesn't hold back the game, it runs on the uploading goroutine.
+// None of this ends up in a recording, so it doesn't go through the Clock.
+type UploadLimiter struct {
+	// 0 means no limit.
+	BytesPerSecond int64
+	// When the uploads so far are done, at BytesPerSecond.
+	free time.Time
+
+	// Replaced by tests.
+	sleep func(time.Duration)
+	now   func() time.Time
+}
+
+// Wait blocks until an upload of nBytes may start.
+func (l *UploadLimiter) Wait(nBytes int64) {
+	if l.BytesPerSecond <= 0 {
+		return
+	}
+	sleep, now := l.sleep, l.now
+	if sleep == nil {
+		sleep = time.Sleep
+	}
+	if now == nil {
+		now = time.Now
+	}
+
+	start := now()
+	if l.free.Before(start) {
+		l.free = start
+	}
+	wait := l.free.Sub(start)
+	l.free = l.free.Add(time.Duration(nBytes) * time.Second /
+		time.Duration(l.BytesPerSecond))
+	if wait > 0 {
+		sleep(wait)
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func (f *fakeTime) limiter(bytesPerSecond int64) *UploadLimiter {
+	return &UploadLimiter{
+		BytesPerSecond: bytesPerSecond,
+		now:            func() time.Time { return f.t },
+		sleep: func(d time.Duration) {
+			f.sleeps = append(f.sleeps, d)
+			f.t = f.t.Add(d)
+		},
+	}
+}
+
+func TestUploadLimiter(t *testing.T) {
+	var f fakeTime
+	l := f.limiter(1000)
+
+	// The first upload goes out at once, the next ones wait for it.
+	l.Wait(2000)
+	l.Wait(500)
+	l.Wait(100)
+	assert.Equal(t, []time.Duration{2 * time.Second,
+		500 * time.Millisecond}, f.sleeps)
+
+	// Time that passed without uploads isn't saved up for later.
+	f.sleeps = nil
+	f.t = f.t.Add(time.Minute)
+	l.Wait(1000)
+	l.Wait(1000)
+	assert.Equal(t, []time.Duration{time.Second}, f.sleeps)
+}
+
+func TestUploadLimiter_NoLimit(t *testing.T) {
+	var f fakeTime
+	l := f.limiter(0)
+	l.Wait(1000000)
+	l.Wait(1000000)
+	assert.Empty(t, f.sleeps)
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"github.com/hajimehoshi/ebiten/v2"
+	"strconv"
+)
+
+// FrameToTimecode returns the time at which frameIdx happens, as mm:ss.
+// Playthroughs are recorded at one input per tick, so a frame index is also
+// a time. This assumes the playthrough was recorded with a SlowdownFactor of
+// 1, which is the case for everything recorded by players. A pause is a
+// single input, so the time is the time spent playing.
+func FrameToTimecode(frameIdx int64) string {
+	seconds := frameIdx / ebiten.DefaultTPS
+	return fmt.Sprintf("%02d:%02d", seconds/60, seconds%60)
+}
+
+// TimecodeToFrame returns the first frame of the time typed as digits, where
+// the last two digits are the seconds and the ones before them are the
+// minutes, like on a microwave: "300" is 3:00 and "90" is 1:30. It returns
+// false if digits isn't made of digits.
+func TimecodeToFrame(digits string) (int64, bool) {
+	if digits == "" {
+		return 0, false
+	}
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+	}
+	split := max(len(digits)-2, 0)
+	minutes, _ := strconv.ParseInt("0"+digits[:split], 10, 64)
+	seconds, _ := strconv.ParseInt(digits[split:], 10, 64)
+	return (minutes*60 + seconds) * ebiten.DefaultTPS, true
+}
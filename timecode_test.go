@@ -0,0 +1,26 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTimecode(t *testing.T) {
+	assert.Equal(t, "00:00", FrameToTimecode(0))
+	assert.Equal(t, "00:01", FrameToTimecode(119))
+	assert.Equal(t, "03:05", FrameToTimecode(185*60))
+	assert.Equal(t, "61:00", FrameToTimecode(3660*60))
+
+	frameIdx, ok := TimecodeToFrame("300")
+	assert.True(t, ok)
+	assert.Equal(t, int64(180*60), frameIdx)
+	frameIdx, _ = TimecodeToFrame("90")
+	assert.Equal(t, int64(90*60), frameIdx)
+	frameIdx, _ = TimecodeToFrame("7")
+	assert.Equal(t, int64(7*60), frameIdx)
+
+	_, ok = TimecodeToFrame("")
+	assert.False(t, ok)
+	_, ok = TimecodeToFrame("3:00")
+	assert.False(t, ok)
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"slices"
+)
+
+// Settled returns true if nothing in the World is moving on its own or held
+// by the player, so that the World can be described by a Level without losing
+// anything that matters for the next frames.
+func (w *World) Settled() bool {
+	if w.State != Regular {
+		return false
+	}
+	for i := range w.Bricks {
+		if w.Bricks[i].State == Dragged || w.Bricks[i].State == Falling {
+			return false
+		}
+	}
+	return true
+}
+
+// ToLevel returns a Level that starts with the bricks and chains of the World.
+// The timer is disabled, like in the levels of the regression tests, because
+// the Level can't say how far along the timer was.
+func (w *World) ToLevel() (l Level) {
+	l.TimerDisabled = true
+	l.AllowOverlappingDrags = w.AllowOverlappingDrags
+	l.Mode = w.Mode
+	l.PushNowEnabled = w.PushNowEnabled
+	l.MaxBrickValue = w.MaxBrickValue
+	l.MaxInitialBrickValue = w.MaxInitialBrickValue
+	l.DifficultyParams = w.DifficultyParams
+	for i := range w.Bricks {
+		b := &w.Bricks[i]
+		l.BricksParams = append(l.BricksParams,
+			BrickParams{Pos: b.PixelPos, Val: b.Val, Stone: b.Stone})
+	}
+	brickIdx := func(id int64) int64 {
+		return int64(slices.IndexFunc(w.Bricks, func(b Brick) bool {
+			return b.Id == id
+		}))
+	}
+	for _, g := range w.ChainGroups {
+		for _, link := range g.Links {
+			// ChainBricks wants the left or top brick first, which a chain
+			// that was rotated may no longer have.
+			b1, b2 := w.GetBrick(link.Brick1), w.GetBrick(link.Brick2)
+			if b1.CanonicalPos.X > b2.CanonicalPos.X ||
+				b1.CanonicalPos.Y > b2.CanonicalPos.Y {
+				b1, b2 = b2, b1
+			}
+			l.ChainsParams = append(l.ChainsParams,
+				ChainParams{brickIdx(b1.Id), brickIdx(b2.Id)})
+		}
+	}
+	return
+}
+
+// Trim returns frames start to end (not included) of p, as a playthrough of
+// their own. The playthrough starts from the World as it is at frame start,
+// see ToLevel. This is meant for turning long recordings into small
+// regression tests.
+// The trimmed playthrough has no FinalHash, as it doesn't end like p.
+// The World must be Settled at frame start. If it isn't, the error says
+// which was the last frame before start at which it was.
+func (p *Playthrough) Trim(start int64, end int64) (t Playthrough) {
+	if start < 0 || start > end || end > int64(len(p.History)) {
+		Check(fmt.Errorf("can't trim frames %d to %d of a playthrough "+
+			"with %d frames", start, end, len(p.History)))
+	}
+	w := NewWorldFromPlaythrough(*p)
+	lastSettled := int64(-1)
+	for i := range start {
+		if w.Settled() {
+			lastSettled = i
+		}
+		w.Step(p.History[i])
+	}
+	if !w.Settled() {
+		Check(fmt.Errorf("can't trim at frame %d, the World is not settled "+
+			"there - the last frame before it that is settled is %d", start,
+			lastSettled))
+	}
+
+	t = *p.Clone()
+	t.Id = uuid.New()
+	t.Level = w.ToLevel()
+	t.History = slices.Clone(p.History[start:end])
+	t.FinalHash = FrameHash{}
+	return
+}
+
+// Split returns every game of the session that has any input, as separate
+// playthroughs. A new game starts whenever the player resets the World or
+// starts over from a menu.
+func (s *Session) Split() (games []Playthrough) {
+	for _, p := range s.Playthroughs {
+		if len(p.History) > 0 {
+			games = append(games, *p.Clone())
+		}
+	}
+	return
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPlaythrough_Trim(t *testing.T) {
+	p := DeserializePlaythrough(ReadFile("data/demo.clone1"))
+	p.FinalHash = FrameHash{1}
+	w := NewWorldFromPlaythrough(p)
+	start := int64(1000)
+	for i := range start {
+		w.Step(p.History[i])
+	}
+	for !w.Settled() {
+		w.Step(p.History[start])
+		start++
+	}
+	end := start + 300
+
+	trimmed := p.Trim(start, end)
+	assert.Equal(t, end-start, int64(len(trimmed.History)))
+	assert.NotEqual(t, p.Id, trimmed.Id)
+
+	// The trimmed playthrough starts from the same bricks.
+	tw := NewWorldFromPlaythrough(trimmed)
+	assert.Equal(t, len(w.Bricks), len(tw.Bricks))
+	assert.Equal(t, len(w.ChainGroups), len(tw.ChainGroups))
+	for i := range w.Bricks {
+		assert.Equal(t, w.Bricks[i].PixelPos, tw.Bricks[i].PixelPos)
+		assert.Equal(t, w.Bricks[i].Val, tw.Bricks[i].Val)
+	}
+	r := Replay(trimmed)
+	assert.Equal(t, int64(-1), r.CrashFrameIdx)
+	assert.False(t, r.HasFinalHash)
+
+	// It survives serialization, like any other playthrough.
+	loaded := DeserializePlaythrough(trimmed.Serialize())
+	assert.Equal(t, trimmed.BricksParams, loaded.BricksParams)
+	assert.Equal(t, len(trimmed.ChainsParams), len(loaded.ChainsParams))
+	assert.Equal(t, trimmed.History, loaded.History)
+}
+
+func TestPlaythrough_TrimNotSettled(t *testing.T) {
+	p := DeserializePlaythrough(ReadFile("data/demo.clone1"))
+	// The first rows of bricks are still coming up.
+	assert.Panics(t, func() { p.Trim(1, 10) })
+	assert.Panics(t, func() { p.Trim(10, 1) })
+}
+
+func TestSession_Split(t *testing.T) {
+	p := DeserializePlaythrough(ReadFile("data/demo.clone1"))
+	var s Session
+	s.Playthroughs = []Playthrough{p, {}, p}
+	games := s.Split()
+	assert.Len(t, games, 2)
+	assert.Equal(t, p.History, games[1].History)
+}
+
+func TestWorld_ToLevel(t *testing.T) {
+	var l Level
+	l.TimerDisabled = true
+	l.BricksParams = []BrickParams{
+		{Pos: CanonicalPosToPixelPos(Pt{0, 0}), Val: 1},
+		{Pos: CanonicalPosToPixelPos(Pt{1, 0}), Val: 2},
+		{Pos: CanonicalPosToPixelPos(Pt{2, 0}), Stone: true},
+	}
+	l.ChainsParams = []ChainParams{{0, 1}}
+	w := NewWorld(0, l)
+	w.Step(PlayerInput{})
+
+	l2 := w.ToLevel()
+	assert.Equal(t, l.BricksParams, l2.BricksParams)
+	assert.Equal(t, l.ChainsParams, l2.ChainsParams)
+	assert.True(t, l2.TimerDisabled)
+}
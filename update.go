@@ -3,14 +3,22 @@ package main
 import (
 	"fmt"
 	"github.com/goccy/go-yaml"
+	"github.com/google/uuid"
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/inpututil"
 	"slices"
+	"strconv"
+	"strings"
 )
 
 func (g *Gui) Update() error {
 	defer g.HandlePanic()
 
+	if ebiten.IsWindowBeingClosed() {
+		g.CloseWindow()
+		return ebiten.Termination
+	}
+
 	if g.folderWatcher1.FolderContentsChanged() {
 		g.LoadGuiData()
 	}
@@ -21,14 +29,14 @@ func (g *Gui) Update() error {
 		// the test.
 		g.LoadGuiData()
 		if g.LoadTest {
-			var test Test
-			LoadYAML(g.FSys, g.TestFile, &test)
+			test := LoadTest(g.FSys, g.TestFile)
 			g.playthrough.Level = test.GetLevel()
 		}
 		g.InitializeWorldToNewGame()
 	}
 
 	g.pointer = g.GetPointerState()
+	g.touchPointers = g.GetTouchPointers()
 	if g.pointer.JustPressed {
 		g.Log("info", fmt.Sprintf("JustPressed. frameIdx: %d", g.frameIdx))
 	}
@@ -38,6 +46,14 @@ func (g *Gui) Update() error {
 	g.justPressedKeys = g.justPressedKeys[:0]
 	g.justPressedKeys = inpututil.AppendJustPressedKeys(g.justPressedKeys)
 
+	if g.enableDebugAreas && g.JustPressedKey(ebiten.KeyF2) {
+		g.showBrickInternals = !g.showBrickInternals
+	}
+
+	if g.gifExport != nil {
+		g.UpdateGifExport()
+	}
+
 	switch g.state {
 	case HomeScreen:
 		g.UpdateHomeScreen()
@@ -53,27 +69,123 @@ func (g *Gui) Update() error {
 		g.UpdatePlayback()
 	case DebugCrash:
 		g.UpdateDebugCrash()
+	case LeaderboardScreen:
+		g.UpdateLeaderboardScreen()
+	case RecordingsScreen:
+		g.UpdateRecordingsScreen()
+	case ConsentScreen:
+		g.UpdateConsentScreen()
 	default:
 		panic("unhandled default case")
 	}
 
+	select {
+	case g.serverHealth = <-g.healthChannel:
+	default:
+	}
+	g.UpdateWorkerFailures()
+	g.UpdateUserData()
+	g.ReceiveMotd()
+
+	g.sessionFrameIdx++
 	return nil
 }
 
 func (g *Gui) UpdateHomeScreen() {
+	playerActed := g.pointer.JustPressed || len(g.justPressedKeys) > 0
+	if g.attractMode {
+		if playerActed {
+			// The first input only brings back the menu. It shouldn't also
+			// press a button that the player can barely see.
+			g.StopAttractMode()
+		} else {
+			g.StepAttractMode()
+		}
+		return
+	}
+
+	if g.ShowingMotd() && g.JustPressed(homeScreenMotdCard) {
+		g.motd.Dismissed = true
+		SaveYAML(motdCacheFile, g.motd)
+		g.homeIdleFrames = 0
+		return
+	}
+
 	if g.JustPressed(playScreenMenuButton) {
 		g.InitializeWorldToNewGame()
-		g.state = PlayScreen
+		g.ChangeState(PlayScreen, PlayButtonPressed)
+		return
+	}
+
+	if g.JustPressed(homeScreenLeaderboardButton) {
+		g.OpenLeaderboard()
+		g.ChangeState(LeaderboardScreen, LeaderboardButtonPressed)
+		return
+	}
+
+	if g.JustPressed(homeScreenRecordingsButton) {
+		g.OpenRecordings()
+		g.ChangeState(RecordingsScreen, RecordingsButtonPressed)
+		return
+	}
+
+	if g.JustPressed(homeScreenConsentButton) {
+		g.OpenConsent(HomeScreen)
+		g.ChangeState(ConsentScreen, ConsentButtonPressed)
+		return
+	}
+
+	if playerActed {
+		g.homeIdleFrames = 0
+	} else {
+		g.homeIdleFrames++
+	}
+	// AttractModeDelay is in seconds and the game runs at 60 frames per
+	// second. A delay of 0 disables attract mode.
+	if g.AttractModeDelay > 0 && g.homeIdleFrames >= g.AttractModeDelay*60 &&
+		len(g.demoPlaythrough.History) > 0 {
+		g.StartAttractMode()
 	}
 }
 
+// StartAttractMode starts replaying the demo playthrough behind the home
+// screen.
+// The demo uses g.world and g.visWorld, so that it can be drawn exactly like
+// a regular game. This is fine because nothing needs them while we are on the
+// home screen and starting a game always creates a new World anyway.
+func (g *Gui) StartAttractMode() {
+	g.attractMode = true
+	g.attractFrameIdx = 0
+	g.SetWorld(NewWorldFromPlaythrough(g.demoPlaythrough))
+	g.visWorld = NewVisWorld(g.Animations)
+}
+
+func (g *Gui) StopAttractMode() {
+	g.attractMode = false
+	g.homeIdleFrames = 0
+	g.world = World{}
+	g.visWorld = NewVisWorld(g.Animations)
+}
+
+func (g *Gui) StepAttractMode() {
+	// Start over when the demo ends, either because we ran out of recorded
+	// input or because the recorded game ended before that.
+	if g.attractFrameIdx >= int64(len(g.demoPlaythrough.History)) ||
+		g.world.State == Lost || g.world.State == Won {
+		g.StartAttractMode()
+	}
+	g.world.Step(g.demoPlaythrough.History[g.attractFrameIdx])
+	g.visWorld.Step(&g.world)
+	g.attractFrameIdx++
+}
+
 func (g *Gui) UpdatePlayScreen() {
 	if g.panicHappened {
 		return
 	}
 	if g.JustPressed(homeScreenMenuButton) {
 		g.uploadCurrentWorld()
-		g.state = PausedScreen
+		g.ChangeState(PausedScreen, MenuButtonPressed)
 		return
 	}
 
@@ -82,18 +194,59 @@ func (g *Gui) UpdatePlayScreen() {
 	input.JustPressed = g.pointer.JustPressed
 	input.JustReleased = g.pointer.JustReleased
 	input.Pos = g.ScreenToWorld(g.pointer.Pos)
-	if g.JustPressedKey(ebiten.KeyEscape) {
+	input.Device = g.pointer.Device
+	if g.pointer.Device == Touch {
+		// Each finger controls its own pointer, so that the player can drag
+		// two bricks at once.
+		for i, t := range g.touchPointers {
+			pointer := PointerInput{g.ScreenToWorld(t.Pos), t.JustPressed,
+				t.JustReleased}
+			if i == 0 {
+				input.Pos = pointer.Pos
+				input.JustPressed = pointer.JustPressed
+				input.JustReleased = pointer.JustReleased
+			} else {
+				input.OtherPointers[i-1] = pointer
+			}
+		}
+	}
+	if g.playthrough.PushNowEnabled && g.JustPressed(playScreenPushNowButton) {
+		// The button is outside the play area, the click is not meant for the
+		// bricks.
+		input.JustPressed = false
+		for i := range input.OtherPointers {
+			input.OtherPointers[i].JustPressed = false
+		}
+		input.PushNow = true
+	}
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonRight) {
+		input.RotateChain = true
+	}
+	if g.JustPressedKey(ebiten.KeyEscape) && g.world.IsDragging() {
+		// Escape cancels the drag first and only pauses the game if nothing
+		// is being dragged.
+		input.CancelDrag = true
+	} else if g.JustPressedKey(ebiten.KeyEscape) {
 		g.uploadCurrentWorld()
-		g.state = PausedScreen
+		g.ChangeState(PausedScreen, EscapePressed)
 		return
 	}
 	if g.JustPressedKey(ebiten.KeyR) {
 		g.uploadCurrentWorld()
 		g.ResetWorld()
+		g.ChangeState(PlayScreen, ResetKeyPressed)
 	}
 	if g.JustPressedKey(ebiten.KeyC) {
 		g.uploadCurrentWorld()
 		input.TriggerComingUp = true
+		input.Device = KeyboardAssist
+	}
+	if g.JustPressedKey(ebiten.KeyG) {
+		input.TriggerGravityFlip = true
+		input.Device = KeyboardAssist
+	}
+	if g.devModeEnabled {
+		g.UpdateSlowMotion()
 	}
 
 	// We want to slow down the game sometimes by only updating the World once
@@ -117,35 +270,34 @@ func (g *Gui) UpdatePlayScreen() {
 		// occurred.
 		if !g.accumulatedInput.EventOccurred() {
 			g.accumulatedInput.Pos = input.Pos
+			for i := range input.OtherPointers {
+				g.accumulatedInput.OtherPointers[i].Pos =
+					input.OtherPointers[i].Pos
+			}
+			g.accumulatedInput.Device = input.Device
 		}
 	}
-	if g.frameIdx%g.SlowdownFactor == 0 {
+	if g.frameIdx%g.StepDivisor() == 0 {
+		// IMPORTANT: save the input before stepping the World. If a bug in
+		// the World causes it to crash, we want to save the input that caused
+		// the bug before the program crashes.
 		if g.RecordToFile || g.UploadPlaybackToHttp {
-			// Save the input in the playthrough.
-			g.playthrough.History = append(g.playthrough.History, g.accumulatedInput)
-		}
-
-		// IMPORTANT: save the playthrough before stepping the World. If
-		// a bug in the World causes it to crash, we want to save the input
-		// that caused the bug before the program crashes.
-		if g.RecordToFile {
-			WriteFile(g.RecordingFile, g.playthrough.Serialize())
+			g.RecordInput(g.accumulatedInput)
 		}
 		if g.frameIdx%600 == 0 {
 			g.uploadCurrentWorld()
 		}
 
 		// Step the world.
-		g.world.Step(g.accumulatedInput)
+		g.StepWorld(g.accumulatedInput)
 		g.visWorld.Step(&g.world)
+		g.AppendHashTrace()
 
 		// Save best score if it got increased.
-		if g.world.Score > g.BestScore {
-			g.BestScore = g.world.Score
-			// Only upload if you don't risk blocking.
-			if len(g.uploadUserDataChannel) < cap(g.uploadUserDataChannel) {
-				g.uploadUserDataChannel <- g.UserData
-			}
+		bestScore := g.BestScoreForMode(g.playthrough.Mode)
+		if g.world.Score > *bestScore {
+			*bestScore = g.world.Score
+			g.uploadUserData()
 		}
 
 		g.accumulatedInput = PlayerInput{}
@@ -153,53 +305,287 @@ func (g *Gui) UpdatePlayScreen() {
 
 	// Finally increase the frame.
 	g.frameIdx++
+}
 
-	if g.world.State == Lost {
-		g.uploadCurrentWorld()
-		g.state = GameOverScreen
+// UpdateSlowMotion slows the game down with - and speeds it back up with =,
+// so that I can play at regular speed until right before a tricky move.
+func (g *Gui) UpdateSlowMotion() {
+	if g.JustPressedKey(ebiten.KeyMinus) {
+		g.slowMotion = min(max(g.slowMotion, 1)*2, maxSlowMotion)
 	}
-	if g.world.State == Won {
-		g.uploadCurrentWorld()
-		g.state = GameWonScreen
+	if g.JustPressedKey(ebiten.KeyEqual) {
+		g.slowMotion = max(g.slowMotion, 1) / 2
+	}
+}
+
+// The slowest slow motion: one World step every 64 frames is about one step
+// per second.
+const maxSlowMotion = 64
+
+// StepDivisor is how many frames go by for each step of the World. Inputs
+// from the frames in between accumulate in g.accumulatedInput, which doesn't
+// depend on the divisor, so it can change between any two frames.
+func (g *Gui) StepDivisor() int64 {
+	return g.SlowdownFactor * max(g.slowMotion, 1)
+}
+
+// StepWorld steps the World of the game being played, measuring the step if
+// the steps are profiled and watching it for the hints.
+func (g *Gui) StepWorld(input PlayerInput) {
+	g.hintTracker.Step(&g.world, input)
+	if g.profiler != nil {
+		g.profiler.Step(&g.world, input)
+	} else {
+		g.world.Step(input)
+	}
+	if g.RecordToFile || g.UploadPlaybackToHttp {
+		g.playthrough.FinalHash = g.world.FrameHash()
+	}
+}
+
+// GameOver is called by the World when the game it plays is over.
+func (g *Gui) GameOver(final WorldState) {
+	// This is called at the end of the last Step, before StepWorld gets to
+	// update the FinalHash.
+	g.playthrough.FinalHash = g.world.FrameHash()
+	g.uploadWorld(true)
+	g.RecordAnalytics(AnalyticsEvent{Name: AnalyticsGameOver,
+		Outcome: final.String()})
+	g.AddScore(ScoreRecord{g.playthrough.Mode, g.world.Score, final == Won,
+		g.clock.Now().Unix()})
+	g.uploadUserData()
+	if g.profiler != nil {
+		WriteFile("profile.txt", []byte(g.profiler.Report()))
+		WriteFile("profile.csv", g.profiler.CSV())
+	}
+	if final == Lost {
+		g.hints = g.hintTracker.Hints(&g.world)
+		g.ChangeState(GameOverScreen, GameEnded)
+	} else {
+		g.ChangeState(GameWonScreen, GameEnded)
 	}
 }
 
 func (g *Gui) UpdatePausedScreen() {
+	// Time stops for the World but not for the playthrough. The pause is
+	// counted in frames of the World, like the inputs of the PlayScreen.
+	if g.RecordToFile || g.UploadPlaybackToHttp {
+		if g.pausedFrameIdx%g.StepDivisor() == 0 {
+			g.RecordPausedFrame()
+		}
+		g.pausedFrameIdx++
+	}
+
 	if g.JustPressed(pausedScreenContinueButton1) ||
-		g.JustPressed(pausedScreenContinueButton2) ||
-		g.JustPressedKey(ebiten.KeyEscape) {
-		g.state = PlayScreen
+		g.JustPressed(pausedScreenContinueButton2) {
+		g.ChangeState(PlayScreen, ContinueButtonPressed)
+	}
+	if g.JustPressedKey(ebiten.KeyEscape) {
+		g.ChangeState(PlayScreen, EscapePressed)
 	}
 	if g.JustPressed(pausedScreenRestartButton) {
 		g.InitializeWorldToNewGame()
-		g.state = PlayScreen
+		g.ChangeState(PlayScreen, RestartButtonPressed)
 	}
 	if g.JustPressed(pausedScreenHomeButton) {
-		g.state = HomeScreen
+		g.ChangeState(HomeScreen, HomeButtonPressed)
 	}
 }
 
 func (g *Gui) UpdateGameOverScreen() {
 	if g.JustPressed(gameOverScreenRestartButton) {
 		g.InitializeWorldToNewGame()
-		g.state = PlayScreen
+		g.ChangeState(PlayScreen, RestartButtonPressed)
 	}
 	if g.JustPressed(gameOverScreenHomeButton) {
-		g.state = HomeScreen
+		g.ChangeState(HomeScreen, HomeButtonPressed)
+	}
+}
+
+// OpenLeaderboard asks the server for the leaderboard on another goroutine,
+// so that the leaderboard screen keeps drawing while it waits.
+func (g *Gui) OpenLeaderboard() {
+	g.leaderboard = nil
+	ch := make(chan Leaderboard, 1)
+	g.leaderboardChannel = ch
+	sink := g.telemetry
+	user := g.username
+	bestScore := g.BestScore
+	go func() {
+		defer g.HandlePanic()
+		ch <- LoadLeaderboard(sink, ReleaseVersion, user, bestScore)
+	}()
+}
+
+func (g *Gui) UpdateLeaderboardScreen() {
+	select {
+	case l := <-g.leaderboardChannel:
+		g.leaderboard = &l
+	default:
+	}
+
+	if g.JustPressed(leaderboardScreenBackButton) {
+		g.ChangeState(HomeScreen, HomeButtonPressed)
+	}
+	if g.JustPressedKey(ebiten.KeyEscape) {
+		g.ChangeState(HomeScreen, EscapePressed)
+	}
+}
+
+// OpenRecordings asks the server for the player's recordings on another
+// goroutine, like OpenLeaderboard.
+func (g *Gui) OpenRecordings() {
+	g.recordings = nil
+	ch := make(chan Recordings, 1)
+	g.recordingsChannel = ch
+	g.downloadChannel = nil
+	sink := g.telemetry
+	user := g.username
+	go func() {
+		defer g.HandlePanic()
+		ch <- LoadRecordings(sink, user)
+	}()
+}
+
+// recordingsScreenLine is the area of line i of the recordings screen, after
+// the title.
+func recordingsScreenLine(i int) Rectangle {
+	area := recordingsScreenCard
+	area.Min.Y += int64(i+2) * recordingsScreenLineHeight
+	area.Max.Y = area.Min.Y + recordingsScreenLineHeight
+	return area
+}
+
+func (g *Gui) UpdateRecordingsScreen() {
+	select {
+	case r := <-g.recordingsChannel:
+		g.recordings = &r
+	default:
+	}
+	select {
+	case d := <-g.downloadChannel:
+		g.downloadChannel = nil
+		g.recordings.Downloading = false
+		g.recordings.DownloadErr = d.Err
+		if d.Err == nil {
+			g.PlayRecording(d.Playthrough)
+			return
+		}
+	default:
+	}
+
+	if g.recordings != nil && !g.recordings.Downloading {
+		for i, e := range g.recordings.Entries {
+			if e.Playable() && g.JustPressed(recordingsScreenLine(i)) {
+				g.DownloadRecording(e.Id)
+				return
+			}
+		}
+	}
+	if g.JustPressed(recordingsScreenBackButton) {
+		g.ChangeState(HomeScreen, HomeButtonPressed)
+	}
+	if g.JustPressedKey(ebiten.KeyEscape) {
+		g.ChangeState(HomeScreen, EscapePressed)
 	}
 }
 
+// DownloadRecording gets the recording id from the server on another
+// goroutine and plays it back once it arrives, see UpdateRecordingsScreen.
+func (g *Gui) DownloadRecording(id uuid.UUID) {
+	g.recordings.Downloading = true
+	g.recordings.DownloadErr = nil
+	ch := make(chan DownloadedRecording, 1)
+	g.downloadChannel = ch
+	sink := g.telemetry
+	user := g.username
+	go func() {
+		defer g.HandlePanic()
+		ch <- LoadRecording(sink, user, id)
+	}()
+}
+
+// PlayRecording plays back p, a recording from the recordings screen, with
+// the same controls as a playthrough given on the command line. The back
+// button or escape return to the recordings screen, see CloseRecording.
+func (g *Gui) PlayRecording(p Playthrough) {
+	g.beforeRecording = &beforeRecording{
+		playthrough:      g.playthrough,
+		playbackFile:     g.PlaybackFile,
+		enableDebugAreas: g.enableDebugAreas,
+	}
+	g.playthrough = p
+	g.PlaybackFile = p.Id.String() + ".clone1"
+	g.bookmarks = LoadBookmarks(g.PlaybackFile)
+	g.jumpFrames = FindJumpFrames(g.playthrough)
+	g.annotations = LoadAnnotations()
+	g.SetWorld(NewWorldFromPlaythrough(g.playthrough))
+	g.playbackSnapshots = g.playbackSnapshots[:0]
+	g.frameIdx = 0
+	g.playbackPaused = false
+	ResetBreakpoints(g.breakpoints, &g.world)
+	g.enableDebugAreas = true
+	g.AddBreadcrumb("game", "playing back recording %v", p.Id)
+	g.ChangeState(Playback, RecordingPressed)
+}
+
+// CloseRecording stops playing back a recording from the recordings screen
+// and goes back to it.
+func (g *Gui) CloseRecording(cause TransitionCause) {
+	b := g.beforeRecording
+	g.beforeRecording = nil
+	g.playthrough = b.playthrough
+	g.PlaybackFile = b.playbackFile
+	g.enableDebugAreas = b.enableDebugAreas
+	g.SetWorld(NewWorldFromPlaythrough(g.playthrough))
+	g.ChangeState(RecordingsScreen, cause)
+}
+
 func (g *Gui) UpdateGameWonScreen() {
 	if g.JustPressed(gameWonScreenRestartButton) {
 		g.InitializeWorldToNewGame()
-		g.state = PlayScreen
+		g.ChangeState(PlayScreen, RestartButtonPressed)
 	}
 	if g.JustPressed(gameWonScreenHomeButton) {
-		g.state = HomeScreen
+		g.ChangeState(HomeScreen, HomeButtonPressed)
 	}
 }
 
 func (g *Gui) UpdatePlayback() {
+	// While a note is typed, keys are text and not playback controls.
+	if g.typingNote {
+		g.UpdateNote()
+		return
+	}
+
+	if g.beforeRecording != nil {
+		if g.JustPressed(playbackBackButton) {
+			g.CloseRecording(HomeButtonPressed)
+			return
+		}
+		if g.JustPressedKey(ebiten.KeyEscape) {
+			g.CloseRecording(EscapePressed)
+			return
+		}
+	}
+
+	// Go to the previous or next game of the session being played back.
+	if len(g.session.Playthroughs) > 0 {
+		var dir int64
+		if g.JustPressedKey(ebiten.KeyPageUp) {
+			dir = -1
+		}
+		if g.JustPressedKey(ebiten.KeyPageDown) {
+			dir = 1
+		}
+		if dir != 0 {
+			if i := g.session.NextGame(g.sessionGameIdx, dir); i !=
+				g.sessionGameIdx {
+				g.LoadSessionGame(i)
+			}
+		}
+	}
+
 	nFrames := int64(len(g.playthrough.History))
 	pos := g.pointer.Pos.Minus(g.horizontalDebugArea.Min)
 
@@ -207,6 +593,7 @@ func (g *Gui) UpdatePlayback() {
 		g.pointer.JustPressed && debugPlayButton.ContainsPt(pos)
 	if userRequestedPlaybackPause {
 		g.playbackPaused = !g.playbackPaused
+		g.breakpointHit = ""
 	}
 
 	// Choose target frame.
@@ -252,6 +639,69 @@ func (g *Gui) UpdatePlayback() {
 		targetFrameIdx += g.FrameSkipArrow
 	}
 
+	// Export the whole playthrough to a GIF. The export runs alongside the
+	// playback, see UpdateGifExport.
+	if g.JustPressedKey(ebiten.KeyE) && g.gifExport == nil {
+		g.gifExport = NewGifExport(g.playthrough, g.PlaybackFile+".gif")
+	}
+
+	// Bookmark the current frame or jump between bookmarks.
+	if g.JustPressedKey(ebiten.KeyB) {
+		g.bookmarks.Toggle(g.frameIdx)
+		g.bookmarks.Save(g.PlaybackFile)
+	}
+
+	if g.JustPressedKey(ebiten.KeyBracketLeft) {
+		targetFrameIdx = g.bookmarks.Previous(g.frameIdx)
+	}
+
+	if g.JustPressedKey(ebiten.KeyBracketRight) {
+		targetFrameIdx = g.bookmarks.Next(g.frameIdx)
+	}
+
+	// Jump to the next merge, coming up, row spawn, state change or score
+	// jump, or to the previous one if Shift is pressed.
+	jumpKeys := [NJumpKinds]ebiten.Key{
+		MergeJump:       ebiten.KeyM,
+		ComingUpJump:    ebiten.KeyU,
+		SpawnJump:       ebiten.KeyN,
+		StateChangeJump: ebiten.KeyT,
+		ScoreJump:       ebiten.KeyP,
+	}
+	for k, key := range jumpKeys {
+		if !g.JustPressedKey(key) {
+			continue
+		}
+		if g.IsPressed(ebiten.KeyShift) {
+			targetFrameIdx = g.jumpFrames[k].Previous(g.frameIdx)
+		} else {
+			targetFrameIdx = g.jumpFrames[k].Next(g.frameIdx)
+		}
+		// Stay at the frame, otherwise the playback is already past it by
+		// the time it is drawn.
+		g.playbackPaused = true
+	}
+
+	// Type a time and press Enter to go to it, e.g. 3, 0, 0, Enter goes to
+	// 3:00.
+	digitKeys := []ebiten.Key{ebiten.Key0, ebiten.Key1, ebiten.Key2,
+		ebiten.Key3, ebiten.Key4, ebiten.Key5, ebiten.Key6, ebiten.Key7,
+		ebiten.Key8, ebiten.Key9}
+	for digit, key := range digitKeys {
+		if g.JustPressedKey(key) {
+			g.seekDigits += strconv.Itoa(digit)
+		}
+	}
+	if g.JustPressedKey(ebiten.KeyBackspace) && len(g.seekDigits) > 0 {
+		g.seekDigits = g.seekDigits[:len(g.seekDigits)-1]
+	}
+	if g.JustPressedKey(ebiten.KeyEnter) {
+		if frameIdx, ok := TimecodeToFrame(g.seekDigits); ok {
+			targetFrameIdx = frameIdx
+		}
+		g.seekDigits = ""
+	}
+
 	if targetFrameIdx < 0 {
 		targetFrameIdx = 0
 	}
@@ -260,25 +710,54 @@ func (g *Gui) UpdatePlayback() {
 		targetFrameIdx = nFrames - 1
 	}
 
+	jumped := targetFrameIdx != g.frameIdx
 	if targetFrameIdx > g.frameIdx {
 		// Advance the world.
 		for i := g.frameIdx; i < targetFrameIdx; i++ {
-			g.world.Step(g.playthrough.History[i])
+			g.StepPlayback(i)
 		}
 
 		// Set the current frame idx.
 		g.frameIdx = targetFrameIdx
 	} else if targetFrameIdx < g.frameIdx {
-		// Rewind.
-		g.world = NewWorldFromPlaythrough(g.playthrough)
+		g.RewindPlayback(targetFrameIdx)
+	}
+	if jumped {
+		// Jumping to a frame doesn't trigger breakpoints.
+		ResetBreakpoints(g.breakpoints, &g.world)
+	}
 
-		// Replay the world.
-		for i := int64(0); i < targetFrameIdx; i++ {
-			g.world.Step(g.playthrough.History[i])
+	if g.playbackPaused {
+		g.EditPlayback()
+	}
+
+	// Mark the current frame as the start or end of the loop, or stop looping.
+	if g.JustPressedKey(ebiten.KeyZ) {
+		g.loopIn = g.frameIdx
+	}
+	if g.JustPressedKey(ebiten.KeyX) {
+		g.loopOut = g.frameIdx
+	}
+	if g.JustPressedKey(ebiten.KeyC) {
+		g.loopIn, g.loopOut = 0, 0
+	}
+
+	// Write a note for the current frame, or delete its notes with Shift.
+	if g.playbackPaused && g.JustPressedKey(ebiten.KeyF3) {
+		if g.IsPressed(ebiten.KeyShift) {
+			g.annotations.Remove(g.playthrough.Id.String(), g.frameIdx)
+			g.annotations.Save()
+		} else {
+			g.typingNote = true
+			g.noteText = ""
 		}
+	}
 
-		// Set the current frame idx.
-		g.frameIdx = targetFrameIdx
+	// Save the bricks of the current frame as a test level.
+	if g.JustPressedKey(ebiten.KeyF4) {
+		filename := strings.TrimSuffix(g.PlaybackFile, ".clone1") +
+			fmt.Sprintf("-%d.yaml", g.frameIdx)
+		SaveYAML(filename, TestFromWorld(&g.world))
 	}
 
 	// Get input from recording.
@@ -289,16 +768,79 @@ func (g *Gui) UpdatePlayback() {
 
 	// input = g.ai.Step(&g.world)
 	if !g.playbackPaused {
-		g.world.Step(input)
+		g.StepPlayback(g.frameIdx)
 
 		if g.frameIdx < nFrames-1 {
 			g.frameIdx++
 		}
+
+		if hit := CheckBreakpoints(g.breakpoints, &g.world); hit != nil {
+			g.playbackPaused = true
+			g.breakpointHit = hit.Name
+		}
+	}
+
+	// Go back to the start of the loop once its end is played.
+	if g.loopOut > g.loopIn && !g.playbackPaused && g.frameIdx >= g.loopOut {
+		g.RewindPlayback(g.loopIn)
+		ResetBreakpoints(g.breakpoints, &g.world)
 	}
 
 	if g.world.AssertionFailed {
 		g.playbackPaused = true
 	}
+
+	if g.compareMode {
+		g.SyncCompareWorld()
+	}
+}
+
+// UpdateNote types the note for the current frame. Enter saves it and Escape
+// drops it.
+func (g *Gui) UpdateNote() {
+	g.noteText = string(ebiten.AppendInputChars([]rune(g.noteText)))
+	if g.JustPressedKey(ebiten.KeyBackspace) && len(g.noteText) > 0 {
+		runes := []rune(g.noteText)
+		g.noteText = string(runes[:len(runes)-1])
+	}
+	if g.JustPressedKey(ebiten.KeyEnter) {
+		if g.noteText != "" {
+			g.annotations.Add(g.playthrough.Id.String(), g.frameIdx,
+				g.noteText)
+			g.annotations.Save()
+		}
+		g.typingNote = false
+	}
+	if g.JustPressedKey(ebiten.KeyEscape) {
+		g.typingNote = false
+	}
+}
+
+// LoadSessionGame starts playing back game i of g.session from its first
+// frame.
+func (g *Gui) LoadSessionGame(i int64) {
+	g.sessionGameIdx = i
+	g.playthrough = g.session.Playthroughs[i]
+	g.SetWorld(NewWorldFromPlaythrough(g.playthrough))
+	g.playbackSnapshots = g.playbackSnapshots[:0]
+	g.frameIdx = 0
+	ResetBreakpoints(g.breakpoints, &g.world)
+	g.jumpFrames = FindJumpFrames(g.playthrough)
+}
+
+// SyncCompareWorld brings g.compareWorld to the same frame as g.world, or to
+// the end of g.comparePlaythrough if it is shorter.
+func (g *Gui) SyncCompareWorld() {
+	target := min(g.frameIdx, int64(len(g.comparePlaythrough.History)))
+	if target < g.compareFrameIdx {
+		g.compareWorld = RewindReplay(&g.comparePlaythrough,
+			&g.compareSnapshots, target)
+		g.compareFrameIdx = target
+	}
+	for ; g.compareFrameIdx < target; g.compareFrameIdx++ {
+		StepReplay(&g.comparePlaythrough, &g.compareWorld,
+			&g.compareSnapshots, g.compareFrameIdx)
+	}
 }
 
 func (g *Gui) UpdateDebugCrash() {
@@ -317,25 +859,158 @@ func (g *Gui) UpdateDebugCrash() {
 	goToNextFrame := slices.Contains(justPressedKeys, ebiten.KeyD) ||
 		slices.Contains(justPressedKeys, ebiten.KeyRight)
 	if goToNextFrame && g.frameIdx < int64(len(g.playthrough.History)) {
-		g.world.Step(input)
+		g.StepPlayback(g.frameIdx)
 		g.frameIdx++
+		g.crashPhase = AllPhases
+	}
+
+	// Go to the next phase of the current frame. After the last phase, the
+	// frame is done and the next phase is the first one of the next frame.
+	goToNextPhase := slices.Contains(justPressedKeys, ebiten.KeyS) ||
+		slices.Contains(justPressedKeys, ebiten.KeyDown)
+	if goToNextPhase && g.frameIdx < int64(len(g.playthrough.History)) {
+		g.StepCrashPhase(input)
 	}
 
 	// Go to the previous frame.
 	goToPreviousFrame := slices.Contains(justPressedKeys, ebiten.KeyA) ||
 		slices.Contains(justPressedKeys, ebiten.KeyLeft)
 	if goToPreviousFrame && g.frameIdx > 0 {
-		g.frameIdx--
+		g.RewindPlayback(g.frameIdx - 1)
+		g.crashPhase = AllPhases
+	}
+}
+
+// StepCrashPhase runs the current frame up to the phase after g.crashPhase,
+// on a copy of g.world so that the next phase can start over from the
+// beginning of the frame.
+func (g *Gui) StepCrashPhase(input PlayerInput) {
+	if g.crashPhase == MergePhase {
+		g.StepPlayback(g.frameIdx)
+		g.frameIdx++
+		g.crashPhase = AllPhases
+		return
+	}
+
+	if g.crashPhase == AllPhases {
+		g.crashPhase = DragPhase
+	} else {
+		g.crashPhase++
+	}
+	g.crashPhaseWorld = g.world.Clone()
+	g.crashPhaseWorld.StepUntil(input, g.crashPhase)
+}
+
+// The number of frames between two snapshots of the World kept during
+// playback. A rewind replays at most this many frames.
+const playbackSnapshotInterval = 600
+
+// StepPlayback steps g.world with the input of frame i of g.playthrough. The
+// World must be at frame i, meaning it went through frames 0 to i-1.
+func (g *Gui) StepPlayback(i int64) {
+	StepReplay(&g.playthrough, &g.world, &g.playbackSnapshots, i)
+}
+
+// RewindPlayback brings g.world back to frame frameIdx, which must not be
+// after the current frame.
+func (g *Gui) RewindPlayback(frameIdx int64) {
+	g.world = RewindReplay(&g.playthrough, &g.playbackSnapshots, frameIdx)
+	g.frameIdx = frameIdx
+}
 
-		// I have no better way to go to the previous frame than redoing all the
-		// frames from the beginning.
-		g.world = NewWorldFromPlaythrough(g.playthrough)
-		for i := range g.frameIdx {
-			g.world.Step(g.playthrough.History[i])
+// UpdateGifExport exports the next gifExportFramesPerUpdate frames of
+// g.gifExport. Every gifFrameStep-th frame is drawn offscreen and added to the
+// GIF.
+func (g *Gui) UpdateGifExport() {
+	for range gifExportFramesPerUpdate {
+		if g.gifExport.FrameIdx%gifFrameStep == 0 {
+			g.DrawGifExportFrame(g.gifExport)
+			g.gifExport.AddFrame()
+		}
+		g.gifExport.Step()
+		if g.gifExport.Done() {
+			g.gifExport = nil
+			return
 		}
 	}
 }
 
+// StepReplay steps w with the input of frame i of p. w must be at frame i,
+// meaning it went through frames 0 to i-1. Every playbackSnapshotInterval
+// frames a snapshot of w is added to snapshots before the step.
+func StepReplay(p *Playthrough, w *World, snapshots *[]World, i int64) {
+	if i == int64(len(*snapshots))*playbackSnapshotInterval {
+		*snapshots = append(*snapshots, w.Clone())
+	}
+	w.Step(p.History[i])
+}
+
+// RewindReplay returns the World at frame frameIdx of p. It starts from the
+// closest snapshot taken by StepReplay instead of replaying all the frames
+// from the beginning.
+func RewindReplay(p *Playthrough, snapshots *[]World,
+	frameIdx int64) (w World) {
+	snapshotIdx := min(frameIdx/playbackSnapshotInterval,
+		int64(len(*snapshots))-1)
+	start := int64(0)
+	if snapshotIdx < 0 {
+		w = NewWorldFromPlaythrough(*p)
+	} else {
+		w = (*snapshots)[snapshotIdx].Clone()
+		start = snapshotIdx * playbackSnapshotInterval
+	}
+
+	// Replay the world.
+	for i := start; i < frameIdx; i++ {
+		StepReplay(p, &w, snapshots, i)
+	}
+	return
+}
+
+// EditPlayback edits the inputs of the paused playback at the current frame:
+// - Delete removes the input
+// - Insert adds an input in which nothing happens
+// - I, J, K and L move the pointer by one pixel up, left, down and right
+// - Ctrl+S saves the edited playthrough next to the original
+// The World isn't affected until the playback goes past the current frame,
+// then it plays the edited inputs.
+func (g *Gui) EditPlayback() {
+	if g.JustPressedKey(ebiten.KeyS) && g.IsPressed(ebiten.KeyControl) {
+		filename := strings.TrimSuffix(g.PlaybackFile, ".clone1") +
+			"-edited.clone1"
+		WriteFile(filename,
+			g.playthrough.SerializeWith(g.CompressionParams))
+	}
+
+	p := &g.playthrough
+	frameIdx := g.frameIdx
+	if g.JustPressedKey(ebiten.KeyDelete) && len(p.History) > 1 {
+		p.DeleteInput(frameIdx)
+	} else if g.JustPressedKey(ebiten.KeyInsert) {
+		// The pointer stays where it was.
+		p.InsertInput(frameIdx, PlayerInput{Pos: p.History[frameIdx].Pos,
+			Device: p.History[frameIdx].Device})
+	} else if g.JustPressedKey(ebiten.KeyI) {
+		p.NudgeInput(frameIdx, Pt{0, -1})
+	} else if g.JustPressedKey(ebiten.KeyJ) {
+		p.NudgeInput(frameIdx, Pt{-1, 0})
+	} else if g.JustPressedKey(ebiten.KeyK) {
+		p.NudgeInput(frameIdx, Pt{0, 1})
+	} else if g.JustPressedKey(ebiten.KeyL) {
+		p.NudgeInput(frameIdx, Pt{1, 0})
+	} else {
+		return
+	}
+
+	// The snapshots taken after the edited frame are no longer valid.
+	nValid := min(int64(len(g.playbackSnapshots)),
+		frameIdx/playbackSnapshotInterval+1)
+	g.playbackSnapshots = g.playbackSnapshots[:nValid]
+	g.RewindPlayback(min(frameIdx, int64(len(p.History))-1))
+	ResetBreakpoints(g.breakpoints, &g.world)
+	g.jumpFrames = FindJumpFrames(*p)
+}
+
 func (g *Gui) IsPressed(k ebiten.Key) bool {
 	return slices.Contains(g.pressedKeys, k)
 }
@@ -357,73 +1032,141 @@ func (g *Gui) GetPointerState() PointerState {
 	// Check for justPressed.
 	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
 		x, y := ebiten.CursorPosition()
-		return PointerState{true, true, false, Pt{int64(x), int64(y)}}
+		return PointerState{true, true, false, Pt{int64(x), int64(y)},
+			Mouse}
 	}
 
 	touchIDs := inpututil.AppendJustPressedTouchIDs([]ebiten.TouchID{})
 	if len(touchIDs) > 0 {
 		x, y := ebiten.TouchPosition(touchIDs[0])
-		return PointerState{true, true, false, Pt{int64(x), int64(y)}}
+		return PointerState{true, true, false, Pt{int64(x), int64(y)},
+			Touch}
 	}
 
 	// Check for justReleased.
 	if inpututil.IsMouseButtonJustReleased(ebiten.MouseButtonLeft) {
 		x, y := ebiten.CursorPosition()
-		return PointerState{false, false, true, Pt{int64(x), int64(y)}}
+		return PointerState{false, false, true, Pt{int64(x), int64(y)},
+			Mouse}
 	}
 
 	touchIDs = inpututil.AppendJustReleasedTouchIDs([]ebiten.TouchID{})
 	if len(touchIDs) > 0 {
 		x, y := ebiten.TouchPosition(touchIDs[0])
-		return PointerState{false, false, true, Pt{int64(x), int64(y)}}
+		return PointerState{false, false, true, Pt{int64(x), int64(y)},
+			Touch}
 	}
 
 	// Check for pressed.
 	if ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
 		x, y := ebiten.CursorPosition()
-		return PointerState{true, false, false, Pt{int64(x), int64(y)}}
+		return PointerState{true, false, false, Pt{int64(x), int64(y)},
+			Mouse}
 	}
 
 	touchIDs = ebiten.AppendTouchIDs([]ebiten.TouchID{})
 	if len(touchIDs) > 0 {
 		x, y := ebiten.TouchPosition(touchIDs[0])
-		return PointerState{true, false, false, Pt{int64(x), int64(y)}}
+		return PointerState{true, false, false, Pt{int64(x), int64(y)},
+			Touch}
 	}
 
 	// Nothing is pressed, just pressed or just released.
 	// Set x, y to the mouse position. This will return 0, 0 on mobile but the
 	// button position should not be used by anything on the mobile if nothing
 	// is pressed.
+	// There is no way to tell which device is idle, so assume it is the last
+	// device that did something.
 	x, y := ebiten.CursorPosition()
-	return PointerState{false, false, false, Pt{int64(x), int64(y)}}
+	return PointerState{false, false, false, Pt{int64(x), int64(y)},
+		g.pointer.Device}
 }
 
-func LoadUserData(username string) (data UserData) {
-	var s string
-	for i := 1; i < 3; i++ {
-		// This might fail, but we really do not care that much. The game should
-		// not be interrupted by this function failing. If it does fail, just
-		// try a couple more times, then give up.
-		var err error
-		s, err = GetUserDataHttp(username)
-		if err == nil {
+// GetTouchPointers follows every finger on the screen in its own pointer, for
+// as long as the finger touches the screen. A finger that touches the screen
+// takes the first free pointer. Fingers beyond MaxPointers are ignored.
+func (g *Gui) GetTouchPointers() (pointers [MaxPointers]PointerState) {
+	for i := range g.touches {
+		if !g.touchActive[i] {
+			continue
+		}
+		id := g.touches[i]
+		if inpututil.IsTouchJustReleased(id) {
+			// The position of a released touch is no longer available.
+			x, y := inpututil.TouchPositionInPreviousTick(id)
+			pointers[i] = PointerState{false, false, true,
+				Pt{int64(x), int64(y)}, Touch}
+			g.touchActive[i] = false
+		} else {
+			x, y := ebiten.TouchPosition(id)
+			pointers[i] = PointerState{true, false, false,
+				Pt{int64(x), int64(y)}, Touch}
+		}
+	}
+
+	for _, id := range inpututil.AppendJustPressedTouchIDs(nil) {
+		// Don't reuse a pointer that was just released, the World must see
+		// the release.
+		i := slices.IndexFunc(pointers[:], func(p PointerState) bool {
+			return !p.Pressed && !p.JustReleased
+		})
+		if i < 0 {
 			break
 		}
+		g.touches[i] = id
+		g.touchActive[i] = true
+		x, y := ebiten.TouchPosition(id)
+		pointers[i] = PointerState{true, true, false, Pt{int64(x), int64(y)},
+			Touch}
 	}
+	return
+}
+
+func LoadUserData(sink TelemetrySink, username string) (data UserData) {
+	var s string
+	// This might fail, but we really do not care that much. The game should
+	// not be interrupted by this function failing. If it does fail, just
+	// try a couple more times, then give up.
+	_ = blockingRetryPolicy.Do(func() (err error) {
+		s, err = sink.GetUserData(username)
+		return
+	})
 	err := yaml.Unmarshal([]byte(s), &data)
 	Check(err)
 	return
 }
 
-func (g *Gui) UploadUserData(username string, ch chan UserData) {
-	defer g.HandlePanic()
+// downloadUserData is LoadUserData for UploadUserData, which needs to know if
+// the server answered, with the retries of policy.
+func (g *Gui) downloadUserData(username string,
+	policy RetryPolicy) (data UserData, err error) {
+	var s string
+	err = policy.Do(func() (err error) {
+		s, err = g.telemetry.GetUserData(username)
+		return
+	})
+	if err != nil {
+		return
+	}
+	err = yaml.Unmarshal([]byte(s), &data)
+	return
+}
 
+// UploadUserData uploads the UserData that comes through ch and sends what
+// it merged it with to syncedUserDataChannel, see UpdateUserData.
+func (g *Gui) UploadUserData(username string, displayName string,
+	ch chan UserData) {
 	for {
 		// Receive a struct from the channel.
 		// Blocks until a struct is received.
 		var data UserData
 		for {
-			data = <-ch
+			var ok bool
+			data, ok = <-ch
+			if !ok {
+				// Closed, see Shutdown.
+				return
+			}
 			// If there are multiple values available in the channel, keep
 			// getting them until the last value is retrieved. There's no point
 			// in uploading intermediate values, just upload the latest value.
@@ -432,23 +1175,44 @@ func (g *Gui) UploadUserData(username string, ch chan UserData) {
 			}
 		}
 
-		// Upload the data.
-		bytes, err := yaml.Marshal(data)
-		Check(err)
-		for i := 1; i < 3; i++ {
-			// This might fail, but we really do not care that much. The game
-			// should not be interrupted by this function failing. If it does
-			// fail, just try a couple more times, then give up.
-			err = SetUserDataHttp(username, string(bytes))
+		// Merge the data with what the server has, which may come from
+		// another device, instead of overwriting it. If the server can't be
+		// read, nothing is written: the data goes with the next upload.
+		// This might fail, but we really do not care that much. The game
+		// should not be interrupted by this function failing. If it does
+		// fail, just try a couple more times, then give up.
+		// The sync and the score count as one upload, see UploadMetrics.
+		g.uploadMetrics.Start(userDataQueue)
+		policy := g.uploadMetrics.Policy(userDataQueue, backgroundRetryPolicy)
+		remote, err := g.downloadUserData(username, policy)
+		if err == nil {
+			data = MergeUserData(data, remote)
+			if data.Revision != remote.Revision {
+				bytes, marshalErr := yaml.Marshal(data)
+				Check(marshalErr)
+				err = policy.Do(func() error {
+					return g.telemetry.SetUserData(username, string(bytes))
+				})
+			}
+			if len(g.syncedUserDataChannel) < cap(g.syncedUserDataChannel) {
+				g.syncedUserDataChannel <- data
+			}
+		}
+		if data.BestScore > 0 {
+			scoreErr := policy.Do(func() error {
+				return g.telemetry.SubmitScore(username, displayName,
+					ReleaseVersion, data.BestScore)
+			})
 			if err == nil {
-				break
+				err = scoreErr
 			}
 		}
+		g.uploadMetrics.Done(userDataQueue, err)
 	}
 }
 
 func (g *Gui) Log(level string, message string) {
-	if !g.LogNonErrors {
+	if !g.LogNonErrors || !g.UploadsAllowed() {
 		return
 	}
 
@@ -466,31 +1230,35 @@ func (g *Gui) Log(level string, message string) {
 }
 
 func (g *Gui) UploadLogs(ch chan logData) {
-	defer g.HandlePanic()
-
 	for {
 		// Receive a struct from the channel.
 		// Blocks until a struct is received.
-		log := <-ch
+		log, ok := <-ch
+		if !ok {
+			// Closed, see Shutdown.
+			return
+		}
 
 		// Upload the data.
-		for i := 1; i < 3; i++ {
-			// This might fail, but we really do not care that much. The game
-			// should not be interrupted by this function failing. If it does
-			// fail, just try a couple more times, then give up.
-			err := LogHttp(
-				log.user,
-				log.releaseVersion,
-				log.simulationVersion,
-				log.inputVersion,
-				log.playthroughId,
-				log.level,
-				log.message,
-				nil)
-
-			if err == nil {
-				break
-			}
-		}
+		// This might fail, but we really do not care that much. The game
+		// should not be interrupted by this function failing. If it does
+		// fail, just try a couple more times, then give up.
+		_ = g.uploadMetrics.Do(logsQueue, backgroundRetryPolicy,
+			func() error {
+				return g.telemetry.Log(
+					log.user,
+					log.releaseVersion,
+					log.simulationVersion,
+					log.inputVersion,
+					log.playthroughId,
+					log.level,
+					log.message,
+					nil)
+			})
 	}
 }
+
+// ShowingMotd returns true if the home screen has a message of the day to show.
+func (g *Gui) ShowingMotd() bool {
+	return g.motd.Message != "" && !g.motd.Dismissed && !g.attractMode
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestGui_AttractModeLoops(t *testing.T) {
+	var g Gui
+	g.FSys = &embeddedFiles
+	g.demoPlaythrough = DeserializePlaythrough(ReadFile("data/demo.clone1"))
+	// The demo is a game of several minutes. If it looks much shorter, the
+	// Playthrough format changed and the demo must be recorded again.
+	require.Greater(t, len(g.demoPlaythrough.History), 60*60)
+	g.demoPlaythrough.History = g.demoPlaythrough.History[:100]
+
+	g.StartAttractMode()
+	for range 100 {
+		g.StepAttractMode()
+	}
+	assert.Equal(t, int64(100), g.attractFrameIdx)
+
+	// The demo starts over once it runs out of input.
+	g.StepAttractMode()
+	assert.Equal(t, int64(1), g.attractFrameIdx)
+
+	g.StopAttractMode()
+	assert.False(t, g.attractMode)
+}
+
+func TestLoadDemoPlaythrough(t *testing.T) {
+	// The demo that ships in the executable must replay to the end with the
+	// current simulation.
+	p, err := LoadDemoPlaythrough(&embeddedFiles)
+	require.NoError(t, err)
+	w := NewWorldFromPlaythrough(p)
+	for _, input := range p.History {
+		w.Step(input)
+	}
+
+	// A demo that this simulation can't replay disables attract mode instead
+	// of crashing.
+	p.SimulationVersion = SimulationVersion + 1
+	fsys := fstest.MapFS{
+		"data/demo.clone1": &fstest.MapFile{Data: p.Serialize()},
+	}
+	p, err = LoadDemoPlaythrough(fsys)
+	assert.Error(t, err)
+	assert.Empty(t, p.History)
+
+	fsys["data/demo.clone1"] = &fstest.MapFile{Data: []byte("garbage")}
+	_, err = LoadDemoPlaythrough(fsys)
+	assert.Error(t, err)
+	_, err = LoadDemoPlaythrough(fstest.MapFS{})
+	assert.Error(t, err)
+}
+
+func TestGui_SetWorld(t *testing.T) {
+	var g Gui
+	g.FSys = &embeddedFiles
+	// Only the images of the bricks the level can have are loaded.
+	g.SetWorld(NewWorld(0, Level{MaxBrickValue: 5}))
+	assert.Len(t, g.imgBrick, 6)
+	g.SetWorld(NewWorld(0, Level{}))
+	assert.Len(t, g.imgBrick, int(DefaultMaxBrickValue)+1)
+	assert.NotNil(t, g.BrickImage(DefaultMaxBrickValue))
+}
+
+func TestGui_SyncCompareWorld(t *testing.T) {
+	var g Gui
+	g.comparePlaythrough = DeserializePlaythrough(ReadFile("data/demo.clone1"))
+	g.comparePlaythrough.History = g.comparePlaythrough.History[:2000]
+	g.compareWorld = NewWorldFromPlaythrough(g.comparePlaythrough)
+
+	expected := func(frameIdx int64) []byte {
+		w := NewWorldFromPlaythrough(g.comparePlaythrough)
+		for i := range frameIdx {
+			w.Step(g.comparePlaythrough.History[i])
+		}
+		return w.StateBytes()
+	}
+
+	// Forward, backward through snapshots and past the end of the compared
+	// playthrough.
+	for _, frameIdx := range []int64{1500, 700, 1300, 2500} {
+		g.frameIdx = frameIdx
+		g.SyncCompareWorld()
+		target := min(frameIdx, 2000)
+		assert.Equal(t, target, g.compareFrameIdx)
+		assert.Equal(t, expected(target), g.compareWorld.StateBytes())
+	}
+}
+
+func TestGui_PlaybackLoop(t *testing.T) {
+	var g Gui
+	g.playthrough = DeserializePlaythrough(ReadFile("data/demo.clone1"))
+	g.world = NewWorldFromPlaythrough(g.playthrough)
+	g.loopIn = 700
+	g.loopOut = 730
+	g.RewindPlayback(725)
+
+	for range 100 {
+		g.UpdatePlayback()
+		require.GreaterOrEqual(t, g.frameIdx, g.loopIn)
+		require.Less(t, g.frameIdx, g.loopOut)
+	}
+
+	// After looping, the World is exactly where playing from the start
+	// would have brought it.
+	w := NewWorldFromPlaythrough(g.playthrough)
+	for i := range g.frameIdx {
+		w.Step(g.playthrough.History[i])
+	}
+	assert.Equal(t, w.StateBytes(), g.world.StateBytes())
+}
+
+func TestLatestErrorRecording(t *testing.T) {
+	fsys := fstest.MapFS{}
+	assert.Equal(t, "", LatestErrorRecording(fsys))
+
+	fsys["error-20250101-100000.clone1"] = &fstest.MapFile{}
+	fsys["error-20250101-100000.clone1-trace"] = &fstest.MapFile{}
+	fsys["error-20250101-120000.clone1"] = &fstest.MapFile{}
+	fsys["error-20250101-120000-02.clone1"] = &fstest.MapFile{}
+	fsys["recording.clone1"] = &fstest.MapFile{}
+	assert.Equal(t, "./error-20250101-120000-02.clone1",
+		LatestErrorRecording(fsys))
+}
+
+func TestGui_SlowMotion(t *testing.T) {
+	var g Gui
+	g.SlowdownFactor = 2
+	assert.Equal(t, int64(2), g.StepDivisor())
+
+	g.justPressedKeys = []ebiten.Key{ebiten.KeyMinus}
+	g.UpdateSlowMotion()
+	assert.Equal(t, int64(4), g.StepDivisor())
+	for range 10 {
+		g.UpdateSlowMotion()
+	}
+	assert.Equal(t, int64(2*maxSlowMotion), g.StepDivisor())
+
+	g.justPressedKeys = []ebiten.Key{ebiten.KeyEqual}
+	for range 10 {
+		g.UpdateSlowMotion()
+	}
+	assert.Equal(t, int64(2), g.StepDivisor())
+}
+
+func TestGui_RecordPausedFrame(t *testing.T) {
+	var g Gui
+	g.clock = FixedClock{time.UnixMilli(1700000000000)}
+	g.world = NewWorld(0, Level{})
+	g.RecordInput(PlayerInput{Pos: Pt{1, 1}})
+
+	// However long the pause, it is a single input.
+	for range 1000 {
+		g.RecordPausedFrame()
+	}
+	g.RecordInput(PlayerInput{Pos: Pt{2, 2}})
+	g.RecordPausedFrame()
+	require.Len(t, g.playthrough.History, 4)
+	assert.Equal(t, int64(1000), g.playthrough.History[1].PausedFrames)
+	assert.Equal(t, int64(1), g.playthrough.History[3].PausedFrames)
+	assert.Equal(t, int64(1001), g.playthrough.PausedFrames())
+	assert.Equal(t, int64(2), g.playthrough.PlayedFrames())
+}
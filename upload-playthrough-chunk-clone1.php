@@ -0,0 +1,79 @@
+<?php
+require_once "./auth-clone1.php";
+$servername = "172.232.206.74";
+$username = "playfulp_temp";
+$password = "comeonthough";
+$dbname = "playfulp_clone1";
+
+// Receives a large playthrough in chunks, so that an upload interrupted by a
+// dropped connection continues where it stopped instead of starting over.
+// The chunks are collected in the upload column, for the upload with the
+// given digest (the sha256 of the whole playthrough). A chunk is only
+// appended if the server has exactly offset bytes of that upload. Once it has
+// all total bytes, they replace the playthrough. Either way, the response is
+// how many bytes of the upload the server has, which is where the next chunk
+// has to start.
+// A chunk for a different digest starts a new upload.
+
+function LogInfo($message) {
+    // 	file_put_contents("./upload-playthrough-chunk-clone1.log", "INFO: " . $message . "\n", FILE_APPEND);
+}
+
+function LogError($message) {
+	file_put_contents("./upload-playthrough-chunk-clone1.log", "ERROR: " . $message . "\n", FILE_APPEND);
+    http_response_code(513);
+	die();
+}
+
+LogInfo("Start.");
+if ($_SERVER['REQUEST_METHOD'] == 'POST') {
+    VerifyRequest();
+    $table = Table("playthroughs");
+    LogInfo("Attempt to connect to database.");
+    $conn = new mysqli($servername, $username, $password, $dbname);
+    if ($conn->connect_error) {
+        LogError("Connection failed: " . $conn->connect_error);
+    }
+    LogInfo("Connection succeeded!");
+
+    $user = $conn->real_escape_string($_POST['user']);
+    $id = $conn->real_escape_string($_POST['id']);
+    $digest = $conn->real_escape_string($_POST['digest']);
+    $total = intval($_POST['total']);
+    $offset = intval($_POST['offset']);
+    LogInfo("We got user: " . $user . ", id: " . $id . ", digest: " . $digest .
+        ", total: " . $total . ", offset: " . $offset);
+    if (!isset($_FILES['chunk'])) {
+        LogError("No chunk.");
+    }
+    $chunk = file_get_contents($_FILES['chunk']['tmp_name']);
+    if ($offset + strlen($chunk) > $total) {
+        LogError("Chunk goes past the end of the upload.");
+    }
+    $chunk = $conn->real_escape_string($chunk);
+
+    $where = "WHERE user = '$user' AND id = '$id'";
+    try {
+        $conn->query("UPDATE $table SET upload = '', upload_digest = '$digest' " .
+            "$where AND COALESCE(upload_digest, '') <> '$digest'");
+        $conn->query("UPDATE $table SET upload = CONCAT(upload, '$chunk') " .
+            "$where AND upload_digest = '$digest' AND LENGTH(upload) = $offset");
+        // The upload stays marked with its digest when it is done, so that
+        // the answer to a repeated last chunk is still that it is done.
+        $conn->query("UPDATE $table SET end_moment = now(), playthrough = upload, upload = NULL " .
+            "$where AND upload_digest = '$digest' AND LENGTH(upload) = $total");
+        $result = $conn->query("SELECT COALESCE(LENGTH(upload), $total) AS length FROM $table $where");
+    } catch(Exception $e) {
+        LogError("Error uploading chunk: " . $e->getMessage());
+    }
+
+    if ($result->num_rows > 0) {
+        $row = $result->fetch_assoc();
+        echo $row["length"];
+    } else {
+        LogError("Unknown playthrough.");
+    }
+    $conn->close();
+}
+LogInfo("End.");
+?>
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// Playthroughs bigger than this are uploaded in chunks of this size, see
+// UploadChunks. Most playthroughs are smaller and go in one request.
+const uploadChunkSize = 256 * 1024
+
+// How many chunks in a row may be answered without progress before
+// UploadChunks gives up, e.g. because the server keeps losing them.
+const maxStalledChunks = 3
+
+func Sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// UploadChunks uploads data with upload, uploadChunkSize bytes at a time.
+// upload sends the chunk that starts at offset and returns how many bytes of
+// data the server has, which is where the next chunk starts. This is how the
+// server and the game agree on where to resume: after a dropped connection,
+// the upload continues from wherever the server got to, not from the start.
+// A chunk that arrived but whose answer was lost is sent again, but the
+// server only answers with how far it got. Each chunk is retried with retry
+// and waits for limiter.
+func UploadChunks(data []byte, retry RetryPolicy, limiter *UploadLimiter,
+	upload func(offset int64, chunk []byte) (int64, error)) error {
+	total := int64(len(data))
+	offset := int64(0)
+	nStalled := 0
+	for offset < total {
+		previous := offset
+		err := retry.Do(func() error {
+			end := min(offset+uploadChunkSize, total)
+			limiter.Wait(end - offset)
+			n, err := upload(offset, data[offset:end])
+			if err != nil {
+				return err
+			}
+			if n < 0 || n > total {
+				return fmt.Errorf("invalid upload offset: %d", n)
+			}
+			offset = n
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if offset <= previous {
+			nStalled++
+			if nStalled >= maxStalledChunks {
+				return fmt.Errorf("upload stalled at %d of %d bytes", offset,
+					total)
+			}
+		} else {
+			nStalled = 0
+		}
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+)
+
+// flakyServer keeps the chunks it gets, like
+// upload-playthrough-chunk-clone1.php, but drops the connection of some
+// requests, before or after storing their chunk.
+type flakyServer struct {
+	data []byte
+	// Whether to drop each request, in order, before or after the chunk is
+	// stored.
+	dropBefore []bool
+	dropAfter  []bool
+	nRequests  int
+	offsets    []int64
+}
+
+func (s *flakyServer) upload(offset int64, chunk []byte) (int64, error) {
+	i := s.nRequests
+	s.nRequests++
+	s.offsets = append(s.offsets, offset)
+	if i < len(s.dropBefore) && s.dropBefore[i] {
+		return 0, errors.New("connection dropped")
+	}
+	if int64(len(s.data)) == offset {
+		s.data = append(s.data, chunk...)
+	}
+	if i < len(s.dropAfter) && s.dropAfter[i] {
+		return 0, errors.New("connection dropped")
+	}
+	return int64(len(s.data)), nil
+}
+
+func testUploadData() []byte {
+	data := make([]byte, uploadChunkSize*3+100)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	return data
+}
+
+func TestUploadChunks(t *testing.T) {
+	var f fakeTime
+	data := testUploadData()
+	s := &flakyServer{}
+	err := UploadChunks(data, f.policy(backgroundRetryPolicy),
+		&UploadLimiter{}, s.upload)
+	assert.Nil(t, err)
+	assert.Equal(t, data, s.data)
+	assert.Equal(t, []int64{0, uploadChunkSize, 2 * uploadChunkSize,
+		3 * uploadChunkSize}, s.offsets)
+}
+
+func TestUploadChunks_Resumes(t *testing.T) {
+	var f fakeTime
+	data := testUploadData()
+	// The second chunk is lost on the way to the server, the answer to the
+	// third one is lost on the way back.
+	s := &flakyServer{
+		dropBefore: []bool{false, true},
+		dropAfter:  []bool{false, false, false, true}}
+	err := UploadChunks(data, f.policy(backgroundRetryPolicy),
+		&UploadLimiter{}, s.upload)
+	assert.Nil(t, err)
+	assert.Equal(t, data, s.data)
+	// Neither drop makes the upload start over, and the chunk that arrived
+	// isn't stored twice.
+	assert.Equal(t, []int64{0, uploadChunkSize, uploadChunkSize,
+		2 * uploadChunkSize, 2 * uploadChunkSize, 3 * uploadChunkSize},
+		s.offsets)
+}
+
+func TestUploadChunks_Stalled(t *testing.T) {
+	var f fakeTime
+	nCalls := 0
+	err := UploadChunks(testUploadData(), f.policy(backgroundRetryPolicy),
+		&UploadLimiter{}, func(offset int64, chunk []byte) (int64, error) {
+			nCalls++
+			return 0, nil
+		})
+	assert.Error(t, err)
+	assert.Equal(t, maxStalledChunks, nCalls)
+}
+
+func TestFileSink_UploadPlaythroughChunk(t *testing.T) {
+	var f fakeTime
+	sink := NewFileSink(t.TempDir(), SystemClock{})
+	id := uuid.New()
+	data := testUploadData()
+	digest := Sha256Hex(data)
+	upload := func(offset int64, chunk []byte) (int64, error) {
+		return sink.UploadPlaythroughChunk("user", id, digest,
+			int64(len(data)), offset, chunk)
+	}
+
+	// An interrupted upload is resumed by the next one.
+	n, err := upload(0, data[:uploadChunkSize])
+	assert.Nil(t, err)
+	assert.Equal(t, int64(uploadChunkSize), n)
+	n, err = upload(0, data[:uploadChunkSize])
+	assert.Nil(t, err)
+	assert.Equal(t, int64(uploadChunkSize), n)
+	err = UploadChunks(data, f.policy(backgroundRetryPolicy),
+		&UploadLimiter{}, upload)
+	assert.Nil(t, err)
+	stored, err := os.ReadFile(sink.playthroughPath(id))
+	assert.Nil(t, err)
+	assert.Equal(t, data, stored)
+
+	// Once it is done, the last chunk is answered as done.
+	n, err = upload(3*uploadChunkSize, data[3*uploadChunkSize:])
+	assert.Nil(t, err)
+	assert.Equal(t, int64(len(data)), n)
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The upload queues, as named on the debug area.
+const (
+	playthroughsQueue = "playthroughs"
+	userDataQueue     = "user data"
+	analyticsQueue    = "analytics"
+	logsQueue         = "logs"
+)
+
+// QueueStats is how the uploads of one queue went so far.
+type QueueStats struct {
+	// Uploads that are being made now, retries included.
+	InFlight  int64
+	Succeeded int64
+	// Uploads that the retry policy gave up on.
+	Failed    int64
+	LastError string
+	// When the upload that failed last will try again, zero if it isn't
+	// waiting.
+	RetryAt time.Time
+}
+
+// UploadMetrics counts the uploads of the workers, so that the debug area
+// can show during a playtest whether the data reaches the server. The
+// workers update it on their goroutines and Draw reads it, hence the mutex.
+// Like the retries, none of this ends up in a recording, so it doesn't go
+// through the Clock.
+type UploadMetrics struct {
+	mutex  sync.Mutex
+	queues map[string]*QueueStats
+
+	// Replaced by tests.
+	now func() time.Time
+}
+
+func (m *UploadMetrics) timeNow() time.Time {
+	if m.now == nil {
+		return time.Now()
+	}
+	return m.now()
+}
+
+// queue returns the stats of name. The mutex must be locked.
+func (m *UploadMetrics) queue(name string) *QueueStats {
+	if m.queues == nil {
+		m.queues = map[string]*QueueStats{}
+	}
+	q := m.queues[name]
+	if q == nil {
+		q = &QueueStats{}
+		m.queues[name] = q
+	}
+	return q
+}
+
+// Start counts an upload of queue that begins.
+func (m *UploadMetrics) Start(queue string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.queue(queue).InFlight++
+}
+
+// Policy returns p, with the retries of queue recorded.
+func (m *UploadMetrics) Policy(queue string, p RetryPolicy) RetryPolicy {
+	p.OnRetry = func(err error, delay time.Duration) {
+		m.mutex.Lock()
+		defer m.mutex.Unlock()
+		q := m.queue(queue)
+		q.LastError = err.Error()
+		q.RetryAt = m.timeNow().Add(delay)
+	}
+	return p
+}
+
+// Done counts an upload of queue that ended with err.
+func (m *UploadMetrics) Done(queue string, err error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	q := m.queue(queue)
+	q.InFlight--
+	q.RetryAt = time.Time{}
+	if err == nil {
+		q.Succeeded++
+	} else {
+		q.Failed++
+		q.LastError = err.Error()
+	}
+}
+
+// Do makes an upload of queue with f, retried according to p, and counts
+// it.
+func (m *UploadMetrics) Do(queue string, p RetryPolicy, f func() error) error {
+	m.Start(queue)
+	err := m.Policy(queue, p).Do(f)
+	m.Done(queue, err)
+	return err
+}
+
+// Stats returns a copy of the stats of queue.
+func (m *UploadMetrics) Stats(queue string) QueueStats {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return *m.queue(queue)
+}
+
+// Lines describes the queue named queue, with queued uploads waiting in its
+// channel, for the debug area.
+func (m *UploadMetrics) Lines(queue string, queued int) []string {
+	q := m.Stats(queue)
+	lines := []string{
+		queue,
+		fmt.Sprintf("  pending: %d", int64(queued)+q.InFlight),
+		fmt.Sprintf("  succeeded: %d", q.Succeeded),
+		fmt.Sprintf("  failed: %d", q.Failed),
+	}
+	if !q.RetryAt.IsZero() {
+		wait := max(q.RetryAt.Sub(m.timeNow()), 0)
+		lines = append(lines, fmt.Sprintf("  retry in %.1fs", wait.Seconds()))
+	}
+	if q.LastError != "" {
+		lines = append(lines, "  last error: "+
+			strings.SplitN(q.LastError, "\n", 2)[0])
+	}
+	return lines
+}
+
+// UploadMetricsLines are the lines of the vertical debug area: one block per
+// upload queue.
+func (g *Gui) UploadMetricsLines() (lines []string) {
+	for _, q := range []struct {
+		name   string
+		queued int
+	}{
+		{playthroughsQueue, len(g.uploadDataChannel)},
+		{userDataQueue, len(g.uploadUserDataChannel)},
+		{analyticsQueue, len(g.analyticsChannel)},
+		{logsQueue, len(g.uploadLogChannel)},
+	} {
+		lines = append(lines, g.uploadMetrics.Lines(q.name, q.queued)...)
+		lines = append(lines, "")
+	}
+	if !g.UploadsAllowed() {
+		lines = append(lines, "uploads off (opted out)")
+	}
+	return
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestUploadMetrics(t *testing.T) {
+	moment := time.Unix(1700000000, 0)
+	m := UploadMetrics{now: func() time.Time { return moment }}
+	var slept []time.Duration
+	policy := RetryPolicy{MaxAttempts: 3, InitialDelay: time.Second,
+		MaxDelay: time.Second, MaxElapsed: time.Minute,
+		sleep: func(d time.Duration) { slept = append(slept, d) }}
+
+	assert.Nil(t, m.Do(logsQueue, policy, func() error { return nil }))
+	offline := errors.New("offline")
+	assert.Equal(t, offline, m.Do(logsQueue, policy,
+		func() error { return offline }))
+	assert.Equal(t, 2, len(slept))
+	assert.Equal(t, QueueStats{Succeeded: 1, Failed: 1, LastError: "offline"},
+		m.Stats(logsQueue))
+
+	// While an upload waits for a retry, the debug area says so.
+	m.Start(playthroughsQueue)
+	policy.sleep = func(time.Duration) {
+		assert.Equal(t, []string{"playthroughs", "  pending: 3",
+			"  succeeded: 0", "  failed: 0", "  retry in 1.0s",
+			"  last error: offline"}, m.Lines(playthroughsQueue, 2))
+	}
+	attempt := 0
+	err := m.Policy(playthroughsQueue, policy).Do(func() error {
+		attempt++
+		if attempt == 1 {
+			return offline
+		}
+		return nil
+	})
+	m.Done(playthroughsQueue, err)
+	assert.Equal(t, []string{"playthroughs", "  pending: 0",
+		"  succeeded: 1", "  failed: 0", "  last error: offline"},
+		m.Lines(playthroughsQueue, 0))
+}
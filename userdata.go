@@ -0,0 +1,104 @@
+package main
+
+import (
+	"cmp"
+	"slices"
+)
+
+// ScoreRecord is the result of one finished game, as kept in UserData.
+type ScoreRecord struct {
+	Mode  GameMode `yaml:"Mode"`
+	Score int64    `yaml:"Score"`
+	Won   bool     `yaml:"Won"`
+	// When the game ended, in Unix seconds.
+	Moment int64 `yaml:"Moment"`
+}
+
+// UserData keeps the last maxRecentScores games, for stats and for analyzing
+// how players progress. Older games only count through the best scores.
+const maxRecentScores = 50
+
+// AddScore remembers the result of a finished game and updates the best score
+// of its mode.
+func (u *UserData) AddScore(r ScoreRecord) {
+	u.RecentScores = append(u.RecentScores, r)
+	if len(u.RecentScores) > maxRecentScores {
+		u.RecentScores = u.RecentScores[len(u.RecentScores)-maxRecentScores:]
+	}
+	best := u.BestScoreForMode(r.Mode)
+	*best = max(*best, r.Score)
+}
+
+// BestScoreForMode returns the personal best that the player is competing
+// against in mode. Endless scores are not comparable to Classic scores so they
+// are tracked separately.
+func (u *UserData) BestScoreForMode(mode GameMode) *int64 {
+	if mode == Endless {
+		return &u.BestEndlessScore
+	}
+	return &u.BestScore
+}
+
+// RecentScoresForMode returns the recent scores of mode, oldest first.
+func (u *UserData) RecentScoresForMode(mode GameMode) (scores []int64) {
+	for _, r := range u.RecentScores {
+		if r.Mode == mode {
+			scores = append(scores, r.Score)
+		}
+	}
+	return
+}
+
+// MergeUserData combines the UserData of two devices of the same player, e.g.
+// the desktop and the browser, so that neither loses the progress of the
+// other: the best scores are the best of both and the recent games are those
+// of both, in the order they ended. The order of a and b doesn't matter.
+//
+// The Revision of the result is the highest of the two, plus one if that
+// Revision has other scores. So the result has the Revision of a or b only
+// if it has the same scores, e.g. a result with the Revision of the server is
+// already on the server.
+func MergeUserData(a UserData, b UserData) (m UserData) {
+	m.BestScore = max(a.BestScore, b.BestScore)
+	m.BestEndlessScore = max(a.BestEndlessScore, b.BestEndlessScore)
+	for _, r := range append(slices.Clone(a.RecentScores),
+		b.RecentScores...) {
+		if !slices.Contains(m.RecentScores, r) {
+			m.RecentScores = append(m.RecentScores, r)
+		}
+	}
+	slices.SortStableFunc(m.RecentScores, func(r1, r2 ScoreRecord) int {
+		return cmp.Compare(r1.Moment, r2.Moment)
+	})
+	if len(m.RecentScores) > maxRecentScores {
+		m.RecentScores = m.RecentScores[len(m.RecentScores)-maxRecentScores:]
+	}
+
+	m.Revision = max(a.Revision, b.Revision)
+	if (a.Revision == m.Revision && !m.sameScores(a)) ||
+		(b.Revision == m.Revision && !m.sameScores(b)) {
+		m.Revision++
+	}
+	return
+}
+
+// sameScores is true if u and v are the same, apart from their Revision.
+func (u *UserData) sameScores(v UserData) bool {
+	return u.BestScore == v.BestScore &&
+		u.BestEndlessScore == v.BestEndlessScore &&
+		slices.Equal(u.RecentScores, v.RecentScores)
+}
+
+// UpdateUserData takes the UserData that UploadUserData merged with the
+// server since the last frame, so that the progress made on other devices
+// shows up here too.
+func (g *Gui) UpdateUserData() {
+	for {
+		select {
+		case data := <-g.syncedUserDataChannel:
+			g.UserData = MergeUserData(g.UserData, data)
+		default:
+			return
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"github.com/goccy/go-yaml"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestUserData_AddScore(t *testing.T) {
+	var u UserData
+	u.AddScore(ScoreRecord{Mode: Classic, Score: 100})
+	u.AddScore(ScoreRecord{Mode: Endless, Score: 300})
+	u.AddScore(ScoreRecord{Mode: Classic, Score: 50, Moment: 7})
+	assert.Equal(t, int64(100), u.BestScore)
+	assert.Equal(t, int64(300), u.BestEndlessScore)
+	assert.Equal(t, []int64{100, 50}, u.RecentScoresForMode(Classic))
+
+	// Only the last games are kept, the best scores stay.
+	for i := range maxRecentScores {
+		u.AddScore(ScoreRecord{Mode: Endless, Score: int64(i)})
+	}
+	assert.Len(t, u.RecentScores, maxRecentScores)
+	assert.Empty(t, u.RecentScoresForMode(Classic))
+	assert.Equal(t, int64(100), u.BestScore)
+
+	// The history goes through the same YAML as the best scores.
+	data, err := yaml.Marshal(u)
+	assert.NoError(t, err)
+	var loaded UserData
+	assert.NoError(t, yaml.Unmarshal(data, &loaded))
+	assert.Equal(t, u, loaded)
+
+	// UserData saved before the history existed still loads.
+	loaded = UserData{}
+	assert.NoError(t, yaml.Unmarshal([]byte("BestScore: 12\n"), &loaded))
+	assert.Equal(t, int64(12), loaded.BestScore)
+	assert.Empty(t, loaded.RecentScores)
+}
+
+func TestMergeUserData(t *testing.T) {
+	// Played on the desktop and in the browser since the last sync.
+	var desktop, browser UserData
+	desktop.AddScore(ScoreRecord{Mode: Classic, Score: 100, Moment: 1})
+	desktop.AddScore(ScoreRecord{Mode: Classic, Score: 80, Moment: 3})
+	desktop.Revision = 2
+	browser.AddScore(ScoreRecord{Mode: Classic, Score: 100, Moment: 1})
+	browser.AddScore(ScoreRecord{Mode: Endless, Score: 300, Moment: 2})
+	browser.Revision = 2
+
+	m := MergeUserData(desktop, browser)
+	assert.Equal(t, m, MergeUserData(browser, desktop))
+	assert.Equal(t, int64(100), m.BestScore)
+	assert.Equal(t, int64(300), m.BestEndlessScore)
+	assert.Equal(t, []ScoreRecord{{Classic, 100, false, 1},
+		{Endless, 300, false, 2}, {Classic, 80, false, 3}}, m.RecentScores)
+	// New to both.
+	assert.Equal(t, int64(3), m.Revision)
+
+	// Merging again changes nothing.
+	assert.Equal(t, m, MergeUserData(m, browser))
+	assert.Equal(t, m, MergeUserData(m, m))
+
+	// Data that has it all keeps its Revision only if it is the latest.
+	assert.Equal(t, m, MergeUserData(m, UserData{}))
+	old := desktop
+	old.Revision = 5
+	assert.Equal(t, int64(6), MergeUserData(m, old).Revision)
+
+	// Only the last games are kept.
+	var many UserData
+	for i := range maxRecentScores {
+		many.AddScore(ScoreRecord{Mode: Classic, Moment: int64(i + 10)})
+	}
+	m = MergeUserData(many, desktop)
+	assert.Len(t, m.RecentScores, maxRecentScores)
+	assert.Equal(t, int64(10), m.RecentScores[0].Moment)
+}
+
+func TestGui_UploadUserData(t *testing.T) {
+	sink := NewFileSink(t.TempDir(), SystemClock{})
+	assert.Nil(t, sink.SetUserData("user",
+		"Revision: 4\nBestScore: 500\nBestEndlessScore: 20\n"))
+	var g Gui
+	g.telemetry = sink
+	g.syncedUserDataChannel = make(chan UserData, 10)
+
+	// The upload doesn't overwrite the best score of the other device.
+	g.BestEndlessScore = 70
+	g.uploadUserData()
+	ch := make(chan UserData, 10)
+	ch <- g.UserData
+	close(ch)
+	g.UploadUserData("user", "", ch)
+	synced := LoadUserData(sink, "user")
+	assert.Equal(t, int64(500), synced.BestScore)
+	assert.Equal(t, int64(70), synced.BestEndlessScore)
+	assert.Equal(t, int64(5), synced.Revision)
+
+	// And the other device's progress shows up here.
+	g.UpdateUserData()
+	assert.Equal(t, int64(5), g.Revision)
+	assert.Equal(t, int64(500), g.BestScore)
+}
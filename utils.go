@@ -18,14 +18,19 @@ import (
 )
 
 var CheckCrashes = true
+
+// CheckFailed is the last error Check got while CheckCrashes was false. While
+// CheckCrashes is true, Check panics with the error and leaves CheckFailed
+// alone, so that it can run on many goroutines at once, e.g. in the
+// regression tests that run in parallel.
 var CheckFailed error
 
 func Check(e error) {
 	if e != nil {
-		CheckFailed = e
 		if CheckCrashes {
 			panic(e)
 		}
+		CheckFailed = e
 	}
 }
 
@@ -166,6 +171,70 @@ func DeserializeSlice[T any](buf *bytes.Buffer, s *[]T) {
 	Deserialize(buf, *s)
 }
 
+// SerializeField writes one field of a tagged structure: its id, the number
+// of bytes written by serialize and the bytes themselves. A reader that doesn't
+// know the id skips the field, and a reader that expects a field which isn't
+// there leaves it at its zero value. This way fields can be added without
+// breaking the readers of the old structure, see DeserializeFields.
+func SerializeField(buf *bytes.Buffer, id int64,
+	serialize func(buf *bytes.Buffer)) {
+	field := new(bytes.Buffer)
+	serialize(field)
+	Serialize(buf, id)
+	Serialize(buf, int64(field.Len()))
+	_, err := buf.Write(field.Bytes())
+	Check(err)
+}
+
+// SerializeValueField is SerializeField for a value that Serialize can write.
+func SerializeValueField(buf *bytes.Buffer, id int64, value any) {
+	SerializeField(buf, id, func(buf *bytes.Buffer) { Serialize(buf, value) })
+}
+
+// DeserializeFields reads the fields written by SerializeField, until the end
+// of buf.
+func DeserializeFields(buf *bytes.Buffer) map[int64]*bytes.Buffer {
+	fields := map[int64]*bytes.Buffer{}
+	for buf.Len() > 0 {
+		var id, length int64
+		Deserialize(buf, &id)
+		Deserialize(buf, &length)
+		if length < 0 || length > int64(buf.Len()) {
+			Check(fmt.Errorf("field %d should have %d bytes, but only %d "+
+				"are left", id, length, buf.Len()))
+		}
+		fields[id] = bytes.NewBuffer(buf.Next(int(length)))
+	}
+	return fields
+}
+
+// DeserializeField calls deserialize on the bytes of field id, if it exists.
+func DeserializeField(fields map[int64]*bytes.Buffer, id int64,
+	deserialize func(buf *bytes.Buffer)) {
+	if field, ok := fields[id]; ok {
+		deserialize(field)
+	}
+}
+
+// DeserializeValueField is DeserializeField for a value that Deserialize can
+// read.
+func DeserializeValueField(fields map[int64]*bytes.Buffer, id int64,
+	value any) {
+	DeserializeField(fields, id, func(buf *bytes.Buffer) {
+		Deserialize(buf, value)
+	})
+}
+
+func SerializeString(buf *bytes.Buffer, s string) {
+	SerializeSlice(buf, []byte(s))
+}
+
+func DeserializeString(buf *bytes.Buffer, s *string) {
+	var b []byte
+	DeserializeSlice(buf, &b)
+	*s = string(b)
+}
+
 func Unzip(data []byte) []byte {
 	// Get a bytes.Reader, which implements the io.ReaderAt interface required
 	// by the zip.NewReader() function.
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
@@ -11,3 +12,21 @@ func TestGetDigitArray(t *testing.T) {
 	assert.Equal(t, GetDigitArray(725), []int64{7, 2, 5})
 	assert.Equal(t, GetDigitArray(123456), []int64{1, 2, 3, 4, 5, 6})
 }
+
+func TestCheck(t *testing.T) {
+	err := errors.New("failed")
+	CheckFailed = nil
+	assert.PanicsWithValue(t, err, func() { Check(err) })
+	// Nothing is shared between the goroutines that panic.
+	assert.Nil(t, CheckFailed)
+
+	CheckCrashes = false
+	defer func() {
+		CheckCrashes = true
+		CheckFailed = nil
+	}()
+	Check(nil)
+	assert.Nil(t, CheckFailed)
+	Check(err)
+	assert.Equal(t, err, CheckFailed)
+}
@@ -48,13 +48,17 @@ func (v *VisWorld) Step(w *World) {
 	v.Temporary = v.Temporary[:n]
 
 	// Create new animations if necessary.
-	for _, b := range w.JustMergedBricks {
+	for _, e := range w.Events {
+		if e.Type != MergeEvent {
+			continue
+		}
+
 		// The radial splash has its center match the brick's center.
 		splashRadial := TemporaryAnimation{}
 		splashRadial.Animation = v.Animations.animSplashRadial
 		// One-shot animation, go through all the images once then end.
 		splashRadial.NFramesLeft = splashRadial.Animation.TotalNFrames()
-		splashRadial.Pos = b.Bounds.Center()
+		splashRadial.Pos = e.Pos
 		v.Temporary = append(v.Temporary, &splashRadial)
 
 		// The radial splash has its top-center match the brick's center.
@@ -62,8 +66,8 @@ func (v *VisWorld) Step(w *World) {
 		splashDown.Animation = v.Animations.animSplashDown
 		// One-shot animation, go through all the images once then end.
 		splashDown.NFramesLeft = splashDown.Animation.TotalNFrames()
-		splashDown.Pos = b.Bounds.Center()
-		splashDown.Pos.Y += b.Bounds.Height() / 2
+		splashDown.Pos = e.Pos
+		splashDown.Pos.Y += BrickPixelSize / 2
 		v.Temporary = append(v.Temporary, &splashDown)
 	}
 }
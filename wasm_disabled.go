@@ -2,10 +2,40 @@
 
 package main
 
-import "os"
+import (
+	"io"
+	"os"
+)
 
-func getUsername() string {
-	return "vali-dev"
+// defaultDisplayName is the DisplayName of an Identity that has none. Outside
+// the browser, players set theirs in the identity file.
+func defaultDisplayName() string {
+	return ""
+}
+
+// userAgent describes the browser, for crash reports. There is none outside
+// the browser.
+func userAgent() string {
+	return ""
+}
+
+// onPageHidden calls f whenever the page is hidden. There is no page outside
+// the browser, the window closing is handled in Update.
+func onPageHidden(f func()) {
+}
+
+// LoadStoredString returns what StoreString stored under key, which is the
+// name of a file in the current folder.
+func LoadStoredString(key string) (string, bool) {
+	data, err := os.ReadFile(key)
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
+
+func StoreString(key string, value string) {
+	WriteFile(key, []byte(value))
 }
 
 func WriteFile(name string, data []byte) {
@@ -13,6 +43,13 @@ func WriteFile(name string, data []byte) {
 	Check(err)
 }
 
+// CreateFile creates or truncates the file name and opens it for writing.
+func CreateFile(name string) io.WriteCloser {
+	f, err := os.Create(name)
+	Check(err)
+	return f
+}
+
 func AppendToFile(name string, str string) {
 	f, err := os.OpenFile(name, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	Check(err)
@@ -3,17 +3,86 @@
 package main
 
 import (
+	"io"
 	"syscall/js"
 )
 
-func getUsername() string {
+// defaultDisplayName is the DisplayName of an Identity that has none: the
+// name that the page gives the game, if any.
+func defaultDisplayName() string {
 	// Retrieve parameter from JavaScript global scope.
-	return js.Global().Get("username").String()
+	name := js.Global().Get("username")
+	if name.Type() != js.TypeString {
+		return ""
+	}
+	return name.String()
+}
+
+// userAgent describes the browser, for crash reports.
+func userAgent() string {
+	navigator := js.Global().Get("navigator")
+	if navigator.Type() != js.TypeObject {
+		return ""
+	}
+	return navigator.Get("userAgent").String()
+}
+
+// onPageHidden calls f whenever the page is hidden: the tab is closed or in
+// the background, or the browser is minimized. This is the last event that
+// is sure to come before the tab is closed, beforeunload doesn't come on
+// mobile and can't wait for anything anyway.
+func onPageHidden(f func()) {
+	document := js.Global().Get("document")
+	if document.Type() != js.TypeObject {
+		return
+	}
+	document.Call("addEventListener", "visibilitychange",
+		js.FuncOf(func(js.Value, []js.Value) any {
+			if document.Get("visibilityState").String() == "hidden" {
+				f()
+			}
+			return nil
+		}))
+}
+
+// LoadStoredString returns what StoreString stored under key, in the
+// localStorage of the browser. Files don't last in the browser.
+func LoadStoredString(key string) (string, bool) {
+	storage := js.Global().Get("localStorage")
+	if storage.Type() != js.TypeObject {
+		return "", false
+	}
+	value := storage.Call("getItem", key)
+	if value.Type() != js.TypeString {
+		return "", false
+	}
+	return value.String(), true
+}
+
+func StoreString(key string, value string) {
+	storage := js.Global().Get("localStorage")
+	if storage.Type() != js.TypeObject {
+		return
+	}
+	storage.Call("setItem", key, value)
 }
 
 func WriteFile(name string, data []byte) {
 }
 
+// discardCloser is where files go in the browser.
+type discardCloser struct {
+	io.Writer
+}
+
+func (d discardCloser) Close() error {
+	return nil
+}
+
+func CreateFile(name string) io.WriteCloser {
+	return discardCloser{io.Discard}
+}
+
 func AppendToFile(name string, str string) {
 }
 
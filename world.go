@@ -225,18 +225,175 @@ const (
 type BrickParams struct {
 	Pos Pt
 	Val int64
+	// Stone bricks have no value, Val is ignored.
+	Stone bool
 }
 
+// ChainParams chains two bricks of a Level. Brick1 and Brick2 are indexes in
+// Level.BricksParams. Several ChainParams can share a brick, in which case all
+// the bricks involved end up in the same ChainGroup.
 type ChainParams struct {
 	Brick1 int64
 	Brick2 int64
 }
 
+// DifficultyParams groups the values that decide how fast the difficulty
+// ramps up during a level. They are part of the Level so that they can be tuned
+// per level from a test's YAML, instead of editing constants in the World.
+type DifficultyParams struct {
+	// The timer cooldown, in frames, is computed as:
+	// TimerCooldownBase + TimerCooldownPerVal * maxVal
+	// where maxVal is the maximum brick value currently present on the board.
+	TimerCooldownBase   int64 `yaml:"TimerCooldownBase"`
+	TimerCooldownPerVal int64 `yaml:"TimerCooldownPerVal"`
+	// A new row of bricks gets values between 1 and
+	// maxVal - NewRowMaxValMargin.
+	NewRowMaxValMargin int64 `yaml:"NewRowMaxValMargin"`
+	// Chains start appearing in new rows once maxVal reaches ChainsMinVal.
+	// On average, a new row gets one chain for every ValsPerChain values that
+	// maxVal has.
+	ChainsMinVal int64 `yaml:"ChainsMinVal"`
+	ValsPerChain int64 `yaml:"ValsPerChain"`
+	// The rate at which the bricks slow down during a coming up event.
+	ComingUpDeceleration int64 `yaml:"ComingUpDeceleration"`
+	// The maximum number of bricks that a new row may chain together, in a
+	// single ChainGroup.
+	MaxChainLength int64 `yaml:"MaxChainLength"`
+	// Only used in Endless mode. Every new row of bricks shortens the timer
+	// cooldown. After n rows, the cooldown is:
+	// cooldown * 100 / (100 + EndlessSpeedupPerRow * n)
+	// but never less than EndlessMinTimerCooldown frames.
+	EndlessSpeedupPerRow    int64 `yaml:"EndlessSpeedupPerRow"`
+	EndlessMinTimerCooldown int64 `yaml:"EndlessMinTimerCooldown"`
+	// Only used if the Level has PushNowEnabled. Pushing a new row early adds
+	// PushNowBonusPerSec points to the score for every full second left on
+	// the timer.
+	PushNowBonusPerSec int64 `yaml:"PushNowBonusPerSec"`
+	// How long, in frames, gravity stays flipped after a gravity flip event.
+	GravityFlipDuration int64 `yaml:"GravityFlipDuration"`
+}
+
+// DefaultDifficultyParams returns the values that follow the original game.
+func DefaultDifficultyParams() DifficultyParams {
+	// The formula for the timer is 11.3 sec + 0.2 sec * maxVal. In terms of
+	// frames: 678 + 12 * maxVal
+	return DifficultyParams{
+		TimerCooldownBase:    678,
+		TimerCooldownPerVal:  12,
+		NewRowMaxValMargin:   2,
+		ChainsMinVal:         10,
+		ValsPerChain:         10,
+		ComingUpDeceleration: 2,
+		MaxChainLength:       2,
+		// With these values the timer is at half its initial duration after
+		// 25 rows and it bottoms out at 3 sec.
+		EndlessSpeedupPerRow:    4,
+		EndlessMinTimerCooldown: 180,
+		// A merge of two 2s is worth 3 points, so pushing with the whole timer
+		// left is worth a handful of merges at the start of a game and much
+		// less than a single merge later on.
+		PushNowBonusPerSec:  1,
+		GravityFlipDuration: 300,
+	}
+}
+
+// Gravity is the direction in which unsupported bricks fall.
+type Gravity int64
+
+const (
+	Down Gravity = iota
+	Up
+)
+
+type WorldEventType int64
+
+const (
+	// Two bricks merged. The event describes the brick that remained.
+	MergeEvent WorldEventType = iota
+	// A new row of bricks came up. Val is the number of rows created so far,
+	// like NewRowsCount.
+	RowSpawnedEvent
+	// A falling brick hit something and stopped falling.
+	BrickFellToRestEvent
+	GameOverEvent
+	GameWonEvent
+)
+
+// WorldEvent is something notable that happened during a Step. The Gui, the
+// VisWorld, analysis code and tests can react to events instead of deriving
+// what happened by comparing World states.
+// It has a fixed size so that events can be serialized (and hashed) like the
+// rest of the World.
+type WorldEvent struct {
+	Type WorldEventType
+	// The brick the event is about, 0 if the event isn't about a brick.
+	BrickId int64
+	// The center of the brick, in pixels, at the moment of the event.
+	Pos Pt
+	// The value of the brick, or a count for events about something else.
+	Val int64
+}
+
+// GameMode decides what the player is trying to achieve in a Level.
+type GameMode int64
+
+const (
+	// Classic is the original game: get a brick to MaxBrickValue.
+	Classic GameMode = iota
+	// Endless is about the score only. The timer gets shorter with every new
+	// row of bricks, following the curve in DifficultyParams, until the
+	// player can't keep up anymore.
+	Endless
+)
+
+const (
+	DefaultMaxBrickValue        = 30
+	DefaultMaxInitialBrickValue = 5
+	// The GUI only has images for bricks up to this value.
+	MaxSupportedBrickValue = 30
+)
+
 type Level struct {
 	BricksParams          []BrickParams
 	ChainsParams          []ChainParams
 	TimerDisabled         bool
 	AllowOverlappingDrags bool
+	Mode                  GameMode
+	// The player may bring up the next row of bricks before the timer runs
+	// out, for a score bonus.
+	PushNowEnabled bool
+	// Merging up to MaxBrickValue wins the game. The first rows of bricks
+	// only go up to MaxInitialBrickValue. Left at 0, they take the default
+	// values, DefaultMaxBrickValue and DefaultMaxInitialBrickValue, except
+	// that MaxInitialBrickValue stays below MaxBrickValue. Short test levels
+	// can win at lower values. MaxBrickValue can't go above
+	// MaxSupportedBrickValue.
+	MaxBrickValue        int64
+	MaxInitialBrickValue int64
+	// If DifficultyParams is left at its zero value, the World uses
+	// DefaultDifficultyParams. This way, a Level that doesn't care about
+	// difficulty doesn't have to know the default values.
+	DifficultyParams
+}
+
+// ChainLink is a chain between two neighboring bricks, the way the player sees
+// it.
+type ChainLink struct {
+	Brick1 int64
+	Brick2 int64
+}
+
+// ChainGroup is a set of bricks connected by chains, which move together as a
+// single solid piece. The bricks can form a line, an L or any other shape, as
+// long as each link connects two neighboring bricks.
+// The first brick in Members is the leader. The leader's State is the State of
+// the whole group (Canonical, Dragged or Falling) and the leader is the only
+// brick in the group that is ever moved directly. The other bricks are in the
+// Follower state and they move whenever the leader moves.
+type ChainGroup struct {
+	Id      int64
+	Members []int64
+	Links   []ChainLink
 }
 
 type Brick struct {
@@ -246,7 +403,15 @@ type Brick struct {
 	PixelPos     Pt
 	State        BrickState
 	FallingSpeed int64
-	ChainedTo    int64
+	// The Id of the ChainGroup the brick belongs to, 0 if it is not chained.
+	Group int64
+	// A stone brick is a permanent obstacle. It can't be dragged, it can't be
+	// chained and it doesn't merge with anything, not even other stones. It
+	// still falls and comes up like any other brick. Its Val is always 0.
+	Stone bool
+	// The pointer that drags the brick. Only meaningful while the brick is
+	// Dragged.
+	Pointer int64
 	// Derived values. These should only ever be read. They are re-computed
 	// every time PixelPos changes.
 	CanonicalPos      Pt
@@ -272,10 +437,11 @@ func (w *World) NewBrick(pixelPos Pt, val int64) Brick {
 }
 
 func (w *World) SetBrickPos(b *Brick, newPos Pt) {
-	if b.ChainedTo > 0 {
-		b2 := w.GetBrick(b.ChainedTo)
-		if b2.State == Follower {
-			dif := newPos.Minus(b.PixelPos)
+	if b.Group > 0 && b.State != Follower {
+		// b is the leader of a group, the followers move along with it.
+		dif := newPos.Minus(b.PixelPos)
+		for _, id := range w.GetChainGroup(b.Group).Members[1:] {
+			b2 := w.GetBrick(id)
 			w.SetBrickPos(b2, b2.PixelPos.Plus(dif))
 		}
 	}
@@ -299,22 +465,40 @@ const (
 	Won
 )
 
+// worldStateNames are the names of the WorldStates, as written by people,
+// e.g. in the config.
+var worldStateNames = map[string]WorldState{
+	"Regular":  Regular,
+	"ComingUp": ComingUp,
+	"Lost":     Lost,
+	"Won":      Won,
+}
+
+func (s WorldState) String() string {
+	for name, state := range worldStateNames {
+		if state == s {
+			return name
+		}
+	}
+	return fmt.Sprintf("WorldState(%d)", int64(s))
+}
+
 type World struct {
 	Rand
+	DifficultyParams
 	Seed                     int64
 	NextBrickId              int64
 	DragSpeed                int64
 	CanonicalAdjustmentSpeed int64
 	BrickFallAcceleration    int64
 	Bricks                   []Brick
-	DraggingOffset           Pt
+	Drags                    [MaxPointers]Drag
 	DebugPts                 []Pt
 	TimerDisabled            bool
 	TimerCooldown            int64
 	TimerCooldownIdx         int64
 	ComingUpDistanceLeft     int64
 	ComingUpSpeed            int64
-	ComingUpDeceleration     int64
 	State                    WorldState
 	PreviousState            WorldState
 	SolvedFirstState         bool
@@ -325,49 +509,186 @@ type World struct {
 	ColumnsBuffer            [][]*Brick
 	FirstComingUp            bool
 	Score                    int64
-	JustMergedBricks         []*Brick
-	SlotsBuffer              Mat
-	AllowOverlappingDrags    bool
-	GroupsBuffer             [31][]*Brick
+	// Everything notable that happened during the last Step.
+	Events                []WorldEvent
+	SlotsBuffer           Mat
+	AllowOverlappingDrags bool
+	GroupsBuffer          [][]*Brick
+	Mode                  GameMode
+	PushNowEnabled        bool
+	Gravity               Gravity
+	// The number of frames left until gravity goes back to Down.
+	GravityFlipIdx int64
+	// The number of rows created since the start of the level, not counting
+	// the first rows.
+	NewRowsCount     int64
+	ChainGroups      []ChainGroup
+	NextChainGroupId int64
+	Hooks            WorldHooks
+}
+
+// InputDevice is the kind of device that produced a PlayerInput.
+// The World doesn't care about it, a click is a click. It is recorded so that
+// playthroughs can be analyzed separately for mouse and touch players, which
+// otherwise produce identical data.
+type InputDevice int64
+
+const (
+	// UnknownDevice is used when nothing happened yet, so there is no device
+	// to attribute the input to.
+	UnknownDevice InputDevice = iota
+	Mouse
+	Touch
+	// KeyboardAssist is used for inputs triggered by a key instead of the
+	// pointer (e.g. triggering a coming up event).
+	KeyboardAssist
+)
+
+// MaxPointers is the number of pointers the World follows at the same time.
+// The first pointer is the mouse or the first finger on a touch screen. The
+// others only exist on multi-touch devices, where the player may drag two
+// bricks at once.
+const MaxPointers = 2
+
+// PointerInput is the state of one pointer in one frame.
+type PointerInput struct {
+	Pos          Pt
+	JustPressed  bool
+	JustReleased bool
 }
 
 type PlayerInput struct {
-	Pos             Pt
-	JustPressed     bool
-	JustReleased    bool
+	Pos          Pt
+	JustPressed  bool
+	JustReleased bool
+	// The pointers after the first one. The first pointer is described by
+	// Pos, JustPressed and JustReleased, which is all a mouse needs.
+	OtherPointers   [MaxPointers - 1]PointerInput
 	TriggerComingUp bool
+	// Flips gravity for GravityFlipDuration frames. Like TriggerComingUp,
+	// this is a debugging tool for now.
+	TriggerGravityFlip bool
+	// The player wants the dragged brick to go back where it was picked up
+	// from, instead of being released wherever it is.
+	CancelDrag bool
+	// The player wants the followers of the dragged chain to swing a quarter
+	// turn clockwise around the leader.
+	RotateChain bool
+	// The player wants the next row of bricks to come up now. Unlike
+	// TriggerComingUp, which is a debugging tool, this only works if the
+	// Level has PushNowEnabled and it is rewarded.
+	PushNow bool
+	// If not 0, the game was paused for this many frames and the input
+	// stands for the whole pause. The World doesn't change during a pause.
+	// Pauses are recorded so that a playback shows when the player stopped
+	// playing and for how long. A pause is a single input, however long it
+	// is, so that it doesn't make the History any longer.
+	PausedFrames int64
+	Device       InputDevice
+	// When the input was recorded, in Unix milliseconds, or 0 if it is not
+	// known. The World ignores it. It is there so that reaction times and
+	// idle periods can be measured in real time, which frame counts don't
+	// give when the game ran slowed down or dropped frames.
+	Moment int64
 }
 
 func (p *PlayerInput) EventOccurred() bool {
-	return p.JustPressed || p.JustReleased || p.TriggerComingUp
+	for _, o := range p.OtherPointers {
+		if o.JustPressed || o.JustReleased {
+			return true
+		}
+	}
+	return p.JustPressed || p.JustReleased || p.TriggerComingUp ||
+		p.TriggerGravityFlip || p.CancelDrag || p.RotateChain || p.PushNow
 }
 
-// NewWorld creates a world object that is ready for updates.
-func NewWorld(seed int64, l Level) (w World) {
-	// Set constants and buffers.
-	w.NextBrickId = 1
-	w.MaxBrickValue = 30
-	w.MaxInitialBrickValue = 5
-	w.DragSpeed = 100
-	w.CanonicalAdjustmentSpeed = 21
-	w.BrickFallAcceleration = 2
-	w.ComingUpDeceleration = 2
+// Pointer returns the state of pointer i, 0 being the first pointer.
+func (p *PlayerInput) Pointer(i int64) PointerInput {
+	if i == 0 {
+		return PointerInput{p.Pos, p.JustPressed, p.JustReleased}
+	}
+	return p.OtherPointers[i-1]
+}
+
+// AllocateBuffers gives the World its own buffers. The buffers only hold
+// temporary data during a Step, so they never need to be copied, but two
+// Worlds must not share them.
+func (w *World) AllocateBuffers() {
 	w.ObstaclesBuffer = make([]Rectangle, NCols*NRows+4)
 	w.ColumnsBuffer = make([][]*Brick, NCols)
 	for i := range w.ColumnsBuffer {
 		w.ColumnsBuffer[i] = make([]*Brick, NRows)
 	}
 	w.SlotsBuffer = NewMat(Pt{NCols, NRows})
-	w.Bricks = make([]Brick, 0, NCols*(NRows+1))
+	// There is a group for each brick value.
+	w.GroupsBuffer = make([][]*Brick, w.MaxBrickValue+1)
 	for i := range w.GroupsBuffer {
 		w.GroupsBuffer[i] = make([]*Brick, 0, NCols*(NRows+1))
 	}
+}
+
+// Clone returns a deep copy of the World. Stepping the copy and the original
+// with the same inputs gives the same results, including the random numbers
+// they generate, so a clone is an exact snapshot of the World at this frame.
+func (w *World) Clone() (c World) {
+	c = *w
+	c.Bricks = make([]Brick, len(w.Bricks), cap(w.Bricks))
+	copy(c.Bricks, w.Bricks)
+	c.ChainGroups = make([]ChainGroup, len(w.ChainGroups))
+	for i, g := range w.ChainGroups {
+		c.ChainGroups[i] = ChainGroup{
+			Id:      g.Id,
+			Members: slices.Clone(g.Members),
+			Links:   slices.Clone(g.Links),
+		}
+	}
+	c.Events = slices.Clone(w.Events)
+	c.DebugPts = slices.Clone(w.DebugPts)
+	c.AllocateBuffers()
+	// Whoever listens to w doesn't expect to hear about the copy.
+	c.Hooks = WorldHooks{}
+	return
+}
+
+// NewWorld creates a world object that is ready for updates.
+func NewWorld(seed int64, l Level) (w World) {
+	// Set constants and buffers.
+	w.NextBrickId = 1
+	w.NextChainGroupId = 1
+	w.MaxBrickValue = l.MaxBrickValue
+	if w.MaxBrickValue == 0 {
+		w.MaxBrickValue = DefaultMaxBrickValue
+	}
+	if w.MaxBrickValue > MaxSupportedBrickValue {
+		Check(fmt.Errorf("MaxBrickValue is %d, but bricks only go up to %d",
+			w.MaxBrickValue, MaxSupportedBrickValue))
+	}
+	w.MaxInitialBrickValue = l.MaxInitialBrickValue
+	if w.MaxInitialBrickValue == 0 {
+		w.MaxInitialBrickValue = min(DefaultMaxInitialBrickValue,
+			w.MaxBrickValue-1)
+	}
+	w.DragSpeed = 100
+	w.CanonicalAdjustmentSpeed = 21
+	w.BrickFallAcceleration = 2
+	w.AllocateBuffers()
+	w.Bricks = make([]Brick, 0, NCols*(NRows+1))
 
 	// Initialize the world from level parameters.
 	w.Seed = seed
 	w.RSeed(w.Seed)
 	w.TimerDisabled = l.TimerDisabled
 	w.AllowOverlappingDrags = l.AllowOverlappingDrags
+	w.Mode = l.Mode
+	w.PushNowEnabled = l.PushNowEnabled
+	w.DifficultyParams = l.DifficultyParams
+	if w.DifficultyParams == (DifficultyParams{}) {
+		w.DifficultyParams = DefaultDifficultyParams()
+	}
+	// The coming up event and the computation of chains rely on these being
+	// strictly positive.
+	Assert(w.ComingUpDeceleration > 0)
+	Assert(w.ValsPerChain > 0)
 
 	w.Bricks = w.Bricks[:0]
 	if len(l.BricksParams) == 0 {
@@ -382,12 +703,17 @@ func NewWorld(seed int64, l Level) (w World) {
 		// Bricks specified. Assume this is a test and initialize based on the
 		// specifications.
 		for i := range l.BricksParams {
-			w.Bricks = append(w.Bricks, w.NewBrick(
-				l.BricksParams[i].Pos,
-				l.BricksParams[i].Val))
+			p := l.BricksParams[i]
+			if p.Stone {
+				b := w.NewBrick(p.Pos, 0)
+				b.Stone = true
+				w.Bricks = append(w.Bricks, b)
+			} else {
+				w.Bricks = append(w.Bricks, w.NewBrick(p.Pos, p.Val))
+			}
 		}
 		for _, c := range l.ChainsParams {
-			ChainBricks(&w.Bricks[c.Brick1], &w.Bricks[c.Brick2])
+			w.ChainBricks(&w.Bricks[c.Brick1], &w.Bricks[c.Brick2])
 		}
 		w.ResetTimerCooldown()
 		w.SolvedFirstState = false
@@ -406,17 +732,104 @@ func (w *World) GetBrick(id int64) *Brick {
 	panic(fmt.Errorf("brick not found: %d", id))
 }
 
-func ChainBricks(b1 *Brick, b2 *Brick) {
+func (w *World) GetChainGroup(id int64) *ChainGroup {
+	for i := range w.ChainGroups {
+		if w.ChainGroups[i].Id == id {
+			return &w.ChainGroups[i]
+		}
+	}
+	panic(fmt.Errorf("chain group not found: %d", id))
+}
+
+// LeaderOf returns the leader of b's group, or b if b is not chained.
+func (w *World) LeaderOf(b *Brick) *Brick {
+	if b.Group == 0 {
+		return b
+	}
+	return w.GetBrick(w.GetChainGroup(b.Group).Members[0])
+}
+
+// ChainGroupSize returns the number of bricks in b's group, counting b. An
+// unchained brick is a group of one.
+func (w *World) ChainGroupSize(b *Brick) int64 {
+	if b.Group == 0 {
+		return 1
+	}
+	return int64(len(w.GetChainGroup(b.Group).Members))
+}
+
+// ChainBricks chains two neighboring bricks. b1 must be to the left of b2 or
+// below it.
+// If neither brick is chained, they form a new group with b1 as the leader.
+// If only one of them is chained, the other one joins its group. If both are
+// chained, b2's group joins b1's group.
+func (w *World) ChainBricks(b1 *Brick, b2 *Brick) {
 	Assert(
 		(b1.CanonicalPos.Y == b2.CanonicalPos.Y &&
 			b1.CanonicalPos.X+1 == b2.CanonicalPos.X) ||
 			(b1.CanonicalPos.Y+1 == b2.CanonicalPos.Y &&
 				b1.CanonicalPos.X == b2.CanonicalPos.X))
-	Assert(b1.ChainedTo == 0)
-	Assert(b2.ChainedTo == 0)
-	b1.ChainedTo = b2.Id
-	b2.ChainedTo = b1.Id
-	b2.State = Follower
+	Assert(b1.Group == 0 || b1.Group != b2.Group)
+	Assert(!b1.Stone && !b2.Stone)
+	w.LinkBricks(b1, b2)
+}
+
+// LinkBricks does the work of ChainBricks, without checking that the bricks
+// are neighbors.
+func (w *World) LinkBricks(b1 *Brick, b2 *Brick) {
+	link := ChainLink{b1.Id, b2.Id}
+	if b1.Group == 0 && b2.Group == 0 {
+		w.ChainGroups = append(w.ChainGroups, ChainGroup{
+			Id:      w.NextChainGroupId,
+			Members: []int64{b1.Id, b2.Id},
+			Links:   []ChainLink{link},
+		})
+		b1.Group = w.NextChainGroupId
+		b2.Group = w.NextChainGroupId
+		b2.State = Follower
+		w.NextChainGroupId++
+		return
+	}
+
+	if b1.Group == 0 {
+		// b1 joins b2's group.
+		g := w.GetChainGroup(b2.Group)
+		g.Members = append(g.Members, b1.Id)
+		g.Links = append(g.Links, link)
+		b1.Group = g.Id
+		b1.State = Follower
+		return
+	}
+
+	g := w.GetChainGroup(b1.Group)
+	g.Links = append(g.Links, link)
+	if b2.Group == g.Id {
+		// The bricks were already connected through other links.
+		return
+	}
+	if b2.Group == 0 {
+		// b2 joins b1's group.
+		g.Members = append(g.Members, b2.Id)
+		b2.Group = g.Id
+		b2.State = Follower
+		return
+	}
+
+	// b2's whole group joins b1's group, including b2's leader which becomes a
+	// follower.
+	idx := slices.IndexFunc(w.ChainGroups, func(g2 ChainGroup) bool {
+		return g2.Id == b2.Group
+	})
+	g2 := w.ChainGroups[idx]
+	for _, id := range g2.Members {
+		b := w.GetBrick(id)
+		b.Group = g.Id
+		b.State = Follower
+	}
+	g.Members = append(g.Members, g2.Members...)
+	g.Links = append(g.Links, g2.Links...)
+	// Remove g2 last, it invalidates g.
+	w.ChainGroups = Remove(w.ChainGroups, idx)
 }
 
 // NewWorldFromPlaythrough checks if the Playthrough has the same simulation
@@ -434,19 +847,72 @@ func NewWorldFromPlaythrough(p Playthrough) (w World) {
 
 func (w *World) ResetTimerCooldown() {
 	// The timer cooldown depends on the maximum brick value currently present
-	// on the board. The formula is 11.3 sec + 0.2 sec * maxValue. In terms of
-	// frames: 678 + 12 * maxValue
-	w.TimerCooldown = 678 + 12*w.CurrentMaxVal()
+	// on the board.
+	w.TimerCooldown = w.TimerCooldownBase +
+		w.TimerCooldownPerVal*w.CurrentMaxVal()
+	if w.Mode == Endless {
+		w.TimerCooldown = w.TimerCooldown * 100 /
+			(100 + w.EndlessSpeedupPerRow*w.NewRowsCount)
+		w.TimerCooldown = max(w.TimerCooldown, w.EndlessMinTimerCooldown)
+	}
 	w.TimerCooldownIdx = w.TimerCooldown
 }
 
+// PushNowBonus returns the points the player gets for bringing up the next row
+// of bricks right now.
+func (w *World) PushNowBonus() int64 {
+	return w.TimerCooldownIdx / 60 * w.PushNowBonusPerSec
+}
+
 func (w *World) Step(input PlayerInput) {
-	w.JustMergedBricks = w.JustMergedBricks[:0]
+	w.StepUntil(input, AllPhases)
+}
+
+// StepPhase is one of the phases a Regular step goes through, in order.
+type StepPhase int64
+
+const (
+	DragPhase StepPhase = iota
+	FallPhase
+	CanonicalPhase
+	MergePhase
+	AllPhases
+)
+
+var stepPhaseNames = map[StepPhase]string{
+	DragPhase:      "UpdateDraggedBrick",
+	FallPhase:      "UpdateFallingBricks",
+	CanonicalPhase: "UpdateCanonicalBricks",
+	MergePhase:     "MergeBricks",
+	AllPhases:      "all phases",
+}
+
+func (p StepPhase) String() string {
+	return stepPhaseNames[p]
+}
+
+// StepUntil is Step, except that a Regular step stops right after phase
+// last. This lets me see which phase of a step corrupts the World. The World
+// is left in the middle of a step, so it should be a throwaway copy.
+func (w *World) StepUntil(input PlayerInput, last StepPhase) {
+	w.Events = w.Events[:0]
+	if input.PausedFrames > 0 {
+		return
+	}
+	stateBefore := w.State
 
 	// Trigger a coming up event.
 	if input.TriggerComingUp {
 		w.State = ComingUp
 	}
+	if input.TriggerGravityFlip && w.State == Regular {
+		w.Gravity = Up
+		w.GravityFlipIdx = w.GravityFlipDuration
+	}
+	if input.PushNow && w.PushNowEnabled && w.State == Regular {
+		w.Score += w.PushNowBonus()
+		w.State = ComingUp
+	}
 
 	var justEnteredState bool
 	if !w.SolvedFirstState {
@@ -463,7 +929,7 @@ func (w *World) Step(input PlayerInput) {
 
 	switch w.State {
 	case Regular:
-		w.StepRegular(justEnteredState, input)
+		w.StepRegular(justEnteredState, input, last)
 	case ComingUp:
 		w.StepComingUp(justEnteredState)
 	}
@@ -472,17 +938,44 @@ func (w *World) Step(input PlayerInput) {
 	// Consider testing for game over here, as well, or inside StepRegular, just
 	// as an added precaution, even if I can't think of a way in which a game
 	// over could be reached during a StepRegular.
+
+	w.RunHooks(stateBefore)
 }
 
-func (w *World) DetermineDraggedBrick(input PlayerInput) {
-	var dragged *Brick
+// Drag is what the World remembers about the brick dragged by one pointer.
+type Drag struct {
+	// The position of the brick relative to the pointer.
+	Offset Pt
+	// The position the brick was picked up from.
+	Origin Pt
+	// The player canceled the drag and the brick is going back to Origin.
+	Canceled bool
+}
+
+// DraggedBrick returns the brick dragged by pointer p, or nil if p isn't
+// dragging anything.
+func (w *World) DraggedBrick(p int64) (dragged *Brick) {
 	for i := range w.Bricks {
-		if w.Bricks[i].State == Dragged {
+		if w.Bricks[i].State == Dragged && w.Bricks[i].Pointer == p {
 			dragged = &w.Bricks[i]
 		}
 	}
+	return
+}
+
+func (w *World) DetermineDraggedBrick(input PlayerInput) {
+	for p := range int64(MaxPointers) {
+		w.DeterminePointerDrag(p, input.Pointer(p), input)
+	}
+}
 
-	if input.JustPressed {
+// DeterminePointerDrag decides which brick pointer p drags, if any.
+func (w *World) DeterminePointerDrag(p int64, pointer PointerInput,
+	input PlayerInput) {
+	dragged := w.DraggedBrick(p)
+	drag := &w.Drags[p]
+
+	if pointer.JustPressed {
 		// Check if there's any brick under the click.
 		// Get the closest brick.
 		var closest *Brick
@@ -490,7 +983,7 @@ func (w *World) DetermineDraggedBrick(input PlayerInput) {
 		for i := range w.Bricks {
 			r := w.Bricks[i].Bounds
 			center := r.Min.Plus(r.Max).DivBy(2)
-			dist := center.SquaredDistTo(input.Pos)
+			dist := center.SquaredDistTo(pointer.Pos)
 			if dist < minDist {
 				minDist = dist
 				closest = &w.Bricks[i]
@@ -498,8 +991,12 @@ func (w *World) DetermineDraggedBrick(input PlayerInput) {
 		}
 
 		// Check if the closest brick is close enough to be dragged.
+		// Stones can't be dragged, clicking them is like clicking empty space.
+		// A brick that another pointer drags can't be taken away from it.
 		minDistForDragging := Sqr(int64(135))
-		if minDist <= minDistForDragging {
+		closeEnough := minDist <= minDistForDragging
+		if closeEnough && !closest.Stone &&
+			!w.DraggedByOtherPointer(w.LeaderOf(closest), p) {
 			// We can check here if dragged == nil. If not, it means that
 			// somehow the player clicked a brick, didn't release it and
 			// then clicked on another brick. This should not be possible
@@ -516,26 +1013,119 @@ func (w *World) DetermineDraggedBrick(input PlayerInput) {
 				dragged.State = Canonical
 			}
 
-			if closest.State == Follower {
-				dragged = w.GetBrick(closest.ChainedTo)
-			} else {
-				dragged = closest
-			}
+			dragged = w.LeaderOf(closest)
 			dragged.State = Dragged
-			w.DraggingOffset = dragged.Bounds.Min.Minus(input.Pos)
+			dragged.Pointer = p
+			drag.Offset = dragged.Bounds.Min.Minus(pointer.Pos)
+			drag.Origin = dragged.PixelPos
+			drag.Canceled = false
+		} else if !closeEnough && p > 0 {
+			// A second finger tapping away from the bricks cancels whatever
+			// the other fingers drag.
+			for p2 := range int64(MaxPointers) {
+				if p2 != p && w.DraggedBrick(p2) != nil {
+					w.Drags[p2].Canceled = true
+				}
+			}
 		}
 	}
 
-	if input.JustReleased {
+	if input.CancelDrag {
 		if dragged != nil {
+			// The brick stays dragged but UpdateDraggedBrick takes it back to
+			// its origin instead of following the pointer.
+			drag.Canceled = true
+		}
+	}
+
+	if input.RotateChain {
+		if dragged != nil && !drag.Canceled {
+			w.RotateChain(dragged)
+		}
+	}
+
+	if pointer.JustReleased {
+		// A canceled drag keeps going back to its origin after the pointer is
+		// released. The player asked for the brick to go back, not to be
+		// dropped on the way.
+		if dragged != nil && !drag.Canceled {
 			dragged.State = Canonical
 			return
 		}
 	}
 }
 
-func (w *World) StepRegular(justEnteredState bool, input PlayerInput) {
-	if !w.TimerDisabled {
+// DraggedByOtherPointer returns true if b is dragged by a pointer other than
+// p.
+func (w *World) DraggedByOtherPointer(b *Brick, p int64) bool {
+	return b.State == Dragged && b.Pointer != p
+}
+
+// RotateChain swings the followers of leader a quarter turn clockwise around
+// it, so a vertical chain becomes horizontal and the other way around.
+// Each follower travels in a straight line towards its new position. If any
+// follower would leave the play area or hit an obstacle on the way, nothing
+// moves.
+func (w *World) RotateChain(leader *Brick) {
+	if leader.Group == 0 {
+		return
+	}
+
+	followers := w.GetChainGroup(leader.Group).Members[1:]
+	targets := make([]Pt, len(followers))
+	for i, id := range followers {
+		b := w.GetBrick(id)
+		dif := b.PixelPos.Minus(leader.PixelPos)
+		// Y goes down on the screen, so this turns right into down, down into
+		// left and so on.
+		targets[i] = leader.PixelPos.Plus(Pt{-dif.Y, dif.X})
+		if targets[i].X < 0 ||
+			targets[i].X > PlayAreaWidth-BrickPixelSize ||
+			targets[i].Y < 0 ||
+			targets[i].Y > PlayAreaHeight-BrickPixelSize {
+			return
+		}
+
+		if !w.AllowOverlappingDrags {
+			w.GetObstacles(b, IncludingTop, &w.ObstaclesBuffer)
+			newR, _ := MoveRect(b.Bounds, targets[i],
+				Abs(dif.X)+Abs(dif.Y), w.ObstaclesBuffer)
+			if newR.Min != targets[i] {
+				return
+			}
+		}
+	}
+
+	for i, id := range followers {
+		w.SetBrickPos(w.GetBrick(id), targets[i])
+	}
+}
+
+// IsDragging returns true if the player is dragging a brick around, not
+// counting a brick that is going back to its origin after a canceled drag.
+func (w *World) IsDragging() bool {
+	for i := range w.Bricks {
+		b := &w.Bricks[i]
+		if b.State == Dragged && !w.Drags[b.Pointer].Canceled {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *World) StepRegular(justEnteredState bool, input PlayerInput,
+	last StepPhase) {
+	if w.Gravity != Down {
+		w.GravityFlipIdx--
+		if w.GravityFlipIdx <= 0 {
+			w.Gravity = Down
+		}
+	}
+
+	// The timer stops while gravity is flipped. A new row pushes everything
+	// up and the bricks are all gathered at the top, so it would end the game
+	// right away.
+	if !w.TimerDisabled && w.Gravity == Down {
 		w.TimerCooldownIdx--
 		if w.TimerCooldownIdx <= 0 {
 			w.State = ComingUp
@@ -550,19 +1140,39 @@ func (w *World) StepRegular(justEnteredState bool, input PlayerInput) {
 	}
 
 	w.UpdateDraggedBrick(input)
+	w.PhaseDone(DragPhase)
+	if last == DragPhase {
+		return
+	}
 	w.UpdateFallingBricks()
+	w.PhaseDone(FallPhase)
+	if last == FallPhase {
+		return
+	}
 	w.UpdateCanonicalBricks()
+	w.PhaseDone(CanonicalPhase)
+	if last == CanonicalPhase {
+		return
+	}
 	w.MergeBricks()
+	w.PhaseDone(MergePhase)
+	if last == MergePhase {
+		return
+	}
 
-	// Check if bricks went over the top.
+	// Check if bricks went over the top, or below the floor if gravity is
+	// flipped.
 	// This can be possible due to adjustments made in UpdateCanonicalBricks.
 	for i := range w.Bricks {
 		top := int64(0)
 		brickTop := w.Bricks[i].Bounds.Min.Y
+		brickBottom := w.Bricks[i].Bounds.Max.Y
 
-		if brickTop < top {
-			// The brick is over the top.
+		if brickTop < top ||
+			w.Gravity == Up && brickBottom > PlayAreaHeight {
+			// The brick is out of the play area.
 			w.State = Lost
+			w.Events = append(w.Events, WorldEvent{Type: GameOverEvent})
 			return
 		}
 	}
@@ -596,6 +1206,9 @@ func (w *World) NoMoreMergesArePossible() bool {
 	}
 	for i := range w.Bricks {
 		b := &w.Bricks[i]
+		if !w.CanMerge(b) {
+			continue
+		}
 		groups[b.Val] = append(groups[b.Val], b)
 	}
 
@@ -607,7 +1220,7 @@ func (w *World) NoMoreMergesArePossible() bool {
 				b1 := g[i]
 				b2 := g[j]
 				// We can merge b1 to b2 if they are not chained to each other.
-				if b1.ChainedTo != b2.Id {
+				if b1.Group == 0 || b1.Group != b2.Group {
 					// A merge is possible.
 					return false
 				}
@@ -619,19 +1232,26 @@ func (w *World) NoMoreMergesArePossible() bool {
 }
 
 func (w *World) UpdateDraggedBrick(input PlayerInput) {
-	var dragged *Brick
-	for i := range w.Bricks {
-		if w.Bricks[i].State == Dragged {
-			dragged = &w.Bricks[i]
-		}
+	for p := range int64(MaxPointers) {
+		w.UpdatePointerDrag(p, input.Pointer(p).Pos)
 	}
+}
 
+// UpdatePointerDrag moves the brick dragged by pointer p, if any, towards pos.
+func (w *World) UpdatePointerDrag(p int64, pos Pt) {
+	dragged := w.DraggedBrick(p)
 	if dragged == nil {
 		return
 	}
 
+	drag := &w.Drags[p]
+	if drag.Canceled {
+		w.ReturnDraggedBrick(dragged, drag)
+		return
+	}
+
 	if w.AllowOverlappingDrags {
-		targetPos := input.Pos.Plus(w.DraggingOffset)
+		targetPos := pos.Plus(drag.Offset)
 		// Clip targetPos to within the bounds of the game area.
 		if targetPos.X < 0 {
 			targetPos.X = 0
@@ -657,21 +1277,41 @@ func (w *World) UpdateDraggedBrick(input PlayerInput) {
 			return
 		}
 
-		// Also check if the follower intersects something.
-		if dragged.ChainedTo != 0 {
-			b2 := w.GetBrick(dragged.ChainedTo)
-			w.GetObstacles(b2, IncludingTop, &w.ObstaclesBuffer)
-			if RectIntersectsRects(b2.Bounds, w.ObstaclesBuffer) {
-				dragged.State = Canonical
-				return
+		// Also check if the followers intersect something.
+		if dragged.Group != 0 {
+			for _, id := range w.GetChainGroup(dragged.Group).Members[1:] {
+				b2 := w.GetBrick(id)
+				w.GetObstacles(b2, IncludingTop, &w.ObstaclesBuffer)
+				if RectIntersectsRects(b2.Bounds, w.ObstaclesBuffer) {
+					dragged.State = Canonical
+					return
+				}
 			}
 		}
 
-		targetPos := input.Pos.Plus(w.DraggingOffset)
+		targetPos := pos.Plus(drag.Offset)
 		w.MoveBrick(dragged, targetPos, w.DragSpeed, SlideOnObstacles)
 	}
 }
 
+// ReturnDraggedBrick moves the brick of a canceled drag towards the position
+// it was picked up from. The brick becomes canonical once it gets there, or
+// once it can't get any closer. Other bricks may have fallen into its origin
+// in the meantime and the canonical adjustment system decides where it ends
+// up in that case.
+func (w *World) ReturnDraggedBrick(dragged *Brick, drag *Drag) {
+	previousPos := dragged.PixelPos
+	if w.AllowOverlappingDrags {
+		w.MoveBrick(dragged, drag.Origin, w.DragSpeed, IgnoreObstacles)
+	} else {
+		w.MoveBrick(dragged, drag.Origin, w.DragSpeed, SlideOnObstacles)
+	}
+	if dragged.PixelPos == drag.Origin || dragged.PixelPos == previousPos {
+		dragged.State = Canonical
+		drag.Canceled = false
+	}
+}
+
 func BrickBounds(posPixels Pt) Rectangle {
 	return NewRectangle(posPixels,
 		posPixels.Plus(Pt{BrickPixelSize, BrickPixelSize}))
@@ -686,14 +1326,24 @@ func (w *World) UpdateFallingBricks() {
 		}
 
 		// Move the brick.
+		// Bricks that fall down ignore the top, so that a row coming up may
+		// push them over it. Bricks that fall up must stop at the top.
 		b.FallingSpeed += w.BrickFallAcceleration
-		hitObstacle := w.MoveBrick(b, b.PixelPos.Plus(Pt{0, 1000}),
-			b.FallingSpeed, StopAtFirstObstacleExceptTop)
+		var hitObstacle bool
+		if w.Gravity == Down {
+			hitObstacle = w.MoveBrick(b, b.PixelPos.Plus(Pt{0, 1000}),
+				b.FallingSpeed, StopAtFirstObstacleExceptTop)
+		} else {
+			hitObstacle = w.MoveBrick(b, b.PixelPos.Plus(Pt{0, -1000}),
+				b.FallingSpeed, StopAtFirstObstacle)
+		}
 		if hitObstacle {
 			// We hit something.
 			// The brick becomes canonical.
 			b.State = Canonical
 			b.FallingSpeed = 0
+			w.Events = append(w.Events, WorldEvent{BrickFellToRestEvent, b.Id,
+				b.Bounds.Center(), b.Val})
 		}
 	}
 }
@@ -719,30 +1369,32 @@ func (w *World) MarkFallingBricks() {
 			continue
 		}
 
-		if b.CanonicalPos.Y == 0 {
-			// The brick is already at the bottom, it cannot fall any lower.
+		if w.Gravity == Down && b.CanonicalPos.Y == 0 ||
+			w.Gravity == Up && b.CanonicalPos.Y == NRows-1 {
+			// The brick is already at the bottom (or the top, if gravity is
+			// flipped), it cannot fall any further.
 			continue
 		}
 
 		// Check if the brick or the slot underneath intersects other bricks.
-		w.GetObstacles(b, IncludingTop, &w.ObstaclesBuffer)
-		r := b.Bounds
-		r.Max.Y += BrickPixelSize + BrickMarginPixelSize
-		if RectIntersectsRects(r, w.ObstaclesBuffer) {
+		if w.IsSupported(b) {
 			continue
 		}
 
-		if b.ChainedTo != 0 {
-			// Check the chained brick as well.
-			// Checking underneath the chained brick is only relevant for a
-			// horizontally chained brick but it doesn't hurt logically or
-			// computationally to do the same operation for vertically chained
-			// bricks as well.
-			b2 := w.GetBrick(b.ChainedTo)
-			w.GetObstacles(b2, IncludingTop, &w.ObstaclesBuffer)
-			r2 := b2.Bounds
-			r2.Max.Y += BrickPixelSize + BrickMarginPixelSize
-			if RectIntersectsRects(r2, w.ObstaclesBuffer) {
+		if b.Group != 0 {
+			// Check the chained bricks as well.
+			// Checking underneath a chained brick is only relevant if it is
+			// not right on top of another brick of the group but it doesn't
+			// hurt logically or computationally to do the same operation for
+			// all of them.
+			supported := false
+			for _, id := range w.GetChainGroup(b.Group).Members[1:] {
+				if w.IsSupported(w.GetBrick(id)) {
+					supported = true
+					break
+				}
+			}
+			if supported {
 				continue
 			}
 		}
@@ -753,6 +1405,20 @@ func (w *World) MarkFallingBricks() {
 	}
 }
 
+// IsSupported checks if b or the slot underneath it intersect anything that is
+// not part of b's group. If gravity is flipped, it checks the slot above it
+// instead.
+func (w *World) IsSupported(b *Brick) bool {
+	w.GetObstacles(b, IncludingTop, &w.ObstaclesBuffer)
+	r := b.Bounds
+	if w.Gravity == Down {
+		r.Max.Y += BrickPixelSize + BrickMarginPixelSize
+	} else {
+		r.Min.Y -= BrickPixelSize + BrickMarginPixelSize
+	}
+	return RectIntersectsRects(r, w.ObstaclesBuffer)
+}
+
 func (w *World) ConvergeTowardsCanonicalPositions() {
 	// Motivation
 	// ----------
@@ -811,11 +1477,21 @@ func (w *World) ConvergeTowardsCanonicalPositions() {
 	// natural in the average case, and somewhat natural in edge cases. This has
 	// been tested manually and I can confirm is looks natural enough.
 
+	// When gravity is flipped, everything above is mirrored: positions are
+	// assigned starting from the top and conflicts are resolved by searching
+	// downwards. A column that is too full then goes below the floor instead
+	// of over the top, which ends the game all the same, see StepRegular.
+	searchDir := int64(1)
+	if w.Gravity == Up {
+		searchDir = -1
+	}
+
 	// Sort bricks.
 	slices.SortStableFunc(w.Bricks, func(b1, b2 Brick) int {
 		if b2.PixelPos.Y != b1.PixelPos.Y {
-			// Bigger Y has priority (must appear first in the list).
-			return cmp.Compare(b2.PixelPos.Y, b1.PixelPos.Y)
+			// Bigger Y has priority (must appear first in the list), or
+			// smaller Y if gravity is flipped.
+			return cmp.Compare(b2.PixelPos.Y, b1.PixelPos.Y) * int(searchDir)
 		} else {
 			// When Y is the same, smaller X has priority (must appear first in
 			// the list).
@@ -834,18 +1510,26 @@ func (w *World) ConvergeTowardsCanonicalPositions() {
 
 		// We need to find a position for b, in this column.
 		// We start off from b's current position.
+		// The followers keep their position relative to b, so the target
+		// position of a follower b2 is always:
+		// targetCanPos + b2.CanonicalPos - b.CanonicalPos
 		targetCanPos := b.CanonicalPos
-		var b2 *Brick
-		var targetCanPos2 Pt
-		if b.ChainedTo > 0 {
-			b2 = w.GetBrick(b.ChainedTo)
-			Assert(b2.State == Follower)
-			targetCanPos2 = b2.CanonicalPos
+		var followers []int64
+		if b.Group > 0 {
+			followers = w.GetChainGroup(b.Group).Members[1:]
 		}
 
 		// Find an unoccupied position.
 		for {
-			if !slots.InBounds(targetCanPos) || !slots.InBounds(targetCanPos2) {
+			outOfBounds := !slots.InBounds(targetCanPos)
+			for _, id := range followers {
+				b2 := w.GetBrick(id)
+				Assert(b2.State == Follower)
+				targetCanPos2 := targetCanPos.Plus(
+					b2.CanonicalPos.Minus(b.CanonicalPos))
+				outOfBounds = outOfBounds || !slots.InBounds(targetCanPos2)
+			}
+			if outOfBounds {
 				// The brick is going out of bounds, so just let it go
 				// there and trigger game over.
 				break
@@ -854,15 +1538,20 @@ func (w *World) ConvergeTowardsCanonicalPositions() {
 			// Check if the position is already occupied by another brick of a
 			// different value.
 			otherB := slots.Get(targetCanPos)
-			occupied := otherB != nil && otherB.Val != b.Val
-			if b2 != nil && !occupied {
+			occupied := otherB != nil && !w.CanOverlap(otherB, b)
+			for _, id := range followers {
+				if occupied {
+					break
+				}
+				b2 := w.GetBrick(id)
+				targetCanPos2 := targetCanPos.Plus(
+					b2.CanonicalPos.Minus(b.CanonicalPos))
 				otherB2 := slots.Get(targetCanPos2)
-				occupied = otherB2 != nil && otherB2.Val != b2.Val
+				occupied = otherB2 != nil && !w.CanOverlap(otherB2, b2)
 			}
 
 			if occupied {
-				targetCanPos.Y++
-				targetCanPos2.Y++
+				targetCanPos.Y += searchDir
 			} else {
 				// Found an unoccupied position.
 				break
@@ -873,9 +1562,14 @@ func (w *World) ConvergeTowardsCanonicalPositions() {
 			slots.Set(targetCanPos, b)
 		}
 		// If we decided the position of a leader brick, we also decided the
-		// position of the follower brick.
-		if b2 != nil && slots.InBounds(targetCanPos2) {
-			slots.Set(targetCanPos2, b2)
+		// position of the follower bricks.
+		for _, id := range followers {
+			b2 := w.GetBrick(id)
+			targetCanPos2 := targetCanPos.Plus(
+				b2.CanonicalPos.Minus(b.CanonicalPos))
+			if slots.InBounds(targetCanPos2) {
+				slots.Set(targetCanPos2, b2)
+			}
 		}
 
 		// Go towards the target pos, without considering any obstacles.
@@ -941,7 +1635,6 @@ func (w *World) MergeBricks() {
 			brickToUpdate = b2
 			idxToRemove = i
 		}
-		w.JustMergedBricks = append(w.JustMergedBricks, brickToUpdate)
 
 		// A merge breaks the chains off the bricks involved in the merge.
 		w.UnchainBrick(b1)
@@ -953,38 +1646,77 @@ func (w *World) MergeBricks() {
 		// Perform the merge.
 		brickToUpdate.Val++
 		brickToUpdate.State = Canonical
+		w.Events = append(w.Events, WorldEvent{MergeEvent, brickToUpdate.Id,
+			brickToUpdate.Bounds.Center(), brickToUpdate.Val})
 		w.Bricks = Remove(w.Bricks, idxToRemove)
-		if brickToUpdate.Val == w.MaxBrickValue {
+		// In Endless mode the score is the only goal, a brick at
+		// MaxBrickValue just doesn't merge anymore, see CanMerge.
+		if w.Mode == Classic && brickToUpdate.Val == w.MaxBrickValue {
 			w.State = Won
+			w.Events = append(w.Events, WorldEvent{Type: GameWonEvent})
 		}
 	}
 }
 
+// UnchainBrick takes b out of its group. Without b, the rest of the group may
+// fall apart into several pieces. Each piece that still has at least two
+// bricks stays chained. If possible, the old leader stays a leader.
+// Every brick that is no longer a follower gets the state of the old leader.
 func (w *World) UnchainBrick(b *Brick) {
-	if b.ChainedTo == 0 {
+	if b.Group == 0 {
 		return
 	}
 
-	b2 := w.GetBrick(b.ChainedTo)
-	b.ChainedTo = 0
-	b2.ChainedTo = 0
+	idx := slices.IndexFunc(w.ChainGroups, func(g ChainGroup) bool {
+		return g.Id == b.Group
+	})
+	g := w.ChainGroups[idx]
+	w.ChainGroups = Remove(w.ChainGroups, idx)
+	leader := w.GetBrick(g.Members[0])
+	state := leader.State
+	Assert(state != Follower)
+	for _, id := range g.Members {
+		b2 := w.GetBrick(id)
+		b2.Group = 0
+		b2.State = state
+		b2.Pointer = leader.Pointer
+	}
 
-	if b.State == Follower {
-		b.State = b2.State
-	} else if b2.State == Follower {
-		b2.State = b.State
-	} else {
-		panic("no follower")
+	// Rebuild the pieces out of the links that remain.
+	for _, l := range g.Links {
+		if l.Brick1 != b.Id && l.Brick2 != b.Id {
+			w.LinkBricks(w.GetBrick(l.Brick1), w.GetBrick(l.Brick2))
+		}
+	}
+	if leader != b && leader.Group != 0 {
+		g2 := w.GetChainGroup(leader.Group)
+		i := slices.Index(g2.Members, leader.Id)
+		oldLeader := w.GetBrick(g2.Members[0])
+		g2.Members[0], g2.Members[i] = g2.Members[i], g2.Members[0]
+		oldLeader.State = Follower
+		leader.State = state
 	}
 }
 
+// CanMerge checks if b can merge at all. Stones never merge and neither do
+// bricks at MaxBrickValue in Endless mode, as there are no bricks beyond it.
+func (w *World) CanMerge(b *Brick) bool {
+	return !b.Stone && !(w.Mode == Endless && b.Val == w.MaxBrickValue)
+}
+
+// CanOverlap checks if two bricks are allowed to go through each other, which
+// is also what allows them to merge.
+func (w *World) CanOverlap(b1 *Brick, b2 *Brick) bool {
+	return b1.Val == b2.Val && w.CanMerge(b1) && w.CanMerge(b2)
+}
+
 func (w *World) FindMergingBricks() (foundMerge bool, i, j int) {
 	// Two bricks merge if they are close enough for each other.
 	// We decide here what "close enough" means.
 	mergeDist := Sqr(BrickPixelSize / 3)
 	for i = range w.Bricks {
 		for j = i + 1; j < len(w.Bricks); j++ {
-			if w.Bricks[i].Val != w.Bricks[j].Val {
+			if !w.CanOverlap(&w.Bricks[i], &w.Bricks[j]) {
 				continue
 			}
 
@@ -998,6 +1730,11 @@ func (w *World) FindMergingBricks() (foundMerge bool, i, j int) {
 }
 
 func (w *World) CreateFirstRowsOfBricks() {
+	// The first rows need values below MaxInitialBrickValue and the game
+	// must not be won before it starts.
+	Assert(w.MaxInitialBrickValue >= 2)
+	Assert(w.MaxBrickValue > w.MaxInitialBrickValue)
+
 	w.Bricks = w.Bricks[:0]
 
 	// Create the first row.
@@ -1059,8 +1796,9 @@ func (w *World) CreateNewRowOfBricks(maxVal int64) {
 		w.Bricks = append(w.Bricks, w.NewBrick(newPos, val))
 
 		if brickAbove != nil &&
-			brickAbove.State == Canonical &&
-			brickAbove.ChainedTo == 0 {
+			!brickAbove.Stone &&
+			w.LeaderOf(brickAbove).State == Canonical &&
+			w.ChainGroupSize(brickAbove) < w.MaxChainLength {
 			// Vertical chain possible.
 			possibleChains = append(possibleChains,
 				BrickPair{
@@ -1076,19 +1814,20 @@ func (w *World) CreateNewRowOfBricks(maxVal int64) {
 		}
 	}
 
-	// Add chains, if we are at 10 or more.
+	// Add chains, if we are at ChainsMinVal or more.
 	currentMaxVal := w.CurrentMaxVal()
-	if currentMaxVal < 10 {
+	if currentMaxVal < w.ChainsMinVal {
 		return
 	}
 
 	// Compute how many chains there will be in this new row.
-	// On average, the number of chains per row = (currentMaxVal - 1) / 10.
+	// On average, the number of chains per row is
+	// (currentMaxVal - 1) / ValsPerChain.
 	// Since this will usually be a decimal number, we add the integral part
 	// and then we increase by 1 randomly, where the chance of increasing by 1
 	// depends on the decimal part.
-	nChainsToAdd := (currentMaxVal - 1) / 10
-	if w.RInt(0, 10) < (currentMaxVal-1)%10 {
+	nChainsToAdd := (currentMaxVal - 1) / w.ValsPerChain
+	if w.RInt(0, w.ValsPerChain) < (currentMaxVal-1)%w.ValsPerChain {
 		nChainsToAdd++
 	}
 
@@ -1110,18 +1849,20 @@ func (w *World) CreateNewRowOfBricks(maxVal int64) {
 		}
 		chosenOne := w.RInt(int64(0), int64(len(possibleChains)-1))
 		c := possibleChains[chosenOne]
-		ChainBricks(c.b1, c.b2)
+		w.ChainBricks(c.b1, c.b2)
 		possibleChains = Remove(possibleChains, int(chosenOne))
 		nChainsToAdd--
 
 		// A brick might appear multiple times in possibleChains, because it
 		// may be chained to the brick above or the brick next to it. So remove
-		// entries in possibleChains which include the recently chained bricks.
+		// entries in possibleChains which would now join bricks that are
+		// already in the same group or would make a group that is too long.
 		n := 0
 		for i, p := range possibleChains {
-			containsNewlyChainedBricks :=
-				p.b1 == c.b1 || p.b1 == c.b2 || p.b2 == c.b1 || p.b2 == c.b2
-			if !containsNewlyChainedBricks {
+			sameGroup := p.b1.Group != 0 && p.b1.Group == p.b2.Group
+			tooLong := w.ChainGroupSize(p.b1)+w.ChainGroupSize(p.b2) >
+				w.MaxChainLength
+			if !sameGroup && !tooLong {
 				possibleChains[n] = possibleChains[i]
 				n++
 			}
@@ -1138,7 +1879,7 @@ func (w *World) CreateNewRowOfBricks(maxVal int64) {
 		lastB := &w.Bricks[len(w.Bricks)-1]
 
 		// Make sure the last brick is unchained.
-		if lastB.ChainedTo != 0 {
+		if lastB.Group != 0 {
 			w.UnchainBrick(lastB)
 		}
 
@@ -1151,7 +1892,7 @@ func (w *World) CreateNewRowOfBricks(maxVal int64) {
 			// the forbidden value, we have 5 other bricks in the new row,
 			// they can't all have the forbiddenValue but also not allow
 			// any merges.
-			if b.CanonicalPos != posAbove {
+			if b.CanonicalPos != posAbove && w.CanMerge(&b) {
 				lastB.Val = b.Val
 				break
 			}
@@ -1192,7 +1933,10 @@ func (w *World) StepComingUp(justEnteredState bool) {
 		if w.FirstComingUp {
 			w.FirstComingUp = false
 		} else {
-			w.CreateNewRowOfBricks(w.CurrentMaxVal() - 2)
+			w.CreateNewRowOfBricks(w.CurrentMaxVal() - w.NewRowMaxValMargin)
+			w.NewRowsCount++
+			w.Events = append(w.Events, WorldEvent{Type: RowSpawnedEvent,
+				Val: w.NewRowsCount})
 		}
 
 		w.ResetTimerCooldown()
@@ -1233,9 +1977,7 @@ func (w *World) StepComingUp(justEnteredState bool) {
 			// dragged bricks and bricks who were recently dragged and now are
 			// in the middle of adjusting their position, because only those
 			// bricks will have space to be moved down.
-			if b.State == Follower {
-				b = w.GetBrick(b.ChainedTo)
-			}
+			b = w.LeaderOf(b)
 			hitObstacle := w.MoveBrick(b, b.PixelPos.Plus(Pt{0, 1000}),
 				top-brickTop, StopAtFirstObstacleExceptTop)
 
@@ -1243,6 +1985,7 @@ func (w *World) StepComingUp(justEnteredState bool) {
 				// We couldn't move the brick all the way down, which means it
 				// hit another brick, so it's game over.
 				w.State = Lost
+				w.Events = append(w.Events, WorldEvent{Type: GameOverEvent})
 				return
 			}
 		}
@@ -1289,11 +2032,11 @@ func (w *World) GetObstacles(
 	obstacles := (*buffer)[:0]
 	for j := range w.Bricks {
 		otherB := &w.Bricks[j]
-		if otherB == b || otherB.Id == b.ChainedTo {
+		if otherB == b || (b.Group != 0 && otherB.Group == b.Group) {
 			continue
 		}
 		// Skip bricks that have the same value.
-		if b.Val == otherB.Val {
+		if w.CanOverlap(b, otherB) {
 			continue
 		}
 
@@ -1324,6 +2067,7 @@ type MoveType int64
 const (
 	IgnoreObstacles MoveType = iota
 	StopAtFirstObstacleExceptTop
+	StopAtFirstObstacle
 	SlideOnObstacles
 )
 
@@ -1339,8 +2083,7 @@ func (w *World) MoveBrick(b *Brick, targetPos Pt, nMaxPixels int64,
 
 	if moveType == IgnoreObstacles {
 		// Go towards the target pos, without considering any obstacles.
-		pts := GetLinePoints(b.PixelPos, targetPos, nMaxPixels)
-		w.SetBrickPos(b, pts[len(pts)-1])
+		w.SetBrickPos(b, lineEnd(b.PixelPos, targetPos, nMaxPixels))
 		return false
 	}
 
@@ -1350,6 +2093,12 @@ func (w *World) MoveBrick(b *Brick, targetPos Pt, nMaxPixels int64,
 		return nPixelsLeft > 0
 	}
 
+	if moveType == StopAtFirstObstacle {
+		nPixelsLeft := w.MoveBrickHelper(b, targetPos, nMaxPixels,
+			IncludingTop)
+		return nPixelsLeft > 0
+	}
+
 	if moveType == SlideOnObstacles {
 		// The overall logic of the movement is this:
 		// - simulate the brick being dragged/moved towards the mouse position
@@ -1417,22 +2166,24 @@ func (w *World) MoveBrickHelper(
 		w.ObstaclesBuffer)
 	dif := newR.Min.Minus(b.Bounds.Min)
 
-	if b.ChainedTo > 0 {
-		// Get the follower brick.
-		b2 := w.GetBrick(b.ChainedTo)
-		targetPos2 := b2.PixelPos.Plus(targetPos.Minus(b.PixelPos))
-
-		// Check how much the follower brick can move.
-		w.GetObstacles(b2, o, &w.ObstaclesBuffer)
-		newR2, nPixelsLeft2 := MoveRect(b2.Bounds, targetPos2, nMaxPixels,
-			w.ObstaclesBuffer)
-		dif2 := newR2.Min.Minus(b2.Bounds.Min)
-
-		// If the follower brick can move less than the leader brick, limit the
-		// movement.
-		if nPixelsLeft2 > nPixelsLeft {
-			nPixelsLeft = nPixelsLeft2
-			dif = dif2
+	if b.Group > 0 {
+		for _, id := range w.GetChainGroup(b.Group).Members[1:] {
+			// Get the follower brick.
+			b2 := w.GetBrick(id)
+			targetPos2 := b2.PixelPos.Plus(targetPos.Minus(b.PixelPos))
+
+			// Check how much the follower brick can move.
+			w.GetObstacles(b2, o, &w.ObstaclesBuffer)
+			newR2, nPixelsLeft2 := MoveRect(b2.Bounds, targetPos2, nMaxPixels,
+				w.ObstaclesBuffer)
+			dif2 := newR2.Min.Minus(b2.Bounds.Min)
+
+			// If the follower brick can move less than the leader brick, limit
+			// the movement.
+			if nPixelsLeft2 > nPixelsLeft {
+				nPixelsLeft = nPixelsLeft2
+				dif = dif2
+			}
 		}
 	}
 
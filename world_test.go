@@ -1,23 +1,71 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+	"hash/crc32"
 	"os"
+	"reflect"
+	"slices"
+	"sync"
 	"testing"
+	"testing/fstest"
 )
 
 func TestWorld_RegressionTests(t *testing.T) {
-	tests := GetFiles(os.DirFS(".").(FS), "regression-tests", "*.clone1")
+	// Each World has its own state, so the tests run in parallel. A test
+	// that panics would take the whole test binary down with it, so each one
+	// runs like in the self-test, which turns panics into errors.
+	fsys := os.DirFS(".").(FS)
+	tests := GetFiles(fsys, "regression-tests", "*.clone1")
+	require.NotEmpty(t, tests)
 	for _, test := range tests {
-		playthrough := DeserializePlaythrough(ReadFile(test))
-		expected := string(ReadFile(test + "-hash"))
-		actual := RegressionId(playthrough)
-		println(test)
-		println(actual)
-		println()
-		assert.Equal(t, expected, actual)
+		t.Run(test, func(t *testing.T) {
+			t.Parallel()
+			if err := selfTestPlaythrough(fsys, test); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestSelfTest(t *testing.T) {
+	// The regression tests themselves run above, this only checks that the
+	// failures are counted instead of stopping the self-test.
+	const test = "coming-up-release-dragged.clone1"
+	data := ReadFile("regression-tests/" + test)
+	hash := ReadFile("regression-tests/" + test + "-hash")
+	fsys := fstest.MapFS{
+		"tests/ok.clone1":            {Data: data},
+		"tests/ok.clone1-hash":       {Data: hash},
+		"tests/wrong.clone1":         {Data: data},
+		"tests/wrong.clone1-hash":    {Data: []byte("not the hash")},
+		"tests/corrupt.clone1":       {Data: []byte("not a playthrough")},
+		"tests/corrupt.clone1-hash":  {Data: hash},
+		"tests/no-hash.clone1":       {Data: data},
+		"tests/not-a-test.clone1-01": {Data: data},
+	}
+	assert.Equal(t, 3, SelfTest(fsys, "tests"))
+}
+
+func TestWorld_Parallel(t *testing.T) {
+	playthrough := DeserializePlaythrough(ReadFile("data/demo.clone1"))
+	expected := RegressionId(playthrough)
+	ids := make([]string, 4)
+	var wg sync.WaitGroup
+	for i := range ids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ids[i] = RegressionId(playthrough)
+		}()
+	}
+	wg.Wait()
+	for _, id := range ids {
+		assert.Equal(t, expected, id)
 	}
 }
 
@@ -59,11 +107,11 @@ func TestWorld_RegressionTests(t *testing.T) {
 func TestWorld_ConvertRegressionTests(t *testing.T) {
 	tests := GetFiles(os.DirFS(".").(FS), "regression-tests", "*.clone1")
 	for _, test := range tests {
-		playthrough := DeserializePlaythrough(ReadFile(test))
+		data := ReadFile(test)
+		playthrough := DeserializePlaythrough(data)
 		fmt.Printf("%s changing SimulationVersion from %d to %d\n", test,
 			playthrough.SimulationVersion, SimulationVersion)
-		playthrough.SimulationVersion = SimulationVersion
-		WriteFile(test, playthrough.Serialize())
+		WriteFile(test, SetSimulationVersion(data, SimulationVersion))
 	}
 	assert.True(t, true)
 }
@@ -157,3 +205,920 @@ func TestWorld_CreateNewRowOfBricks(t *testing.T) {
 		require.False(t, w.NoMoreMergesArePossible())
 	}
 }
+
+func TestWorld_DifficultyParams(t *testing.T) {
+	// A Level that doesn't specify difficulty gets the default difficulty.
+	var l Level
+	l.BricksParams = append(l.BricksParams, BrickParams{
+		Pos: CanonicalPosToPixelPos(Pt{0, 0}),
+		Val: 7,
+	})
+	w := NewWorld(0, l)
+	assert.Equal(t, DefaultDifficultyParams(), w.DifficultyParams)
+	assert.Equal(t, int64(678+12*7), w.TimerCooldown)
+
+	// A Level that specifies difficulty gets exactly what it specified.
+	l.DifficultyParams = DefaultDifficultyParams()
+	l.TimerCooldownBase = 100
+	l.TimerCooldownPerVal = 1
+	w = NewWorld(0, l)
+	assert.Equal(t, int64(100+1*7), w.TimerCooldown)
+}
+
+func TestLoadTest_DifficultyDefaults(t *testing.T) {
+	fsys := fstest.MapFS{
+		"test.yaml": &fstest.MapFile{Data: []byte(
+			"Difficulty:\n  ComingUpDeceleration: 5\n")},
+	}
+	test := LoadTest(fsys, "test.yaml")
+	expected := DefaultDifficultyParams()
+	expected.ComingUpDeceleration = 5
+	assert.Equal(t, expected, test.GetLevel().DifficultyParams)
+}
+
+func TestTestFromWorld(t *testing.T) {
+	// Every test level survives a round trip through a World and a YAML file.
+	type brickSummary struct {
+		Pos     Pt
+		Val     int64
+		Chained bool
+	}
+	summarize := func(w *World) (s []brickSummary) {
+		for _, b := range w.Bricks {
+			s = append(s, brickSummary{b.PixelPos, b.Val, b.Group != 0})
+		}
+		slices.SortFunc(s, func(a, b brickSummary) int {
+			if a.Pos.Y != b.Pos.Y {
+				return int(a.Pos.Y - b.Pos.Y)
+			}
+			if a.Pos.X != b.Pos.X {
+				return int(a.Pos.X - b.Pos.X)
+			}
+			return int(a.Val - b.Val)
+		})
+		return
+	}
+
+	dir := t.TempDir()
+	files := GetFiles(os.DirFS(".").(FS), "regression-tests", "*.yaml")
+	require.NotEmpty(t, files)
+	for _, file := range files {
+		if file == "regression-tests/regression-MoveBrick.yaml" {
+			// This one holds a copy of the .txt instead of a level.
+			continue
+		}
+		test := LoadTest(os.DirFS(".").(FS), file)
+		w := NewWorld(0, test.GetLevel())
+
+		SaveYAML(dir+"/test.yaml", TestFromWorld(&w))
+		saved := LoadTest(os.DirFS(dir).(FS), "test.yaml")
+		w2 := NewWorld(0, saved.GetLevel())
+		assert.Equal(t, summarize(&w), summarize(&w2), file)
+		assert.Equal(t, len(w.ChainGroups), len(w2.ChainGroups), file)
+		assert.Equal(t, w.DifficultyParams, w2.DifficultyParams, file)
+	}
+}
+
+func TestLoadTest_FromRecording(t *testing.T) {
+	p := DeserializePlaythrough(ReadFile("data/demo.clone1"))
+	fsys := fstest.MapFS{
+		"demo.clone1": &fstest.MapFile{Data: p.Serialize()},
+	}
+	w := NewWorldFromPlaythrough(p)
+	for i := range 1000 {
+		w.Step(p.History[i])
+	}
+	expected := TestFromWorld(&w)
+	assert.Equal(t, expected, LoadTest(fsys, "demo.clone1@frame=1000"))
+
+	assert.Panics(t, func() { LoadTest(fsys, "demo.clone1@frame=abc") })
+	assert.Panics(t, func() {
+		LoadTest(fsys, fmt.Sprintf("demo.clone1@frame=%d",
+			len(p.History)+1))
+	})
+}
+
+func TestWorld_EndlessTimerEscalates(t *testing.T) {
+	var l Level
+	l.Mode = Endless
+	l.BricksParams = append(l.BricksParams, BrickParams{
+		Pos: CanonicalPosToPixelPos(Pt{0, 0}),
+		Val: 10,
+	})
+	w := NewWorld(0, l)
+	initial := int64(678 + 12*10)
+	assert.Equal(t, initial, w.TimerCooldown)
+
+	// The timer gets shorter with every new row.
+	w.NewRowsCount = 25
+	w.ResetTimerCooldown()
+	assert.Equal(t, initial/2, w.TimerCooldown)
+
+	// But never shorter than the minimum.
+	w.NewRowsCount = 10000
+	w.ResetTimerCooldown()
+	assert.Equal(t, w.EndlessMinTimerCooldown, w.TimerCooldown)
+
+	// Classic mode ignores the new rows.
+	l.Mode = Classic
+	w = NewWorld(0, l)
+	w.NewRowsCount = 25
+	w.ResetTimerCooldown()
+	assert.Equal(t, initial, w.TimerCooldown)
+}
+
+func TestWorld_ChainGroups(t *testing.T) {
+	// An L made of three bricks.
+	var l Level
+	for _, pos := range []Pt{{0, 0}, {1, 0}, {1, 1}} {
+		l.BricksParams = append(l.BricksParams, BrickParams{
+			Pos: CanonicalPosToPixelPos(pos),
+			Val: pos.X + 2*pos.Y + 1,
+		})
+	}
+	l.ChainsParams = []ChainParams{{0, 1}, {1, 2}}
+	w := NewWorld(0, l)
+	require.Equal(t, 1, len(w.ChainGroups))
+	assert.Equal(t, int64(3), w.ChainGroupSize(&w.Bricks[2]))
+	assert.Equal(t, Canonical, w.Bricks[0].State)
+	assert.Equal(t, Follower, w.Bricks[1].State)
+	assert.Equal(t, Follower, w.Bricks[2].State)
+	assert.Equal(t, &w.Bricks[0], w.LeaderOf(&w.Bricks[2]))
+
+	// Taking out the corner splits the L into two single bricks.
+	w.UnchainBrick(&w.Bricks[1])
+	assert.Equal(t, 0, len(w.ChainGroups))
+	for i := range w.Bricks {
+		assert.Equal(t, int64(0), w.Bricks[i].Group)
+		assert.Equal(t, Canonical, w.Bricks[i].State)
+	}
+
+	// Taking out the leader leaves a chained pair with a new leader.
+	w = NewWorld(0, l)
+	w.UnchainBrick(&w.Bricks[0])
+	require.Equal(t, 1, len(w.ChainGroups))
+	assert.Equal(t, Canonical, w.Bricks[1].State)
+	assert.Equal(t, Follower, w.Bricks[2].State)
+}
+
+func TestWorld_ChainGroupFallsTogether(t *testing.T) {
+	// A line of three bricks, one row above the bottom.
+	var l Level
+	l.TimerDisabled = true
+	for x := range int64(3) {
+		l.BricksParams = append(l.BricksParams, BrickParams{
+			Pos: CanonicalPosToPixelPos(Pt{x, 1}),
+			Val: x + 1,
+		})
+	}
+	l.ChainsParams = []ChainParams{{0, 1}, {1, 2}}
+
+	// With a brick under the last brick of the line, the line stays put.
+	supported := l
+	supported.BricksParams = append(slices.Clone(l.BricksParams), BrickParams{
+		Pos: CanonicalPosToPixelPos(Pt{2, 0}),
+		Val: 9,
+	})
+	w := NewWorld(0, supported)
+	for range 100 {
+		w.Step(PlayerInput{})
+	}
+	for i := range 3 {
+		assert.Equal(t, int64(1), w.GetBrick(int64(i+1)).CanonicalPos.Y)
+	}
+
+	// Without it, the whole line falls to the bottom.
+	w = NewWorld(0, l)
+	for range 100 {
+		w.Step(PlayerInput{})
+	}
+	for i := range 3 {
+		assert.Equal(t, int64(0), w.GetBrick(int64(i+1)).CanonicalPos.Y)
+		assert.Equal(t, w.GetBrick(int64(i+1)).CanonicalPixelPos,
+			w.GetBrick(int64(i+1)).PixelPos)
+	}
+}
+
+func TestWorld_CreateNewRowOfBricksLongChains(t *testing.T) {
+	RSeed(0)
+	nLongChains := 0
+	for range 1000 {
+		var l Level
+		l.DifficultyParams = DefaultDifficultyParams()
+		l.MaxChainLength = 3
+		l.BricksParams = append(l.BricksParams, BrickParams{
+			Pos: CanonicalPosToPixelPos(Pt{5, 0}),
+			Val: 29,
+		})
+		w := NewWorld(RInt(0, 10000), l)
+		for w.NewRowsCount < 3 && w.State != Lost {
+			w.Step(PlayerInput{TriggerComingUp: w.State == Regular &&
+				w.PreviousState == Regular})
+		}
+		for _, g := range w.ChainGroups {
+			require.LessOrEqual(t, len(g.Members), 3)
+			if len(g.Members) == 3 {
+				nLongChains++
+			}
+		}
+	}
+	// Chains of three do get created.
+	assert.Greater(t, nLongChains, 0)
+}
+
+func TestWorld_StoneBricks(t *testing.T) {
+	var l Level
+	l.TimerDisabled = true
+	l.BricksParams = []BrickParams{
+		{Pos: CanonicalPosToPixelPos(Pt{0, 0}), Stone: true},
+		{Pos: CanonicalPosToPixelPos(Pt{1, 0}), Stone: true},
+		{Pos: CanonicalPosToPixelPos(Pt{2, 0}), Val: 3},
+	}
+	w := NewWorld(0, l)
+
+	// Stones don't merge with each other.
+	assert.True(t, w.NoMoreMergesArePossible())
+	foundMerge, _, _ := w.FindMergingBricks()
+	assert.False(t, foundMerge)
+
+	// Stones can't be dragged.
+	stonePos := w.Bricks[0].Bounds.Center()
+	w.Step(PlayerInput{Pos: stonePos, JustPressed: true})
+	assert.Equal(t, Canonical, w.Bricks[0].State)
+
+	// Stones block other bricks.
+	brickPos := w.Bricks[2].Bounds.Center()
+	w.Step(PlayerInput{Pos: brickPos, JustPressed: true})
+	require.Equal(t, Dragged, w.Bricks[2].State)
+	for range 20 {
+		w.Step(PlayerInput{Pos: stonePos})
+	}
+	assert.Equal(t, Pt{2, 0}, w.Bricks[2].CanonicalPos)
+	assert.Equal(t, 3, len(w.Bricks))
+}
+
+func TestWorld_CancelDrag(t *testing.T) {
+	var l Level
+	l.TimerDisabled = true
+	l.BricksParams = []BrickParams{
+		{Pos: CanonicalPosToPixelPos(Pt{0, 0}), Val: 3},
+		{Pos: CanonicalPosToPixelPos(Pt{5, 0}), Val: 5},
+	}
+	dragAway := func(w *World) {
+		pos := w.GetBrick(1).Bounds.Center()
+		w.Step(PlayerInput{Pos: pos, JustPressed: true})
+		require.Equal(t, Dragged, w.GetBrick(1).State)
+		target := CanonicalPosToPixelPos(Pt{3, 2}).Plus(
+			Pt{BrickPixelSize / 2, BrickPixelSize / 2})
+		for range 30 {
+			w.Step(PlayerInput{Pos: target})
+		}
+		require.Equal(t, Pt{3, 2}, w.GetBrick(1).CanonicalPos)
+	}
+
+	// A released brick stays in the column where it was released.
+	w := NewWorld(0, l)
+	dragAway(&w)
+	w.Step(PlayerInput{JustReleased: true})
+	for range 100 {
+		w.Step(PlayerInput{})
+	}
+	assert.Equal(t, Pt{3, 0}, w.GetBrick(1).CanonicalPos)
+
+	// A canceled drag goes back to where it started, even if the pointer is
+	// released before the brick gets there.
+	w = NewWorld(0, l)
+	dragAway(&w)
+	assert.True(t, w.IsDragging())
+	w.Step(PlayerInput{CancelDrag: true})
+	assert.False(t, w.IsDragging())
+	w.Step(PlayerInput{JustReleased: true})
+	for range 100 {
+		w.Step(PlayerInput{})
+	}
+	assert.Equal(t, Canonical, w.GetBrick(1).State)
+	assert.Equal(t, Pt{0, 0}, w.GetBrick(1).CanonicalPos)
+	assert.Equal(t, w.GetBrick(1).CanonicalPixelPos, w.GetBrick(1).PixelPos)
+	assert.False(t, w.Drags[0].Canceled)
+}
+
+func TestWorld_RotateChain(t *testing.T) {
+	var l Level
+	l.TimerDisabled = true
+	l.BricksParams = []BrickParams{
+		{Pos: CanonicalPosToPixelPos(Pt{1, 0}), Val: 3},
+		{Pos: CanonicalPosToPixelPos(Pt{2, 0}), Val: 5},
+	}
+	l.ChainsParams = []ChainParams{{0, 1}}
+	w := NewWorld(0, l)
+	group := w.GetChainGroup(w.GetBrick(1).Group)
+	leader := w.GetBrick(group.Members[0]).Id
+	follower := w.GetBrick(group.Members[1]).Id
+	offset := func() Pt {
+		return w.GetBrick(follower).CanonicalPos.Minus(
+			w.GetBrick(leader).CanonicalPos)
+	}
+	startOffset := offset()
+	require.Equal(t, int64(0), startOffset.Y)
+
+	// On the bottom row, the floor is in the way.
+	pos := w.GetBrick(leader).Bounds.Center()
+	w.Step(PlayerInput{Pos: pos, JustPressed: true})
+	require.Equal(t, Dragged, w.GetBrick(leader).State)
+	w.Step(PlayerInput{Pos: pos, RotateChain: true})
+	assert.Equal(t, startOffset, offset())
+
+	// Higher up, the chain turns vertical and falls like that.
+	pos = CanonicalPosToPixelPos(Pt{4, 3}).Plus(
+		Pt{BrickPixelSize / 2, BrickPixelSize / 2})
+	for range 30 {
+		w.Step(PlayerInput{Pos: pos})
+	}
+	w.Step(PlayerInput{Pos: pos, RotateChain: true})
+	assert.Equal(t, Pt{0, -startOffset.X}, offset())
+	w.Step(PlayerInput{Pos: pos, JustReleased: true})
+	for range 100 {
+		w.Step(PlayerInput{})
+	}
+	assert.Equal(t, Pt{0, -startOffset.X}, offset())
+	assert.Equal(t, int64(0), min(w.GetBrick(leader).CanonicalPos.Y,
+		w.GetBrick(follower).CanonicalPos.Y))
+}
+
+func TestWorld_RotateChainOverlappingDrags(t *testing.T) {
+	var l Level
+	l.TimerDisabled = true
+	l.AllowOverlappingDrags = true
+	l.BricksParams = []BrickParams{
+		{Pos: CanonicalPosToPixelPos(Pt{1, 0}), Val: 3},
+		{Pos: CanonicalPosToPixelPos(Pt{2, 0}), Val: 5},
+	}
+	l.ChainsParams = []ChainParams{{0, 1}}
+	w := NewWorld(0, l)
+	group := w.GetChainGroup(w.GetBrick(1).Group)
+	leader := w.GetBrick(group.Members[0]).Id
+
+	// Nothing checks for obstacles, but the floor must still stop the
+	// follower from going under it.
+	pos := w.GetBrick(leader).Bounds.Center()
+	w.Step(PlayerInput{Pos: pos, JustPressed: true})
+	require.Equal(t, Dragged, w.GetBrick(leader).State)
+	w.Step(PlayerInput{Pos: pos, RotateChain: true})
+	for _, id := range group.Members {
+		assert.LessOrEqual(t, w.GetBrick(id).PixelPos.Y,
+			PlayAreaHeight-BrickPixelSize)
+		assert.Equal(t, int64(0), w.GetBrick(id).CanonicalPos.Y)
+	}
+}
+
+func TestWorld_PushNow(t *testing.T) {
+	var l Level
+	l.BricksParams = []BrickParams{
+		{Pos: CanonicalPosToPixelPos(Pt{0, 0}), Val: 3},
+		{Pos: CanonicalPosToPixelPos(Pt{5, 0}), Val: 3},
+	}
+
+	// Without PushNowEnabled the input is ignored.
+	w := NewWorld(0, l)
+	w.Step(PlayerInput{PushNow: true})
+	assert.Equal(t, Regular, w.State)
+	assert.Equal(t, int64(0), w.Score)
+
+	// With it, a new row comes up and the seconds left on the timer are
+	// rewarded.
+	l.PushNowEnabled = true
+	w = NewWorld(0, l)
+	for range 120 {
+		w.Step(PlayerInput{})
+	}
+	bonus := w.PushNowBonus()
+	assert.Equal(t, (w.TimerCooldown-120)/60, bonus)
+	w.Step(PlayerInput{PushNow: true})
+	assert.Equal(t, ComingUp, w.State)
+	assert.Equal(t, bonus, w.Score)
+
+	// Pushing again while the row is coming up does nothing.
+	w.Step(PlayerInput{PushNow: true})
+	assert.Equal(t, bonus, w.Score)
+}
+
+func TestWorld_TwoPointers(t *testing.T) {
+	var l Level
+	l.TimerDisabled = true
+	l.BricksParams = []BrickParams{
+		{Pos: CanonicalPosToPixelPos(Pt{0, 0}), Val: 3},
+		{Pos: CanonicalPosToPixelPos(Pt{5, 0}), Val: 5},
+	}
+	center := func(canPos Pt) Pt {
+		return CanonicalPosToPixelPos(canPos).Plus(
+			Pt{BrickPixelSize / 2, BrickPixelSize / 2})
+	}
+	w := NewWorld(0, l)
+
+	// Each pointer grabs and drags its own brick.
+	var input PlayerInput
+	input.Pos = center(Pt{0, 0})
+	input.JustPressed = true
+	input.OtherPointers[0] = PointerInput{Pos: center(Pt{5, 0}),
+		JustPressed: true}
+	w.Step(input)
+	require.Equal(t, Dragged, w.GetBrick(1).State)
+	require.Equal(t, Dragged, w.GetBrick(2).State)
+	input = PlayerInput{Pos: center(Pt{1, 3})}
+	input.OtherPointers[0].Pos = center(Pt{4, 3})
+	for range 30 {
+		w.Step(input)
+	}
+	assert.Equal(t, Pt{1, 3}, w.GetBrick(1).CanonicalPos)
+	assert.Equal(t, Pt{4, 3}, w.GetBrick(2).CanonicalPos)
+
+	// Releasing one pointer drops only its brick.
+	input.OtherPointers[0] = PointerInput{Pos: center(Pt{4, 3}),
+		JustReleased: true}
+	w.Step(input)
+	assert.Equal(t, Dragged, w.GetBrick(1).State)
+	assert.NotEqual(t, Dragged, w.GetBrick(2).State)
+
+	// A pointer can't take a brick away from another pointer.
+	input.OtherPointers[0] = PointerInput{Pos: center(Pt{1, 3}),
+		JustPressed: true}
+	w.Step(input)
+	assert.Equal(t, Dragged, w.GetBrick(1).State)
+	assert.Equal(t, int64(0), w.GetBrick(1).Pointer)
+	input.OtherPointers[0] = PointerInput{Pos: center(Pt{1, 3}),
+		JustReleased: true}
+	w.Step(input)
+	assert.Equal(t, Dragged, w.GetBrick(1).State)
+
+	// A second finger tapping away from the bricks cancels the drag of the
+	// first one.
+	input.OtherPointers[0] = PointerInput{Pos: center(Pt{3, 6}),
+		JustPressed: true}
+	w.Step(input)
+	input.OtherPointers[0] = PointerInput{}
+	for range 100 {
+		w.Step(input)
+	}
+	assert.Equal(t, Canonical, w.GetBrick(1).State)
+	assert.Equal(t, Pt{0, 0}, w.GetBrick(1).CanonicalPos)
+	assert.Equal(t, Pt{4, 0}, w.GetBrick(2).CanonicalPos)
+}
+
+func TestWorld_GravityFlip(t *testing.T) {
+	var l Level
+	l.BricksParams = []BrickParams{
+		{Pos: CanonicalPosToPixelPos(Pt{0, 0}), Val: 3},
+		{Pos: CanonicalPosToPixelPos(Pt{0, 1}), Val: 5},
+		{Pos: CanonicalPosToPixelPos(Pt{5, 0}), Val: 3},
+	}
+	w := NewWorld(0, l)
+	w.Step(PlayerInput{TriggerGravityFlip: true})
+	assert.Equal(t, Up, w.Gravity)
+	timerIdx := w.TimerCooldownIdx
+
+	// The bricks fall up and keep their order in the column.
+	for range 100 {
+		w.Step(PlayerInput{})
+	}
+	assert.Equal(t, Pt{0, NRows - 2}, w.GetBrick(1).CanonicalPos)
+	assert.Equal(t, Pt{0, NRows - 1}, w.GetBrick(2).CanonicalPos)
+	assert.Equal(t, Pt{5, NRows - 1}, w.GetBrick(3).CanonicalPos)
+	assert.Equal(t, Regular, w.State)
+	// The timer doesn't run while gravity is flipped.
+	assert.Equal(t, timerIdx, w.TimerCooldownIdx)
+
+	// Gravity goes back to normal and so do the bricks.
+	for range w.GravityFlipDuration {
+		w.Step(PlayerInput{})
+	}
+	assert.Equal(t, Down, w.Gravity)
+	for range 100 {
+		w.Step(PlayerInput{})
+	}
+	assert.Equal(t, Pt{0, 0}, w.GetBrick(1).CanonicalPos)
+	assert.Equal(t, Pt{0, 1}, w.GetBrick(2).CanonicalPos)
+	assert.Equal(t, Pt{5, 0}, w.GetBrick(3).CanonicalPos)
+}
+
+func TestWorld_GravityFlipFullColumn(t *testing.T) {
+	var l Level
+	l.TimerDisabled = true
+	for y := range NRows {
+		l.BricksParams = append(l.BricksParams, BrickParams{
+			Pos: CanonicalPosToPixelPos(Pt{0, y}), Val: y + 1})
+	}
+	// One brick too many for the column. The values are all different, so
+	// nothing merges to make room.
+	l.BricksParams = append(l.BricksParams, BrickParams{
+		Pos: CanonicalPosToPixelPos(Pt{0, NRows / 2}), Val: NRows + 1})
+	w := NewWorld(0, l)
+	w.Step(PlayerInput{TriggerGravityFlip: true})
+
+	// The brick that doesn't fit goes below the floor instead of over the
+	// top, which ends the game all the same.
+	for range 100 {
+		w.Step(PlayerInput{})
+	}
+	assert.Equal(t, Lost, w.State)
+	below := slices.ContainsFunc(w.Bricks, func(b Brick) bool {
+		return b.Bounds.Max.Y > PlayAreaHeight
+	})
+	assert.True(t, below)
+}
+
+func TestWorld_Events(t *testing.T) {
+	var l Level
+	l.TimerDisabled = true
+	l.BricksParams = []BrickParams{
+		{Pos: CanonicalPosToPixelPos(Pt{0, 0}), Val: 3},
+		{Pos: CanonicalPosToPixelPos(Pt{0, 3}), Val: 3},
+		{Pos: CanonicalPosToPixelPos(Pt{2, 2}), Val: 5},
+	}
+	w := NewWorld(0, l)
+	var events []WorldEvent
+	for range 100 {
+		w.Step(PlayerInput{})
+		events = append(events, w.Events...)
+	}
+
+	// The 5 falls to the bottom. The top 3 never comes to rest, it falls
+	// into the bottom 3 and they merge.
+	var types []WorldEventType
+	for _, e := range events {
+		types = append(types, e.Type)
+	}
+	assert.ElementsMatch(t, []WorldEventType{BrickFellToRestEvent,
+		MergeEvent}, types)
+	merge := events[slices.IndexFunc(events, func(e WorldEvent) bool {
+		return e.Type == MergeEvent
+	})]
+	assert.Equal(t, int64(4), merge.Val)
+	assert.Equal(t, w.GetBrick(merge.BrickId).Bounds.Center(), merge.Pos)
+
+	// Events only last for one step.
+	w.Step(PlayerInput{})
+	assert.Empty(t, w.Events)
+	assert.NotEmpty(t, w.EventsBytes())
+}
+
+func TestWorld_Clone(t *testing.T) {
+	playthrough := DeserializePlaythrough(ReadFile("data/demo.clone1"))
+	half := len(playthrough.History) / 2
+	w := NewWorldFromPlaythrough(playthrough)
+	for i := range half {
+		w.Step(playthrough.History[i])
+	}
+
+	// The clone and the original go on to play the same frames, and must end
+	// up in the same state at every frame.
+	c := w.Clone()
+	assert.Equal(t, w.StateBytes(), c.StateBytes())
+	for i := half; i < len(playthrough.History); i++ {
+		w.Step(playthrough.History[i])
+		c.Step(playthrough.History[i])
+		require.Equal(t, w.StateBytes(), c.StateBytes())
+	}
+	assert.Equal(t, w.RInt(0, 1000000), c.RInt(0, 1000000))
+
+	// Changing the clone leaves the original untouched.
+	before := w.StateBytes()
+	c.Step(PlayerInput{TriggerComingUp: true})
+	for range 100 {
+		c.Step(PlayerInput{})
+	}
+	assert.Equal(t, before, w.StateBytes())
+}
+
+func TestWorld_StepUntil(t *testing.T) {
+	playthrough := DeserializePlaythrough(ReadFile("data/demo.clone1"))
+	w := NewWorldFromPlaythrough(playthrough)
+	for _, input := range playthrough.History {
+		// Stopping after the last phase only skips the checks at the end of
+		// the step, which change nothing unless the game is lost.
+		c := w.Clone()
+		c.StepUntil(input, MergePhase)
+		w.Step(input)
+		if w.State != Lost {
+			require.Equal(t, w.StateBytes(), c.StateBytes())
+		}
+	}
+}
+
+func TestWorld_VerifyPlaythrough(t *testing.T) {
+	playthrough := DeserializePlaythrough(ReadFile("data/demo.clone1"))
+	trace := ComputeHashTrace(playthrough, false)
+	assert.Equal(t, len(playthrough.History)+1, len(trace))
+	loaded, withEvents := DeserializeHashTrace(trace.Serialize(false))
+	assert.Equal(t, trace, loaded)
+	assert.False(t, withEvents)
+	assert.Equal(t, int64(-1), VerifyPlaythrough(playthrough, trace, false))
+
+	// A trace of a crash stops early, only the frames it has are checked.
+	assert.Equal(t, int64(-1),
+		VerifyPlaythrough(playthrough, trace[:100], false))
+
+	// The first frame that differs is reported.
+	corrupted := slices.Clone(trace)
+	corrupted[500][0]++
+	assert.Equal(t, int64(500),
+		VerifyPlaythrough(playthrough, corrupted, false))
+
+	// A trace with more frames than the playthrough diverges at the first
+	// missing frame.
+	longer := append(slices.Clone(trace), trace[len(trace)-1])
+	assert.Equal(t, int64(len(trace)),
+		VerifyPlaythrough(playthrough, longer, false))
+}
+
+func TestWorld_HashTraceWithEvents(t *testing.T) {
+	playthrough := DeserializePlaythrough(ReadFile("data/demo.clone1"))
+	playthrough.History = playthrough.History[:2000]
+	trace := ComputeHashTrace(playthrough, true)
+	assert.Equal(t, int64(-1), VerifyPlaythrough(playthrough, trace, true))
+	assert.Equal(t, trace, ReplayWithEvents(playthrough).Trace)
+	loaded, withEvents := DeserializeHashTrace(trace.Serialize(true))
+	assert.Equal(t, trace, loaded)
+	assert.True(t, withEvents)
+
+	// Traces saved before the events existed have no header.
+	buf := new(bytes.Buffer)
+	SerializeSlice(buf, trace)
+	loaded, withEvents = DeserializeHashTrace(buf.Bytes())
+	assert.Equal(t, trace, loaded)
+	assert.False(t, withEvents)
+
+	// The events are what makes the traces different, so they are the same
+	// until the first frame that has any.
+	w := NewWorldFromPlaythrough(playthrough)
+	firstEvents := int64(-1)
+	for i, input := range playthrough.History {
+		w.Step(input)
+		if len(w.Events) > 0 {
+			firstEvents = int64(i + 1)
+			break
+		}
+	}
+	require.Greater(t, firstEvents, int64(0))
+	assert.Equal(t, firstEvents,
+		CompareHashTraces(ComputeHashTrace(playthrough, false), trace))
+	assert.Equal(t, firstEvents,
+		VerifyPlaythrough(playthrough, trace, false))
+
+	// The RegressionId and the FinalHash don't depend on the events.
+	assert.Equal(t, RegressionId(playthrough),
+		ReplayWithEvents(playthrough).RegressionId)
+}
+
+func TestWorld_CompareHashTraces(t *testing.T) {
+	playthrough := DeserializePlaythrough(ReadFile("data/demo.clone1"))
+	trace := ComputeHashTrace(playthrough, false)
+	assert.Equal(t, trace, Replay(playthrough).Trace)
+	assert.Equal(t, int64(-1), CompareHashTraces(trace, slices.Clone(trace)))
+
+	corrupted := slices.Clone(trace)
+	corrupted[700][5]++
+	assert.Equal(t, int64(700), CompareHashTraces(trace, corrupted))
+	assert.Equal(t, int64(300), CompareHashTraces(trace[:300], trace))
+	assert.Equal(t, int64(300), CompareHashTraces(trace, trace[:300]))
+}
+
+func TestWorld_Hooks(t *testing.T) {
+	var l Level
+	l.TimerDisabled = true
+	l.BricksParams = []BrickParams{
+		{Pos: CanonicalPosToPixelPos(Pt{0, 0}), Val: 3},
+		{Pos: CanonicalPosToPixelPos(Pt{0, 3}), Val: 3},
+	}
+	// A full column of bricks that can't merge, so that the next row of
+	// bricks ends the game.
+	for y := range NRows {
+		l.BricksParams = append(l.BricksParams, BrickParams{
+			Pos: CanonicalPosToPixelPos(Pt{NCols - 1, y}), Val: 10 + y%2})
+	}
+	w := NewWorld(0, l)
+
+	var merges []WorldEvent
+	var changes [][2]WorldState
+	var finals []WorldState
+	w.OnMerge(func(e WorldEvent) { merges = append(merges, e) })
+	w.OnStateChange(func(from WorldState, to WorldState) {
+		changes = append(changes, [2]WorldState{from, to})
+	})
+	w.OnGameOver(func(final WorldState) { finals = append(finals, final) })
+
+	// A clone doesn't call the hooks of the original.
+	c := w.Clone()
+	for range 100 {
+		c.Step(PlayerInput{})
+	}
+	assert.Empty(t, merges)
+
+	for range 100 {
+		w.Step(PlayerInput{})
+	}
+	require.Len(t, merges, 1)
+	assert.Equal(t, int64(4), merges[0].Val)
+	assert.Empty(t, changes)
+
+	w.Step(PlayerInput{TriggerComingUp: true})
+	for range 1000 {
+		w.Step(PlayerInput{})
+	}
+	assert.Equal(t, Lost, w.State)
+	assert.Equal(t, [2]WorldState{Regular, ComingUp}, changes[0])
+	assert.Equal(t, [2]WorldState{ComingUp, Lost}, changes[len(changes)-1])
+	assert.Equal(t, []WorldState{Lost}, finals)
+}
+
+func TestWorld_Paused(t *testing.T) {
+	var l Level
+	l.BricksParams = []BrickParams{
+		{Pos: CanonicalPosToPixelPos(Pt{0, 3}), Val: 3},
+	}
+	w := NewWorld(0, l)
+	w.Step(PlayerInput{})
+	before := w.StateBytes()
+	timer := w.TimerCooldownIdx
+
+	// Nothing moves while the game is paused, not even the timer.
+	w.Step(PlayerInput{PausedFrames: 100})
+	assert.Equal(t, before, w.StateBytes())
+	assert.Equal(t, timer, w.TimerCooldownIdx)
+
+	p := Playthrough{History: []PlayerInput{{}, {PausedFrames: 2}, {},
+		{PausedFrames: 3}}}
+	assert.Equal(t, int64(5), p.PausedFrames())
+	assert.Equal(t, int64(2), p.PlayedFrames())
+}
+
+func TestWorld_MaxBrickValue(t *testing.T) {
+	var l Level
+	l.TimerDisabled = true
+	l.MaxBrickValue = 4
+	l.BricksParams = []BrickParams{
+		{Pos: CanonicalPosToPixelPos(Pt{0, 0}), Val: 3},
+		{Pos: CanonicalPosToPixelPos(Pt{0, 3}), Val: 3},
+	}
+	w := NewWorld(0, l)
+	assert.Equal(t, int64(3), w.MaxInitialBrickValue)
+	for range 100 {
+		w.Step(PlayerInput{})
+	}
+	assert.Equal(t, Won, w.State)
+
+	// A short level without any bricks of its own gets the first rows
+	// below MaxBrickValue.
+	w = NewWorld(0, Level{MaxBrickValue: 4})
+	for _, b := range w.Bricks {
+		assert.Less(t, b.Val, int64(4))
+	}
+
+	// There are no images for the bricks of higher levels.
+	assert.Panics(t, func() {
+		NewWorld(0, Level{MaxBrickValue: MaxSupportedBrickValue + 1})
+	})
+
+	// The first rows of a regular level respect MaxInitialBrickValue.
+	w = NewWorld(0, Level{MaxBrickValue: 10, MaxInitialBrickValue: 3})
+	for _, b := range w.Bricks {
+		assert.LessOrEqual(t, b.Val, int64(3))
+	}
+	assert.Equal(t, int64(3), w.CurrentMaxVal())
+}
+
+func TestWorld_EndlessMaxBrickValue(t *testing.T) {
+	var l Level
+	l.Mode = Endless
+	l.TimerDisabled = true
+	l.MaxBrickValue = 4
+	l.BricksParams = []BrickParams{
+		{Pos: CanonicalPosToPixelPos(Pt{0, 0}), Val: 3},
+		{Pos: CanonicalPosToPixelPos(Pt{0, 3}), Val: 3},
+		{Pos: CanonicalPosToPixelPos(Pt{1, 0}), Val: 4},
+		{Pos: CanonicalPosToPixelPos(Pt{1, 3}), Val: 4},
+	}
+	w := NewWorld(0, l)
+	for range 100 {
+		w.Step(PlayerInput{})
+		for _, e := range w.Events {
+			assert.NotEqual(t, GameWonEvent, e.Type)
+		}
+	}
+	// Reaching MaxBrickValue doesn't win in Endless mode, the bricks at
+	// MaxBrickValue stay where they are instead of merging.
+	assert.Equal(t, Regular, w.State)
+	assert.Equal(t, 3, len(w.Bricks))
+	for _, b := range w.Bricks {
+		assert.Equal(t, int64(4), b.Val)
+	}
+	assert.True(t, w.NoMoreMergesArePossible())
+}
+
+func TestPlaythrough_EditInputs(t *testing.T) {
+	var p Playthrough
+	p.InputVersion = InputVersion
+	p.History = []PlayerInput{{Pos: Pt{1, 1}}, {Pos: Pt{2, 2}},
+		{Pos: Pt{3, 3}}}
+	p.FinalHash = FrameHash{1}
+
+	p.DeleteInput(1)
+	assert.Equal(t, FrameHash{}, p.FinalHash)
+	assert.Equal(t, []PlayerInput{{Pos: Pt{1, 1}}, {Pos: Pt{3, 3}}},
+		p.History)
+
+	p.InsertInput(1, PlayerInput{Pos: Pt{5, 5}, JustPressed: true})
+	assert.Equal(t, []PlayerInput{{Pos: Pt{1, 1}},
+		{Pos: Pt{5, 5}, JustPressed: true}, {Pos: Pt{3, 3}}}, p.History)
+
+	p.NudgeInput(2, Pt{-1, 2})
+	assert.Equal(t, Pt{2, 5}, p.History[2].Pos)
+
+	// The edited playthrough survives a round trip through a file.
+	assert.Equal(t, p.History, DeserializePlaythrough(p.Serialize()).History)
+}
+
+func TestPlaythrough_JSON(t *testing.T) {
+	data := ReadFile("data/demo.clone1")
+	playthrough := DeserializePlaythrough(data)
+	fromJSON := PlaythroughFromJSON(playthrough.ToJSON())
+	assert.Equal(t, playthrough, fromJSON)
+	assert.Equal(t, RegressionId(playthrough), RegressionId(fromJSON))
+}
+
+func TestPlaythrough_Corrupted(t *testing.T) {
+	p := DeserializePlaythrough(ReadFile("data/demo.clone1"))
+	corrupted := func(data []byte) (msg string) {
+		defer func() {
+			msg = fmt.Sprint(recover())
+		}()
+		DeserializePlaythrough(data)
+		return
+	}
+
+	// A damaged byte in the middle of the payload.
+	data := p.SerializeUncompressed()
+	data[len(data)/2]++
+	assert.Contains(t, corrupted(Zip(data)), "corrupted recording")
+
+	// A download that was cut short.
+	for _, c := range []Compression{ZipCompression, ZstdCompression} {
+		data = p.SerializeWith(CompressionParams{Compression: c})
+		assert.Contains(t, corrupted(data[:len(data)/2]),
+			"corrupted recording", c)
+	}
+}
+
+func TestPlaythrough_UnknownFields(t *testing.T) {
+	p := DeserializePlaythrough(ReadFile("data/demo.clone1"))
+
+	// A newer executable added a field, this one skips it.
+	data := p.SerializeUncompressed()
+	buf := bytes.NewBuffer(data[:len(data)-4])
+	buf.Write(protowire.AppendVarint(
+		protowire.AppendTag(nil, 1000, protowire.VarintType), 7))
+	Serialize(buf, crc32.ChecksumIEEE(buf.Bytes()))
+	assert.Equal(t, p, DeserializePlaythrough(Zip(buf.Bytes())))
+
+	// The same goes for the legacy layout.
+	legacy := DeserializePlaythrough(Zip(p.SerializeLegacy()))
+	data = p.SerializeLegacy()
+	buf = bytes.NewBuffer(data[:len(data)-4])
+	SerializeValueField(buf, 1000, int64(7))
+	Serialize(buf, crc32.ChecksumIEEE(buf.Bytes()))
+	assert.Equal(t, legacy, DeserializePlaythrough(Zip(buf.Bytes())))
+
+	// An older executable didn't know about a field, it stays at its zero
+	// value.
+	buf = new(bytes.Buffer)
+	Serialize(buf, p.InputVersion)
+	SerializeValueField(buf, playthroughSeed, p.Seed)
+	Serialize(buf, crc32.ChecksumIEEE(buf.Bytes()))
+	old := DeserializePlaythrough(Zip(buf.Bytes()))
+	assert.Equal(t, p.Seed, old.Seed)
+	assert.Empty(t, old.History)
+	assert.Equal(t, Metadata{}, old.Metadata)
+}
+
+func TestPlaythrough_SerializeFullLevel(t *testing.T) {
+	var p Playthrough
+	p.InputVersion = InputVersion
+	p.SimulationVersion = SimulationVersion
+	p.BricksParams = []BrickParams{{Pt{1, 2}, 3, false}, {Pt{4, 5}, 0, true}}
+	p.ChainsParams = []ChainParams{{0, 1}}
+	p.TimerDisabled = true
+	p.AllowOverlappingDrags = true
+	p.Mode = Endless
+	p.PushNowEnabled = true
+	p.MaxBrickValue = 12
+	p.MaxInitialBrickValue = 5
+	p.DifficultyParams = DefaultDifficultyParams()
+
+	// If a field is added to Level, it must be set above, so that this test
+	// checks that it survives serialization.
+	l := reflect.ValueOf(p.Level)
+	for i := 0; i < l.NumField(); i++ {
+		assert.False(t, l.Field(i).IsZero(), l.Type().Field(i).Name)
+	}
+
+	assert.Equal(t, p.Level, DeserializePlaythrough(p.Serialize()).Level)
+}